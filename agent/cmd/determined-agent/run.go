@@ -89,7 +89,7 @@ func newRunCmd() *cobra.Command {
 
 	// Labels flags.
 	cmd.Flags().StringVar(&opts.Label, "label", "",
-		"This field has been deprecated and will be ignored, use ``resource_pool`` instead.")
+		"Label of the agent, used to schedule the maintenance of agents")
 
 	// ResourcePool flags.
 	cmd.Flags().StringVar(&opts.ResourcePool, "resource-pool", "",
@@ -137,6 +137,8 @@ func newRunCmd() *cobra.Command {
 	cmd.Flags().StringVar(&opts.BindIP, "bind-ip", "0.0.0.0",
 		"IP address to listen on for API requests")
 	cmd.Flags().IntVar(&opts.BindPort, "bind-port", 9090, "Port to listen on for API requests")
+	cmd.Flags().StringVar(&opts.APIToken, "api-token", "",
+		"Bearer token that API requests must send, if set")
 
 	// Proxy flags.
 	cmd.Flags().StringVar(&opts.HTTPProxy, "http-proxy", "",
@@ -155,6 +157,13 @@ func newRunCmd() *cobra.Command {
 		"TCP port for the Fluent Bit daemon to listen on")
 	cmd.Flags().StringVar(&opts.Fluent.ContainerName, "fluent-container-name", "determined-fluent",
 		"Name for the Fluent Bit container")
+	cmd.Flags().StringVar(&opts.LogShipper.Type, "log-shipper", options.FluentLogShipper,
+		"How to ship the logs of task containers: fluent (with a Fluent Bit container) or native "+
+			"(from the agent itself)")
+	cmd.Flags().StringVar(&opts.LogShipper.BufferDir, "log-shipper-buffer-dir",
+		"/var/lib/determined/agent/logs", "Directory the native log shipper buffers logs in")
+	cmd.Flags().IntVar(&opts.LogShipper.BufferMaxSizeMB, "log-shipper-buffer-max-size-mb", 1024,
+		"Size the logs buffered by the native log shipper may take up, in megabytes")
 
 	// Fault-tolerance flags.
 	cmd.Flags().IntVar(&opts.AgentReconnectAttempts, "agent-reconnect-attempts",
@@ -162,8 +171,32 @@ func newRunCmd() *cobra.Command {
 	cmd.Flags().IntVar(&opts.AgentReconnectBackoff, "agent-reconnect-backoff",
 		int(aproto.AgentReconnectBackoff/time.Second), "Time between agent reconnect attempts")
 
+	cmd.Flags().IntVar(&opts.GPUHealthCheckInterval, "gpu-health-check-interval", 60,
+		"Time between GPU health checks, in seconds; 0 disables them")
+	cmd.Flags().IntVar(&opts.GPUTelemetryInterval, "gpu-telemetry-interval", 10,
+		"Time between samples of the usage of GPUs assigned to containers, in seconds; "+
+			"0 disables them")
+	cmd.Flags().IntVar(&opts.ImageCacheMaxSizeGB, "image-cache-max-size-gb", 0,
+		"Size the images on the agent may take up, in gigabytes, before the least recently used "+
+			"ones are removed; 0 disables the limit")
+	cmd.Flags().BoolVar(&opts.SlotResourceLimits.Enabled, "slot-resource-limits-enabled", false,
+		"Limit the CPUs, memory and shared memory of containers in proportion to their slots")
+	cmd.Flags().IntVar(&opts.SlotResourceLimits.ReservedCPUs,
+		"slot-resource-limits-reserved-cpus", 0,
+		"Number of CPUs kept for the system when limiting containers")
+	cmd.Flags().IntVar(&opts.SlotResourceLimits.ReservedMemoryMB,
+		"slot-resource-limits-reserved-memory-mb", 0,
+		"Memory kept for the system when limiting containers, in megabytes")
+	cmd.Flags().Float64Var(&opts.SlotResourceLimits.ShmFraction,
+		"slot-resource-limits-shm-fraction", 0,
+		"Fraction of its memory limit a container gets as shared memory; 0 keeps the task's size")
+
 	cmd.Flags().StringVar(&opts.ContainerRuntime, "container-runtime",
 		options.DockerContainerRuntime, "The container runtime to use")
+	cmd.Flags().StringVar(&opts.ContainerdOptions.Address, "containerd-address",
+		"/run/containerd/containerd.sock", "Path to the containerd socket")
+	cmd.Flags().StringVar(&opts.ContainerdOptions.Namespace, "containerd-namespace",
+		"determined", "containerd namespace to create images and containers in")
 
 	return cmd
 }
@@ -6,7 +6,10 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"os/exec"
+	"reflect"
 	"strings"
 	"time"
 
@@ -19,7 +22,10 @@ import (
 	"github.com/determined-ai/determined/agent/internal/containers"
 	"github.com/determined-ai/determined/agent/internal/detect"
 	"github.com/determined-ai/determined/agent/internal/fluent"
+	"github.com/determined-ai/determined/agent/internal/imagecache"
+	"github.com/determined-ai/determined/agent/internal/logship"
 	"github.com/determined-ai/determined/agent/internal/options"
+	"github.com/determined-ai/determined/agent/pkg/containerd"
 	"github.com/determined-ai/determined/agent/pkg/docker"
 	"github.com/determined-ai/determined/agent/pkg/events"
 	"github.com/determined-ai/determined/agent/pkg/podman"
@@ -27,17 +33,24 @@ import (
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	opentelemetry "github.com/determined-ai/determined/master/pkg/opentelemetry"
 	"github.com/determined-ai/determined/master/pkg/syncx/errgroupx"
 	"github.com/determined-ai/determined/master/pkg/ws"
 )
 
 const (
-	wsInsecureScheme = "ws"
-	wsSecureScheme   = "wss"
-	eventChanSize    = 64 // same size as the websocket outbox
-	fluentRetries    = 5
-	fluentBackoff    = 5 * time.Second
-	logSourceAgent   = "agent"
+	wsInsecureScheme  = "ws"
+	wsSecureScheme    = "wss"
+	eventChanSize     = 64 // same size as the websocket outbox
+	logShipperRetries = 5
+	logShipperBackoff = 5 * time.Second
+	logSourceAgent    = "agent"
+
+	// gpuTelemetryBatchSize is the number of samples per device sent to the master at once.
+	gpuTelemetryBatchSize = 6
+	// maintenanceHookBacklog is the number of maintenance changes waiting for the hook to run.
+	maintenanceHookBacklog = 8
 )
 
 // MasterWebsocket is the type for a websocket which communicates with the master.
@@ -50,15 +63,20 @@ type MasterWebsocket = ws.WebSocket[*aproto.AgentMessage, *aproto.MasterMessage]
 type Agent struct {
 	version string
 	opts    options.Options
+	status  *agentStatus
 	log     *logrus.Entry
 	wg      errgroupx.Group
 }
 
-// NewAgent constructs and runs a new agent according to the provided configuration.
-func NewAgent(parent context.Context, version string, opts options.Options) *Agent {
+// NewAgent constructs and runs a new agent according to the provided configuration. The agent
+// keeps the status up to date for the agent API.
+func NewAgent(
+	parent context.Context, version string, opts options.Options, status *agentStatus,
+) *Agent {
 	a := &Agent{
 		version: version,
 		opts:    opts,
+		status:  status,
 		log:     logrus.WithField("component", "agent"),
 		wg:      errgroupx.WithContext(parent),
 	}
@@ -115,6 +133,15 @@ func (a *Agent) run(ctx context.Context) error {
 		return fmt.Errorf("canceled while reading setup messages: %w", ctx.Err())
 	}
 
+	if t := mopts.MasterInfo.Telemetry; t.OtelEnabled && t.OtelExportedOtlpEndpoint != "" {
+		a.log.Trace("configuring opentelemetry")
+		opentelemetry.ConfigureOtel(t.OtelExportedOtlpEndpoint, "determined-agent")
+	}
+
+	if a.reconcilesMIG(mopts) {
+		a.reconcileMIG(mopts)
+	}
+
 	a.log.Trace("detecting devices")
 	devices, err := detect.Detect(
 		a.opts.SlotType, a.opts.AgentID, a.opts.VisibleGPUs, a.opts.ArtificialSlots,
@@ -122,10 +149,11 @@ func (a *Agent) run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to detect devices: %v", devices)
 	}
+	a.status.setDevices(devices)
 
 	a.log.Tracef("setting up %s runtime", a.opts.ContainerRuntime)
 	var cruntime container.ContainerRuntime
-	var logShipper *fluent.Fluent
+	var logShipper logShipper
 	var logShipperDone chan struct{}
 	switch a.opts.ContainerRuntime {
 	case options.PodmanContainerRuntime:
@@ -139,6 +167,17 @@ func (a *Agent) run(ctx context.Context) error {
 			}
 		}()
 		cruntime = acl
+	case options.ContainerdContainerRuntime:
+		acl, sErr := containerd.New(a.opts)
+		if sErr != nil {
+			return fmt.Errorf("failed to build containerd client: %w", sErr)
+		}
+		defer func() {
+			if cErr := acl.Close(); cErr != nil {
+				a.log.WithError(cErr).Error("failed to close containerd client")
+			}
+		}()
+		cruntime = acl
 	case options.SingularityContainerRuntime:
 		acl, sErr := singularity.New(a.opts)
 		if sErr != nil {
@@ -164,19 +203,18 @@ func (a *Agent) run(ctx context.Context) error {
 		cl := docker.NewClient(dcl)
 		cruntime = cl
 
-		a.log.Trace("setting up fluentbit daemon")
-		fl, fErr := fluent.Start(ctx, a.opts, mopts, cl)
-		if fErr != nil {
-			return fmt.Errorf("setting up fluentbit failed: %w", fErr)
+		a.log.Tracef("setting up %s log shipper", a.opts.LogShipper.Type)
+		ls, done, lErr := a.startLogShipper(ctx, mopts, cl)
+		if lErr != nil {
+			return fmt.Errorf("setting up log shipper failed: %w", lErr)
 		}
 		defer func() {
-			a.log.Trace("cleaning up fluent client")
-			if cErr := fl.Close(); cErr != nil {
-				a.log.WithError(cErr).Error("failed to close fluentbit")
+			a.log.Trace("cleaning up log shipper")
+			if cErr := logShipper.Close(); cErr != nil {
+				a.log.WithError(cErr).Error("failed to close log shipper")
 			}
 		}()
-		logShipper = fl
-		logShipperDone = fl.Done
+		logShipper, logShipperDone = ls, done
 	}
 
 	a.log.Trace("setting up container manager")
@@ -189,6 +227,7 @@ func (a *Agent) run(ctx context.Context) error {
 		a.log.Trace("detaching container manager")
 		manager.Detach()
 	}()
+	a.status.setManager(manager)
 
 	a.log.Trace("reattaching containers")
 	reattached, err := manager.ReattachContainers(ctx, mopts.ContainersToReattach)
@@ -206,6 +245,31 @@ func (a *Agent) run(ctx context.Context) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	}
+	a.status.setConnected(true)
+	defer a.status.setConnected(false)
+
+	if a.opts.GPUHealthCheckInterval > 0 {
+		a.log.Trace("monitoring device health")
+		healthCtx, cancelHealth := context.WithCancel(ctx)
+		defer cancelHealth()
+		go a.monitorDeviceHealth(healthCtx, devices, outbox)
+	}
+
+	if a.opts.GPUTelemetryInterval > 0 {
+		a.log.Trace("sampling container GPU usage")
+		telemetryCtx, cancelTelemetry := context.WithCancel(ctx)
+		defer cancelTelemetry()
+		go a.monitorGPUTelemetry(telemetryCtx, manager, outbox)
+	}
+
+	a.log.Trace("managing image cache")
+	cache := imagecache.New(cruntime, a.opts.ImageCacheMaxSizeGB, manager.Images)
+	cacheCtx, cancelCache := context.WithCancel(ctx)
+	defer cancelCache()
+	go cache.Run(cacheCtx, outbox)
+
+	maintenance := make(chan model.AgentMaintenance, maintenanceHookBacklog)
+	go a.runMaintenanceHooks(ctx, maintenance)
 
 	a.log.Trace("watching for ws requests and system events")
 	inbox := socket.Inbox
@@ -220,11 +284,23 @@ func (a *Agent) run(ctx context.Context) error {
 
 			switch {
 			case msg.StartContainer != nil:
+				cache.Track(msg.StartContainer.Spec.RunSpec.ContainerConfig.Image)
 				if err := manager.StartContainer(ctx, *msg.StartContainer); err != nil {
 					a.log.WithError(err).Error("could not start container")
 				}
 			case msg.SignalContainer != nil:
 				manager.SignalContainer(ctx, *msg.SignalContainer)
+			case msg.PrePullImages != nil:
+				cache.SetPrePull(msg.PrePullImages.Images)
+			case msg.MaintenanceChanged != nil:
+				m := msg.MaintenanceChanged.Maintenance
+				a.log.Infof("maintenance %s: starts at %s, deadline %s, %d containers left",
+					m.Phase, m.StartTime, m.Deadline, m.NumContainers)
+				select {
+				case maintenance <- m:
+				default:
+					a.log.Warnf("dropping maintenance hook for phase %s, too many pending", m.Phase)
+				}
 			case msg.AgentShutdown != nil:
 				return errors.New(msg.AgentShutdown.ErrMsg)
 			default:
@@ -244,6 +320,7 @@ func (a *Agent) run(ctx context.Context) error {
 			} else {
 				a.log.Trace("socket disconnected")
 			}
+			a.status.setConnected(false)
 
 			newSocket, newMopts, err := a.reconnectFlow(ctx, manager, devices, outbox)
 			if err != nil {
@@ -251,20 +328,22 @@ func (a *Agent) run(ctx context.Context) error {
 			}
 			socket = newSocket
 			inbox = socket.Inbox
-			mopts = *newMopts // TODO: Reload fluent with new mopts.
+			mopts = *newMopts // TODO: Reload the log shipper with new mopts.
+			cache.Resend()
+			a.status.setConnected(true)
 
 		case <-logShipperDone:
-			a.log.Trace("fluent exited")
+			a.log.Trace("log shipper exited")
 			if err := logShipper.Error(); err != nil {
-				a.log.Errorf("restarting fluent due to failure: %s", err)
+				a.log.Errorf("restarting log shipper due to failure: %s", err)
 			}
 
-			newLogShipper, err := a.restartFluent(ctx, mopts, cruntime.(*docker.Client))
+			newLogShipper, newDone, err := a.restartLogShipper(
+				ctx, mopts, cruntime.(*docker.Client))
 			if err != nil {
-				return fmt.Errorf("crashing due to fluent failure: %w", err)
+				return fmt.Errorf("crashing due to log shipper failure: %w", err)
 			}
-			logShipper = newLogShipper
-			logShipperDone = newLogShipper.Done
+			logShipper, logShipperDone = newLogShipper, newDone
 
 		case <-ctx.Done():
 			a.log.Trace("context canceled")
@@ -290,9 +369,9 @@ func (a *Agent) connect(ctx context.Context, reconnect bool) (*MasterWebsocket,
 	}
 
 	masterAddr := fmt.Sprintf(
-		"%s://%s:%d/agents?id=%s&version=%s&resource_pool=%s&reconnect=%v",
+		"%s://%s:%d/agents?id=%s&version=%s&resource_pool=%s&label=%s&reconnect=%v",
 		masterProto, a.opts.MasterHost, a.opts.MasterPort, a.opts.AgentID, a.version,
-		a.opts.ResourcePool, reconnect,
+		a.opts.ResourcePool, url.QueryEscape(a.opts.Label), reconnect,
 	)
 	a.log.Infof("connecting to master at: %s", masterAddr)
 	conn, resp, err := dialer.DialContext(ctx, masterAddr, nil)
@@ -343,6 +422,144 @@ func (a *Agent) sender(out chan *aproto.MasterMessage) events.Publisher[containe
 	)
 }
 
+// reconcilesMIG returns true if the agent should partition its GPUs into the MIG profiles of its
+// resource pool. Partitioning destroys the MIG instances of the GPUs, so it is only done while the
+// agent is idle, that is, when it has no containers to reattach.
+func (a *Agent) reconcilesMIG(mopts aproto.MasterSetAgentOptions) bool {
+	switch {
+	case len(mopts.MIGProfiles) == 0, len(mopts.ContainersToReattach) > 0:
+		return false
+	case a.opts.ArtificialSlots > 0:
+		return false
+	default:
+		return a.opts.SlotType == "cuda" || a.opts.SlotType == "gpu" || a.opts.SlotType == "auto"
+	}
+}
+
+// reconcileMIG partitions the GPUs into the MIG profiles of the resource pool, returning true if
+// it changed the partitioning of any GPU.
+func (a *Agent) reconcileMIG(mopts aproto.MasterSetAgentOptions) bool {
+	a.log.Trace("reconciling MIG instances")
+	partitioned, err := detect.ReconcileMIG(mopts.MIGProfiles, a.opts.VisibleGPUs)
+	if err != nil {
+		a.log.WithError(err).Error("failed to partition GPUs into the MIG profiles of the pool")
+	}
+	return partitioned
+}
+
+// monitorDeviceHealth periodically checks the health of the devices and notifies the master
+// whenever it changes.
+func (a *Agent) monitorDeviceHealth(
+	ctx context.Context, devices []device.Device, out chan *aproto.MasterMessage,
+) {
+	checker := detect.NewHealthChecker(devices)
+	interval := time.Duration(a.opts.GPUHealthCheckInterval) * time.Second
+	var last []aproto.DeviceHealth
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+
+		health := checker.Check()
+		if health == nil || reflect.DeepEqual(health, last) {
+			continue
+		}
+		for _, h := range health {
+			if len(h.Problems) > 0 {
+				a.log.Warnf("device %s is unhealthy: %v", h.Device.UUID, h.Problems)
+			}
+		}
+
+		select {
+		case out <- &aproto.MasterMessage{
+			DevicesHealthChanged: &aproto.DevicesHealthChanged{Devices: health},
+		}:
+			last = health
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runMaintenanceHooks runs the maintenance hook for each change of the agent's maintenance, in
+// order, until the context is canceled.
+func (a *Agent) runMaintenanceHooks(ctx context.Context, changes <-chan model.AgentMaintenance) {
+	for {
+		select {
+		case m := <-changes:
+			cmd := a.opts.Hooks.OnMaintenance
+			if len(cmd) == 0 {
+				continue
+			}
+			hook := exec.CommandContext(ctx, cmd[0], cmd[1:]...) //nolint:gosec
+			hook.Env = append(os.Environ(),
+				"DET_AGENT_ID="+a.opts.AgentID,
+				"DET_MAINTENANCE_PHASE="+string(m.Phase),
+				"DET_MAINTENANCE_START_TIME="+m.StartTime.Format(time.RFC3339),
+				"DET_MAINTENANCE_DEADLINE="+m.Deadline.Format(time.RFC3339),
+				fmt.Sprintf("DET_MAINTENANCE_NUM_CONTAINERS=%d", m.NumContainers),
+			)
+			if out, err := hook.CombinedOutput(); err != nil {
+				a.log.
+					WithError(err).
+					WithField("output", string(out)).
+					Errorf("error running maintenance hook for phase %s", m.Phase)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// monitorGPUTelemetry periodically samples the usage of the devices assigned to running containers
+// and sends the samples to the master in batches, or once the container stops running.
+func (a *Agent) monitorGPUTelemetry(
+	ctx context.Context, manager *containers.Manager, out chan *aproto.MasterMessage,
+) {
+	interval := time.Duration(a.opts.GPUTelemetryInterval) * time.Second
+	pending := map[cproto.ID][]aproto.GPUSample{}
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+
+		running := manager.RunningContainerDevices()
+		if len(running) > 0 {
+			var devices []device.Device
+			for _, ds := range running {
+				devices = append(devices, ds...)
+			}
+			samples := detect.SampleGPUs(devices)
+			for id, ds := range running {
+				for _, d := range ds {
+					if s, ok := samples[d.UUID]; ok {
+						pending[id] = append(pending[id], s)
+					}
+				}
+			}
+		}
+
+		for id, batch := range pending {
+			if ds, ok := running[id]; ok && len(batch) < gpuTelemetryBatchSize*len(ds) {
+				continue
+			}
+			delete(pending, id)
+			select {
+			case out <- &aproto.MasterMessage{ContainerGPUTelemetry: &aproto.ContainerGPUTelemetry{
+				ContainerID: id,
+				Samples:     batch,
+			}}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
 func (a *Agent) enrichLog(log *aproto.ContainerLog) *aproto.ContainerLog {
 	log.AgentID = &a.opts.AgentID
 	if log.Source == nil {
@@ -352,6 +569,12 @@ func (a *Agent) enrichLog(log *aproto.ContainerLog) *aproto.ContainerLog {
 	return log
 }
 
+// enrichTaskLog enriches the task logs that the agent ships itself like Fluent Bit does, which
+// only adds the agent ID.
+func (a *Agent) enrichTaskLog(log *model.TaskLog) {
+	log.AgentID = &a.opts.AgentID
+}
+
 func (a *Agent) reconnectFlow(
 	ctx context.Context,
 	manager *containers.Manager,
@@ -384,6 +607,21 @@ func (a *Agent) reconnectFlow(
 		return nil, nil, fmt.Errorf("canceled while reading setup messages: %w", ctx.Err())
 	}
 
+	// The master sends its options again on every reconnect, so MIG profiles changed in the
+	// configuration of the resource pool are applied once the agent is idle. The master shuts down
+	// agents whose devices change, so the agent restarts with the new instances.
+	if a.reconcilesMIG(*mopts) && len(manager.Containers()) == 0 && a.reconcileMIG(*mopts) {
+		a.log.Trace("detecting devices after partitioning GPUs")
+		redetected, err := detect.Detect(
+			a.opts.SlotType, a.opts.AgentID, a.opts.VisibleGPUs, a.opts.ArtificialSlots,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to detect devices: %w", err)
+		}
+		devices = redetected
+		a.status.setDevices(devices)
+	}
+
 	a.log.Tracef("reattaching containers after reconnect: %+v", mopts.ContainersToReattach)
 	reattached, err := manager.RevalidateContainers(ctx, mopts.ContainersToReattach)
 	if err != nil {
@@ -470,27 +708,60 @@ func (a *Agent) reconnect(ctx context.Context) (*MasterWebsocket, error) {
 	}
 }
 
-func (a *Agent) restartFluent(
+// logShipper ships the logs of task containers.
+type logShipper interface {
+	// Error returns the error the shipper exited with, if any.
+	Error() error
+	Close() error
+}
+
+// startLogShipper starts the Fluent Bit container or, if configured, the agent's own log shipper.
+// It returns the shipper and a channel that is closed when the shipper exits.
+func (a *Agent) startLogShipper(
+	ctx context.Context,
+	mopts aproto.MasterSetAgentOptions,
+	docker *docker.Client,
+) (logShipper, chan struct{}, error) {
+	if a.opts.LogShipper.Type != options.NativeLogShipper {
+		fl, err := fluent.Start(ctx, a.opts, mopts, docker)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fl, fl.Done, nil
+	}
+
+	tlsConfig, err := a.tlsConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to construct TLS config")
+	}
+	s, err := logship.Start(ctx, a.opts, mopts, docker, tlsConfig, a.enrichTaskLog)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, s.Done, nil
+}
+
+func (a *Agent) restartLogShipper(
 	ctx context.Context,
 	mopts aproto.MasterSetAgentOptions,
 	docker *docker.Client,
-) (*fluent.Fluent, error) {
-	a.log.Trace("restarting up fluentbit daemon...")
+) (logShipper, chan struct{}, error) {
+	a.log.Trace("restarting log shipper...")
 	for i := 1; ; i++ {
-		fluent, err := fluent.Start(ctx, a.opts, mopts, docker)
+		ls, done, err := a.startLogShipper(ctx, mopts, docker)
 		if err == nil {
-			return fluent, nil
+			return ls, done, nil
 		}
 
-		a.log.WithError(err).Error("error restarting fluentbit")
-		if i >= fluentRetries {
-			return nil, err
+		a.log.WithError(err).Error("error restarting log shipper")
+		if i >= logShipperRetries {
+			return nil, nil, err
 		}
 
 		select {
-		case <-time.After(fluentBackoff):
+		case <-time.After(logShipperBackoff):
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, nil, ctx.Err()
 		}
 	}
 }
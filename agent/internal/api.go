@@ -1,16 +1,22 @@
 package internal
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
+	"github.com/determined-ai/determined/agent/internal/containers"
 	"github.com/determined-ai/determined/agent/internal/options"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/logger"
 )
 
@@ -20,9 +26,26 @@ type agentAPIServer struct {
 
 	// Internal state.
 	server *echo.Echo
+	status *agentStatus
 }
 
-func newAgentAPIServer(opts options.Options) *agentAPIServer {
+// connectionResponse describes the connection of the agent to the master.
+type connectionResponse struct {
+	MasterHost string     `json:"master_host"`
+	MasterPort int        `json:"master_port"`
+	Connected  bool       `json:"connected"`
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// containerExit describes how a container exited.
+type containerExit struct {
+	ID          cproto.ID          `json:"id"`
+	ExitCode    *aproto.ExitCode   `json:"exit_code,omitempty"`
+	FailureType aproto.FailureType `json:"failure_type,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+func newAgentAPIServer(opts options.Options, status *agentStatus) *agentAPIServer {
 	server := echo.New()
 	server.Logger = logger.New()
 	server.HidePort = true
@@ -30,6 +53,23 @@ func newAgentAPIServer(opts options.Options) *agentAPIServer {
 	server.Use(middleware.Recover())
 	server.Pre(middleware.RemoveTrailingSlash())
 	server.Use(otelecho.Middleware("determined-agent"))
+	if opts.APIToken != "" {
+		server.Use(middleware.KeyAuth(func(key string, _ echo.Context) (bool, error) {
+			return subtle.ConstantTimeCompare([]byte(key), []byte(opts.APIToken)) == 1, nil
+		}))
+	}
+
+	a := &agentAPIServer{
+		opts:   opts,
+		server: server,
+		status: status,
+	}
+
+	server.GET("/devices", a.getDevices)
+	server.GET("/containers", a.getContainers)
+	server.GET("/containers/exits", a.getContainerExits)
+	server.GET("/connection", a.getConnection)
+	server.GET("/options", a.getOptions)
 
 	server.Any("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
 	server.Any("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
@@ -37,10 +77,64 @@ func newAgentAPIServer(opts options.Options) *agentAPIServer {
 	server.Any("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
 	server.Any("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
 
-	return &agentAPIServer{
-		opts:   opts,
-		server: server,
+	return a
+}
+
+func (a *agentAPIServer) getDevices(c echo.Context) error {
+	devices := a.status.getDevices()
+	if devices == nil {
+		devices = []device.Device{}
+	}
+	return c.JSON(http.StatusOK, devices)
+}
+
+func (a *agentAPIServer) getContainers(c echo.Context) error {
+	infos := []containers.Info{}
+	if manager := a.status.getManager(); manager != nil {
+		infos = manager.Containers()
+	}
+	return c.JSON(http.StatusOK, infos)
+}
+
+func (a *agentAPIServer) getContainerExits(c echo.Context) error {
+	exits := []containerExit{}
+	if manager := a.status.getManager(); manager != nil {
+		for _, stop := range manager.RecentExits() {
+			exit := containerExit{ID: stop.Container.ID}
+			if stop.ContainerStopped != nil && stop.ContainerStopped.Failure != nil {
+				failure := stop.ContainerStopped.Failure
+				exit.ExitCode = failure.ExitCode
+				exit.FailureType = failure.FailureType
+				exit.Error = failure.ErrMsg
+			} else {
+				code := aproto.ExitCode(aproto.SuccessExitCode)
+				exit.ExitCode = &code
+			}
+			exits = append(exits, exit)
+		}
+	}
+	return c.JSON(http.StatusOK, exits)
+}
+
+func (a *agentAPIServer) getConnection(c echo.Context) error {
+	connected, since := a.status.getConnection()
+	resp := connectionResponse{
+		MasterHost: a.opts.MasterHost,
+		MasterPort: a.opts.MasterPort,
+		Connected:  connected,
+	}
+	if !since.IsZero() {
+		resp.Since = &since
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (a *agentAPIServer) getOptions(c echo.Context) error {
+	printable, err := a.opts.Printable()
+	if err != nil {
+		return err
 	}
+	return c.JSONBlob(http.StatusOK, printable)
 }
 
 func (a *agentAPIServer) serve() error {
@@ -8,6 +8,11 @@ import (
 	"syscall"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slices"
 
 	"github.com/sirupsen/logrus"
@@ -22,6 +27,8 @@ import (
 	"github.com/determined-ai/determined/master/pkg/syncx/waitgroupx"
 )
 
+var tracer = otel.Tracer("github.com/determined-ai/determined/agent/internal/container")
+
 // Container is a layer for managing a single Docker container. It can be constructed by launching
 // a new container or reattaching an existing one. Once constructed, it provides an interface to
 // interact with a running container.
@@ -29,7 +36,9 @@ type Container struct {
 	// Configuration details. Set in initialization and never modified after.
 	containerID  cproto.ID
 	allocationID model.AllocationID
+	taskID       model.TaskID
 	spec         *cproto.Spec
+	image        string // Kept after the spec is evicted; empty for reattached containers.
 	devices      []device.Device
 
 	// System dependencies. Also set in initialization and never modified after.
@@ -37,6 +46,12 @@ type Container struct {
 	cruntime ContainerRuntime
 	pub      events.Publisher[Event]
 
+	// Tracing of the container, as part of the trace of its allocation. Reattached containers
+	// are not traced.
+	traceCtx context.Context
+	span     trace.Span
+	phase    trace.Span
+
 	// Internal state. Access should be protected.
 	mu       sync.RWMutex
 	state    cproto.State // Updated throughout run, access protected.
@@ -54,10 +69,20 @@ func Start(
 	cl ContainerRuntime,
 	pub events.Publisher[Event],
 ) *Container {
+	traceCtx := otel.GetTextMapPropagator().Extract(
+		context.Background(), propagation.MapCarrier(req.TraceContext))
+	traceCtx, span := tracer.Start(traceCtx, "container", trace.WithAttributes(
+		attribute.String("container.id", req.Container.ID.String()),
+		attribute.String("allocation.id", hackEnvVar(&req.Spec, AllocationIDEnvVar)),
+		attribute.String("container.image", req.Spec.RunSpec.ContainerConfig.Image),
+	))
+
 	c := &Container{
 		containerID:  req.Container.ID,
-		allocationID: hackAllocationID(&req.Spec),
+		allocationID: model.AllocationID(hackEnvVar(&req.Spec, AllocationIDEnvVar)),
+		taskID:       model.TaskID(hackEnvVar(&req.Spec, TaskIDEnvVar)),
 		spec:         &req.Spec,
+		image:        req.Spec.RunSpec.ContainerConfig.Image,
 		devices:      req.Container.Devices,
 		log: logrus.WithFields(logrus.Fields{
 			"component": "container",
@@ -65,6 +90,8 @@ func Start(
 		}),
 		cruntime: cl,
 		pub:      pub,
+		traceCtx: traceCtx,
+		span:     span,
 		state:    req.Container.State,
 		signals:  make(chan syscall.Signal),
 		done:     make(chan struct{}),
@@ -103,6 +130,8 @@ func Reattach(
 		}),
 		cruntime: cl,
 		pub:      pub,
+		traceCtx: context.Background(),
+		span:     trace.SpanFromContext(context.Background()),
 		state:    container.State,
 		signals:  make(chan syscall.Signal, 16), // Not infinite, but large enough to not drop often.
 		done:     make(chan struct{}),
@@ -130,6 +159,22 @@ func (c *Container) Summary() cproto.Container {
 	return c.summary()
 }
 
+// Image returns the image of the container, or an empty string for reattached containers.
+func (c *Container) Image() string {
+	return c.image
+}
+
+// AllocationID returns the allocation the container belongs to, or an empty string for reattached
+// containers.
+func (c *Container) AllocationID() model.AllocationID {
+	return c.allocationID
+}
+
+// TaskID returns the task the container belongs to, or an empty string for reattached containers.
+func (c *Container) TaskID() model.TaskID {
+	return c.taskID
+}
+
 // Detach the monitoring loops without affecting the Docker container.
 func (c *Container) Detach() {
 	c.log.Trace("detach called")
@@ -365,6 +410,7 @@ func (c *Container) transition(
 	c.mu.Lock()
 	c.log.WithField("stop", stop).Infof("transitioning state from %s to %s", c.state, state)
 	c.state = state
+	c.traceTransition(state, stop)
 	csc := &aproto.ContainerStateChanged{
 		Container:        c.summary(),
 		ContainerStarted: start,
@@ -381,6 +427,25 @@ func (c *Container) transition(
 	return nil
 }
 
+// traceTransition records a state transition as an event of the container's span and starts a
+// span covering the time spent in the new state, ending the container's span on termination.
+func (c *Container) traceTransition(state cproto.State, stop *aproto.ContainerStopped) {
+	c.span.AddEvent(strings.ToLower(state.String()))
+	if c.phase != nil {
+		c.phase.End()
+		c.phase = nil
+	}
+	if state != cproto.Terminated {
+		_, c.phase = tracer.Start(c.traceCtx, strings.ToLower(state.String()))
+		return
+	}
+	if stop != nil && stop.Failure != nil {
+		c.span.RecordError(stop.Failure)
+		c.span.SetStatus(codes.Error, stop.Failure.Error())
+	}
+	c.span.End()
+}
+
 func (c *Container) running(ctx context.Context, start aproto.ContainerStarted) error {
 	return c.transition(ctx, cproto.Running, &start, nil)
 }
@@ -428,19 +493,17 @@ func (c *Container) shimDockerEvents() events.Publisher[docker.Event] {
 	})
 }
 
-// hackAllocationID hacks the allocation ID back from the container spec.
+// hackEnvVar hacks an environment variable, such as the allocation ID, back from the container spec.
 // TODO(Brad): we should just.. pass this down?
-func hackAllocationID(spec *cproto.Spec) model.AllocationID {
+func hackEnvVar(spec *cproto.Spec, name string) string {
 	for _, env := range spec.RunSpec.ContainerConfig.Env {
 		split := strings.SplitN(env, "=", 2)
 		if len(split) < 2 {
 			continue
 		}
 
-		value := split[1]
-		switch split[0] {
-		case AllocationIDEnvVar:
-			return model.AllocationID(value)
+		if split[0] == name {
+			return split[1]
 		}
 	}
 	return ""
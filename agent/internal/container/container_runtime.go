@@ -44,3 +44,12 @@ type ContainerRuntime interface {
 
 	ListRunningContainers(ctx context.Context, fs filters.Args) (map[cproto.ID]types.Container, error)
 }
+
+// ImageStore is implemented by container runtimes that can list and remove their images, which
+// lets the agent limit the size of its image cache.
+type ImageStore interface {
+	ListImages(ctx context.Context) ([]docker.Image, error)
+	// RemoveImage removes a name of an image, and the image with its last name, unless a container
+	// uses it.
+	RemoveImage(ctx context.Context, name string) error
+}
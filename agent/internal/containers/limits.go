@@ -0,0 +1,144 @@
+package containers
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/agent/internal/detect"
+	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const mb = 1 << 20
+
+// slotResourceLimits gives each container CPU, memory and shared memory limits in proportion to
+// the slots it holds. Every slot gets its own share of the CPUs, taken from the NUMA node of its
+// GPU where possible, so that containers on the same agent do not compete for CPUs. Containers
+// that hold no slots run on the reserved CPUs.
+type slotResourceLimits struct {
+	conf         model.SlotResourceLimitsConfig
+	reservedCPUs []int
+	slotCPUs     map[device.ID][]int
+	slotMemory   int64
+}
+
+// newSlotResourceLimits returns the limits for the slots of the agent, or nil if they are disabled.
+func newSlotResourceLimits(
+	conf model.SlotResourceLimitsConfig, devices []device.Device, topo detect.Topology,
+) *slotResourceLimits {
+	if !conf.Enabled || len(devices) == 0 {
+		return nil
+	}
+
+	l := &slotResourceLimits{conf: conf, slotCPUs: map[device.ID][]int{}}
+	if memory := topo.MemoryBytes - int64(conf.ReservedMemoryMB)*mb; memory > 0 {
+		l.slotMemory = memory / int64(len(devices))
+	} else {
+		log.Warnf("reserved memory of %d MB leaves no memory to limit containers to",
+			conf.ReservedMemoryMB)
+	}
+
+	// Reserve the lowest CPUs, where the system tends to run, and assign the rest to slots.
+	var all []int
+	for _, cpus := range topo.NodeCPUs {
+		all = append(all, cpus...)
+	}
+	sort.Ints(all)
+	reserved := conf.ReservedCPUs
+	if reserved >= len(all) {
+		log.Warnf("reserved CPUs (%d) leave no CPUs for containers, keeping one", reserved)
+		reserved = len(all) - 1
+	}
+	if reserved < 0 {
+		reserved = 0
+	}
+	l.reservedCPUs = all[:reserved]
+	available := map[int]bool{}
+	for _, cpu := range all[reserved:] {
+		available[cpu] = true
+	}
+
+	slots := append([]device.Device(nil), devices...)
+	sort.Slice(slots, func(i, j int) bool { return slots[i].ID < slots[j].ID })
+	share, extra := len(available)/len(slots), len(available)%len(slots)
+	if share == 0 {
+		// Fewer CPUs than slots; slots share CPUs.
+		free := all[reserved:]
+		for i, d := range slots {
+			l.slotCPUs[d.ID] = []int{free[i%len(free)]}
+		}
+		return l
+	}
+
+	quota := map[device.ID]int{}
+	for i, d := range slots {
+		quota[d.ID] = share
+		if i < extra {
+			quota[d.ID]++
+		}
+	}
+	take := func(d device.Device, cpus []int) {
+		for _, cpu := range cpus {
+			if len(l.slotCPUs[d.ID]) == quota[d.ID] {
+				return
+			}
+			if available[cpu] {
+				delete(available, cpu)
+				l.slotCPUs[d.ID] = append(l.slotCPUs[d.ID], cpu)
+			}
+		}
+	}
+	// First give slots the CPUs of their own NUMA node, then fill them up with what is left.
+	for _, d := range slots {
+		if node, ok := topo.DeviceNodes[d.UUID]; ok {
+			take(d, topo.NodeCPUs[node])
+		}
+	}
+	for _, d := range slots {
+		take(d, all)
+		sort.Ints(l.slotCPUs[d.ID])
+	}
+	return l
+}
+
+// apply sets the limits of the container on the host config of its spec. Limits the task already
+// set are kept.
+func (l *slotResourceLimits) apply(spec *cproto.RunSpec, cont cproto.Container) {
+	if l == nil {
+		return
+	}
+
+	hostConfig := &spec.HostConfig
+	if len(cont.Devices) == 0 {
+		if hostConfig.CpusetCpus == "" && len(l.reservedCPUs) > 0 {
+			hostConfig.CpusetCpus = detect.FormatCPUList(l.reservedCPUs)
+		}
+		return
+	}
+
+	if hostConfig.CpusetCpus == "" {
+		set := map[int]bool{}
+		for _, d := range cont.Devices {
+			for _, cpu := range l.slotCPUs[d.ID] {
+				set[cpu] = true
+			}
+		}
+		cpus := make([]int, 0, len(set))
+		for cpu := range set {
+			cpus = append(cpus, cpu)
+		}
+		sort.Ints(cpus)
+		hostConfig.CpusetCpus = detect.FormatCPUList(cpus)
+	}
+
+	if hostConfig.Memory == 0 && l.slotMemory > 0 {
+		hostConfig.Memory = l.slotMemory * int64(len(cont.Devices))
+	}
+
+	if l.conf.ShmFraction > 0 && hostConfig.Memory > 0 &&
+		(hostConfig.ShmSize == 0 || spec.ShmSizeUnset) {
+		hostConfig.ShmSize = int64(l.conf.ShmFraction * float64(hostConfig.Memory))
+	}
+}
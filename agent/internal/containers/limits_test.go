@@ -0,0 +1,86 @@
+package containers
+
+import (
+	"testing"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/agent/internal/detect"
+	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestSlotResourceLimits(t *testing.T) {
+	devices := []device.Device{
+		{ID: 0, UUID: "GPU-0", Type: device.CUDA},
+		{ID: 1, UUID: "GPU-1", Type: device.CUDA},
+		{ID: 2, UUID: "GPU-2", Type: device.CUDA},
+		{ID: 3, UUID: "GPU-3", Type: device.CUDA},
+	}
+	topo := detect.Topology{
+		NodeCPUs:    map[int][]int{0: {0, 1, 2, 3, 4, 5, 6, 7}, 1: {8, 9, 10, 11, 12, 13, 14, 15}},
+		MemoryBytes: 66 * 1024 * mb,
+		// GPU-1 is on node 1 with the others on node 0; GPU-3's node is unknown.
+		DeviceNodes: map[string]int{"GPU-0": 0, "GPU-1": 1, "GPU-2": 0},
+	}
+	l := newSlotResourceLimits(model.SlotResourceLimitsConfig{
+		Enabled:          true,
+		ReservedCPUs:     2,
+		ReservedMemoryMB: 2 * 1024,
+		ShmFraction:      0.5,
+	}, devices, topo)
+
+	// The 14 CPUs left are split between the slots, on the node of their GPU where possible.
+	require.Equal(t, map[device.ID][]int{
+		0: {2, 3, 4, 5},
+		1: {8, 9, 10, 11},
+		2: {6, 7, 12},
+		3: {13, 14, 15},
+	}, l.slotCPUs)
+
+	spec := cproto.RunSpec{
+		HostConfig:   dcontainer.HostConfig{ShmSize: model.DefaultTaskContainerDefaults().ShmSizeBytes},
+		ShmSizeUnset: true,
+	}
+	l.apply(&spec, cproto.Container{Devices: devices[:2]})
+	require.Equal(t, "2-5,8-11", spec.HostConfig.CpusetCpus)
+	require.Equal(t, int64(32*1024*mb), spec.HostConfig.Memory)
+	require.Equal(t, int64(16*1024*mb), spec.HostConfig.ShmSize)
+
+	// Limits the task set itself are kept, even a shared memory size equal to the default.
+	explicit := dcontainer.HostConfig{
+		ShmSize:   model.DefaultTaskContainerDefaults().ShmSizeBytes,
+		Resources: dcontainer.Resources{CpusetCpus: "0", Memory: mb},
+	}
+	spec = cproto.RunSpec{HostConfig: explicit}
+	l.apply(&spec, cproto.Container{Devices: devices[2:3]})
+	require.Equal(t, explicit, spec.HostConfig)
+
+	// Containers without slots run on the reserved CPUs.
+	spec = cproto.RunSpec{}
+	l.apply(&spec, cproto.Container{})
+	require.Equal(t, dcontainer.HostConfig{
+		Resources: dcontainer.Resources{CpusetCpus: "0-1"},
+	}, spec.HostConfig)
+
+	// Disabled limits change nothing.
+	l = newSlotResourceLimits(model.SlotResourceLimitsConfig{}, devices, topo)
+	spec = cproto.RunSpec{}
+	l.apply(&spec, cproto.Container{Devices: devices})
+	require.Equal(t, cproto.RunSpec{}, spec)
+}
+
+func TestSlotResourceLimitsMoreSlotsThanCPUs(t *testing.T) {
+	devices := []device.Device{{ID: 0}, {ID: 1}, {ID: 2}}
+	l := newSlotResourceLimits(model.SlotResourceLimitsConfig{Enabled: true}, devices,
+		detect.Topology{NodeCPUs: map[int][]int{0: {0, 1}}})
+	require.Equal(t, map[device.ID][]int{0: {0}, 1: {1}, 2: {0}}, l.slotCPUs)
+	require.Equal(t, int64(0), l.slotMemory)
+
+	// Without reserved CPUs, containers without slots are not limited.
+	spec := cproto.RunSpec{}
+	l.apply(&spec, cproto.Container{})
+	require.Equal(t, cproto.RunSpec{}, spec)
+}
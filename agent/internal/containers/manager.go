@@ -4,6 +4,7 @@ import (
 	"container/ring"
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"syscall"
 
@@ -14,12 +15,14 @@ import (
 	"golang.org/x/sys/unix"
 
 	"github.com/determined-ai/determined/agent/internal/container"
+	"github.com/determined-ai/determined/agent/internal/detect"
 	"github.com/determined-ai/determined/agent/internal/options"
 	"github.com/determined-ai/determined/agent/pkg/docker"
 	"github.com/determined-ai/determined/agent/pkg/events"
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/syncx/waitgroupx"
 )
 
@@ -37,6 +40,7 @@ type Manager struct {
 	opts    options.Options
 	mopts   aproto.MasterSetAgentOptions
 	devices []device.Device
+	limits  *slotResourceLimits
 
 	// System dependencies. Also set in initialization and never modified after.
 	log      *log.Entry
@@ -58,10 +62,20 @@ func New(
 	cl container.ContainerRuntime,
 	pub events.Publisher[container.Event],
 ) (*Manager, error) {
+	conf := opts.SlotResourceLimits
+	if mopts.SlotResourceLimits != nil {
+		conf = *mopts.SlotResourceLimits
+	}
+	var limits *slotResourceLimits
+	if conf.Enabled {
+		limits = newSlotResourceLimits(conf, devices, detect.DetectTopology(devices))
+	}
+
 	return &Manager{
 		opts:        opts,
 		mopts:       mopts,
 		devices:     devices,
+		limits:      limits,
 		log:         log.WithField("component", "container-manager"),
 		cruntime:    cl,
 		pub:         pub,
@@ -203,6 +217,7 @@ func (m *Manager) StartContainer(ctx context.Context, req aproto.StartContainer)
 	if err != nil {
 		return fmt.Errorf("failed to overwrite spec: %w", err)
 	}
+	m.limits.apply(&spec.RunSpec, req.Container)
 	req.Spec = spec
 
 	m.mu.Lock()
@@ -270,6 +285,70 @@ func (m *Manager) Close() {
 	m.wg.Wait()
 }
 
+// RunningContainerDevices returns the devices assigned to each running container.
+func (m *Manager) RunningContainerDevices() map[cproto.ID][]device.Device {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	running := map[cproto.ID][]device.Device{}
+	for id, c := range m.containers {
+		if summary := c.Summary(); summary.State == cproto.Running && len(summary.Devices) > 0 {
+			running[id] = summary.Devices
+		}
+	}
+	return running
+}
+
+// Images returns the images of the managed containers.
+func (m *Manager) Images() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var images []string
+	for _, c := range m.containers {
+		if image := c.Image(); image != "" {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// Info describes a managed container.
+type Info struct {
+	cproto.Container
+	AllocationID model.AllocationID `json:"allocation_id,omitempty"`
+	TaskID       model.TaskID       `json:"task_id,omitempty"`
+	Image        string             `json:"image,omitempty"`
+}
+
+// Containers returns the managed containers, sorted by ID.
+func (m *Manager) Containers() []Info {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	infos := make([]Info, 0, len(m.containers))
+	for _, c := range m.containers {
+		infos = append(infos, Info{
+			Container:    c.Summary(),
+			AllocationID: c.AllocationID(),
+			TaskID:       c.TaskID(),
+			Image:        c.Image(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// RecentExits returns the exits of the containers that stopped recently, most recent first.
+func (m *Manager) RecentExits() []aproto.ContainerStateChanged {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var exits []aproto.ContainerStateChanged
+	m.recentExits.Do(func(v any) {
+		if v != nil {
+			exits = append(exits, *v.(*aproto.ContainerStateChanged))
+		}
+	})
+	return exits
+}
+
 // NumContainers returns the number of containers being managed.
 func (m *Manager) NumContainers() int {
 	m.mu.RLock()
@@ -1,11 +1,14 @@
 package containers
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/determined-ai/determined/agent/internal/options"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/cproto"
 )
 
 func TestAddProxyInfo(t *testing.T) {
@@ -78,3 +81,21 @@ func TestAddProxyInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestRecentExits(t *testing.T) {
+	m, err := New(options.Options{}, aproto.MasterSetAgentOptions{}, nil, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, m.RecentExits())
+
+	for i := 0; i < RecentExitsCacheSize+2; i++ {
+		m.recentExits = m.recentExits.Prev()
+		m.recentExits.Value = &aproto.ContainerStateChanged{
+			Container: cproto.Container{ID: cproto.ID(strconv.Itoa(i)), State: cproto.Terminated},
+		}
+	}
+
+	exits := m.RecentExits()
+	require.Len(t, exits, RecentExitsCacheSize)
+	require.Equal(t, cproto.ID(strconv.Itoa(RecentExitsCacheSize+1)), exits[0].Container.ID)
+	require.Equal(t, cproto.ID("2"), exits[len(exits)-1].Container.ID)
+}
@@ -55,7 +55,7 @@ func overwriteSpec(
 		}
 	}
 
-	spec.RunSpec.HostConfig.LogConfig = generateLoggingConfig(opts.Fluent.Port)
+	spec.RunSpec.HostConfig.LogConfig = generateLoggingConfig(opts)
 
 	return spec, nil
 }
@@ -83,11 +83,15 @@ func addProxyInfo(env []string, opts options.Options) []string {
 	return env
 }
 
-func generateLoggingConfig(port int) dcontainer.LogConfig {
+func generateLoggingConfig(opts options.Options) dcontainer.LogConfig {
+	if opts.LogShipper.Type == options.NativeLogShipper {
+		// The agent reads the logs back from Docker, which the local driver supports and rotates.
+		return dcontainer.LogConfig{Type: "local"}
+	}
 	return dcontainer.LogConfig{
 		Type: "fluentd",
 		Config: map[string]string{
-			"fluentd-address":              "localhost:" + strconv.Itoa(port),
+			"fluentd-address":              "localhost:" + strconv.Itoa(opts.Fluent.Port),
 			"fluentd-sub-second-precision": "true",
 			"mode":                         "non-blocking",
 			"max-buffer-size":              "10m",
@@ -0,0 +1,326 @@
+package detect
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+var (
+	checkCudaHealthArgs = []string{
+		"nvidia-smi",
+		"--query-gpu=uuid,pci.bus_id,retired_pages.pending,ecc.errors.uncorrected.volatile.total," +
+			"clocks_throttle_reasons.hw_thermal_slowdown,clocks_throttle_reasons.sw_thermal_slowdown",
+		"--format=csv,noheader",
+	}
+	checkRocmHealthArgs = []string{"rocm-smi", "--showuniqueid", "--json"}
+	kernelLogArgs       = []string{"dmesg"}
+
+	// fallenOffBusChecks is how many checks in a row a GPU must be missing from nvidia-smi or
+	// rocm-smi before it is considered to have fallen off the bus, since they occasionally fail to
+	// query a healthy GPU.
+	fallenOffBusChecks = 3
+
+	xidRegExp = regexp.MustCompile(`NVRM: Xid \(PCI:([0-9a-fA-F:.]+)\): (\d+)`)
+
+	// fatalXids are the Xid errors after which a GPU must be reset or replaced, c.f.
+	// https://docs.nvidia.com/deploy/xid-errors/index.html.
+	fatalXids = map[int]string{
+		48:  "double bit ECC error",
+		63:  "ECC page retirement or row remapping recording event",
+		64:  "ECC page retirement or row remapper recording failure",
+		74:  "NVLink error",
+		79:  "GPU has fallen off the bus",
+		92:  "high single-bit ECC error rate",
+		94:  "contained ECC error",
+		95:  "uncontained ECC error",
+		119: "GSP RPC timeout",
+		120: "GSP error",
+	}
+)
+
+// runDeviceCommand runs a command and returns its output. It is a variable so that tests can fake
+// the commands.
+var runDeviceCommand = func(args []string) ([]byte, error) {
+	// #nosec G204
+	return exec.Command(args[0], args[1:]...).Output()
+}
+
+// HealthChecker checks the health of GPUs. Xid errors are read from the kernel log and, since the
+// GPU needs a reset to recover from them, reported until the agent restarts.
+type HealthChecker struct {
+	devices []device.Device
+
+	seenXids map[string]int
+	xids     map[string][]int
+	// missing counts the checks in a row each GPU has been missing for, by UUID.
+	missing map[string]int
+	// migParents maps the UUID of each MIG instance to the UUID of its GPU.
+	migParents map[string]string
+}
+
+// NewHealthChecker returns a HealthChecker for the devices.
+func NewHealthChecker(devices []device.Device) *HealthChecker {
+	return &HealthChecker{devices: devices, missing: map[string]int{}}
+}
+
+// Check returns the health of every GPU, or nil if it could not be checked.
+func (h *HealthChecker) Check() []aproto.DeviceHealth {
+	var cuda, rocm []device.Device
+	for _, d := range h.devices {
+		switch d.Type {
+		case device.CUDA:
+			cuda = append(cuda, d)
+		case device.ROCM:
+			rocm = append(rocm, d)
+		}
+	}
+
+	var health []aproto.DeviceHealth
+	if len(cuda) > 0 {
+		health = append(health, h.checkCuda(cuda)...)
+	}
+	if len(rocm) > 0 {
+		health = append(health, h.checkRocm(rocm)...)
+	}
+	return health
+}
+
+func (h *HealthChecker) checkCuda(devices []device.Device) []aproto.DeviceHealth {
+	// nvidia-smi fails when a GPU has fallen off the bus, but still prints the healthy GPUs.
+	out, err := runDeviceCommand(checkCudaHealthArgs)
+	if execError, ok := err.(*exec.Error); ok && execError.Err == exec.ErrNotFound {
+		return nil
+	} else if err != nil {
+		log.WithError(err).WithField("output", string(out)).Debug(
+			"error while executing nvidia-smi to check GPU health")
+	}
+	status := parseCudaHealth(out)
+	if len(status) == 0 {
+		// Rather than report every GPU as lost, wait for nvidia-smi to work again.
+		log.WithField("output", string(out)).Warn("nvidia-smi reported no GPU health")
+		return nil
+	}
+
+	if kernelLog, err := runDeviceCommand(kernelLogArgs); err != nil {
+		log.WithError(err).Debug("cannot read kernel log to check for Xid errors")
+	} else {
+		h.recordXids(parseXids(kernelLog))
+	}
+
+	health := make([]aproto.DeviceHealth, 0, len(devices))
+	for _, d := range devices {
+		dh := aproto.DeviceHealth{Device: d}
+		// The health of MIG instances is not queried separately from their GPU, so they share the
+		// problems of their GPU.
+		uuid := d.UUID
+		if strings.HasPrefix(uuid, "MIG-") {
+			parent, ok := h.migParent(uuid)
+			if !ok {
+				health = append(health, dh)
+				continue
+			}
+			uuid = parent
+		}
+		s, ok := status[uuid]
+		if !ok {
+			dh.Problems = append(dh.Problems, h.missingProblem(d.UUID, "nvidia-smi"))
+			health = append(health, dh)
+			continue
+		}
+		delete(h.missing, d.UUID)
+		dh.Problems = append(dh.Problems, s.problems...)
+		for _, xid := range h.xids[s.bus] {
+			reason, fatal := fatalXids[xid]
+			if !fatal {
+				reason = "see the NVIDIA Xid documentation"
+			}
+			dh.Problems = append(dh.Problems, aproto.DeviceProblem{
+				Kind:   aproto.XidError,
+				Detail: fmt.Sprintf("Xid %d, %s", xid, reason),
+				Fatal:  fatal,
+			})
+		}
+		health = append(health, dh)
+	}
+	return health
+}
+
+// migParent returns the UUID of the GPU of a MIG instance. MIG instances are listed again when
+// one is not known, since they change when GPUs are partitioned.
+func (h *HealthChecker) migParent(uuid string) (string, bool) {
+	if parent, ok := h.migParents[uuid]; ok {
+		return parent, true
+	}
+	out, err := runDeviceCommand(detectCudaDevices)
+	if err != nil {
+		log.WithError(err).WithField("output", string(out)).Debug(
+			"error while executing nvidia-smi to list MIG instances")
+		return "", false
+	}
+	h.migParents = map[string]string{}
+	for _, gpu := range parseCudaDeviceList(out) {
+		for _, d := range gpu.instances {
+			h.migParents[d.UUID] = gpu.uuid
+		}
+	}
+	parent, ok := h.migParents[uuid]
+	return parent, ok
+}
+
+// missingProblem counts another check that a GPU is missing from the given tool and returns the
+// problem to report, which is only fatal once the GPU has been missing for fallenOffBusChecks checks
+// in a row.
+func (h *HealthChecker) missingProblem(uuid, tool string) aproto.DeviceProblem {
+	h.missing[uuid]++
+	return aproto.DeviceProblem{
+		Kind:   aproto.FallenOffBus,
+		Detail: "GPU is missing from " + tool,
+		Fatal:  h.missing[uuid] >= fallenOffBusChecks,
+	}
+}
+
+// recordXids records the Xid errors logged since the previous check. Errors logged before the
+// first check are ignored, since they may have been handled before the agent started.
+func (h *HealthChecker) recordXids(xids map[string][]int) {
+	first := h.seenXids == nil
+	if first {
+		h.seenXids = make(map[string]int, len(xids))
+		h.xids = make(map[string][]int)
+	}
+	for bus, codes := range xids {
+		// The kernel log is a ring buffer, so errors dropped from it shrink the count.
+		if seen := h.seenXids[bus]; !first && len(codes) > seen {
+			for _, code := range codes[seen:] {
+				if !slices.Contains(h.xids[bus], code) {
+					h.xids[bus] = append(h.xids[bus], code)
+				}
+			}
+		}
+		h.seenXids[bus] = len(codes)
+	}
+}
+
+type cudaHealth struct {
+	bus      string
+	problems []aproto.DeviceProblem
+}
+
+// normalizeBus reduces a PCI address such as 00000000:3B:00.0 to the bus and device, 3b:00, which
+// is how Xid errors identify GPUs.
+func normalizeBus(addr string) string {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	addr = strings.SplitN(addr, ".", 2)[0]
+	parts := strings.Split(addr, ":")
+	if len(parts) < 2 {
+		return addr
+	}
+	return strings.Join(parts[len(parts)-2:], ":")
+}
+
+// parseCudaHealth parses the output of checkCudaHealthArgs, skipping the lines of GPUs that could
+// not be queried.
+func parseCudaHealth(out []byte) map[string]cudaHealth {
+	health := map[string]cudaHealth{}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return health
+		} else if err != nil || len(record) != 6 {
+			continue
+		}
+
+		uuid := strings.TrimSpace(record[0])
+		h := cudaHealth{bus: normalizeBus(record[1])}
+		if strings.TrimSpace(record[2]) == "Yes" {
+			h.problems = append(h.problems, aproto.DeviceProblem{
+				Kind:   aproto.RetiredPagesPending,
+				Detail: "retired memory pages are pending a GPU reset",
+				Fatal:  true,
+			})
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(record[3])); err == nil && n > 0 {
+			h.problems = append(h.problems, aproto.DeviceProblem{
+				Kind:   aproto.UncorrectableECCErrors,
+				Detail: fmt.Sprintf("%d uncorrectable ECC errors since the last driver reload", n),
+				Fatal:  true,
+			})
+		}
+		for i, kind := range []string{"hardware", "software"} {
+			if strings.TrimSpace(record[4+i]) == "Active" {
+				h.problems = append(h.problems, aproto.DeviceProblem{
+					Kind:   aproto.ThermalThrottling,
+					Detail: kind + " thermal slowdown is active",
+				})
+			}
+		}
+		health[uuid] = h
+	}
+}
+
+// parseXids returns the codes of the Xid errors in a kernel log, by GPU bus.
+func parseXids(kernelLog []byte) map[string][]int {
+	xids := map[string][]int{}
+	scanner := bufio.NewScanner(strings.NewReader(string(kernelLog)))
+	for scanner.Scan() {
+		matches := xidRegExp.FindStringSubmatch(scanner.Text())
+		if matches == nil {
+			continue
+		}
+		code, err := strconv.Atoi(matches[2])
+		if err != nil {
+			continue
+		}
+		bus := normalizeBus(matches[1])
+		xids[bus] = append(xids[bus], code)
+	}
+	return xids
+}
+
+func (h *HealthChecker) checkRocm(devices []device.Device) []aproto.DeviceHealth {
+	out, err := runDeviceCommand(checkRocmHealthArgs)
+	if execError, ok := err.(*exec.Error); ok && execError.Err == exec.ErrNotFound {
+		return nil
+	} else if err != nil {
+		log.WithError(err).WithField("output", string(out)).Debug(
+			"error while executing rocm-smi to check GPU health")
+	}
+
+	parsed := map[string]RocmDevice{}
+	if err := json.Unmarshal(out, &parsed); err != nil || len(parsed) == 0 {
+		// Rather than report every GPU as lost, wait for rocm-smi to work again.
+		log.WithField("output", string(out)).Warn("rocm-smi reported no GPU health")
+		return nil
+	}
+	present := map[string]bool{}
+	for _, d := range parsed {
+		present[d.UUID] = true
+	}
+
+	health := make([]aproto.DeviceHealth, 0, len(devices))
+	for _, d := range devices {
+		dh := aproto.DeviceHealth{Device: d}
+		if present[d.UUID] {
+			delete(h.missing, d.UUID)
+		} else {
+			dh.Problems = append(dh.Problems, h.missingProblem(d.UUID, "rocm-smi"))
+		}
+		health = append(health, dh)
+	}
+	return health
+}
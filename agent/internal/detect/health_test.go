@@ -0,0 +1,102 @@
+package detect
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+const testCudaHealthData = `GPU-aaaa, 00000000:3B:00.0, No, 0, Not Active, Not Active
+GPU-bbbb, 00000000:5E:00.0, Yes, 2, Active, Not Active
+Unable to determine the device handle for GPU0000:86:00.0: Unknown Error
+`
+
+const testCudaDeviceList = `GPU 0: NVIDIA A100-SXM4-80GB (UUID: GPU-aaaa)
+GPU 1: NVIDIA A100-SXM4-80GB (UUID: GPU-bbbb)
+  MIG 3g.40gb     Device  0: (UUID: MIG-dddd)
+`
+
+const testKernelLogAtStart = `[  10.1] nvidia: loading out-of-tree module taints kernel.
+[ 100.2] NVRM: Xid (PCI:0000:5e:00): 13, pid=1234, Graphics Exception
+`
+
+const testKernelLog = testKernelLogAtStart +
+	`[ 200.3] NVRM: Xid (PCI:0000:3b:00): 79, pid=0, GPU has fallen off the bus.
+`
+
+func TestParseCudaHealth(t *testing.T) {
+	health := parseCudaHealth([]byte(testCudaHealthData))
+	assert.Equal(t, len(health), 2)
+
+	assert.Equal(t, health["GPU-aaaa"].bus, "3b:00")
+	assert.Equal(t, len(health["GPU-aaaa"].problems), 0)
+
+	var kinds []aproto.DeviceProblemKind
+	for _, p := range health["GPU-bbbb"].problems {
+		kinds = append(kinds, p.Kind)
+	}
+	assert.DeepEqual(t, kinds, []aproto.DeviceProblemKind{
+		aproto.RetiredPagesPending, aproto.UncorrectableECCErrors, aproto.ThermalThrottling,
+	})
+}
+
+func TestParseXids(t *testing.T) {
+	assert.DeepEqual(t, parseXids([]byte(testKernelLog)), map[string][]int{
+		"5e:00": {13},
+		"3b:00": {79},
+	})
+}
+
+func TestHealthCheckerCuda(t *testing.T) {
+	kernelLog := ""
+	defer func(f func([]string) ([]byte, error)) { runDeviceCommand = f }(runDeviceCommand)
+	runDeviceCommand = func(args []string) ([]byte, error) {
+		if args[0] == kernelLogArgs[0] {
+			return []byte(kernelLog), nil
+		}
+		if args[1] == detectCudaDevices[1] {
+			return []byte(testCudaDeviceList), nil
+		}
+		return []byte(testCudaHealthData), nil
+	}
+
+	devices := []device.Device{
+		{ID: 0, UUID: "GPU-aaaa", Type: device.CUDA},
+		{ID: 1, UUID: "GPU-bbbb", Type: device.CUDA},
+		{ID: 2, UUID: "GPU-cccc", Type: device.CUDA},
+		{ID: 3, UUID: "MIG-dddd", Type: device.CUDA},
+		{ID: 4, Type: device.CPU},
+	}
+	h := NewHealthChecker(devices)
+
+	// Xid errors logged before the first check are ignored.
+	kernelLog = testKernelLogAtStart
+	health := h.Check()
+	assert.Equal(t, len(health), 4)
+	assert.Equal(t, len(health[0].Problems), 0)
+	assert.Equal(t, len(health[1].Fatal()), 2)
+	assert.Equal(t, len(health[1].Problems), 3)
+	assert.DeepEqual(t, health[2].Problems, []aproto.DeviceProblem{{
+		Kind: aproto.FallenOffBus, Detail: "GPU is missing from nvidia-smi",
+	}})
+	// MIG instances share the problems of their GPU.
+	assert.DeepEqual(t, health[3].Problems, health[1].Problems)
+
+	// Xid errors logged since are reported until the agent restarts.
+	kernelLog = testKernelLog
+	for i := 0; i < 2; i++ {
+		health = h.Check()
+		assert.DeepEqual(t, health[0].Fatal(), []aproto.DeviceProblem{{
+			Kind: aproto.XidError, Detail: "Xid 79, GPU has fallen off the bus", Fatal: true,
+		}})
+		assert.Equal(t, len(health[1].Problems), 3)
+	}
+
+	// A GPU is only fatally lost once it has been missing for several checks in a row.
+	assert.DeepEqual(t, health[2].Fatal(), []aproto.DeviceProblem{{
+		Kind: aproto.FallenOffBus, Detail: "GPU is missing from nvidia-smi", Fatal: true,
+	}})
+}
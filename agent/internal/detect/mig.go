@@ -0,0 +1,186 @@
+package detect
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+var (
+	detectMIGEnabled = []string{
+		"nvidia-smi", "--query-gpu=index,uuid,mig.mode.current", "--format=csv,noheader",
+	}
+	detectCudaDevices = []string{"nvidia-smi", "-L"} // Lists both GPUs and MIG instances
+	detectGPURegExp   = regexp.MustCompile(`^GPU (\d+):.+\(UUID: (GPU-[^)]+)\)`)
+	detectMIGRegExp   = regexp.MustCompile(`(?P<dev>MIG \S+).+\(UUID.+(?P<uuid>MIG.+)\)`)
+)
+
+// migGPU is a MIG-enabled GPU and its MIG instances.
+type migGPU struct {
+	index     int
+	uuid      string
+	instances []device.Device
+}
+
+// ReconcileMIG partitions every visible MIG-enabled GPU into the instances of the profiles, unless
+// it is partitioned so already. Partitioning a GPU destroys its instances, so it must only be done
+// while no containers use the GPUs. It returns true if it partitioned any GPU.
+func ReconcileMIG(profiles []model.MIGProfileConfig, visibleGPUs string) (bool, error) {
+	var want []string
+	for _, p := range profiles {
+		for i := 0; i < p.Count; i++ {
+			want = append(want, p.Profile)
+		}
+	}
+	if len(want) == 0 {
+		return false, nil
+	}
+	sort.Strings(want)
+
+	gpus, err := queryMIGGPUs(visibleGPUs)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to detect MIG instances")
+	}
+	var partitioned bool
+	for _, gpu := range gpus {
+		have := make([]string, 0, len(gpu.instances))
+		for _, d := range gpu.instances {
+			have = append(have, d.Profile)
+		}
+		sort.Strings(have)
+		if slices.Equal(have, want) {
+			continue
+		}
+
+		log.Infof("partitioning GPU %d from MIG profiles %v into %v", gpu.index, have, want)
+		if err := partitionMIG(gpu.index, want); err != nil {
+			return partitioned, err
+		}
+		partitioned = true
+	}
+	return partitioned, nil
+}
+
+// partitionMIG replaces the MIG instances of the GPU with instances of the profiles.
+func partitionMIG(index int, profiles []string) error {
+	id := strconv.Itoa(index)
+	// Destroying fails when the GPU has no instances to destroy; creating the instances reports
+	// any real problem, such as processes still using the GPU.
+	for _, flag := range []string{"-dci", "-dgi"} {
+		if out, err := runDeviceCommand([]string{"nvidia-smi", "mig", "-i", id, flag}); err != nil {
+			log.WithError(err).WithField("output", string(out)).Debugf(
+				"nvidia-smi mig %s failed on GPU %d", flag, index)
+		}
+	}
+
+	out, err := runDeviceCommand([]string{
+		"nvidia-smi", "mig", "-i", id, "-cgi", strings.Join(profiles, ","), "-C",
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create MIG instances on GPU %d: %s", index, out)
+	}
+	return nil
+}
+
+// queryMIGGPUs returns the visible GPUs that have MIG enabled, or nothing if nvidia-smi is not
+// installed.
+func queryMIGGPUs(visibleGPUs string) ([]migGPU, error) {
+	out, err := runDeviceCommand(detectMIGEnabled)
+	if execError, ok := err.(*exec.Error); ok && execError.Err == exec.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to detect MIG mode: %s", out)
+	}
+
+	enabled := map[string]bool{}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil || len(record) != 3 {
+			continue
+		}
+		index, uuid := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if strings.TrimSpace(record[2]) == "Enabled" && gpuVisible(visibleGPUs, index, uuid) {
+			enabled[uuid] = true
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, nil
+	}
+
+	out, err = runDeviceCommand(detectCudaDevices)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list MIG instances: %s", out)
+	}
+	var gpus []migGPU
+	for _, gpu := range parseCudaDeviceList(out) {
+		if enabled[gpu.uuid] {
+			gpus = append(gpus, gpu)
+		}
+	}
+	return gpus, nil
+}
+
+// parseCudaDeviceList parses the GPUs and MIG instances that nvidia-smi -L lists, such as
+//
+//	GPU 0: NVIDIA A100-SXM4-80GB (UUID: GPU-5d5ba0d6-...)
+//	  MIG 1g.10gb     Device  0: (UUID: MIG-c6d4f1ef-...)
+func parseCudaDeviceList(out []byte) []migGPU {
+	var gpus []migGPU
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if matches := detectGPURegExp.FindStringSubmatch(line); matches != nil {
+			index, err := strconv.Atoi(matches[1])
+			if err != nil {
+				continue
+			}
+			gpus = append(gpus, migGPU{index: index, uuid: matches[2]})
+			continue
+		}
+
+		matches := detectMIGRegExp.FindStringSubmatch(line)
+		if len(matches) != 3 || len(gpus) == 0 {
+			continue
+		}
+		brand := matches[1]
+		gpu := &gpus[len(gpus)-1]
+		gpu.instances = append(gpu.instances, device.Device{
+			Brand:   brand,
+			UUID:    matches[2],
+			Type:    device.CUDA,
+			Profile: strings.TrimPrefix(brand, "MIG "),
+		})
+	}
+	return gpus
+}
+
+// gpuVisible returns true if the GPU is in the comma-separated list of visible GPU indices or
+// UUIDs, or if the list is empty.
+func gpuVisible(visibleGPUs, index, uuid string) bool {
+	if visibleGPUs == "" {
+		return true
+	}
+	for _, id := range strings.Split(visibleGPUs, ",") {
+		if id = strings.TrimSpace(id); id == index || id == uuid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package detect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// fakeNvidiaSMI fakes the nvidia-smi commands that detect and partition MIG instances.
+type fakeNvidiaSMI struct {
+	migEnabled []bool
+	instances  [][]string
+	commands   []string
+}
+
+func (f *fakeNvidiaSMI) run(args []string) ([]byte, error) {
+	f.commands = append(f.commands, strings.Join(args[1:], " "))
+	var out strings.Builder
+	switch {
+	case args[1] == detectMIGEnabled[1]:
+		for i, enabled := range f.migEnabled {
+			mode := "Disabled"
+			if enabled {
+				mode = "Enabled"
+			}
+			fmt.Fprintf(&out, "%d, GPU-%d, %s\n", i, i, mode)
+		}
+	case args[1] == "-L":
+		for i, profiles := range f.instances {
+			fmt.Fprintf(&out, "GPU %d: NVIDIA A100-SXM4-80GB (UUID: GPU-%d)\n", i, i)
+			for j, p := range profiles {
+				fmt.Fprintf(&out, "  MIG %s     Device  %d: (UUID: MIG-%d-%d)\n", p, j, i, j)
+			}
+		}
+	case args[1] == "mig":
+		i, err := strconv.Atoi(args[3])
+		if err != nil {
+			return nil, err
+		}
+		switch args[4] {
+		case "-dgi":
+			f.instances[i] = nil
+		case "-cgi":
+			f.instances[i] = strings.Split(args[5], ",")
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+func withFakeNvidiaSMI(t *testing.T, f *fakeNvidiaSMI) {
+	orig := runDeviceCommand
+	t.Cleanup(func() { runDeviceCommand = orig })
+	runDeviceCommand = f.run
+}
+
+func TestDetectMigInstances(t *testing.T) {
+	withFakeNvidiaSMI(t, &fakeNvidiaSMI{
+		migEnabled: []bool{true, false, true},
+		instances:  [][]string{{"3g.40gb", "1g.10gb"}, nil, {"7g.80gb"}},
+	})
+
+	devices, err := detectMigInstances("")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, devices, []device.Device{
+		{ID: 0, Brand: "MIG 3g.40gb", UUID: "MIG-0-0", Type: device.CUDA, Profile: "3g.40gb"},
+		{ID: 1, Brand: "MIG 1g.10gb", UUID: "MIG-0-1", Type: device.CUDA, Profile: "1g.10gb"},
+		{ID: 2, Brand: "MIG 7g.80gb", UUID: "MIG-2-0", Type: device.CUDA, Profile: "7g.80gb"},
+	})
+
+	devices, err = detectMigInstances("GPU-2")
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 1)
+	assert.Equal(t, devices[0].UUID, "MIG-2-0")
+
+	devices, err = detectMigInstances("1")
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 0)
+}
+
+func TestReconcileMIG(t *testing.T) {
+	smi := &fakeNvidiaSMI{
+		migEnabled: []bool{true, true, false},
+		instances:  [][]string{{"7g.80gb"}, {"1g.10gb", "2g.20gb", "1g.10gb"}, nil},
+	}
+	withFakeNvidiaSMI(t, smi)
+
+	profiles := []model.MIGProfileConfig{
+		{Profile: "2g.20gb", Count: 1},
+		{Profile: "1g.10gb", Count: 2},
+	}
+	partitioned, err := ReconcileMIG(profiles, "")
+	assert.NilError(t, err)
+	assert.Assert(t, partitioned)
+	assert.DeepEqual(t, smi.instances, [][]string{
+		{"1g.10gb", "1g.10gb", "2g.20gb"}, {"1g.10gb", "2g.20gb", "1g.10gb"}, nil,
+	})
+	assert.DeepEqual(t, smi.commands[2:], []string{
+		"mig -i 0 -dci",
+		"mig -i 0 -dgi",
+		"mig -i 0 -cgi 1g.10gb,1g.10gb,2g.20gb -C",
+	})
+
+	// GPUs already partitioned into the profiles are left alone.
+	smi.commands = nil
+	partitioned, err = ReconcileMIG(profiles, "")
+	assert.NilError(t, err)
+	assert.Assert(t, !partitioned)
+	assert.Equal(t, len(smi.commands), 2)
+
+	devices, err := detectMigInstances("")
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 6)
+	for _, d := range devices {
+		assert.Assert(t, d.Profile == "1g.10gb" || d.Profile == "2g.20gb", d.Profile)
+	}
+}
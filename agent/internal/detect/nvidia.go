@@ -1,12 +1,10 @@
 package detect
 
 import (
-	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
 	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -17,11 +15,6 @@ import (
 )
 
 var (
-	detectMIGEnabled = []string{
-		"nvidia-smi", "--query-gpu=mig.mode.current", "--format=csv,noheader",
-	}
-	detectMIGRegExp    = regexp.MustCompile(`(?P<dev>MIG \S+).+\(UUID.+(?P<uuid>MIG.+)\)`)
-	detectCudaDevices  = []string{"nvidia-smi", "-L"} // Lists both GPUs and MIG instances
 	detectCudaGPUsArgs = []string{
 		"nvidia-smi", "--query-gpu=index,name,uuid", "--format=csv,noheader",
 	}
@@ -112,56 +105,19 @@ func detectCudaGPUs(visibleGPUs string) ([]device.Device, error) {
 	}
 }
 
-// detect if MIG is enabled and if there are instances configured.
+// detectMigInstances returns the MIG instances of the visible GPUs that have MIG enabled.
 func detectMigInstances(visibleGPUs string) ([]device.Device, error) {
-	// Fail fast if MIG isn't even enabled
-	// #nosec G204
-	cmd := exec.Command(detectMIGEnabled[0], detectMIGEnabled[1:]...)
-	out, err := cmd.Output()
-	if execError, ok := err.(*exec.Error); ok && execError.Err == exec.ErrNotFound {
-		return nil, nil
-	} else if err != nil {
-		log.WithError(err).WithField("output", string(out)).Warnf(
-			"error while executing nvidia-smi to detect MIG mode")
-		return nil, nil
-	}
-	if !strings.HasPrefix(string(out), "Enabled") {
-		return nil, nil
-	}
-
-	// #nosec G204
-	cmd = exec.Command(detectCudaDevices[0], detectCudaDevices[1:]...)
-	out, err = cmd.Output()
+	gpus, err := queryMIGGPUs(visibleGPUs)
 	if err != nil {
-		log.WithError(err).WithField("output", string(out)).Warnf(
-			"error while executing nvidia-smi to detect MIG instances")
+		log.WithError(err).Warn("error while executing nvidia-smi to detect MIG instances")
 		return nil, nil
 	}
 
 	devices := make([]device.Device, 0)
-	deviceIndex := 0
-
-	scanner := bufio.NewScanner(strings.NewReader(string(out)))
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if detectMIGRegExp.MatchString(line) {
-			matches := detectMIGRegExp.FindStringSubmatch(line)
-			if len(matches) != 3 {
-				continue
-			}
-			brand := matches[1]
-			uuid := matches[2]
-			devices = append(
-				devices,
-				device.Device{
-					ID:    device.ID(deviceIndex),
-					Brand: brand,
-					UUID:  uuid,
-					Type:  device.CUDA,
-				},
-			)
-			deviceIndex++
+	for _, gpu := range gpus {
+		for _, d := range gpu.instances {
+			d.ID = device.ID(len(devices))
+			devices = append(devices, d)
 		}
 	}
 	return devices, nil
@@ -0,0 +1,145 @@
+package detect
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+const mib = 1 << 20
+
+var (
+	sampleCudaArgs = []string{
+		"nvidia-smi",
+		"--query-gpu=uuid,utilization.gpu,memory.used,power.draw",
+		"--format=csv,noheader,nounits",
+	}
+	sampleRocmArgs = []string{
+		"rocm-smi", "--showuniqueid", "--showuse", "--showmeminfo", "vram", "--showpower", "--json",
+	}
+
+	// rocmPowerKeys are the names rocm-smi has used for the power draw across versions.
+	rocmPowerKeys = []string{
+		"Average Graphics Package Power (W)",
+		"Current Socket Graphics Package Power (W)",
+	}
+)
+
+// SampleGPUs returns the current usage of the GPUs among the devices, by UUID. GPUs that cannot
+// be sampled, such as MIG instances, are left out.
+func SampleGPUs(devices []device.Device) map[string]aproto.GPUSample {
+	var cuda, rocm bool
+	for _, d := range devices {
+		switch d.Type {
+		case device.CUDA:
+			cuda = true
+		case device.ROCM:
+			rocm = true
+		}
+	}
+
+	now := time.Now().UTC()
+	samples := map[string]aproto.GPUSample{}
+	if cuda {
+		if out, ok := runSampleCommand(sampleCudaArgs); ok {
+			for uuid, s := range parseCudaSamples(out) {
+				s.Time = now
+				samples[uuid] = s
+			}
+		}
+	}
+	if rocm {
+		if out, ok := runSampleCommand(sampleRocmArgs); ok {
+			for uuid, s := range parseRocmSamples(out) {
+				s.Time = now
+				samples[uuid] = s
+			}
+		}
+	}
+	return samples
+}
+
+func runSampleCommand(args []string) ([]byte, bool) {
+	out, err := runDeviceCommand(args)
+	if execError, ok := err.(*exec.Error); ok && execError.Err == exec.ErrNotFound {
+		return nil, false
+	} else if err != nil {
+		// The tools fail when a GPU is unhealthy, but still print the healthy GPUs.
+		log.WithError(err).WithField("output", string(out)).Debugf(
+			"error while executing %s to sample GPU usage", args[0])
+	}
+	return out, true
+}
+
+// parseCudaSamples parses the output of sampleCudaArgs. Fields nvidia-smi reports as unsupported
+// are left zero.
+func parseCudaSamples(out []byte) map[string]aproto.GPUSample {
+	samples := map[string]aproto.GPUSample{}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return samples
+		} else if err != nil || len(record) != 4 {
+			continue
+		}
+
+		uuid := strings.TrimSpace(record[0])
+		samples[uuid] = aproto.GPUSample{
+			DeviceUUID:  uuid,
+			Utilization: parseSampleFloat(record[1]),
+			MemoryUsed:  uint64(parseSampleFloat(record[2]) * mib),
+			Power:       parseSampleFloat(record[3]),
+		}
+	}
+}
+
+// parseRocmSamples parses the output of sampleRocmArgs.
+func parseRocmSamples(out []byte) map[string]aproto.GPUSample {
+	parsed := map[string]map[string]string{}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		log.WithError(err).WithField("output", string(out)).Debug(
+			"error parsing rocm-smi output to sample GPU usage")
+		return nil
+	}
+
+	samples := map[string]aproto.GPUSample{}
+	for _, card := range parsed {
+		uuid := card["Unique ID"]
+		if uuid == "" {
+			continue
+		}
+		s := aproto.GPUSample{
+			DeviceUUID:  uuid,
+			Utilization: parseSampleFloat(card["GPU use (%)"]),
+			MemoryUsed:  uint64(parseSampleFloat(card["VRAM Total Used Memory (B)"])),
+		}
+		for _, key := range rocmPowerKeys {
+			if power, ok := card[key]; ok {
+				s.Power = parseSampleFloat(power)
+				break
+			}
+		}
+		samples[uuid] = s
+	}
+	return samples
+}
+
+func parseSampleFloat(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,49 @@
+package detect
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+const testCudaSampleData = `GPU-aaaa, 87, 1024, 250.51
+GPU-bbbb, 0, 0, [N/A]
+Unable to determine the device handle for GPU0000:86:00.0: Unknown Error
+`
+
+const testRocmSampleData = `{
+	"card0": {"Unique ID": "0xaaaa", "GPU use (%)": "42", "VRAM Total Used Memory (B)": "4096",
+		"Average Graphics Package Power (W)": "101.0"},
+	"card1": {"Unique ID": "0xbbbb", "GPU use (%)": "0", "VRAM Total Used Memory (B)": "0",
+		"Current Socket Graphics Package Power (W)": "35.0"}
+}`
+
+func TestSampleGPUs(t *testing.T) {
+	defer func(f func([]string) ([]byte, error)) { runDeviceCommand = f }(runDeviceCommand)
+	runDeviceCommand = func(args []string) ([]byte, error) {
+		if args[0] == sampleRocmArgs[0] {
+			return []byte(testRocmSampleData), nil
+		}
+		return []byte(testCudaSampleData), nil
+	}
+
+	samples := SampleGPUs([]device.Device{
+		{ID: 0, UUID: "GPU-aaaa", Type: device.CUDA},
+		{ID: 1, UUID: "0xaaaa", Type: device.ROCM},
+	})
+	assert.Equal(t, len(samples), 4)
+
+	s := samples["GPU-aaaa"]
+	assert.Assert(t, !s.Time.IsZero())
+	s.Time = time.Time{}
+	assert.DeepEqual(t, s, aproto.GPUSample{
+		DeviceUUID: "GPU-aaaa", Utilization: 87, MemoryUsed: 1024 * mib, Power: 250.51,
+	})
+	assert.Equal(t, samples["GPU-bbbb"].Power, 0.0)
+	assert.Equal(t, samples["0xaaaa"].MemoryUsed, uint64(4096))
+	assert.Equal(t, samples["0xbbbb"].Power, 35.0)
+}
@@ -0,0 +1,200 @@
+package detect
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/mem"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+var (
+	queryCudaBusArgs = []string{"nvidia-smi", "--query-gpu=uuid,pci.bus_id", "--format=csv,noheader"}
+
+	// sysfsRoot is where sysfs is mounted. It is a variable so that tests can fake sysfs.
+	sysfsRoot = "/sys"
+)
+
+// Topology describes the CPUs and memory of the machine and where the GPUs sit among them.
+type Topology struct {
+	// NodeCPUs are the online CPUs of each NUMA node. Machines without NUMA information have all
+	// their CPUs on node 0.
+	NodeCPUs map[int][]int
+	// MemoryBytes is the total memory of the machine, or 0 if it is unknown.
+	MemoryBytes int64
+	// DeviceNodes is the NUMA node of each GPU whose node is known, by UUID.
+	DeviceNodes map[string]int
+}
+
+// DetectTopology returns the topology of the machine for the devices of the agent.
+func DetectTopology(devices []device.Device) Topology {
+	topo := Topology{
+		NodeCPUs:    detectNodeCPUs(),
+		DeviceNodes: map[string]int{},
+	}
+
+	if vm, err := mem.VirtualMemory(); err != nil {
+		log.WithError(err).Warn("failed to detect the memory of the machine")
+	} else {
+		topo.MemoryBytes = int64(vm.Total)
+	}
+
+	var cudaBuses map[string]string
+	for _, d := range devices {
+		var bus string
+		switch d.Type {
+		case device.CUDA:
+			if cudaBuses == nil {
+				cudaBuses = queryCudaBuses()
+			}
+			bus = cudaBuses[d.UUID]
+		case device.ROCM:
+			if rocmDevice := GetRocmDeviceByUUID(d.UUID); rocmDevice != nil {
+				bus = rocmDevice.PCIBus
+			}
+		}
+		if bus == "" {
+			continue
+		}
+		if node, ok := pciNumaNode(bus); ok {
+			topo.DeviceNodes[d.UUID] = node
+		}
+	}
+	return topo
+}
+
+func detectNodeCPUs() map[int][]int {
+	nodeCPUs := map[int][]int{}
+	paths, _ := filepath.Glob(filepath.Join(sysfsRoot, "devices/system/node/node*/cpulist"))
+	for _, path := range paths {
+		node, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(path)), "node"))
+		if err != nil {
+			continue
+		}
+		if cpus := readCPUList(path); len(cpus) > 0 {
+			nodeCPUs[node] = cpus
+		}
+	}
+	if len(nodeCPUs) > 0 {
+		return nodeCPUs
+	}
+
+	if cpus := readCPUList(filepath.Join(sysfsRoot, "devices/system/cpu/online")); len(cpus) > 0 {
+		return map[int][]int{0: cpus}
+	}
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+	return map[int][]int{0: cpus}
+}
+
+func readCPUList(path string) []int {
+	// #nosec G304 // The path is within sysfs.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	cpus, err := ParseCPUList(string(data))
+	if err != nil {
+		log.WithError(err).Warnf("failed to parse CPU list %s", path)
+		return nil
+	}
+	return cpus
+}
+
+// ParseCPUList parses a list of CPUs in the format of sysfs and cpusets, such as 0-3,8,10-11.
+func ParseCPUList(s string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(s), ",") {
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU %q: %w", part, err)
+		}
+		hi := lo
+		if len(bounds) == 2 {
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid CPU range %q: %w", part, err)
+			}
+		}
+		for cpu := lo; cpu <= hi; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// FormatCPUList formats sorted CPUs in the format of sysfs and cpusets, such as 0-3,8,10-11.
+func FormatCPUList(cpus []int) string {
+	var parts []string
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(cpus[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// queryCudaBuses returns the PCI address of each CUDA GPU, by UUID.
+func queryCudaBuses() map[string]string {
+	out, err := runDeviceCommand(queryCudaBusArgs)
+	if err != nil {
+		log.WithError(err).WithField("output", string(out)).Debug(
+			"error while executing nvidia-smi to find the PCI addresses of GPUs")
+	}
+
+	buses := map[string]string{}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return buses
+		} else if err != nil || len(record) != 2 {
+			continue
+		}
+		buses[strings.TrimSpace(record[0])] = strings.TrimSpace(record[1])
+	}
+}
+
+// pciNumaNode returns the NUMA node of the PCI device at the address, such as 00000000:3B:00.0 as
+// nvidia-smi prints it or 0000:3b:00.0 as rocm-smi and sysfs do.
+func pciNumaNode(addr string) (int, bool) {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	if parts := strings.SplitN(addr, ":", 2); len(parts) == 2 && len(parts[0]) > 4 {
+		addr = parts[0][len(parts[0])-4:] + ":" + parts[1]
+	}
+
+	// #nosec G304 // The path is within sysfs.
+	data, err := os.ReadFile(filepath.Join(sysfsRoot, "bus/pci/devices", addr, "numa_node"))
+	if err != nil {
+		return 0, false
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || node < 0 {
+		return 0, false
+	}
+	return node, true
+}
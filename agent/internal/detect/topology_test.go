@@ -0,0 +1,47 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+func TestCPUList(t *testing.T) {
+	cpus, err := ParseCPUList("8,0-3,10-11\n")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cpus, []int{0, 1, 2, 3, 8, 10, 11})
+	assert.Equal(t, FormatCPUList(cpus), "0-3,8,10-11")
+
+	_, err = ParseCPUList("0-a")
+	assert.ErrorContains(t, err, "invalid CPU range")
+}
+
+func TestDetectTopology(t *testing.T) {
+	defer func(root string) { sysfsRoot = root }(sysfsRoot)
+	sysfsRoot = t.TempDir()
+	writeSysfs := func(path, content string) {
+		path = filepath.Join(sysfsRoot, path)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	writeSysfs("devices/system/node/node0/cpulist", "0-3\n")
+	writeSysfs("devices/system/node/node1/cpulist", "4-7\n")
+	writeSysfs("bus/pci/devices/0000:3b:00.0/numa_node", "1\n")
+	writeSysfs("bus/pci/devices/0000:5e:00.0/numa_node", "-1\n")
+
+	defer func(f func([]string) ([]byte, error)) { runDeviceCommand = f }(runDeviceCommand)
+	runDeviceCommand = func(args []string) ([]byte, error) {
+		return []byte("GPU-aaaa, 00000000:3B:00.0\nGPU-bbbb, 00000000:5E:00.0\n"), nil
+	}
+
+	topo := DetectTopology([]device.Device{
+		{ID: 0, UUID: "GPU-aaaa", Type: device.CUDA},
+		{ID: 1, UUID: "GPU-bbbb", Type: device.CUDA},
+	})
+	assert.DeepEqual(t, topo.NodeCPUs, map[int][]int{0: {0, 1, 2, 3}, 1: {4, 5, 6, 7}})
+	assert.DeepEqual(t, topo.DeviceNodes, map[string]int{"GPU-aaaa": 1})
+}
@@ -213,7 +213,7 @@ func (f *Fluent) startContainer(ctx context.Context) (string, error) {
 
 	var tlsConfig model.TLSClientConfig
 	switch l := f.mopts.LoggingOptions; {
-	case l.DefaultLoggingConfig != nil:
+	case l.DefaultLoggingConfig != nil, l.LokiLoggingConfig != nil:
 		t := f.opts.Security.TLS
 		tlsConfig = model.TLSClientConfig{
 			Enabled:         t.Enabled,
@@ -329,7 +329,8 @@ func (f *Fluent) startContainer(ctx context.Context) (string, error) {
 
 	log.Infof("Fluent Bit listening on host port %d", f.opts.Fluent.Port)
 	switch {
-	case f.mopts.LoggingOptions.DefaultLoggingConfig != nil:
+	case f.mopts.LoggingOptions.DefaultLoggingConfig != nil,
+		f.mopts.LoggingOptions.LokiLoggingConfig != nil:
 		log.Infof("Fluent Bit shipping to Determined at %s:%d", f.opts.MasterHost, f.opts.MasterPort)
 	case f.mopts.LoggingOptions.ElasticLoggingConfig != nil:
 		eopts := f.mopts.LoggingOptions.ElasticLoggingConfig
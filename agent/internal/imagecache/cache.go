@@ -0,0 +1,296 @@
+package imagecache
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+
+	"github.com/determined-ai/determined/agent/internal/container"
+	"github.com/determined-ai/determined/agent/pkg/docker"
+	"github.com/determined-ai/determined/agent/pkg/events"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+)
+
+const (
+	syncInterval = time.Minute
+	gb           = 1 << 30
+)
+
+// Cache pulls the images the master asks for ahead of the containers that need them and, when
+// the container runtime can list and remove its images, keeps the images on the agent under a
+// size limit by removing the least recently used ones that no container is using. Only images the
+// agent pulled or ran since it started are removed, so images that others put on the node are left
+// alone. It reports the images on the agent to the master, so that tasks can be scheduled where
+// their image is present.
+type Cache struct {
+	cruntime container.ContainerRuntime
+	maxSize  int64
+	inUse    func() []string
+	log      *logrus.Entry
+
+	mu      sync.Mutex
+	prePull []string
+	managed map[string]bool // The canonical names of the images the agent pulled or ran.
+	resend  bool
+	wake    chan struct{}
+
+	// Only accessed by Run.
+	lastUsed map[string]time.Time
+	reported []string
+}
+
+// New returns a Cache over the images of the container runtime. inUse returns the images of the
+// containers on the agent, which are never removed; maxSizeGB of 0 disables the size limit.
+func New(cruntime container.ContainerRuntime, maxSizeGB int, inUse func() []string) *Cache {
+	return &Cache{
+		cruntime: cruntime,
+		maxSize:  int64(maxSizeGB) * gb,
+		inUse:    inUse,
+		log:      logrus.WithField("component", "image-cache"),
+		managed:  map[string]bool{},
+		wake:     make(chan struct{}, 1),
+		lastUsed: map[string]time.Time{},
+	}
+}
+
+// SetPrePull replaces the images to pull ahead of time. They are kept in the cache regardless of
+// its size.
+func (c *Cache) SetPrePull(images []string) {
+	c.mu.Lock()
+	c.prePull = images
+	c.mu.Unlock()
+	c.notify()
+}
+
+// Resend reports the images on the agent again, even if they have not changed, such as after the
+// agent reconnects to the master.
+func (c *Cache) Resend() {
+	c.mu.Lock()
+	c.resend = true
+	c.mu.Unlock()
+	c.notify()
+}
+
+// Track marks an image that a container is started with as managed by the agent, so that it can
+// be removed once it is no longer used.
+func (c *Cache) Track(image string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.managed[canonical(image)] = true
+}
+
+func (c *Cache) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run synchronizes the cache periodically and whenever the images to pre-pull change, until the
+// context is canceled.
+func (c *Cache) Run(ctx context.Context, out chan *aproto.MasterMessage) {
+	for {
+		images := c.sync(ctx)
+
+		c.mu.Lock()
+		resend := c.resend
+		c.resend = false
+		c.mu.Unlock()
+
+		if images != nil && (resend || !reflect.DeepEqual(images, c.reported)) {
+			select {
+			case out <- &aproto.MasterMessage{
+				ImageCacheChanged: &aproto.ImageCacheChanged{Images: images},
+			}:
+				c.reported = images
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-time.After(syncInterval):
+		case <-c.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync pulls missing images, removes images over the size limit and returns the names of the
+// images on the agent, or nil if they could not be listed.
+func (c *Cache) sync(ctx context.Context) []string {
+	c.mu.Lock()
+	prePull := c.prePull
+	c.mu.Unlock()
+	inUse := c.inUse()
+
+	pulled := make([]string, 0, len(prePull))
+	for _, image := range prePull {
+		// The runtime skips the pull when the image is already present.
+		err := c.cruntime.PullImage(
+			ctx, docker.PullImage{Name: image}, events.NilPublisher[docker.Event]{},
+		)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			c.log.WithError(err).Warnf("failed to pre-pull image %s", image)
+			continue
+		}
+		pulled = append(pulled, image)
+	}
+
+	c.mu.Lock()
+	for _, image := range append(pulled, inUse...) {
+		c.managed[canonical(image)] = true
+	}
+	managed := maps.Clone(c.managed)
+	c.mu.Unlock()
+
+	store, ok := c.cruntime.(container.ImageStore)
+	if !ok {
+		// Without a way to list the images, only report the ones known to be present.
+		return Names(append(pulled, inUse...))
+	}
+
+	images, err := store.ListImages(ctx)
+	if err != nil {
+		c.log.WithError(err).Warn("failed to list images")
+		return nil
+	}
+	if c.maxSize > 0 {
+		images = c.evict(ctx, store, images, managed, canonicalSet(prePull), canonicalSet(inUse))
+	}
+
+	var tags []string
+	for _, image := range images {
+		tags = append(tags, image.Tags...)
+	}
+	return Names(tags)
+}
+
+// evict removes the least recently used managed images that are neither pinned nor in use until
+// the images fit within the size limit, returning the remaining images. Images with names the
+// agent does not manage are never removed.
+func (c *Cache) evict(
+	ctx context.Context,
+	store container.ImageStore,
+	images []docker.Image,
+	managed, pinned, inUse map[string]bool,
+) []docker.Image {
+	now := time.Now()
+	var total int64
+	var candidates, kept []docker.Image
+	present := map[string]bool{}
+	for _, image := range images {
+		total += image.Size
+		present[image.ID] = true
+
+		var used, keep bool
+		for _, tag := range image.Tags {
+			name := canonical(tag)
+			used = used || inUse[name]
+			keep = keep || inUse[name] || pinned[name] || !managed[name]
+		}
+		if used {
+			c.lastUsed[image.ID] = now
+		} else if _, ok := c.lastUsed[image.ID]; !ok {
+			c.lastUsed[image.ID] = image.Created
+		}
+		if keep {
+			kept = append(kept, image)
+		} else {
+			candidates = append(candidates, image)
+		}
+	}
+	for id := range c.lastUsed {
+		if !present[id] {
+			delete(c.lastUsed, id)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return c.lastUsed[candidates[i].ID].Before(c.lastUsed[candidates[j].ID])
+	})
+	for i, image := range candidates {
+		if total <= c.maxSize {
+			return append(kept, candidates[i:]...)
+		}
+		c.log.Infof("removing image %v to keep the image cache under %d bytes", image.Tags, c.maxSize)
+		if err := removeImage(ctx, store, image); err != nil {
+			c.log.WithError(err).Warnf("failed to remove image %v", image.Tags)
+			kept = append(kept, image)
+			continue
+		}
+		total -= image.Size
+		delete(c.lastUsed, image.ID)
+	}
+	if total > c.maxSize {
+		c.log.Warnf("image cache uses %d bytes, over its limit of %d bytes, "+
+			"but the remaining images are in use, pre-pulled or not managed by the agent",
+			total, c.maxSize)
+	}
+	return kept
+}
+
+// removeImage removes every name of an image, which removes the image with the last one. It
+// stops at the first name that cannot be removed, such as when a stopped container uses the image.
+func removeImage(ctx context.Context, store container.ImageStore, image docker.Image) error {
+	for _, tag := range image.Tags {
+		if err := store.RemoveImage(ctx, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names returns the sorted, deduplicated names that tasks may refer to the images by: the short
+// and fully qualified forms, and the repository alone for images tagged latest.
+func Names(images []string) []string {
+	set := map[string]bool{}
+	for _, image := range images {
+		ref, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			set[image] = true
+			continue
+		}
+		ref = reference.TagNameOnly(ref)
+		set[reference.FamiliarString(ref)] = true
+		set[ref.String()] = true
+		if tagged, ok := ref.(reference.Tagged); ok && tagged.Tag() == "latest" {
+			set[reference.FamiliarName(ref)] = true
+			set[ref.Name()] = true
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// canonical returns the fully qualified form of an image name, so that different ways to refer to
+// an image compare equal.
+func canonical(image string) string {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image
+	}
+	return reference.TagNameOnly(ref).String()
+}
+
+func canonicalSet(images []string) map[string]bool {
+	set := make(map[string]bool, len(images))
+	for _, image := range images {
+		set[canonical(image)] = true
+	}
+	return set
+}
@@ -0,0 +1,118 @@
+package imagecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/agent/internal/container"
+	"github.com/determined-ai/determined/agent/pkg/docker"
+	"github.com/determined-ai/determined/agent/pkg/events"
+)
+
+type fakeRuntime struct {
+	container.ContainerRuntime
+
+	images  []docker.Image
+	pulled  []string
+	removed []string
+}
+
+func (f *fakeRuntime) PullImage(
+	ctx context.Context, req docker.PullImage, p events.Publisher[docker.Event],
+) error {
+	for _, image := range f.images {
+		for _, tag := range image.Tags {
+			if canonical(tag) == canonical(req.Name) {
+				return nil
+			}
+		}
+	}
+	f.pulled = append(f.pulled, req.Name)
+	f.images = append(f.images, docker.Image{
+		ID: "sha256:" + req.Name, Tags: []string{req.Name}, Size: gb, Created: time.Now(),
+	})
+	return nil
+}
+
+func (f *fakeRuntime) ListImages(ctx context.Context) ([]docker.Image, error) {
+	return append([]docker.Image(nil), f.images...), nil
+}
+
+func (f *fakeRuntime) RemoveImage(ctx context.Context, name string) error {
+	f.removed = append(f.removed, name)
+	for i, image := range f.images {
+		for j, tag := range image.Tags {
+			if tag != name {
+				continue
+			}
+			image.Tags = append(image.Tags[:j:j], image.Tags[j+1:]...)
+			if len(image.Tags) == 0 {
+				f.images = append(f.images[:i], f.images[i+1:]...)
+			} else {
+				f.images[i] = image
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestNames(t *testing.T) {
+	assert.DeepEqual(t, Names([]string{"ubuntu:latest", "determinedai/env:tag", "ubuntu"}), []string{
+		"determinedai/env:tag",
+		"docker.io/determinedai/env:tag",
+		"docker.io/library/ubuntu",
+		"docker.io/library/ubuntu:latest",
+		"ubuntu",
+		"ubuntu:latest",
+	})
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	cruntime := &fakeRuntime{images: []docker.Image{
+		{ID: "old", Tags: []string{"old:1"}, Size: gb, Created: now.Add(-3 * time.Hour)},
+		{ID: "older", Tags: []string{"older:1"}, Size: gb, Created: now.Add(-4 * time.Hour)},
+		{ID: "oldest", Tags: []string{"oldest:1"}, Size: gb, Created: now.Add(-5 * time.Hour)},
+		{ID: "new", Tags: []string{"new:1", "new:2"}, Size: gb, Created: now.Add(-time.Hour)},
+		{ID: "foreign", Tags: []string{"foreign:1"}, Size: gb, Created: now.Add(-6 * time.Hour)},
+	}}
+	var inUse []string
+	c := New(cruntime, 4, func() []string { return inUse })
+	c.Track("old:1")
+	c.Track("new:1")
+	c.Track("new:2")
+	c.SetPrePull([]string{"docker.io/library/oldest:1", "pulled"})
+	ctx := context.Background()
+
+	// The pre-pulled image is pulled and the oldest managed images not pre-pulled are removed,
+	// by all of their names, while the image the agent did not pull or run is left alone.
+	inUse = []string{"older:1"}
+	names := c.sync(ctx)
+	assert.DeepEqual(t, cruntime.pulled, []string{"pulled"})
+	assert.DeepEqual(t, cruntime.removed, []string{"old:1", "new:1", "new:2"})
+	assert.DeepEqual(t, names, Names([]string{"older:1", "oldest:1", "foreign:1", "pulled"}))
+
+	// Once no longer in use or pre-pulled, the least recently used image is removed first.
+	cruntime.removed = nil
+	inUse = nil
+	c.SetPrePull([]string{"pulled"})
+	cruntime.images = append(cruntime.images, docker.Image{
+		ID: "newest", Tags: []string{"newest:1"}, Size: gb, Created: now,
+	})
+	c.Track("newest:1")
+	c.sync(ctx)
+	assert.DeepEqual(t, cruntime.removed, []string{"oldest:1"})
+}
+
+func TestCacheWithoutLimit(t *testing.T) {
+	cruntime := &fakeRuntime{images: []docker.Image{
+		{ID: "a", Tags: []string{"a:1"}, Size: 10 * gb},
+	}}
+	c := New(cruntime, 0, func() []string { return nil })
+	assert.DeepEqual(t, c.sync(context.Background()), Names([]string{"a:1"}))
+	assert.Equal(t, len(cruntime.removed), 0)
+}
@@ -0,0 +1,175 @@
+package logship
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const batchFileSuffix = ".json"
+
+type bufferedBatch struct {
+	seq  uint64
+	size int64
+}
+
+// buffer is a queue of batches of task logs on disk, so that logs outlive outages of the master or
+// Elastic and restarts of the agent. Once the batches take up more than the limit, the oldest are
+// dropped.
+type buffer struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	batches []bufferedBatch
+	size    int64
+	next    uint64
+	// ready is signaled whenever a batch is pushed.
+	ready chan struct{}
+}
+
+// newBuffer opens the buffer in the directory, picking up the batches left in it.
+func newBuffer(dir string, maxBytes int64) (*buffer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "creating log buffer directory")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading log buffer directory")
+	}
+
+	b := &buffer{dir: dir, maxBytes: maxBytes, ready: make(chan struct{}, 1)}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			// Left over from a write that did not finish.
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, batchFileSuffix), 10, 64)
+		if err != nil || !strings.HasSuffix(name, batchFileSuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading buffered logs %s", name)
+		}
+		b.batches = append(b.batches, bufferedBatch{seq: seq, size: info.Size()})
+		b.size += info.Size()
+	}
+	sort.Slice(b.batches, func(i, j int) bool { return b.batches[i].seq < b.batches[j].seq })
+	if n := len(b.batches); n > 0 {
+		b.next = b.batches[n-1].seq + 1
+		b.ready <- struct{}{}
+	}
+	return b, nil
+}
+
+func (b *buffer) path(seq uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", seq, batchFileSuffix))
+}
+
+// push adds a batch to the end of the queue.
+func (b *buffer) push(logs []*model.TaskLog) error {
+	data, err := json.Marshal(logs)
+	if err != nil {
+		return errors.Wrap(err, "encoding task logs")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	seq := b.next
+	if err := writeFileAtomic(b.path(seq), data); err != nil {
+		return err
+	}
+	b.next++
+	b.batches = append(b.batches, bufferedBatch{seq: seq, size: int64(len(data))})
+	b.size += int64(len(data))
+
+	for b.maxBytes > 0 && b.size > b.maxBytes && len(b.batches) > 1 {
+		oldest := b.batches[0]
+		log.Warnf("log buffer is over %d bytes, dropping %d bytes of the oldest task logs",
+			b.maxBytes, oldest.size)
+		if err := b.remove(oldest.seq); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// peek returns the oldest batch, if there is any.
+func (b *buffer) peek() (seq uint64, logs []*model.TaskLog, ok bool, err error) {
+	b.mu.Lock()
+	if len(b.batches) == 0 {
+		b.mu.Unlock()
+		return 0, nil, false, nil
+	}
+	seq = b.batches[0].seq
+	b.mu.Unlock()
+
+	// The batch may be dropped while it is read; then it reads as missing and is skipped.
+	data, err := os.ReadFile(b.path(seq))
+	if err != nil {
+		return seq, nil, true, errors.Wrap(err, "reading buffered task logs")
+	}
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return seq, nil, true, errors.Wrap(err, "decoding buffered task logs")
+	}
+	return seq, logs, true, nil
+}
+
+// pop removes the batch from the queue, unless it was dropped already.
+func (b *buffer) pop(seq uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remove(seq)
+}
+
+func (b *buffer) remove(seq uint64) error {
+	for i, batch := range b.batches {
+		if batch.seq != seq {
+			continue
+		}
+		if err := os.Remove(b.path(seq)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing buffered task logs")
+		}
+		b.batches = append(b.batches[:i], b.batches[i+1:]...)
+		b.size -= batch.size
+		return nil
+	}
+	return nil
+}
+
+// len returns the number of batches in the queue.
+func (b *buffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.batches)
+}
+
+// writeFileAtomic writes the file through a temporary file, so that it is never read half written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package logship
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func testLogs(msgs ...string) []*model.TaskLog {
+	var logs []*model.TaskLog
+	for _, m := range msgs {
+		logs = append(logs, &model.TaskLog{TaskID: "task", Log: m})
+	}
+	return logs
+}
+
+func TestBuffer(t *testing.T) {
+	dir := t.TempDir()
+	b, err := newBuffer(dir, 0)
+	require.NoError(t, err)
+
+	_, _, ok, err := b.peek()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, b.push(testLogs("a", "b")))
+	require.NoError(t, b.push(testLogs("c")))
+	<-b.ready
+
+	seq, logs, ok, err := b.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, testLogs("a", "b"), logs)
+	require.NoError(t, b.pop(seq))
+	require.Equal(t, 1, b.len())
+
+	// A leftover partial write is cleaned up and the remaining batch is picked up on reopen.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "x.json.tmp"), []byte("{"), 0o600))
+	b, err = newBuffer(dir, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, b.len())
+	_, err = os.Stat(filepath.Join(dir, "x.json.tmp"))
+	require.True(t, os.IsNotExist(err))
+	<-b.ready
+
+	_, logs, ok, err = b.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, testLogs("c"), logs)
+
+	require.NoError(t, b.push(testLogs("d")))
+	seq, _, _, err = b.peek()
+	require.NoError(t, err)
+	require.NoError(t, b.pop(seq))
+	_, logs, _, err = b.peek()
+	require.NoError(t, err)
+	require.Equal(t, testLogs("d"), logs)
+}
+
+func TestBufferDropsOldest(t *testing.T) {
+	b, err := newBuffer(t.TempDir(), 1)
+	require.NoError(t, err)
+
+	require.NoError(t, b.push(testLogs("a")))
+	require.NoError(t, b.push(testLogs("b")))
+	require.NoError(t, b.push(testLogs("c")))
+	require.Equal(t, 1, b.len())
+
+	_, logs, ok, err := b.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, testLogs("c"), logs)
+}
@@ -0,0 +1,382 @@
+package logship
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/agent/internal/options"
+	"github.com/determined-ai/determined/agent/pkg/docker"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/syncx/errgroupx"
+)
+
+const (
+	mb = 1 << 20
+
+	// batchSize and flushInterval bound how many logs are shipped at once and how long a log
+	// waits to be shipped.
+	batchSize     = 1000
+	flushInterval = 250 * time.Millisecond
+
+	minRetryBackoff = time.Second
+	maxRetryBackoff = 30 * time.Second
+
+	// cursorsFile keeps the timestamp of the last buffered log of every container, so that a
+	// restarted agent picks up the logs of its containers where it left off.
+	cursorsFile = "cursors.json"
+)
+
+// record is a task log along with the Docker container it came from.
+type record struct {
+	dockerID string
+	log      *model.TaskLog
+}
+
+// Shipper ships the logs of the task containers of the agent to the master or to Elastic from the
+// agent itself, in place of the Fluent Bit container. It follows the output of the containers
+// through Docker, batches it into a buffer on disk and ships the batches, retrying until they are
+// accepted.
+type Shipper struct {
+	// Configuration details.
+	opts   options.Options
+	enrich func(*model.TaskLog)
+
+	// System dependencies.
+	log    *log.Entry
+	docker *docker.Client
+	sink   sink
+
+	// Internal state.
+	buffer    *buffer
+	records   chan record
+	mu        sync.Mutex
+	following map[string]bool
+	cursors   map[string]time.Time
+	wg        errgroupx.Group
+	err       error
+	Done      chan struct{} // Closed when the shipper exits.
+}
+
+// Start starts shipping the logs of the task containers of the agent, and returns a handle to
+// interact with the shipper. Every task log is enriched before it is shipped.
+func Start(
+	ctx context.Context,
+	opts options.Options,
+	mopts aproto.MasterSetAgentOptions,
+	dclient *docker.Client,
+	masterTLS *tls.Config,
+	enrich func(*model.TaskLog),
+) (*Shipper, error) {
+	sink, err := newSink(opts, mopts, masterTLS)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := newBuffer(opts.LogShipper.BufferDir, int64(opts.LogShipper.BufferMaxSizeMB)*mb)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Shipper{
+		opts:   opts,
+		enrich: enrich,
+
+		log:    log.WithField("component", "log-shipper"),
+		docker: dclient,
+		sink:   sink,
+
+		buffer:    buf,
+		records:   make(chan record, batchSize),
+		following: map[string]bool{},
+		cursors:   map[string]time.Time{},
+		wg:        errgroupx.WithContext(context.Background()),
+		Done:      make(chan struct{}),
+	}
+	if err := s.loadCursors(); err != nil {
+		s.log.WithError(err).Warn("failed to load log cursors, reshipping container logs")
+	}
+
+	for _, f := range []func(context.Context) error{s.watch, s.batch, s.send} {
+		f := f
+		s.wg.Go(func(ctx context.Context) error {
+			defer s.wg.Cancel()
+			if err := f(ctx); !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		})
+	}
+
+	go func() {
+		s.err = s.wg.Wait()
+		close(s.Done)
+	}()
+
+	s.log.Infof("shipping task logs from the agent, buffered in %s", opts.LogShipper.BufferDir)
+	return s, nil
+}
+
+// Error returns the error associated with an exit, if there is any. It returns nil when running.
+func (s *Shipper) Error() error {
+	return s.err
+}
+
+// Wait for the shipper to exit.
+func (s *Shipper) Wait() error {
+	return s.wg.Wait()
+}
+
+// Close the shipper. Logs that are not shipped yet stay in the buffer.
+func (s *Shipper) Close() error {
+	return s.wg.Close()
+}
+
+// watch follows the logs of the task containers that are running and of those that start later.
+func (s *Shipper) watch(ctx context.Context) error {
+	agentFilter := filters.Arg("label", docker.AgentLabel+"="+s.opts.AgentID)
+	// Subscribe to container starts before listing the running containers, so that no container
+	// starts unnoticed in between.
+	events, errs := s.docker.Inner().Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			agentFilter, filters.Arg("type", "container"), filters.Arg("event", "start"),
+		),
+	})
+	running, err := s.docker.Inner().ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(agentFilter),
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing task containers")
+	}
+
+	s.mu.Lock()
+	keep := map[string]time.Time{}
+	for _, c := range running {
+		if t, ok := s.cursors[c.ID]; ok {
+			keep[c.ID] = t
+		}
+	}
+	s.cursors = keep
+	s.mu.Unlock()
+	for _, c := range running {
+		s.follow(c.ID)
+	}
+
+	for {
+		select {
+		case e := <-events:
+			s.follow(e.Actor.ID)
+		case err := <-errs:
+			return errors.Wrap(err, "watching task containers")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// follow ships the logs of the container until it exits, unless they are shipped already. The
+// cursor of the container is only dropped once its logs end because it exited; if the shipper
+// stops first, the cursor is kept for the next shipper to pick up from.
+func (s *Shipper) follow(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.following[id] {
+		return
+	}
+	s.following[id] = true
+
+	s.wg.Go(func(ctx context.Context) error {
+		err := s.followLogs(ctx, id)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.log.WithError(err).Warnf("stopped following the logs of container %s", id)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.following, id)
+		if err == nil && ctx.Err() == nil {
+			delete(s.cursors, id)
+		}
+		return nil
+	})
+}
+
+func (s *Shipper) followLogs(ctx context.Context, id string) error {
+	info, err := s.docker.Inner().ContainerInspect(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "inspecting container")
+	}
+	labels := labelsFromEnv(info.Config.Env)
+
+	var since string
+	s.mu.Lock()
+	if t, ok := s.cursors[id]; ok {
+		t = t.Add(time.Nanosecond)
+		since = fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+	}
+	s.mu.Unlock()
+
+	reader, err := s.docker.Inner().ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      since,
+		Timestamps: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "reading container logs")
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			s.log.WithError(err).Debug("error closing log stream")
+		}
+	}()
+
+	emit := func(tl *model.TaskLog) error {
+		if s.enrich != nil {
+			s.enrich(tl)
+		}
+		select {
+		case s.records <- record{dockerID: id, log: tl}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	stdout := &lineWriter{stdType: stdcopy.Stdout, labels: labels, emit: emit}
+	stderr := &lineWriter{stdType: stdcopy.Stderr, labels: labels, emit: emit}
+
+	// The output of containers with a TTY is not multiplexed.
+	if info.Config.Tty {
+		_, err = io.Copy(stdout, reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, reader)
+	}
+	if err != nil {
+		return errors.Wrap(err, "scanning container logs")
+	}
+	if err := stdout.flush(); err != nil {
+		return err
+	}
+	return stderr.flush()
+}
+
+// batch collects the logs of the containers into batches in the buffer. Logs still pending when
+// the shipper stops are dropped without saving the cursors past them, so that the next shipper
+// reads them from Docker again rather than shipping them twice.
+func (s *Shipper) batch(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var pending []record
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		logs := make([]*model.TaskLog, 0, len(pending))
+		s.mu.Lock()
+		for _, r := range pending {
+			logs = append(logs, r.log)
+			if s.following[r.dockerID] {
+				s.cursors[r.dockerID] = *r.log.Timestamp
+			}
+		}
+		s.mu.Unlock()
+		pending = nil
+
+		if err := s.buffer.push(logs); err != nil {
+			s.log.WithError(err).Errorf("failed to buffer %d task logs, dropping them", len(logs))
+			return
+		}
+		if err := s.saveCursors(); err != nil {
+			s.log.WithError(err).Warn("failed to save log cursors")
+		}
+	}
+
+	for {
+		select {
+		case r := <-s.records:
+			pending = append(pending, r)
+			if len(pending) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send ships the batches in the buffer, oldest first, retrying each until it is accepted.
+func (s *Shipper) send(ctx context.Context) error {
+	backoff := minRetryBackoff
+	for {
+		seq, logs, ok, err := s.buffer.peek()
+		switch {
+		case err != nil:
+			s.log.WithError(err).Error("dropping unreadable buffered task logs")
+			if err := s.buffer.pop(seq); err != nil {
+				return err
+			}
+			continue
+		case !ok:
+			select {
+			case <-s.buffer.ready:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := s.sink.ship(ctx, logs); err != nil {
+			s.log.WithError(err).Warnf("failed to ship %d task logs, retrying in %s",
+				len(logs), backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if backoff *= 2; backoff > maxRetryBackoff {
+				backoff = maxRetryBackoff
+			}
+			continue
+		}
+		backoff = minRetryBackoff
+		if err := s.buffer.pop(seq); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Shipper) loadCursors() error {
+	data, err := os.ReadFile(filepath.Join(s.opts.LogShipper.BufferDir, cursorsFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.cursors)
+}
+
+func (s *Shipper) saveCursors() error {
+	s.mu.Lock()
+	data, err := json.Marshal(s.cursors)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.opts.LogShipper.BufferDir, cursorsFile), data)
+}
@@ -0,0 +1,120 @@
+package logship
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/determined-ai/determined/agent/internal/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// These parse the same fields out of log lines as the parsers of the Fluent Bit configuration.
+var (
+	rankIDRegexp   = regexp.MustCompile(`^\[rank=([0-9]+)\] (.*)`)
+	logLevelRegexp = regexp.MustCompile(`^(DEBUG|INFO|WARNING|ERROR|CRITICAL): (.*)`)
+)
+
+// containerLabels are the IDs of the task a container belongs to, read from its environment.
+type containerLabels struct {
+	containerID  string
+	allocationID string
+	taskID       string
+}
+
+func labelsFromEnv(env []string) containerLabels {
+	var l containerLabels
+	for _, kv := range env {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case container.ContainerIDEnvVar:
+			l.containerID = v
+		case container.AllocationIDEnvVar:
+			l.allocationID = v
+		case container.TaskIDEnvVar:
+			l.taskID = v
+		}
+	}
+	return l
+}
+
+// parseLine turns a line of the output of a container, prefixed by its timestamp as Docker prints
+// it with timestamps on, into a task log.
+func parseLine(line string, stdType stdcopy.StdType, labels containerLabels) *model.TaskLog {
+	ts := time.Now().UTC()
+	if prefix, rest, ok := strings.Cut(line, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+			ts, line = t.UTC(), rest
+		}
+	}
+
+	tl := &model.TaskLog{
+		TaskID:    labels.taskID,
+		Timestamp: &ts,
+	}
+	if labels.allocationID != "" {
+		tl.AllocationID = &labels.allocationID
+	}
+	if labels.containerID != "" {
+		tl.ContainerID = &labels.containerID
+	}
+	std := "stdout"
+	if stdType == stdcopy.Stderr {
+		std = "stderr"
+	}
+	tl.StdType = &std
+
+	if m := rankIDRegexp.FindStringSubmatch(line); m != nil {
+		if rank, err := strconv.Atoi(m[1]); err == nil {
+			tl.RankID = &rank
+			line = m[2]
+		}
+	}
+	if m := logLevelRegexp.FindStringSubmatch(line); m != nil {
+		level := m[1]
+		tl.Level = &level
+		line = m[2]
+	}
+	tl.Log = line + "\n"
+	return tl
+}
+
+// lineWriter splits the output of a container into lines and turns each into a task log.
+type lineWriter struct {
+	stdType stdcopy.StdType
+	labels  containerLabels
+	emit    func(*model.TaskLog) error
+	buf     []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := strings.TrimSuffix(string(w.buf[:i]), "\r")
+		w.buf = w.buf[i+1:]
+		if err := w.emit(parseLine(line, w.stdType, w.labels)); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// flush emits what is left of an unterminated last line.
+func (w *lineWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := string(w.buf)
+	w.buf = nil
+	return w.emit(parseLine(line, w.stdType, w.labels))
+}
@@ -0,0 +1,62 @@
+package logship
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestParseLine(t *testing.T) {
+	labels := labelsFromEnv([]string{
+		"DET_CONTAINER_ID=container",
+		"DET_ALLOCATION_ID=allocation",
+		"DET_TASK_ID=task",
+		"NOT_A_LABEL",
+	})
+
+	tl := parseLine("2022-01-02T03:04:05.123456789Z [rank=3] WARNING: careful",
+		stdcopy.Stderr, labels)
+	require.Equal(t, "task", tl.TaskID)
+	require.Equal(t, "allocation", *tl.AllocationID)
+	require.Equal(t, "container", *tl.ContainerID)
+	require.Equal(t,
+		time.Date(2022, 1, 2, 3, 4, 5, 123456789, time.UTC), *tl.Timestamp)
+	require.Equal(t, "stderr", *tl.StdType)
+	require.Equal(t, 3, *tl.RankID)
+	require.Equal(t, "WARNING", *tl.Level)
+	require.Equal(t, "careful\n", tl.Log)
+
+	tl = parseLine("no timestamp", stdcopy.Stdout, containerLabels{})
+	require.NotNil(t, tl.Timestamp)
+	require.Equal(t, "stdout", *tl.StdType)
+	require.Nil(t, tl.AllocationID)
+	require.Nil(t, tl.RankID)
+	require.Nil(t, tl.Level)
+	require.Equal(t, "no timestamp\n", tl.Log)
+}
+
+func TestLineWriter(t *testing.T) {
+	var logs []string
+	w := &lineWriter{
+		stdType: stdcopy.Stdout,
+		emit: func(tl *model.TaskLog) error {
+			logs = append(logs, tl.Log)
+			return nil
+		},
+	}
+
+	_, err := w.Write([]byte("first\r\nsec"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("ond\nthi"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"first\n", "second\n"}, logs)
+
+	require.NoError(t, w.flush())
+	require.Equal(t, []string{"first\n", "second\n", "thi\n"}, logs)
+	require.NoError(t, w.flush())
+	require.Len(t, logs, 3)
+}
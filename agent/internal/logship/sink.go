@@ -0,0 +1,196 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/agent/internal/options"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const sinkTimeout = 30 * time.Second
+
+// sink is where the shipper ships task logs to.
+type sink interface {
+	ship(ctx context.Context, logs []*model.TaskLog) error
+}
+
+// newSink returns the sink for the logging backend of the master.
+func newSink(
+	opts options.Options, mopts aproto.MasterSetAgentOptions, masterTLS *tls.Config,
+) (sink, error) {
+	switch l := mopts.LoggingOptions; {
+	case l.DefaultLoggingConfig != nil, l.LokiLoggingConfig != nil:
+		scheme := "http"
+		if masterTLS != nil {
+			scheme = "https"
+		}
+		return &masterSink{
+			url: fmt.Sprintf("%s://%s:%d/task-logs", scheme, opts.MasterHost, opts.MasterPort),
+			client: &http.Client{
+				Timeout:   sinkTimeout,
+				Transport: &http.Transport{TLSClientConfig: masterTLS},
+			},
+		}, nil
+	case l.ElasticLoggingConfig != nil:
+		e := l.ElasticLoggingConfig
+		tlsConfig, err := elasticTLSConfig(e.Security.TLS)
+		if err != nil {
+			return nil, err
+		}
+		scheme := "http"
+		if tlsConfig != nil {
+			scheme = "https"
+		}
+		return &elasticSink{
+			url:      fmt.Sprintf("%s://%s:%d/_bulk", scheme, e.Host, e.Port),
+			username: e.Security.Username,
+			password: e.Security.Password,
+			client: &http.Client{
+				Timeout:   sinkTimeout,
+				Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			},
+		}, nil
+	default:
+		return nil, errors.New("no log driver set for agent")
+	}
+}
+
+func elasticTLSConfig(t model.TLSClientConfig) (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+	c := &tls.Config{
+		InsecureSkipVerify: t.SkipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.CertificateName,
+	}
+	if len(t.CertBytes) > 0 {
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(t.CertBytes) {
+			return nil, errors.New("elastic certificate contains no certificates")
+		}
+	}
+	return c, nil
+}
+
+// masterSink ships task logs to the task log endpoint of the master, as Fluent Bit does.
+type masterSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *masterSink) ship(ctx context.Context, logs []*model.TaskLog) error {
+	body, err := json.Marshal(logs)
+	if err != nil {
+		return errors.Wrap(err, "encoding task logs")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	return checkStatus(resp)
+}
+
+// elasticSink ships task logs to the daily task log indices in Elastic, as Fluent Bit does.
+type elasticSink struct {
+	url      string
+	username *string
+	password *string
+	client   *http.Client
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+func (s *elasticSink) ship(ctx context.Context, logs []*model.TaskLog) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, l := range logs {
+		action := map[string]map[string]string{
+			"index": {"_index": l.Timestamp.UTC().Format("determined-tasklogs-2006.01.02")},
+		}
+		if err := enc.Encode(action); err != nil {
+			return errors.Wrap(err, "encoding bulk request")
+		}
+		if err := enc.Encode(l); err != nil {
+			return errors.Wrap(err, "encoding task log")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.username != nil && s.password != nil {
+		req.SetBasicAuth(*s.username, *s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	var bulk bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bulk); err != nil {
+		return errors.Wrap(err, "decoding bulk response")
+	}
+	if !bulk.Errors {
+		return nil
+	}
+	// Retry the batch if Elastic was overloaded; logs it rejected for good are dropped.
+	var rejected int
+	for _, item := range bulk.Items {
+		for _, result := range item {
+			switch {
+			case result.Status == http.StatusTooManyRequests, result.Status >= 500:
+				return errors.Errorf("elastic failed to index task logs: %s", result.Error)
+			case result.Status >= 300:
+				rejected++
+				log.Debugf("elastic rejected task log: %s", result.Error)
+			}
+		}
+	}
+	log.Warnf("elastic rejected %d task logs", rejected)
+	return nil
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return errors.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+}
+
+func closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		log.WithError(err).Debug("closing log shipping response")
+	}
+}
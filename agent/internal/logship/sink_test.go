@@ -0,0 +1,84 @@
+package logship
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestMasterSink(t *testing.T) {
+	var got []*model.TaskLog
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/task-logs", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	s := &masterSink{url: server.URL + "/task-logs", client: server.Client()}
+	require.NoError(t, s.ship(context.Background(), testLogs("a", "b")))
+	require.Equal(t, testLogs("a", "b"), got)
+
+	status = http.StatusInternalServerError
+	require.Error(t, s.ship(context.Background(), testLogs("c")))
+}
+
+func TestElasticSink(t *testing.T) {
+	var actions []map[string]map[string]string
+	var docs []*model.TaskLog
+	itemStatus := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/_bulk", r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "pass", pass)
+
+		actions, docs = nil, nil
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action map[string]map[string]string
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			actions = append(actions, action)
+			require.True(t, scanner.Scan())
+			var doc model.TaskLog
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &doc))
+			docs = append(docs, &doc)
+		}
+		_, err := fmt.Fprintf(w, `{"errors": %t, "items": [{"index": {"status": %d}}]}`,
+			itemStatus >= 300, itemStatus)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	s := &elasticSink{
+		url:      server.URL + "/_bulk",
+		username: ptrs.Ptr("user"),
+		password: ptrs.Ptr("pass"),
+		client:   server.Client(),
+	}
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	logs := testLogs("a")
+	logs[0].Timestamp = &ts
+
+	require.NoError(t, s.ship(context.Background(), logs))
+	require.Equal(t, "determined-tasklogs-2022.01.02", actions[0]["index"]["_index"])
+	require.Equal(t, logs, docs)
+
+	// Overloaded clusters are retried, rejected logs are not.
+	itemStatus = http.StatusTooManyRequests
+	require.Error(t, s.ship(context.Background(), logs))
+	itemStatus = http.StatusBadRequest
+	require.NoError(t, s.ship(context.Background(), logs))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
 
 	"github.com/pkg/errors"
 )
@@ -28,6 +29,8 @@ type Options struct {
 	APIEnabled bool   `json:"api_enabled"`
 	BindIP     string `json:"bind_ip"`
 	BindPort   int    `json:"bind_port"`
+	// APIToken, if set, must be sent as a bearer token with every API request.
+	APIToken string `json:"api_token"`
 
 	VisibleGPUs string `json:"visible_gpus"`
 
@@ -43,6 +46,8 @@ type Options struct {
 	Security SecurityOptions `json:"security"`
 
 	Fluent FluentOptions `json:"fluent"`
+	// LogShipper configures how the logs of task containers are shipped.
+	LogShipper LogShipperOptions `json:"log_shipper"`
 
 	ContainerAutoRemoveDisabled bool `json:"container_auto_remove_disabled"`
 
@@ -53,9 +58,22 @@ type Options struct {
 
 	Hooks HooksOptions `json:"hooks"`
 
+	// GPUHealthCheckInterval is the time between GPU health checks, in seconds; 0 disables them.
+	GPUHealthCheckInterval int `json:"gpu_health_check_interval"`
+	// GPUTelemetryInterval is the time between samples of the usage of the GPUs assigned to
+	// containers, in seconds; 0 disables them.
+	GPUTelemetryInterval int `json:"gpu_telemetry_interval"`
+	// ImageCacheMaxSizeGB is the size the images on the agent may take up, in gigabytes, before
+	// the least recently used ones are removed; 0 disables the limit.
+	ImageCacheMaxSizeGB int `json:"image_cache_max_size_gb"`
+	// SlotResourceLimits limits the CPUs, memory and shared memory of containers in proportion to
+	// the slots they hold. The resource pool of the agent may override it.
+	SlotResourceLimits model.SlotResourceLimitsConfig `json:"slot_resource_limits"`
+
 	ContainerRuntime   string             `json:"container_runtime"`
 	SingularityOptions SingularityOptions `json:"singularity_options"`
 	PodmanOptions      PodmanOptions      `json:"podman_options"`
+	ContainerdOptions  ContainerdOptions  `json:"containerd_options"`
 
 	Debug bool `json:"debug"`
 }
@@ -65,6 +83,10 @@ func (o Options) Validate() []error {
 	return []error{
 		o.validateTLS(),
 		check.In(o.SlotType, []string{"gpu", "cuda", "rocm", "cpu", "auto", "none"}),
+		check.In(o.LogShipper.Type, []string{FluentLogShipper, NativeLogShipper}),
+		check.True(
+			o.LogShipper.Type != NativeLogShipper || o.ContainerRuntime == DockerContainerRuntime,
+			"the native log shipper requires the docker container runtime"),
 		check.NotEmpty(o.MasterHost, "master host must be provided"),
 	}
 }
@@ -84,6 +106,10 @@ func (o Options) validateTLS() error {
 
 // Printable returns a printable string.
 func (o Options) Printable() ([]byte, error) {
+	const hiddenValue = "********"
+	if o.APIToken != "" {
+		o.APIToken = hiddenValue
+	}
 	optJSON, err := json.Marshal(o)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to convert config to JSON")
@@ -143,9 +169,28 @@ type FluentOptions struct {
 	ContainerName string `json:"container_name"`
 }
 
+// These are the ways the agent can ship the logs of task containers.
+const (
+	// FluentLogShipper ships logs with a Fluent Bit container.
+	FluentLogShipper = "fluent"
+	// NativeLogShipper ships logs from the agent itself.
+	NativeLogShipper = "native"
+)
+
+// LogShipperOptions configures how the agent ships the logs of task containers.
+type LogShipperOptions struct {
+	Type string `json:"type"`
+	// BufferDir is where the native log shipper keeps logs until they are shipped.
+	BufferDir string `json:"buffer_dir"`
+	// BufferMaxSizeMB is the size the buffered logs may take up before the oldest are dropped.
+	BufferMaxSizeMB int `json:"buffer_max_size_mb"`
+}
+
 // HooksOptions contains external commands to be run when specific things happen.
 type HooksOptions struct {
 	OnConnectionLost []string `json:"on_connection_lost"`
+	// OnMaintenance runs whenever the agent's scheduled maintenance changes phase.
+	OnMaintenance []string `json:"on_maintenance"`
 }
 
 // ContainerRuntime configures which container runtime to use.
@@ -156,6 +201,7 @@ const (
 	SingularityContainerRuntime = "singularity"
 	DockerContainerRuntime      = "docker"
 	PodmanContainerRuntime      = "podman"
+	ContainerdContainerRuntime  = "containerd"
 )
 
 // SingularityOptions configures how we interact with Singularity.
@@ -170,3 +216,14 @@ type SingularityOptions struct {
 type PodmanOptions struct {
 	AllowNetworkCreation bool `json:"allow_network_creation"` // review
 }
+
+// ContainerdOptions configures how we interact with containerd.
+type ContainerdOptions struct {
+	// Address is the path to the containerd socket.
+	Address string `json:"address"`
+	// Namespace is the containerd namespace that images and containers are created in.
+	Namespace string `json:"namespace"`
+	// AllowNetworkCreation allows the agent to launch containers that request a bridge network
+	// into a new network namespace, configured by the host's CNI plugins.
+	AllowNetworkCreation bool `json:"allow_network_creation"`
+}
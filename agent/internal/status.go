@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/determined-ai/determined/agent/internal/containers"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+// agentStatus tracks the state of the agent that the agent API serves. The agent updates it as it
+// runs and the API reads it concurrently.
+type agentStatus struct {
+	mu                sync.RWMutex
+	devices           []device.Device
+	manager           *containers.Manager
+	connected         bool
+	connectionChanged time.Time
+}
+
+func (s *agentStatus) setDevices(devices []device.Device) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices = devices
+}
+
+func (s *agentStatus) setManager(manager *containers.Manager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.manager = manager
+}
+
+func (s *agentStatus) setConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connected != connected || s.connectionChanged.IsZero() {
+		s.connected = connected
+		s.connectionChanged = time.Now().UTC()
+	}
+}
+
+func (s *agentStatus) getDevices() []device.Device {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.devices
+}
+
+// getManager returns the container manager, or nil before the agent has set it up.
+func (s *agentStatus) getManager() *containers.Manager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.manager
+}
+
+func (s *agentStatus) getConnection() (bool, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.connected, s.connectionChanged
+}
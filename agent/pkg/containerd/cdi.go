@@ -0,0 +1,266 @@
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	"github.com/ghodss/yaml"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+)
+
+// cdiNvidiaGPU is the CDI device kind the NVIDIA Container Toolkit generates specs for.
+const cdiNvidiaGPU = "nvidia.com/gpu"
+
+// cdiSpecDirs are the directories CDI specs are read from. Specs in later directories take
+// precedence over those in earlier ones for devices with the same name.
+var cdiSpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+
+// cdiSpec is a Container Device Interface spec, which describes how to inject the devices of a
+// kind into a container. Only the parts of the spec the agent applies are read.
+type cdiSpec struct {
+	Kind           string            `json:"kind"`
+	Devices        []cdiDevice       `json:"devices"`
+	ContainerEdits cdiContainerEdits `json:"containerEdits"`
+}
+
+type cdiDevice struct {
+	Name           string            `json:"name"`
+	ContainerEdits cdiContainerEdits `json:"containerEdits"`
+}
+
+// cdiContainerEdits are the changes to a container's OCI spec that inject a device. The edits of
+// the spec are made once for any number of its devices, and those of each device for the device.
+type cdiContainerEdits struct {
+	Env         []string        `json:"env"`
+	DeviceNodes []cdiDeviceNode `json:"deviceNodes"`
+	Mounts      []cdiMount      `json:"mounts"`
+	Hooks       []cdiHook       `json:"hooks"`
+}
+
+type cdiDeviceNode struct {
+	Path        string       `json:"path"`
+	HostPath    string       `json:"hostPath"`
+	Type        string       `json:"type"`
+	Major       int64        `json:"major"`
+	Minor       int64        `json:"minor"`
+	FileMode    *os.FileMode `json:"fileMode"`
+	Permissions string       `json:"permissions"`
+	UID         *uint32      `json:"uid"`
+	GID         *uint32      `json:"gid"`
+}
+
+type cdiMount struct {
+	HostPath      string   `json:"hostPath"`
+	ContainerPath string   `json:"containerPath"`
+	Type          string   `json:"type"`
+	Options       []string `json:"options"`
+}
+
+type cdiHook struct {
+	HookName string   `json:"hookName"`
+	Path     string   `json:"path"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env"`
+	Timeout  *int     `json:"timeout"`
+}
+
+// cdiResolvedDevice is a device found in a CDI spec.
+type cdiResolvedDevice struct {
+	spec   *cdiSpec
+	device cdiDevice
+}
+
+// loadCDIDevices reads the CDI specs in the given directories and returns their devices by
+// qualified name, e.g. nvidia.com/gpu=GPU-<uuid>. Missing directories hold no specs.
+func loadCDIDevices(dirs []string) (map[string]cdiResolvedDevice, error) {
+	devices := map[string]cdiResolvedDevice{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("reading CDI specs in %s: %w", dir, err)
+		}
+		for _, e := range entries {
+			switch filepath.Ext(e.Name()) {
+			case ".json", ".yaml", ".yml":
+			default:
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading CDI spec %s: %w", path, err)
+			}
+			var spec cdiSpec
+			if err := yaml.Unmarshal(b, &spec); err != nil {
+				return nil, fmt.Errorf("parsing CDI spec %s: %w", path, err)
+			}
+			for _, d := range spec.Devices {
+				devices[spec.Kind+"="+d.Name] = cdiResolvedDevice{spec: &spec, device: d}
+			}
+		}
+	}
+	return devices, nil
+}
+
+// withCDIDevices injects the named devices into the container as their CDI specs in the given
+// directories describe, like containerd's CDI support in newer releases.
+func withCDIDevices(dirs []string, names ...string) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		devices, err := loadCDIDevices(dirs)
+		if err != nil {
+			return err
+		}
+		applied := map[*cdiSpec]bool{}
+		for _, name := range names {
+			d, ok := devices[name]
+			if !ok {
+				return fmt.Errorf("unresolvable CDI device %s: no spec for it in %s",
+					name, strings.Join(dirs, ", "))
+			}
+			if !applied[d.spec] {
+				applied[d.spec] = true
+				if err := d.spec.ContainerEdits.apply(s); err != nil {
+					return fmt.Errorf("applying CDI spec of %s: %w", d.spec.Kind, err)
+				}
+			}
+			if err := d.device.ContainerEdits.apply(s); err != nil {
+				return fmt.Errorf("applying CDI device %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (e cdiContainerEdits) apply(s *oci.Spec) error {
+	if s.Process == nil {
+		s.Process = &specs.Process{}
+	}
+	if s.Linux == nil {
+		s.Linux = &specs.Linux{}
+	}
+	if s.Linux.Resources == nil {
+		s.Linux.Resources = &specs.LinuxResources{}
+	}
+
+	for _, kv := range e.Env {
+		s.Process.Env = setEnv(s.Process.Env, kv)
+	}
+
+	for _, n := range e.DeviceNodes {
+		dev, err := n.linuxDevice()
+		if err != nil {
+			return err
+		}
+		s.Linux.Devices = append(s.Linux.Devices, dev)
+		if dev.Type == "b" || dev.Type == "c" {
+			perms := n.Permissions
+			if perms == "" {
+				perms = "rwm"
+			}
+			s.Linux.Resources.Devices = append(s.Linux.Resources.Devices, specs.LinuxDeviceCgroup{
+				Allow:  true,
+				Type:   dev.Type,
+				Major:  &dev.Major,
+				Minor:  &dev.Minor,
+				Access: perms,
+			})
+		}
+	}
+
+	for _, m := range e.Mounts {
+		s.Mounts = append(s.Mounts, specs.Mount{
+			Source:      m.HostPath,
+			Destination: m.ContainerPath,
+			Type:        m.Type,
+			Options:     m.Options,
+		})
+	}
+
+	for _, h := range e.Hooks {
+		if s.Hooks == nil {
+			s.Hooks = &specs.Hooks{}
+		}
+		hook := specs.Hook{Path: h.Path, Args: h.Args, Env: h.Env, Timeout: h.Timeout}
+		switch h.HookName {
+		case "prestart":
+			s.Hooks.Prestart = append(s.Hooks.Prestart, hook)
+		case "createRuntime":
+			s.Hooks.CreateRuntime = append(s.Hooks.CreateRuntime, hook)
+		case "createContainer":
+			s.Hooks.CreateContainer = append(s.Hooks.CreateContainer, hook)
+		case "startContainer":
+			s.Hooks.StartContainer = append(s.Hooks.StartContainer, hook)
+		case "poststart":
+			s.Hooks.Poststart = append(s.Hooks.Poststart, hook)
+		case "poststop":
+			s.Hooks.Poststop = append(s.Hooks.Poststop, hook)
+		default:
+			return fmt.Errorf("unknown CDI hook %q", h.HookName)
+		}
+	}
+	return nil
+}
+
+// linuxDevice returns the OCI device of a device node. The type and numbers of the device are
+// read from the host when the spec leaves them out.
+func (n cdiDeviceNode) linuxDevice() (specs.LinuxDevice, error) {
+	dev := specs.LinuxDevice{
+		Path:     n.Path,
+		Type:     n.Type,
+		Major:    n.Major,
+		Minor:    n.Minor,
+		FileMode: n.FileMode,
+		UID:      n.UID,
+		GID:      n.GID,
+	}
+	hostPath := n.HostPath
+	if hostPath == "" {
+		hostPath = n.Path
+	}
+	if dev.Type != "" && (dev.Major != 0 || dev.Type == "p") {
+		return dev, nil
+	}
+
+	var stat unix.Stat_t
+	if err := unix.Stat(hostPath, &stat); err != nil {
+		return dev, fmt.Errorf("reading device node %s: %w", hostPath, err)
+	}
+	switch stat.Mode & unix.S_IFMT {
+	case unix.S_IFBLK:
+		dev.Type = "b"
+	case unix.S_IFCHR:
+		dev.Type = "c"
+	case unix.S_IFIFO:
+		dev.Type = "p"
+	default:
+		return dev, fmt.Errorf("%s is not a device node", hostPath)
+	}
+	//nolint:unconvert // Rdev is 32 bits on some platforms.
+	rdev := uint64(stat.Rdev)
+	dev.Major, dev.Minor = int64(unix.Major(rdev)), int64(unix.Minor(rdev))
+	if dev.FileMode == nil {
+		mode := os.FileMode(stat.Mode &^ unix.S_IFMT)
+		dev.FileMode = &mode
+	}
+	return dev, nil
+}
+
+// setEnv sets an environment variable of the form KEY=VALUE, replacing any earlier value.
+func setEnv(env []string, kv string) []string {
+	key, _, _ := strings.Cut(kv, "=")
+	for i, e := range env {
+		if k, _, _ := strings.Cut(e, "="); k == key {
+			env[i] = kv
+			return env
+		}
+	}
+	return append(env, kv)
+}
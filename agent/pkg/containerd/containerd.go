@@ -0,0 +1,846 @@
+package containerd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	gocni "github.com/containerd/go-cni"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/registry"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/agent/internal/container"
+	"github.com/determined-ai/determined/agent/internal/options"
+	"github.com/determined-ai/determined/agent/pkg/cruntimes"
+	ddocker "github.com/determined-ai/determined/agent/pkg/docker"
+	"github.com/determined-ai/determined/agent/pkg/events"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/syncx/waitgroupx"
+)
+
+const (
+	hostNetworking   = "host"
+	bridgeNetworking = "bridge"
+	archivesName     = "archives"
+	logFileName      = "container.log"
+	defaultShmSize   = 4294967296
+	// logPollInterval is how often the log file of a running container is checked for output.
+	logPollInterval = 100 * time.Millisecond
+)
+
+// ContainerdClient implements ContainerRuntime with the containerd client. Containers are
+// created as containerd containers with a task each, so they are owned by containerd and keep
+// running while the agent restarts; the agent finds them again through their labels.
+//
+// The output of a task is written by containerd to a log file in the scratch space of its
+// container, which the agent follows while it runs the container. Reattached containers are not
+// followed, since task logs are shipped to the master from inside the container.
+type ContainerdClient struct {
+	log      *logrus.Entry
+	opts     options.ContainerdOptions
+	cl       *containerd.Client
+	mu       sync.Mutex
+	wg       waitgroupx.Group
+	specs    map[cproto.ID]cproto.RunSpec // Created, but not yet run, containers.
+	agentTmp string
+	debug    bool
+}
+
+// New returns a new containerd client, which launches and tracks containers.
+func New(opts options.Options) (*ContainerdClient, error) {
+	agentTmp, err := cruntimes.BaseTempDirName(opts.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compose agentTmp directory path: %w", err)
+	}
+	if err := os.MkdirAll(agentTmp, 0o700); err != nil {
+		return nil, fmt.Errorf("preparing agent tmp: %w", err)
+	}
+
+	cl, err := containerd.New(
+		opts.ContainerdOptions.Address,
+		containerd.WithDefaultNamespace(opts.ContainerdOptions.Namespace),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to containerd at %s: %w",
+			opts.ContainerdOptions.Address, err)
+	}
+
+	s := &ContainerdClient{
+		log:      logrus.WithField("component", "containerd"),
+		opts:     opts.ContainerdOptions,
+		cl:       cl,
+		wg:       waitgroupx.WithContext(context.Background()),
+		specs:    make(map[cproto.ID]cproto.RunSpec),
+		agentTmp: agentTmp,
+		debug:    opts.Debug,
+	}
+	if err := s.removeStaleScratch(context.Background()); err != nil {
+		return nil, fmt.Errorf("removing agent tmp from previous runs: %w", err)
+	}
+	return s, nil
+}
+
+// Close the client. Running containers are left to containerd, to be reattached by the next run
+// of the agent.
+func (s *ContainerdClient) Close() error {
+	s.wg.Close()
+	return s.cl.Close()
+}
+
+// removeStaleScratch removes the scratch space of containers that no longer exist, keeping that
+// of containers that may be reattached.
+func (s *ContainerdClient) removeStaleScratch(ctx context.Context) error {
+	entries, err := os.ReadDir(s.agentTmp)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		switch _, err := s.cl.LoadContainer(ctx, e.Name()); {
+		case errdefs.IsNotFound(err):
+			if err := os.RemoveAll(filepath.Join(s.agentTmp, e.Name())); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ContainerdClient) scratchDir(id string) string {
+	return filepath.Join(s.agentTmp, id)
+}
+
+// imageRef converts a canonicalized image into the fully qualified reference containerd
+// requires, e.g. docker://ubuntu becomes docker.io/library/ubuntu:latest.
+func imageRef(image string) string {
+	image = strings.TrimPrefix(image, "docker://")
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+// PullImage implements container.ContainerRuntime.
+func (s *ContainerdClient) PullImage(
+	ctx context.Context,
+	req ddocker.PullImage,
+	p events.Publisher[ddocker.Event],
+) (err error) {
+	ref := imageRef(cruntimes.CanonicalizeImage(req.Name))
+
+	switch _, err = s.cl.GetImage(ctx, ref); {
+	case req.ForcePull:
+		if err != nil {
+			break
+		}
+		if err = p.Publish(ctx, ddocker.NewLogEvent(model.LogLevelInfo, fmt.Sprintf(
+			"image present, but force_pull_image is set; checking for updates: %s", ref,
+		))); err != nil {
+			return err
+		}
+	case errdefs.IsNotFound(err):
+		if err = p.Publish(ctx, ddocker.NewLogEvent(model.LogLevelInfo, fmt.Sprintf(
+			"image not found, pulling image: %s", ref,
+		))); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("error checking if image exists %s: %w", ref, err)
+	default:
+		return p.Publish(ctx, ddocker.NewLogEvent(model.LogLevelInfo, fmt.Sprintf(
+			"image already found, skipping pull phase: %s", ref,
+		)))
+	}
+
+	if err = p.Publish(ctx, ddocker.NewBeginStatsEvent(ddocker.ImagePullStatsKind)); err != nil {
+		return err
+	}
+	defer func() {
+		if scErr := p.Publish(ctx, ddocker.NewEndStatsEvent(ddocker.ImagePullStatsKind)); scErr != nil {
+			s.log.WithError(scErr).Warn("did not send image pull done stats")
+		}
+	}()
+
+	pullOpts := []containerd.RemoteOpt{containerd.WithPullUnpack}
+	if req.Registry != nil {
+		pullOpts = append(pullOpts, containerd.WithResolver(resolver(req.Registry)))
+	}
+	if _, err = s.cl.Pull(ctx, ref, pullOpts...); err != nil {
+		return fmt.Errorf("error pulling image %s: %w", ref, err)
+	}
+	return p.Publish(ctx, ddocker.NewLogEvent(model.LogLevelInfo, fmt.Sprintf(
+		"pulled image %s", ref,
+	)))
+}
+
+// resolver returns a resolver that authenticates with the registry_auth of the task against its
+// server, or against any registry if it has no server address.
+func resolver(auth *types.AuthConfig) remotes.Resolver {
+	server := registry.ConvertToHostname(auth.ServerAddress)
+	if server == "index.docker.io" || server == "docker.io" {
+		server = "registry-1.docker.io"
+	}
+	creds := func(host string) (string, string, error) {
+		if server != "" && server != host {
+			return "", "", nil
+		}
+		if auth.IdentityToken != "" {
+			return "", auth.IdentityToken, nil
+		}
+		return auth.Username, auth.Password, nil
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithAuthorizer(
+			docker.NewDockerAuthorizer(docker.WithAuthCreds(creds)),
+		)),
+	})
+}
+
+// CreateContainer implements container.ContainerRuntime.
+func (s *ContainerdClient) CreateContainer(
+	ctx context.Context,
+	id cproto.ID,
+	req cproto.RunSpec,
+	p events.Publisher[ddocker.Event],
+) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.specs[id] = req
+	return id.String(), nil
+}
+
+// RunContainer implements container.ContainerRuntime.
+// nolint: golint // Both contexts can't both be first.
+func (s *ContainerdClient) RunContainer(
+	ctx context.Context,
+	waitCtx context.Context,
+	id string,
+	p events.Publisher[ddocker.Event],
+) (_ *ddocker.Container, err error) {
+	s.mu.Lock()
+	req, ok := s.specs[cproto.ID(id)]
+	delete(s.specs, cproto.ID(id))
+	s.mu.Unlock()
+	if !ok {
+		return nil, container.ErrMissing
+	}
+
+	ref := imageRef(cruntimes.CanonicalizeImage(req.ContainerConfig.Image))
+	image, err := s.cl.GetImage(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("getting image %s: %w", ref, err)
+	}
+
+	dir := s.scratchDir(id)
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("making scratch dir: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			s.remove(context.Background(), id)
+		}
+	}()
+
+	switch {
+	case req.HostConfig.NetworkMode == bridgeNetworking && s.opts.AllowNetworkCreation:
+	case req.HostConfig.NetworkMode == bridgeNetworking:
+		if err = p.Publish(ctx, ddocker.NewLogEvent(
+			model.LogLevelDebug,
+			"container requested network virtualization, but network creation isn't allowed; "+
+				"overriding to host networking",
+		)); err != nil {
+			return nil, err
+		}
+		req.HostConfig.NetworkMode = hostNetworking
+	case req.HostConfig.NetworkMode == hostNetworking:
+	default:
+		return nil, fmt.Errorf("unsupported network mode %s", req.HostConfig.NetworkMode)
+	}
+
+	archivesPath := filepath.Join(dir, archivesName)
+	mountPoints, err := cruntimes.ArchiveMountPoints(ctx, req, p, archivesPath, s.log)
+	if err != nil {
+		return nil, fmt.Errorf("determining mount points: %w", err)
+	}
+
+	cont, err := s.cl.NewContainer(ctx, id,
+		containerd.WithImage(image),
+		containerd.WithNewSnapshot(id, image),
+		containerd.WithNewSpec(s.specOpts(image, req, archivesPath, mountPoints)...),
+		containerd.WithContainerLabels(req.ContainerConfig.Labels),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating containerd container: %w", err)
+	}
+
+	logPath := filepath.Join(dir, logFileName)
+	task, err := cont.NewTask(ctx, cio.LogFile(logPath))
+	if err != nil {
+		return nil, fmt.Errorf("creating containerd task: %w", err)
+	}
+
+	if req.HostConfig.NetworkMode == bridgeNetworking {
+		if err = s.setupNetwork(ctx, id, task); err != nil {
+			return nil, err
+		}
+	}
+
+	// Subscribe to the exit before starting, to not miss immediate exits.
+	exitC, err := task.Wait(waitCtx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting on containerd task: %w", err)
+	}
+
+	shipped := make(chan struct{})
+	exited := make(chan struct{})
+	s.wg.Go(func(ctx context.Context) {
+		defer close(shipped)
+		s.shipLogs(ctx, logPath, exited, p)
+	})
+
+	if err = task.Start(ctx); err != nil {
+		close(exited)
+		return nil, fmt.Errorf("starting containerd task: %w", err)
+	}
+	s.log.Infof("started container %s with pid %d", id, task.Pid())
+
+	info, err := cont.Info(ctx)
+	if err != nil {
+		close(exited)
+		return nil, fmt.Errorf("getting container info: %w", err)
+	}
+	return &ddocker.Container{
+		ContainerInfo: containerInfo(info, task.Pid(), req),
+		ContainerWaiter: s.waitOnTask(
+			waitCtx, id, exitC, func() {
+				close(exited)
+				<-shipped
+			},
+		),
+	}, nil
+}
+
+// specOpts returns the options that build the OCI spec of a container from its image and the
+// run spec.
+func (s *ContainerdClient) specOpts(
+	image containerd.Image,
+	req cproto.RunSpec,
+	archivesPath string,
+	mountPoints []string,
+) []oci.SpecOpts {
+	// Like docker, an entrypoint replaces that of the image, and so does its command.
+	opts := []oci.SpecOpts{oci.WithImageConfigArgs(image, req.ContainerConfig.Cmd)}
+	if len(req.ContainerConfig.Entrypoint) > 0 {
+		opts = append(opts, oci.WithProcessArgs(
+			append(req.ContainerConfig.Entrypoint, req.ContainerConfig.Cmd...)...,
+		))
+	}
+
+	env := append(req.ContainerConfig.Env, "DET_NO_FLUENT=true", "DET_SHIPPER_EMIT_STDOUT_LOGS=False")
+	if s.debug {
+		env = append(env, "DET_DEBUG=1")
+	}
+	opts = append(opts, oci.WithEnv(env))
+
+	if req.ContainerConfig.WorkingDir != "" {
+		opts = append(opts, oci.WithProcessCwd(req.ContainerConfig.WorkingDir))
+	}
+	if req.ContainerConfig.User != "" {
+		opts = append(opts, oci.WithUser(req.ContainerConfig.User))
+	}
+
+	var mounts []specs.Mount
+	for _, m := range mountPoints {
+		mounts = append(mounts, bindMount(mount.Mount{
+			Source: filepath.Join(archivesPath, m),
+			Target: m,
+		}))
+	}
+	for _, m := range req.HostConfig.Mounts {
+		mounts = append(mounts, bindMount(m))
+	}
+	opts = append(opts, oci.WithMounts(mounts))
+
+	// from master task_container_defaults.shm_size_bytes; containerd otherwise mounts a 64MB
+	// /dev/shm.
+	shmsize := req.HostConfig.ShmSize
+	if shmsize == 0 {
+		shmsize = defaultShmSize
+	}
+	opts = append(opts, oci.WithDevShmSize(shmsize/1024))
+
+	if req.HostConfig.NetworkMode == hostNetworking {
+		opts = append(opts,
+			oci.WithHostNamespace(specs.NetworkNamespace),
+			oci.WithHostHostsFile,
+			oci.WithHostResolvconf,
+		)
+	}
+
+	opts = append(opts,
+		oci.WithAddedCapabilities(canonicalCapabilities(req.HostConfig.CapAdd)),
+		oci.WithDroppedCapabilities(canonicalCapabilities(req.HostConfig.CapDrop)),
+		withResources(req.HostConfig.Resources),
+	)
+	return append(opts, deviceSpecOpts(req)...)
+}
+
+// canonicalCapabilities adds the CAP_ prefix that the OCI spec requires, unlike docker.
+func canonicalCapabilities(caps []string) []string {
+	var canonical []string
+	for _, c := range caps {
+		c = strings.ToUpper(c)
+		if !strings.HasPrefix(c, "CAP_") {
+			c = "CAP_" + c
+		}
+		canonical = append(canonical, c)
+	}
+	return canonical
+}
+
+// deviceSpecOpts injects the devices allocated to the container. NVIDIA GPUs are injected
+// through CDI by their UUID, from the specs the NVIDIA Container Toolkit generates; other devices
+// are passed by path.
+func deviceSpecOpts(req cproto.RunSpec) []oci.SpecOpts {
+	var opts []oci.SpecOpts
+	for _, d := range req.HostConfig.DeviceRequests {
+		if d.Driver != "nvidia" {
+			continue
+		}
+		var names []string
+		for _, id := range d.DeviceIDs {
+			names = append(names, cdiNvidiaGPU+"="+id)
+		}
+		opts = append(opts, withCDIDevices(cdiSpecDirs, names...))
+	}
+	for _, d := range req.HostConfig.Devices {
+		perms := d.CgroupPermissions
+		if perms == "" {
+			perms = "rwm"
+		}
+		opts = append(opts, oci.WithLinuxDevice(d.PathOnHost, perms))
+	}
+	return opts
+}
+
+// withResources applies the CPU and memory limits and the ulimits of the run spec.
+func withResources(r dcontainer.Resources) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		if r.CpusetCpus != "" || r.CpusetMems != "" {
+			if s.Linux.Resources.CPU == nil {
+				s.Linux.Resources.CPU = &specs.LinuxCPU{}
+			}
+			s.Linux.Resources.CPU.Cpus = r.CpusetCpus
+			s.Linux.Resources.CPU.Mems = r.CpusetMems
+		}
+		if r.Memory > 0 {
+			if s.Linux.Resources.Memory == nil {
+				s.Linux.Resources.Memory = &specs.LinuxMemory{}
+			}
+			s.Linux.Resources.Memory.Limit = ptrs.Ptr(r.Memory)
+		}
+		for _, u := range r.Ulimits {
+			s.Process.Rlimits = append(s.Process.Rlimits, specs.POSIXRlimit{
+				Type: "RLIMIT_" + strings.ToUpper(u.Name),
+				Hard: uint64(u.Hard),
+				Soft: uint64(u.Soft),
+			})
+		}
+		return nil
+	}
+}
+
+func bindMount(m mount.Mount) specs.Mount {
+	mountOptions := []string{"rbind"}
+	if m.ReadOnly {
+		mountOptions = append(mountOptions, "ro")
+	} else {
+		mountOptions = append(mountOptions, "rw")
+	}
+	if m.BindOptions != nil && string(m.BindOptions.Propagation) != "" {
+		mountOptions = append(mountOptions, string(m.BindOptions.Propagation))
+	}
+	return specs.Mount{
+		Type:        "bind",
+		Source:      m.Source,
+		Destination: m.Target,
+		Options:     mountOptions,
+	}
+}
+
+// networkID is the ID of the CNI network attachment of a container, as `ctr run --cni` names it.
+func (s *ContainerdClient) networkID(id string) string {
+	return fmt.Sprintf("%s-%s", s.opts.Namespace, id)
+}
+
+// setupNetwork attaches the network namespace of a created task to the node's CNI network.
+func (s *ContainerdClient) setupNetwork(ctx context.Context, id string, task containerd.Task) error {
+	network, err := gocni.New(gocni.WithDefaultConf)
+	if err != nil {
+		return fmt.Errorf("loading CNI configuration: %w", err)
+	}
+	netns := fmt.Sprintf("/proc/%d/ns/net", task.Pid())
+	if _, err := network.Setup(ctx, s.networkID(id), netns); err != nil {
+		return fmt.Errorf("setting up CNI network: %w", err)
+	}
+	return nil
+}
+
+// ReattachContainer implements container.ContainerRuntime.
+func (s *ContainerdClient) ReattachContainer(
+	ctx context.Context,
+	reattachID cproto.ID,
+) (*ddocker.Container, *aproto.ExitCode, error) {
+	id := reattachID.String()
+	cont, err := s.cl.LoadContainer(ctx, id)
+	if errdefs.IsNotFound(err) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("while reattaching container: %w", err)
+	}
+
+	// Attach without IO; containerd keeps writing the output of the task to its log file.
+	task, err := cont.Task(ctx, nil)
+	if errdefs.IsNotFound(err) {
+		s.remove(ctx, id)
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("loading task of reattached container: %w", err)
+	}
+
+	// Subscribe to termination notifications first, to not miss immediate exits.
+	exitC, err := task.Wait(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("waiting on reattached container: %w", err)
+	}
+
+	status, err := task.Status(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inspecting reattached container: %w", err)
+	}
+	// Check if container has exited while we were trying to reattach it.
+	if status.Status == containerd.Stopped {
+		s.remove(ctx, id)
+		return nil, ptrs.Ptr(aproto.ExitCode(status.ExitStatus)), nil
+	}
+
+	info, err := cont.Info(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inspecting reattached container: %w", err)
+	}
+	return &ddocker.Container{
+		ContainerInfo:   containerInfo(info, task.Pid(), cproto.RunSpec{}),
+		ContainerWaiter: s.waitOnTask(ctx, id, exitC, func() {}),
+	}, nil, nil
+}
+
+func containerInfo(info containers.Container, pid uint32, req cproto.RunSpec) types.ContainerJSON {
+	at := info.CreatedAt.String()
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:      info.ID,
+			Created: at,
+			State: &types.ContainerState{
+				Status:    "running",
+				Running:   true,
+				Pid:       int(pid),
+				StartedAt: at,
+			},
+			Image: info.Image,
+			HostConfig: &dcontainer.HostConfig{
+				NetworkMode: req.HostConfig.NetworkMode,
+			},
+		},
+		Config: &dcontainer.Config{
+			Labels:       info.Labels,
+			ExposedPorts: req.ContainerConfig.ExposedPorts,
+		},
+	}
+}
+
+// RemoveContainer implements container.ContainerRuntime.
+func (s *ContainerdClient) RemoveContainer(ctx context.Context, id string, force bool) error {
+	s.mu.Lock()
+	delete(s.specs, cproto.ID(id))
+	s.mu.Unlock()
+
+	cont, err := s.cl.LoadContainer(ctx, id)
+	if errdefs.IsNotFound(err) {
+		return os.RemoveAll(s.scratchDir(id))
+	} else if err != nil {
+		return err
+	}
+	if task, err := cont.Task(ctx, nil); err == nil {
+		status, err := task.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Status == containerd.Running && !force {
+			return fmt.Errorf("cannot remove running container %s", id)
+		}
+	}
+	s.remove(ctx, id)
+	return nil
+}
+
+// remove kills and deletes the task, network attachment, container, snapshot and scratch space of
+// a container, as far as they exist.
+func (s *ContainerdClient) remove(ctx context.Context, id string) {
+	log := s.log.WithField("container-id", id)
+	cont, err := s.cl.LoadContainer(ctx, id)
+	if err == nil {
+		if task, err := cont.Task(ctx, nil); err == nil {
+			if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil {
+				log.WithError(err).Warn("deleting containerd task")
+			}
+		}
+		if spec, err := cont.Spec(ctx); err == nil && !hostNetwork(spec) {
+			if network, err := gocni.New(gocni.WithDefaultConf); err == nil {
+				if err := network.Remove(ctx, s.networkID(id), ""); err != nil {
+					log.WithError(err).Warn("removing CNI network")
+				}
+			}
+		}
+		if err := cont.Delete(ctx, containerd.WithSnapshotCleanup); err != nil {
+			log.WithError(err).Warn("deleting containerd container")
+		}
+	} else if !errdefs.IsNotFound(err) {
+		log.WithError(err).Warn("loading containerd container")
+	}
+
+	// Defer file cleanup until restart if debug logging is enabled.
+	if s.log.Logger.Level <= logrus.DebugLevel {
+		log.Debugf("leaving scratch dir %s for inspection", s.scratchDir(id))
+		return
+	}
+	if err := os.RemoveAll(s.scratchDir(id)); err != nil {
+		log.WithError(err).Warn("failed to cleanup scratch dir (ephemeral mounts, etc)")
+	}
+}
+
+// hostNetwork returns whether the spec joins the network namespace of the host.
+func hostNetwork(spec *oci.Spec) bool {
+	if spec.Linux == nil {
+		return true
+	}
+	for _, ns := range spec.Linux.Namespaces {
+		if ns.Type == specs.NetworkNamespace {
+			return ns.Path != ""
+		}
+	}
+	return true
+}
+
+// SignalContainer implements container.ContainerRuntime.
+func (s *ContainerdClient) SignalContainer(
+	ctx context.Context,
+	id string,
+	sig syscall.Signal,
+) error {
+	cont, err := s.cl.LoadContainer(ctx, id)
+	if errdefs.IsNotFound(err) {
+		return container.ErrMissing
+	} else if err != nil {
+		return err
+	}
+	task, err := cont.Task(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot signal container %s with %s that is not started: %w", id, sig, err)
+	}
+	if err := task.Kill(ctx, sig); err != nil {
+		return fmt.Errorf("signaling container %s with %s: %w", id, sig, err)
+	}
+	return nil
+}
+
+// ListRunningContainers implements container.ContainerRuntime.
+func (s *ContainerdClient) ListRunningContainers(
+	ctx context.Context,
+	fs filters.Args,
+) (map[cproto.ID]types.Container, error) {
+	conts, err := s.cl.Containers(ctx, labelFilters(fs)...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make(map[cproto.ID]types.Container)
+	for _, cont := range conts {
+		task, err := cont.Task(ctx, nil)
+		if errdefs.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		status, err := task.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if status.Status != containerd.Running {
+			continue
+		}
+
+		labels, err := cont.Labels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		containerID, ok := labels[ddocker.ContainerIDLabel]
+		if !ok {
+			s.log.Warnf("container %v has agent label but no container ID", cont.ID())
+			continue
+		}
+		resp[cproto.ID(containerID)] = types.Container{
+			ID:     cont.ID(),
+			Labels: labels,
+			State:  string(status.Status),
+		}
+	}
+	return resp, nil
+}
+
+// labelFilters converts the label filters of docker into containerd filters.
+func labelFilters(fs filters.Args) []string {
+	var cfs []string
+	for _, l := range fs.Get("label") {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok {
+			cfs = append(cfs, fmt.Sprintf("labels.%q", k))
+			continue
+		}
+		cfs = append(cfs, fmt.Sprintf("labels.%q==%q", k, v))
+	}
+	if len(cfs) == 0 {
+		return nil
+	}
+	// containerd ORs separate filters; combine them with a comma to AND them.
+	return []string{strings.Join(cfs, ",")}
+}
+
+// waitOnTask waits for a task to exit, then calls beforeExit, reports the exit and removes the
+// container. Canceling ctx detaches from the task, leaving it running.
+func (s *ContainerdClient) waitOnTask(
+	ctx context.Context,
+	id string,
+	exitC <-chan containerd.ExitStatus,
+	beforeExit func(),
+) ddocker.ContainerWaiter {
+	wchan := make(chan dcontainer.ContainerWaitOKBody, 1)
+	errchan := make(chan error)
+	s.wg.Go(func(wgCtx context.Context) {
+		defer close(wchan)
+		defer close(errchan)
+
+		var status containerd.ExitStatus
+		select {
+		case status = <-exitC:
+		case <-wgCtx.Done():
+			s.log.Trace("detached from container task")
+			return
+		}
+		if ctx.Err() != nil {
+			s.log.Trace("detached from container task")
+			return
+		}
+		beforeExit()
+
+		var body dcontainer.ContainerWaitOKBody
+		if code, _, err := status.Result(); err != nil {
+			s.log.Tracef("task for container %s exited: %s", id, err)
+			body.Error = &dcontainer.ContainerWaitOKBodyError{Message: err.Error()}
+		} else {
+			s.log.Tracef("task for container %s exited with %d", id, code)
+			body.StatusCode = int64(code)
+		}
+
+		select {
+		case wchan <- body:
+		case <-wgCtx.Done():
+			return
+		}
+
+		s.log.Tracef("removing completed container: %s", id)
+		s.remove(wgCtx, id)
+	})
+	return ddocker.ContainerWaiter{Waiter: wchan, Errs: errchan}
+}
+
+// shipLogs follows the log file of a container until its task exits.
+func (s *ContainerdClient) shipLogs(
+	ctx context.Context,
+	path string,
+	exited <-chan struct{},
+	p events.Publisher[ddocker.Event],
+) {
+	// containerd creates the log file when it creates the task, before we start following it.
+	f, err := os.Open(path) // #nosec G304 // We made this filepath.
+	if err != nil {
+		s.log.WithError(err).Warn("opening container log file")
+		return
+	}
+	r := &followReader{ctx: ctx, f: f, exited: exited}
+	cruntimes.ShipContainerCommandLogs(ctx, r, stdcopy.Stdout, p)
+}
+
+// followReader reads a file that is still being written, like `tail -f`, until exited is closed
+// and the file is drained, or ctx is canceled.
+type followReader struct {
+	ctx    context.Context
+	f      *os.File
+	exited <-chan struct{}
+}
+
+func (r *followReader) Read(b []byte) (int, error) {
+	for {
+		n, err := r.f.Read(b)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		select {
+		case <-r.exited:
+			// The task has exited, but may have written more since the read above.
+			if n, err = r.f.Read(b); n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		case <-r.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
+func (r *followReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,193 @@
+package containerd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bindMount(t *testing.T) {
+	tests := []struct {
+		name string
+		m    mount.Mount
+		want []string
+	}{
+		{
+			name: "Simple case",
+			m: mount.Mount{
+				Source:      "/host",
+				Target:      "/container",
+				BindOptions: &mount.BindOptions{},
+			},
+			want: []string{"rbind", "rw"},
+		},
+		{
+			name: "Read-only with propagation",
+			m: mount.Mount{
+				Source:   "/host",
+				Target:   "/container",
+				ReadOnly: true,
+				BindOptions: &mount.BindOptions{
+					Propagation: "rprivate",
+				},
+			},
+			want: []string{"rbind", "ro", "rprivate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := specs.Mount{
+				Type:        "bind",
+				Source:      "/host",
+				Destination: "/container",
+				Options:     tt.want,
+			}
+			if got := bindMount(tt.m); !reflect.DeepEqual(got, want) {
+				t.Errorf("bindMount() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_canonicalCapabilities(t *testing.T) {
+	got := canonicalCapabilities([]string{"SYS_ADMIN", "CAP_IPC_LOCK", "net_raw"})
+	want := []string{"CAP_SYS_ADMIN", "CAP_IPC_LOCK", "CAP_NET_RAW"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("canonicalCapabilities() = %v, want %v", got, want)
+	}
+}
+
+func Test_labelFilters(t *testing.T) {
+	require.Nil(t, labelFilters(filters.NewArgs()))
+	require.Equal(t,
+		[]string{`labels."ai.determined.container.agent"=="agent-1"`},
+		labelFilters(filters.NewArgs(filters.Arg("label", "ai.determined.container.agent=agent-1"))),
+	)
+}
+
+func Test_hostNetwork(t *testing.T) {
+	require.True(t, hostNetwork(&specs.Spec{Linux: &specs.Linux{}}))
+	require.False(t, hostNetwork(&specs.Spec{Linux: &specs.Linux{
+		Namespaces: []specs.LinuxNamespace{{Type: specs.NetworkNamespace}},
+	}}))
+}
+
+func Test_imageRef(t *testing.T) {
+	tests := map[string]string{
+		"docker://ubuntu":                     "docker.io/library/ubuntu:latest",
+		"docker://determinedai/env:tag":       "docker.io/determinedai/env:tag",
+		"docker://registry.example.com/a/b:c": "registry.example.com/a/b:c",
+	}
+	for image, want := range tests {
+		if got := imageRef(image); got != want {
+			t.Errorf("imageRef(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func Test_followReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), logFileName)
+	w, err := os.Create(path)
+	require.NoError(t, err)
+	defer w.Close()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	exited := make(chan struct{})
+	r := &followReader{ctx: context.Background(), f: f, exited: exited}
+	defer r.Close()
+
+	_, err = w.WriteString("before\n")
+	require.NoError(t, err)
+	b := make([]byte, 64)
+	n, err := r.Read(b)
+	require.NoError(t, err)
+	require.Equal(t, "before\n", string(b[:n]))
+
+	// Output written before the exit is still read after it.
+	_, err = w.WriteString("after\n")
+	require.NoError(t, err)
+	close(exited)
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "after\n", string(rest))
+}
+
+func Test_withCDIDevices(t *testing.T) {
+	etc, run := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(etc, "nvidia.yaml"), []byte(`
+cdiVersion: 0.5.0
+kind: nvidia.com/gpu
+devices:
+- name: GPU-0
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia0
+      type: c
+      major: 195
+      minor: 0
+- name: GPU-1
+  containerEdits:
+    deviceNodes:
+    - path: /dev/nvidia1
+      type: c
+      major: 195
+      minor: 1
+containerEdits:
+  env:
+  - NVIDIA_VISIBLE_DEVICES=void
+  deviceNodes:
+  - path: /dev/nvidiactl
+    hostPath: /dev/null
+  mounts:
+  - hostPath: /usr/lib/libcuda.so
+    containerPath: /usr/lib/libcuda.so
+    options: [ro, bind]
+  hooks:
+  - hookName: createContainer
+    path: /usr/bin/nvidia-ctk
+    args: [nvidia-ctk, hook, update-ldcache]
+`), 0o600))
+	// Specs in later directories take precedence.
+	require.NoError(t, os.WriteFile(filepath.Join(run, "nvidia.json"), []byte(`{
+"cdiVersion": "0.5.0", "kind": "nvidia.com/gpu",
+"devices": [{"name": "GPU-1", "containerEdits": {"env": ["GPU=1"]}}]
+}`), 0o600))
+	dirs := []string{etc, run, filepath.Join(t.TempDir(), "missing")}
+
+	s := &specs.Spec{Process: &specs.Process{Env: []string{"NVIDIA_VISIBLE_DEVICES=all"}}}
+	opt := withCDIDevices(dirs, "nvidia.com/gpu=GPU-0")
+	require.NoError(t, opt(context.Background(), nil, nil, s))
+	require.Equal(t, []string{"NVIDIA_VISIBLE_DEVICES=void"}, s.Process.Env)
+	require.Len(t, s.Linux.Devices, 2)
+	require.Equal(t, "/dev/nvidiactl", s.Linux.Devices[0].Path)
+	require.Equal(t, "c", s.Linux.Devices[0].Type)
+	require.Equal(t, int64(1), s.Linux.Devices[0].Major) // Read from /dev/null.
+	require.Equal(t, "/dev/nvidia0", s.Linux.Devices[1].Path)
+	require.Len(t, s.Linux.Resources.Devices, 2)
+	require.Equal(t, "rwm", s.Linux.Resources.Devices[1].Access)
+	require.Equal(t, []specs.Mount{{
+		Source:      "/usr/lib/libcuda.so",
+		Destination: "/usr/lib/libcuda.so",
+		Options:     []string{"ro", "bind"},
+	}}, s.Mounts)
+	require.Len(t, s.Hooks.CreateContainer, 1)
+
+	s = &specs.Spec{}
+	opt = withCDIDevices(dirs, "nvidia.com/gpu=GPU-1")
+	require.NoError(t, opt(context.Background(), nil, nil, s))
+	require.Equal(t, []string{"GPU=1"}, s.Process.Env)
+	require.Empty(t, s.Linux.Devices)
+
+	opt = withCDIDevices(dirs, "nvidia.com/gpu=GPU-2")
+	require.ErrorContains(t, opt(context.Background(), nil, nil, &specs.Spec{}),
+		"unresolvable CDI device nvidia.com/gpu=GPU-2")
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
@@ -354,6 +355,49 @@ func (d *Client) ListRunningContainers(ctx context.Context, fs filters.Args) (
 	return result, nil
 }
 
+// Image is an image present in the Docker daemon.
+type Image struct {
+	ID      string
+	Tags    []string
+	Size    int64
+	Created time.Time
+}
+
+// ListImages lists the tagged images present in the Docker daemon.
+func (d *Client) ListImages(ctx context.Context) ([]Image, error) {
+	summaries, err := d.cl.ImageList(ctx, types.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var images []Image
+	for _, s := range summaries {
+		var tags []string
+		for _, tag := range s.RepoTags {
+			if tag != "<none>:<none>" {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		images = append(images, Image{
+			ID:      s.ID,
+			Tags:    tags,
+			Size:    s.Size,
+			Created: time.Unix(s.Created, 0),
+		})
+	}
+	return images, nil
+}
+
+// RemoveImage removes a tag of an image, and the image with its last tag, unless a container uses
+// it.
+func (d *Client) RemoveImage(ctx context.Context, name string) error {
+	_, err := d.cl.ImageRemove(ctx, name, types.ImageRemoveOptions{PruneChildren: true})
+	return err
+}
+
 // LabelFilter is a convenience that takes a key and value and returns a docker label filter.
 func LabelFilter(key, val string) filters.Args {
 	return filters.NewArgs(filters.Arg("label", key+"="+val))
@@ -256,6 +256,7 @@ func (s *PodmanClient) RunContainer(
 	}
 
 	args = capabilitiesToPodmanArgs(req, args)
+	args = resourcesToPodmanArgs(req, args)
 
 	image := cruntimes.CanonicalizeImage(req.ContainerConfig.Image)
 	args = append(args, image)
@@ -326,6 +327,16 @@ func capabilitiesToPodmanArgs(req cproto.RunSpec, args []string) []string {
 	return args
 }
 
+func resourcesToPodmanArgs(req cproto.RunSpec, args []string) []string {
+	if cpus := req.HostConfig.CpusetCpus; cpus != "" {
+		args = append(args, "--cpuset-cpus", cpus)
+	}
+	if memory := req.HostConfig.Memory; memory > 0 {
+		args = append(args, "--memory", strconv.FormatInt(memory, 10))
+	}
+	return args
+}
+
 func hostMountsToPodmanArgs(m mount.Mount, args []string) []string {
 	var mountOptions []string
 	if m.ReadOnly {
@@ -105,3 +105,18 @@ func Test_processCapabilities(t *testing.T) {
 		})
 	}
 }
+
+func Test_processResources(t *testing.T) {
+	req := cproto.RunSpec{
+		HostConfig: container.HostConfig{
+			Resources: container.Resources{CpusetCpus: "0-3,8", Memory: 1 << 30},
+		},
+	}
+	want := []string{"--cpuset-cpus", "0-3,8", "--memory", "1073741824"}
+	if got := resourcesToPodmanArgs(req, []string{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("resourcesToPodmanArgs() = %v, want %v", got, want)
+	}
+	if got := resourcesToPodmanArgs(cproto.RunSpec{}, nil); len(got) != 0 {
+		t.Errorf("resourcesToPodmanArgs() = %v, want no args", got)
+	}
+}
@@ -203,6 +203,7 @@ class ClusterInfo:
         master_cert_name: Optional[str] = None,
         master_cert_file: Optional[str] = None,
         latest_checkpoint: Optional[str] = None,
+        latest_checkpoint_storage: Optional[Dict[str, Any]] = None,
         # Information which is generated within a container at runtime.
         trial_info: Optional[TrialInfo] = None,
         rendezvous_info: Optional[RendezvousInfo] = None,
@@ -248,6 +249,7 @@ class ClusterInfo:
         self.master_cert_file = master_cert_file
 
         self._latest_checkpoint = latest_checkpoint
+        self._latest_checkpoint_storage = latest_checkpoint_storage
 
         self._trial_info = trial_info
         self._rendezvous_info = rendezvous_info
@@ -283,6 +285,9 @@ class ClusterInfo:
             master_cert_name=os.environ.get("DET_MASTER_CERT_NAME"),
             master_cert_file=os.environ.get("DET_MASTER_CERT_FILE"),
             latest_checkpoint=os.environ.get("DET_LATEST_CHECKPOINT"),
+            latest_checkpoint_storage=json.loads(
+                os.environ.get("DET_LATEST_CHECKPOINT_STORAGE", "null")
+            ),
             # Separate info objects:
             trial_info=TrialInfo._from_file(),
             rendezvous_info=RendezvousInfo._from_file(),
@@ -36,7 +36,16 @@ def logs(args: Namespace) -> None:
     offset: Optional[int] = None
     if args.tail:
         offset = -args.tail
-    responses = bindings.get_MasterLogs(cli.setup_session(args), follow=args.follow, offset=offset)
+    responses = bindings.get_MasterLogs(
+        cli.setup_session(args),
+        component=args.component,
+        follow=args.follow,
+        minLevel=bindings.v1LogLevel[args.level] if args.level else None,
+        offset=offset,
+        searchText=args.search,
+        timestampAfter=args.timestamp_after,
+        timestampBefore=args.timestamp_before,
+    )
     for response in responses:
         print(format_log_entry(response.logEntry))
 
@@ -56,7 +65,16 @@ args_description = [
                 help="follow the logs of master, similar to tail -f"),
             Arg("--tail", type=int,
                 help="number of lines to show, counting from the end "
-                "of the log (default is all)")
+                "of the log (default is all)"),
+            Arg("--level", dest="level",
+                help="show logs with this level or higher",
+                choices=["TRACE", "DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"]),
+            Arg("--component", help="show logs only with this component field"),
+            Arg("--timestamp-before",
+                help="show logs only from before (RFC 3339 format), e.g. '2021-10-26T23:17:12Z'"),
+            Arg("--timestamp-after",
+                help="show logs only from after (RFC 3339 format), e.g. '2021-10-26T23:17:12Z'"),
+            Arg("--search", help="show logs only containing this text, ignoring case"),
         ]),
     ])
 ]  # type: List[Any]
@@ -28,6 +28,7 @@ DEFAULT_DETERMINED_PASSWORD = ""
 DEFAULT_CHECKPOINT_PATH = "checkpoints"
 
 SHARED_FS_CONTAINER_PATH = "/determined_shared_fs"
+LATEST_CHECKPOINT_SHARED_FS_CONTAINER_PATH = "/determined_latest_checkpoint_shared_fs"
 
 # By default, we ignore:
 #  - all byte-compiled Python files to ignore a potential stale compilation
@@ -2,11 +2,12 @@ import abc
 import contextlib
 import copy
 import glob
+import hashlib
 import os
 import pathlib
 import tempfile
 import urllib
-from typing import Any, Callable, Dict, Iterator, List, Optional, Set, Union
+from typing import Any, Callable, Dict, Iterable, Iterator, List, Optional, Set, Union
 
 from determined import util
 from determined.common import storage
@@ -167,6 +168,26 @@ class StorageManager(metaclass=abc.ABCMeta):
 
         return result
 
+    @staticmethod
+    def _checksum_files(root: Union[str, os.PathLike], paths: Iterable[str]) -> Dict[str, str]:
+        """
+        Returns a dict mapping each of the given path names, relative to `root`, to the SHA-256
+        digest of its contents in the form "sha256:<hex>". Directories, signified by a trailing
+        "/", are skipped.
+        """
+        root = os.fspath(root)
+        result = {}
+        for rel_path in paths:
+            if rel_path.endswith("/"):
+                continue
+            digest = hashlib.sha256()
+            with open(os.path.join(root, rel_path), "rb") as f:
+                for chunk in iter(lambda: f.read(1024 * 1024), b""):
+                    digest.update(chunk)
+            result[rel_path] = "sha256:" + digest.hexdigest()
+
+        return result
+
     @staticmethod
     def _apply_globs_to_resources(
         file_paths_to_sizes: Dict[str, int],
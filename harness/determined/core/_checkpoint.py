@@ -182,6 +182,7 @@ class CheckpointContext:
         allocation_id: Optional[str],
         tbd_sync_mode: core.TensorboardMode,
         tensorboard_manager: tensorboard.TensorboardManager,
+        restore_storage_managers: Optional[Dict[str, storage.StorageManager]] = None,
     ) -> None:
         self._dist = dist
         self._storage_manager = storage_manager
@@ -190,6 +191,11 @@ class CheckpointContext:
         self._allocation_id = allocation_id
         self._tensorboard_mode = tbd_sync_mode
         self._tensorboard_manager = tensorboard_manager
+        # Checkpoints that were copied to other storage are read from there instead.
+        self._restore_storage_managers = restore_storage_managers or {}
+
+    def _storage_manager_for(self, storage_id: str) -> storage.StorageManager:
+        return self._restore_storage_managers.get(storage_id, self._storage_manager)
 
     def upload(
         self,
@@ -271,8 +277,9 @@ class CheckpointContext:
             resources = {key: resources[key] for key in resources if selector(key)}
             paths = set(resources)
 
+        checksums = self._storage_manager._checksum_files(ckpt_dir, resources)
         self._storage_manager.upload(src=ckpt_dir, dst=storage_id, paths=paths)
-        self._report_checkpoint(storage_id, resources, metadata)
+        self._report_checkpoint(storage_id, resources, metadata, checksums)
         return storage_id
 
     def _upload_sharded(
@@ -336,16 +343,19 @@ class CheckpointContext:
             self._write_metadata_file(ckpt_dir, all_metadata)
             resources["metadata.json"] = os.path.getsize(os.path.join(ckpt_dir, "metadata.json"))
 
+        checksums = {}
         if want_upload:
             assert ckpt_dir
             paths = set(resources.keys())
+            checksums = self._storage_manager._checksum_files(ckpt_dir, paths)
             self._storage_manager.upload(src=ckpt_dir, dst=storage_id, paths=paths)
 
-        # Synchronize workers.
-        _ = self._dist.allgather(None)
+        # Synchronize workers, collecting the checksums of everything that was uploaded.
+        all_checksums = self._dist.allgather(checksums)
 
         if self._dist.rank == 0:
-            self._report_checkpoint(storage_id, merged_resources, all_metadata)
+            merged_checksums = {k: v for c in all_checksums for k, v in c.items()}
+            self._report_checkpoint(storage_id, merged_resources, all_metadata, merged_checksums)
         return storage_id
 
     def _resolve_conflicts(
@@ -412,7 +422,9 @@ class CheckpointContext:
         download_mode = DownloadMode(download_mode)
 
         if download_mode == DownloadMode.NoSharedDownload:
-            self._storage_manager.download(src=storage_id, dst=ckpt_dir, selector=selector)
+            self._storage_manager_for(storage_id).download(
+                src=storage_id, dst=ckpt_dir, selector=selector
+            )
             return
 
         want_filter = any(self._dist.allgather(selector is not None))
@@ -436,7 +448,9 @@ class CheckpointContext:
                 assert upload_path
                 return any(upload_path)
 
-            self._storage_manager.download(src=storage_id, dst=ckpt_dir, selector=_selector)
+            self._storage_manager_for(storage_id).download(
+                src=storage_id, dst=ckpt_dir, selector=_selector
+            )
             # Tell local workers we finished.
             _ = self._dist.broadcast_local(None)
         else:
@@ -509,8 +523,9 @@ class CheckpointContext:
             yield path, storage_id
             self._write_metadata_file(os.fspath(path), metadata or {})
             resources = self._storage_manager._list_directory(path)
+            checksums = self._storage_manager._checksum_files(path, resources)
 
-        self._report_checkpoint(storage_id, resources, metadata)
+        self._report_checkpoint(storage_id, resources, metadata, checksums)
 
     def _store_path_sharded(
         self, metadata: Optional[Dict[str, Any]] = None
@@ -536,7 +551,8 @@ class CheckpointContext:
             if self._dist.rank == 0:
                 self._write_metadata_file(os.fspath(path), all_metadata)
                 resources = self._storage_manager._list_directory(ckpt_dir)
-                self._report_checkpoint(storage_id, resources, all_metadata)
+                checksums = self._storage_manager._checksum_files(ckpt_dir, resources)
+                self._report_checkpoint(storage_id, resources, all_metadata, checksums)
 
             return
 
@@ -610,7 +626,8 @@ class CheckpointContext:
         download_mode = DownloadMode(download_mode)
 
         if download_mode == DownloadMode.NoSharedDownload:
-            with self._storage_manager.restore_path(storage_id, selector=selector) as path:
+            restore_storage = self._storage_manager_for(storage_id)
+            with restore_storage.restore_path(storage_id, selector=selector) as path:
                 yield path
             return
 
@@ -635,7 +652,8 @@ class CheckpointContext:
                 assert upload_path
                 return any(upload_path)
 
-            with self._storage_manager.restore_path(storage_id, _selector) as path:
+            restore_storage = self._storage_manager_for(storage_id)
+            with restore_storage.restore_path(storage_id, _selector) as path:
                 # Tell local workers that download is finished.
                 _ = self._dist.broadcast_local(None)
                 # Broadcast to local workers.
@@ -678,9 +696,11 @@ class CheckpointContext:
         storage_id: str,
         resources: Optional[Dict[str, int]] = None,
         metadata: Optional[Dict[str, Any]] = None,
+        checksums: Optional[Dict[str, str]] = None,
     ) -> None:
         """
-        After having uploaded a checkpoint, report its existence to the master.
+        After having uploaded a checkpoint, report its existence to the master, along with the
+        checksums of the uploaded files if they are known.
         """
         resources = resources or {}
         metadata = metadata or {}
@@ -693,6 +713,7 @@ class CheckpointContext:
 
         ckpt = bindings.v1Checkpoint(
             allocationId=self._allocation_id,
+            checksums=checksums or None,
             metadata=metadata,
             resources={k: str(v) for k, v in resources.items()},
             taskId=self._task_id,
@@ -723,6 +744,7 @@ class DummyCheckpointContext(CheckpointContext):
         storage_id: str,
         resources: Optional[Dict[str, int]] = None,
         metadata: Optional[Dict[str, Any]] = None,
+        checksums: Optional[Dict[str, str]] = None,
     ) -> None:
         # No master to report to; just log the event.
         logger.info(f"saved checkpoint {storage_id}")
@@ -250,6 +250,14 @@ def init(
                 container_path=constants.SHARED_FS_CONTAINER_PATH,
             )
 
+        # The latest checkpoint may have been copied to storage other than the trial's own.
+        restore_storage_managers: Dict[str, storage.StorageManager] = {}
+        if info.latest_checkpoint is not None and info._latest_checkpoint_storage is not None:
+            restore_storage_managers[info.latest_checkpoint] = storage.build(
+                info._latest_checkpoint_storage,
+                container_path=constants.LATEST_CHECKPOINT_SHARED_FS_CONTAINER_PATH,
+            )
+
         checkpoint = core.CheckpointContext(
             distributed,
             storage_manager,
@@ -258,6 +266,7 @@ def init(
             info.allocation_id,
             tensorboard_mode,
             tensorboard_manager,
+            restore_storage_managers,
         )
 
         preempt = core.PreemptContext(session, info.allocation_id, distributed, preempt_mode)
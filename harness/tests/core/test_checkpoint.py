@@ -29,6 +29,7 @@ def make_mock_storage_manager(basedir: pathlib.Path) -> Any:
     storage_manager.store_path = mock.MagicMock(side_effect=store_path)
     storage_manager.restore_path = mock.MagicMock(side_effect=restore_path)
     storage_manager._list_directory = mock.MagicMock(return_value={"one": 1, "two": 2})
+    storage_manager._checksum_files = mock.MagicMock(return_value={"one": "sha256:01"})
 
     return storage_manager
 
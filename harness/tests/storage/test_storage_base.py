@@ -1,3 +1,4 @@
+import hashlib
 import os
 from typing import Optional
 from unittest import mock
@@ -44,6 +45,16 @@ def test_list_directory() -> None:
     }
 
 
+def test_checksum_files() -> None:
+    root = os.path.join(os.path.dirname(__file__), "fixtures")
+    paths = storage.StorageManager._list_directory(root)
+    checksums = storage.StorageManager._checksum_files(root, paths)
+
+    assert set(checksums) == {"root.txt", "nested/nested.txt", "nested/another.txt"}
+    with open(os.path.join(root, "root.txt"), "rb") as f:
+        assert checksums["root.txt"] == "sha256:" + hashlib.sha256(f.read()).hexdigest()
+
+
 def test_list_directory_on_file() -> None:
     root = os.path.join(os.path.dirname(__file__), "fixtures", "root.txt")
     assert os.path.exists(root)
@@ -42,6 +42,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	command "github.com/determined-ai/determined/master/pkg/command"
+	"github.com/determined-ai/determined/master/pkg/downsample"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/master/pkg/protoutils/protoless"
@@ -446,7 +447,7 @@ func (a *apiServer) deleteExperiments(exps []*model.Experiment, userModel *model
 		return nil, errors.Wrapf(err, "failed to delete trial logs from backend")
 	}
 
-	if err = a.m.taskLogBackend.DeleteTaskLogs(taskIDs); err != nil {
+	if err = a.m.taskLogBackend.DeleteTaskLogs(ctx, taskIDs); err != nil {
 		return nil, errors.Wrapf(err, "failed to delete trial logs from backend (task logs)")
 	}
 
@@ -1344,6 +1345,10 @@ func (a *apiServer) CreateExperiment(
 		return nil, status.Errorf(codes.PermissionDenied, err.Error())
 	}
 
+	if err = a.m.checkCheckpointStorageQuota(ctx, p); err != nil {
+		return nil, err
+	}
+
 	if req.ValidateOnly {
 		return &apiv1.CreateExperimentResponse{
 			Experiment: &experimentv1.Experiment{},
@@ -1373,7 +1378,7 @@ func (a *apiServer) CreateExperiment(
 		}
 	}
 
-	e, launchWarnings, err := newExperiment(a.m, dbExp, activeConfig, taskSpec)
+	e, launchWarnings, err := newExperiment(ctx, a.m, dbExp, activeConfig, taskSpec)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create experiment: %s", err)
 	}
@@ -1712,7 +1717,7 @@ func (a *apiServer) topTrials(
 
 func (a *apiServer) fetchTrialSample(trialID int32, metricName string, metricType apiv1.MetricType,
 	maxDatapoints int, startBatches int, endBatches int, currentTrials map[int32]bool,
-	trialCursors map[int32]time.Time,
+	trialCursors map[int32]time.Time, algorithm downsample.Algorithm,
 ) (*apiv1.TrialsSampleResponse_Trial, error) {
 	var endTime time.Time
 	var zeroTime time.Time
@@ -1748,7 +1753,7 @@ func (a *apiServer) fetchTrialSample(trialID int32, metricName string, metricTyp
 	metricMeasurements, err = trials.MetricsTimeSeries(trialID, startTime,
 		[]string{metricName},
 		startBatches, endBatches, xAxisLabelMetrics, maxDatapoints,
-		"batches", nil, metricID)
+		"batches", nil, metricID, algorithm)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error fetching time series of metrics")
 	}
@@ -1807,6 +1812,10 @@ func (a *apiServer) TrialsSample(req *apiv1.TrialsSampleRequest,
 	if metricName == "" {
 		return status.Error(codes.InvalidArgument, "must specify a metric name")
 	}
+	algorithm, err := a.downsamplingAlgorithm(req.DownsamplingAlgorithm)
+	if err != nil {
+		return err
+	}
 
 	var timeSinceLastAuth time.Time
 	var searcherConfig expconf.LegacySearcher
@@ -1840,7 +1849,7 @@ func (a *apiServer) TrialsSample(req *apiv1.TrialsSampleRequest,
 		for _, trialID := range trialIDs {
 			var trial *apiv1.TrialsSampleResponse_Trial
 			trial, err = a.fetchTrialSample(trialID, metricName, metricType, maxDatapoints,
-				startBatches, endBatches, currentTrials, trialCursors)
+				startBatches, endBatches, currentTrials, trialCursors, algorithm)
 			if err != nil {
 				return err
 			}
@@ -11,19 +11,17 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
-	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/cluster"
 	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/plugin/sso"
 	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/version"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/logv1"
 )
 
-var masterLogsBatchMissWaitTime = time.Second
-
 func (a *apiServer) GetMaster(
 	_ context.Context, _ *apiv1.GetMasterRequest,
 ) (*apiv1.GetMasterResponse, error) {
@@ -94,21 +92,26 @@ func (a *apiServer) MasterLogs(
 	); err != nil {
 		return err
 	}
+	if req.Follow && req.TimestampBefore != nil {
+		return status.Error(codes.InvalidArgument, "follow cannot be used with timestamp_before")
+	}
 
-	fetch := func(lr api.BatchRequest) (api.Batch, error) {
-		if lr.Follow {
-			lr.Limit = -1
+	filter := logger.EntryFilter{Component: req.Component, Text: req.SearchText}
+	if req.MinLevel != logv1.LogLevel_LOG_LEVEL_UNSPECIFIED {
+		level, err := logger.ProtoLevelToLogrus(req.MinLevel)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
 		}
-		return logger.EntriesBatch(a.m.logs.Entries(lr.Offset, -1, lr.Limit)), nil
+		filter.Level = &level
+	}
+	if req.TimestampAfter != nil {
+		filter.Since = ptrs.Ptr(req.TimestampAfter.AsTime())
+	}
+	if req.TimestampBefore != nil {
+		filter.Until = ptrs.Ptr(req.TimestampBefore.AsTime())
 	}
 
-	total := a.m.logs.Len()
-	offset, limit := api.EffectiveOffsetNLimit(int(req.Offset), int(req.Limit), total)
-	lReq := api.BatchRequest{Offset: offset, Limit: limit, Follow: req.Follow}
-
-	ctx, cancel := context.WithCancel(resp.Context())
-	defer cancel()
-
+	ctx := resp.Context()
 	u, _, err := grpcutil.GetUser(ctx)
 	if err != nil {
 		return err
@@ -122,29 +125,17 @@ func (a *apiServer) MasterLogs(
 		return status.Error(codes.PermissionDenied, permErr.Error())
 	}
 
-	res := make(chan api.BatchResult, 1)
-	go api.NewBatchStreamProcessor(
-		lReq,
-		fetch,
-		nil,
-		false,
-		nil,
-		&masterLogsBatchMissWaitTime,
-	).Run(ctx, res)
-
-	return processBatches(res, func(b api.Batch) error {
-		return b.ForEach(func(r interface{}) error {
-			lr := r.(*logger.Entry)
+	return a.m.streamMasterLogs(ctx, filter, int(req.Offset), int(req.Limit), req.Follow,
+		func(e *logger.Entry) error {
 			return resp.Send(&apiv1.MasterLogsResponse{
 				LogEntry: &logv1.LogEntry{
-					Id:        int32(lr.ID),
-					Message:   lr.Message,
-					Timestamp: timestamppb.New(lr.Time),
-					Level:     logger.LogrusLevelToProto(lr.Level),
+					Id:        int32(e.ID),
+					Message:   e.Message,
+					Timestamp: timestamppb.New(e.Time),
+					Level:     logger.LogrusLevelToProto(e.Level),
 				},
 			})
 		})
-	})
 }
 
 func (a *apiServer) ResourceAllocationRaw(
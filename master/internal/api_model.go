@@ -16,10 +16,12 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/api/apiutils"
 	"github.com/determined-ai/determined/master/internal/authz"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	modelauth "github.com/determined-ai/determined/master/internal/model"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
 	"github.com/determined-ai/determined/proto/pkg/modelv1"
@@ -766,7 +768,30 @@ func (a *apiServer) PatchModelVersion(
 		currLabels = reqLabels
 	}
 
+	stageChanged := false
+	if req.ModelVersion.Stage != modelv1.ModelVersionStage_MODEL_VERSION_STAGE_UNSPECIFIED &&
+		req.ModelVersion.Stage != currModelVersion.Stage {
+		stage, err := model.ParseModelVersionStage(
+			strings.TrimPrefix(req.ModelVersion.Stage.String(), "MODEL_VERSION_STAGE_"))
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		log.Infof("model version (%v) stage changing from %s to %s",
+			modelVersionName, currModelVersion.Stage, req.ModelVersion.Stage)
+		if _, err := db.TransitionModelVersionStage(ctx, int(currModelVersion.Id), stage,
+			curUser.ID, "", a.m.config.ModelRegistry.RequireProductionApproval); err != nil {
+			return nil, apiutils.MapAndFilterErrors(err, nil, nil)
+		}
+		stageChanged = true
+	}
+
 	if !madeChanges {
+		if stageChanged {
+			currModelVersion, err = a.ModelVersionFromID(req.ModelName, req.ModelVersionNum)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return &apiv1.PatchModelVersionResponse{ModelVersion: currModelVersion}, nil
 	}
 
@@ -8,6 +8,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/db"
 	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/internal/task/gputelemetry"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/taskv1"
@@ -25,8 +26,13 @@ func (a *apiServer) GetTask(
 	switch err := a.m.db.QueryProto("get_task", t, req.TaskId); {
 	case errors.Is(err, db.ErrNotFound):
 		return nil, api.NotFoundErrs("task", req.TaskId, true)
-	default:
-		return &apiv1.GetTaskResponse{Task: t},
-			errors.Wrapf(err, "error fetching task %s from database", req.TaskId)
+	case err != nil:
+		return nil, errors.Wrapf(err, "error fetching task %s from database", req.TaskId)
 	}
+
+	for _, alloc := range t.Allocations {
+		alloc.GpuTelemetry = gputelemetry.Protos(
+			gputelemetry.Latest(model.AllocationID(alloc.AllocationId)))
+	}
+	return &apiv1.GetTaskResponse{Task: t}, nil
 }
@@ -263,12 +263,13 @@ func (a *apiServer) PostAllocationProxyAddress(
 // must support to provide the features surfaced in our API.
 type TaskLogBackend interface {
 	TaskLogs(
-		taskID model.TaskID, limit int, filters []api.Filter, order apiv1.OrderBy, state interface{},
+		ctx context.Context, taskID model.TaskID, limit int, filters []api.Filter,
+		order apiv1.OrderBy, state interface{},
 	) ([]*model.TaskLog, interface{}, error)
 	AddTaskLogs([]*model.TaskLog) error
-	TaskLogsCount(taskID model.TaskID, filters []api.Filter) (int, error)
-	TaskLogsFields(taskID model.TaskID) (*apiv1.TaskLogsFieldsResponse, error)
-	DeleteTaskLogs(taskIDs []model.TaskID) error
+	TaskLogsCount(ctx context.Context, taskID model.TaskID, filters []api.Filter) (int, error)
+	TaskLogsFields(ctx context.Context, taskID model.TaskID) (*apiv1.TaskLogsFieldsResponse, error)
+	DeleteTaskLogs(ctx context.Context, taskIDs []model.TaskID) error
 	// MaxTerminationDelay is the max delay before a consumer can be sure all logs have been
 	// recevied. A better interface may be an interface for streaming, rather than helper
 	// interfaces to aid streaming, but it's not bad enough to motivate changing it.
@@ -429,7 +430,7 @@ func (a *apiServer) taskLogs(
 		}
 
 		b, state, fErr := a.m.taskLogBackend.TaskLogs(
-			taskID, r.Limit, filters, req.OrderBy, followState)
+			ctx, taskID, r.Limit, filters, req.OrderBy, followState)
 		if fErr != nil {
 			return nil, fErr
 		}
@@ -438,7 +439,7 @@ func (a *apiServer) taskLogs(
 		return model.TaskLogBatch(b), nil
 	}
 
-	total, err := a.m.taskLogBackend.TaskLogsCount(taskID, filters)
+	total, err := a.m.taskLogBackend.TaskLogsCount(ctx, taskID, filters)
 	if err != nil {
 		res <- api.ErrBatchResult(fmt.Errorf("getting log count from backend: %w", err))
 		return
@@ -544,7 +545,7 @@ func (a *apiServer) TaskLogsFields(
 			timeSinceLastAuth = time.Now()
 		}
 
-		fields, err := a.m.taskLogBackend.TaskLogsFields(taskID)
+		fields, err := a.m.taskLogBackend.TaskLogsFields(resp.Context(), taskID)
 		return api.ToBatchOfOne(fields), err
 	}
 
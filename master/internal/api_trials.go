@@ -24,12 +24,15 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	expauth "github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/metricstream"
 	"github.com/determined-ai/determined/master/internal/rm/allocationmap"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/task"
+	"github.com/determined-ai/determined/master/internal/task/gputelemetry"
 	"github.com/determined-ai/determined/master/internal/task/preemptible"
 	"github.com/determined-ai/determined/master/internal/trials"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/downsample"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/master/pkg/protoutils/protoconverter"
@@ -404,7 +407,7 @@ func (a *apiServer) TrialLogsFields(
 				taskLogsTimeSinceLastAuth = time.Now()
 			}
 
-			fields, err := a.m.taskLogBackend.TaskLogsFields(trial.TaskID)
+			fields, err := a.m.taskLogBackend.TaskLogsFields(resp.Context(), trial.TaskID)
 			return api.ToBatchOfOne(&apiv1.TrialLogsFieldsResponse{
 				AgentIds:     fields.AgentIds,
 				ContainerIds: fields.ContainerIds,
@@ -633,6 +636,8 @@ func (a *apiServer) GetTrial(ctx context.Context, req *apiv1.GetTrialRequest) (
 		if err := a.enrichTrialState(resp.Trial); err != nil {
 			return nil, err
 		}
+		resp.GpuTelemetry = gputelemetry.Protos(
+			gputelemetry.LatestOfTask(model.TaskID(resp.Trial.TaskId)))
 	}
 
 	return resp, nil
@@ -678,7 +683,7 @@ func (a *apiServer) multiTrialSample(trialID int32, metricNames []string,
 	metricType model.MetricType, maxDatapoints int, startBatches int,
 	endBatches int, logScale bool,
 	timeSeriesFilter *commonv1.PolymorphicFilter,
-	metricIds []string,
+	metricIds []string, algorithm downsample.Algorithm,
 ) ([]*apiv1.DownsampledMetrics, error) {
 	var startTime time.Time
 	var metrics []*apiv1.DownsampledMetrics
@@ -738,7 +743,7 @@ func (a *apiServer) multiTrialSample(trialID int32, metricNames []string,
 		metricMeasurements, err := trials.MetricsTimeSeries(
 			trialID, startTime, aMetricNames, startBatches, endBatches,
 			xAxisLabelMetrics,
-			maxDatapoints, *timeSeriesColumn, timeSeriesFilter, aMetricType)
+			maxDatapoints, *timeSeriesColumn, timeSeriesFilter, aMetricType, algorithm)
 		if err != nil {
 			return nil, errors.Wrapf(err, fmt.Sprintf("error fetching time series of %s metrics",
 				aMetricType))
@@ -800,7 +805,7 @@ func (a *apiServer) SummarizeTrial(ctx context.Context,
 	tsample, err := a.multiTrialSample(req.TrialId, req.MetricNames, metricType,
 		int(req.MaxDatapoints), int(req.StartBatches), int(req.EndBatches),
 		(req.Scale == apiv1.Scale_SCALE_LOG),
-		nil, metricIds)
+		nil, metricIds, a.m.config.MetricsDownsampling.Algorithm)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed sampling")
 	}
@@ -812,6 +817,10 @@ func (a *apiServer) SummarizeTrial(ctx context.Context,
 func (a *apiServer) CompareTrials(ctx context.Context,
 	req *apiv1.CompareTrialsRequest,
 ) (*apiv1.CompareTrialsResponse, error) {
+	algorithm, err := a.downsamplingAlgorithm(req.DownsamplingAlgorithm)
+	if err != nil {
+		return nil, err
+	}
 	trials := make([]*apiv1.ComparableTrial, 0, len(req.TrialIds))
 	for _, trialID := range req.TrialIds {
 		if err := a.canGetTrialsExperimentAndCheckCanDoAction(ctx, int(trialID),
@@ -835,7 +844,7 @@ func (a *apiServer) CompareTrials(ctx context.Context,
 
 		tsample, err := a.multiTrialSample(trialID, req.MetricNames, metricType,
 			int(req.MaxDatapoints), int(req.StartBatches), int(req.EndBatches),
-			(req.Scale == apiv1.Scale_SCALE_LOG), req.TimeSeriesFilter, req.MetricIds)
+			(req.Scale == apiv1.Scale_SCALE_LOG), req.TimeSeriesFilter, req.MetricIds, algorithm)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed sampling")
 		}
@@ -845,14 +854,43 @@ func (a *apiServer) CompareTrials(ctx context.Context,
 	return &apiv1.CompareTrialsResponse{Trials: trials}, nil
 }
 
+// downsamplingAlgorithm returns the downsampling algorithm a request asked for, or the one of the
+// master config if it did not ask for one.
+func (a *apiServer) downsamplingAlgorithm(
+	algorithm apiv1.DownsamplingAlgorithm,
+) (downsample.Algorithm, error) {
+	switch algorithm {
+	case apiv1.DownsamplingAlgorithm_DOWNSAMPLING_ALGORITHM_UNSPECIFIED:
+		return a.m.config.MetricsDownsampling.Algorithm, nil
+	case apiv1.DownsamplingAlgorithm_DOWNSAMPLING_ALGORITHM_RANDOM:
+		return downsample.Random, nil
+	case apiv1.DownsamplingAlgorithm_DOWNSAMPLING_ALGORITHM_LTTB:
+		return downsample.LTTB, nil
+	case apiv1.DownsamplingAlgorithm_DOWNSAMPLING_ALGORITHM_MIN_MAX:
+		return downsample.MinMax, nil
+	case apiv1.DownsamplingAlgorithm_DOWNSAMPLING_ALGORITHM_LAST:
+		return downsample.Last, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument,
+			"unknown downsampling algorithm %s", algorithm)
+	}
+}
+
 func (a *apiServer) GetMetrics(
 	req *apiv1.GetMetricsRequest, resp apiv1.Determined_GetMetricsServer,
 ) error {
 	sendFunc := func(m []*trialv1.MetricsReport) error {
 		return resp.Send(&apiv1.GetMetricsResponse{Metrics: m})
 	}
+	if req.MaxDatapoints < 0 {
+		return status.Error(codes.InvalidArgument, "max_datapoints must not be negative")
+	}
+	algorithm, err := a.downsamplingAlgorithm(req.DownsamplingAlgorithm)
+	if err != nil {
+		return err
+	}
 	if err := a.streamMetrics(resp.Context(), req.TrialIds, sendFunc,
-		model.MetricType(req.Type)); err != nil {
+		model.MetricType(req.Type), int(req.MaxDatapoints), algorithm); err != nil {
 		return err
 	}
 
@@ -866,7 +904,7 @@ func (a *apiServer) GetTrainingMetrics(
 		return resp.Send(&apiv1.GetTrainingMetricsResponse{Metrics: m})
 	}
 	if err := a.streamMetrics(resp.Context(), req.TrialIds, sendFunc,
-		model.TrainingMetricType); err != nil {
+		model.TrainingMetricType, 0, ""); err != nil {
 		return err
 	}
 
@@ -880,15 +918,61 @@ func (a *apiServer) GetValidationMetrics(
 		return resp.Send(&apiv1.GetValidationMetricsResponse{Metrics: m})
 	}
 	if err := a.streamMetrics(resp.Context(), req.TrialIds, sendFunc,
-		model.ValidationMetricType); err != nil {
+		model.ValidationMetricType, 0, ""); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+func (a *apiServer) StreamTrialMetrics(
+	req *apiv1.StreamTrialMetricsRequest, resp apiv1.Determined_StreamTrialMetricsServer,
+) error {
+	ctx := resp.Context()
+	trialIDs := make([]int, 0, len(req.TrialIds))
+	for _, trialID := range req.TrialIds {
+		if err := a.canGetTrialsExperimentAndCheckCanDoAction(ctx, int(trialID),
+			expauth.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+			return err
+		}
+		trialIDs = append(trialIDs, int(trialID))
+	}
+	var expID *int
+	if req.ExperimentId != 0 {
+		id := int(req.ExperimentId)
+		if _, _, err := a.getExperimentAndCheckCanDoActions(ctx, id,
+			expauth.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+			return err
+		}
+		expID = &id
+	}
+	resume := map[trialMetricsKey]int{}
+	for _, p := range req.Resume {
+		resume[trialMetricsKey{int(p.TrialId), model.MetricType(p.Group)}] = int(p.TotalBatches)
+	}
+
+	s, err := newTrialMetricsStream(trialIDs, expID, req.Groups, resume)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = a.m.runTrialMetricsStream(ctx, s, func(r metricstream.Report) error {
+		return resp.Send(&apiv1.StreamTrialMetricsResponse{
+			Group:  r.Group.ToString(),
+			Report: r.MetricsReport,
+		})
+	})
+	switch {
+	case errors.Is(err, metricstream.ErrNotListening):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, metricstream.ErrMissedReports), errors.Is(err, metricstream.ErrTooSlow):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return err
+}
+
 func (a *apiServer) streamMetrics(ctx context.Context,
 	trialIDs []int32, sendFunc func(m []*trialv1.MetricsReport) error, metricType model.MetricType,
+	maxDatapoints int, algorithm downsample.Algorithm,
 ) error {
 	if len(trialIDs) == 0 {
 		return status.Error(codes.InvalidArgument, "must specify at least one trialId")
@@ -912,6 +996,7 @@ func (a *apiServer) streamMetrics(ctx context.Context,
 
 	trialIDIndex := 0
 	key := -1
+	var pending []*trialv1.MetricsReport
 	for {
 		res, err := db.GetMetrics(ctx, int(trialIDs[trialIDIndex]), key, size, metricType)
 		if err != nil {
@@ -922,17 +1007,39 @@ func (a *apiServer) streamMetrics(ctx context.Context,
 				// TODO we are giving too precise timestamps for our Python parsing code somehow.
 				res[i].EndTime = timestamppb.New(res[i].EndTime.AsTime().Truncate(time.Millisecond))
 			}
+			key = int(res[len(res)-1].TotalBatches)
 
-			if err := ctx.Err(); err != nil {
-				return err
-			}
-			if err := sendFunc(res); err != nil {
-				return err
+			if maxDatapoints > 0 {
+				// Downsample once every report of the trial is read.
+				pending = append(pending, res...)
+			} else {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := sendFunc(res); err != nil {
+					return err
+				}
 			}
-			key = int(res[len(res)-1].TotalBatches)
 		}
 
 		if len(res) != size {
+			if len(pending) > 0 {
+				pending = trials.DownsampleReports(pending, metricType, algorithm, maxDatapoints)
+				for len(pending) > 0 {
+					n := size
+					if len(pending) < n {
+						n = len(pending)
+					}
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+					if err := sendFunc(pending[:n]); err != nil {
+						return err
+					}
+					pending = pending[n:]
+				}
+			}
+
 			trialIDIndex++
 			if trialIDIndex >= len(trialIDs) {
 				break
@@ -1449,6 +1556,7 @@ func checkpointV2FromProtoWithDefaults(p *checkpointv1.Checkpoint) (*model.Check
 		State:        conv.ToCheckpointState(p.State),
 		Resources:    p.Resources,
 		Metadata:     p.Metadata.AsMap(),
+		Checksums:    p.Checksums,
 	}
 	if err := conv.Error(); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "converting checkpoint: %s", err)
@@ -20,6 +20,7 @@ import (
 
 	apiPkg "github.com/determined-ai/determined/master/internal/api"
 	authz2 "github.com/determined-ai/determined/master/internal/authz"
+	"github.com/determined-ai/determined/master/pkg/downsample"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/commonv1"
@@ -260,7 +261,7 @@ func TestMultiTrialSampleMetrics(t *testing.T) {
 
 	maxDataPoints := 7
 	actualTrainingMetrics, err := api.multiTrialSample(int32(trial.ID), trainMetricNames,
-		model.TrainingMetricType, maxDataPoints, 0, 10, false, nil, []string{})
+		model.TrainingMetricType, maxDataPoints, 0, 10, false, nil, []string{}, downsample.LTTB)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(actualTrainingMetrics))
 	var validationMetricNames []string
@@ -271,14 +272,14 @@ func TestMultiTrialSampleMetrics(t *testing.T) {
 
 	actualValidationTrainingMetrics, err := api.multiTrialSample(int32(trial.ID),
 		validationMetricNames, model.ValidationMetricType, maxDataPoints,
-		0, 10, false, nil, []string{})
+		0, 10, false, nil, []string{}, downsample.LTTB)
 	require.Equal(t, 1, len(actualValidationTrainingMetrics))
 	require.NoError(t, err)
 	require.True(t, isMultiTrialSampleCorrect(expectedTrainMetrics, actualTrainingMetrics[0]))
 	require.True(t, isMultiTrialSampleCorrect(expectedValMetrics, actualValidationTrainingMetrics[0]))
 
 	actualAllMetrics, err := api.multiTrialSample(int32(trial.ID), []string{},
-		"", maxDataPoints, 0, 10, false, nil, metricIds)
+		"", maxDataPoints, 0, 10, false, nil, metricIds, downsample.LTTB)
 	require.Equal(t, 2, len(actualAllMetrics))
 	require.NoError(t, err)
 	require.Equal(t, maxDataPoints, len(actualAllMetrics[0].Data)) // max datapoints check
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/chargeback"
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// monitorChargebackBudget alerts when the cost of a month exceeds the chargeback budget.
+func (m *Master) monitorChargebackBudget(ctx context.Context) {
+	monitor, err := chargeback.NewBudgetMonitor(m.config.Chargeback, m.config.ResourcePools)
+	if err != nil {
+		log.WithError(err).Error("failed to start the chargeback budget monitor")
+		return
+	}
+	monitor.Run(ctx)
+}
+
+// chargebackReport prices the slot usage between the start_date and end_date query parameters,
+// both inclusive dates in the chargeback timezone.
+func (m *Master) chargebackReport(c echo.Context) (*chargeback.Report, error) {
+	args := struct {
+		Start string `query:"start_date"`
+		End   string `query:"end_date"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	loc, err := m.config.Chargeback.Location()
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.ParseInLocation("2006-01-02", args.Start, loc)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid start date")
+	}
+	end, err := time.ParseInLocation("2006-01-02", args.End, loc)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid end date")
+	}
+	if start.After(end) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "start date cannot be after end date")
+	}
+	end = end.AddDate(0, 0, 1)
+
+	pricer, err := chargeback.NewPricer(m.config.ResourcePools, loc)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := db.AllocationSlotUsages(c.Request().Context(), start, end)
+	if err != nil {
+		return nil, err
+	}
+	report := chargeback.NewReport(start, end, m.config.Chargeback.Currency, pricer, usages)
+	return &report, nil
+}
+
+//	@Summary	Get the cost of slot usage during the given dates, rolled up by workspace, project, user, experiment and resource pool.
+//	@Tags		Cluster
+//	@ID			get-chargeback-report
+//	@Produce	json
+//	@Param		start_date	query	string	true	"First day of the report (YYYY-MM-DD format)"
+//	@Param		end_date	query	string	true	"Last day of the report (YYYY-MM-DD format)"
+//	@Success	200			{}		string	""
+//	@Router		/resources/chargeback [get]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getChargebackReport(c echo.Context) (interface{}, error) {
+	return m.chargebackReport(c)
+}
+
+//	@Summary	Get the cost of slot usage during the given dates (CSV).
+//	@Tags		Cluster
+//	@ID			get-chargeback-report-csv
+//	@Produce	text/csv
+//	@Param		start_date	query	string	true	"First day of the report (YYYY-MM-DD format)"
+//	@Param		end_date	query	string	true	"Last day of the report (YYYY-MM-DD format)"
+//	@Success	200			{}		string	"aggregation_type,aggregation_key,slot_hours,cost"
+//	@Router		/resources/chargeback/csv [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getChargebackReportCSV(c echo.Context) error {
+	report, err := m.chargebackReport(c)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Content-Type", "text/csv")
+	csvWriter := csv.NewWriter(c.Response())
+	header := []string{"aggregation_type", "aggregation_key", "slot_hours", "cost"}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	if err := csvWriter.WriteAll(report.Rows()); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package chargeback
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/webhooks"
+)
+
+// BudgetMonitor alerts the first time the cost of a month exceeds the monthly budget.
+type BudgetMonitor struct {
+	conf   config.ChargebackConfig
+	loc    *time.Location
+	pricer *Pricer
+}
+
+// NewBudgetMonitor returns a BudgetMonitor for the budget of conf and the pricing of pools.
+func NewBudgetMonitor(
+	conf config.ChargebackConfig, pools []config.ResourcePoolConfig,
+) (*BudgetMonitor, error) {
+	loc, err := conf.Location()
+	if err != nil {
+		return nil, err
+	}
+	pricer, err := NewPricer(pools, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &BudgetMonitor{conf: conf, loc: loc, pricer: pricer}, nil
+}
+
+// Run checks the budget every interval until ctx is canceled.
+func (b *BudgetMonitor) Run(ctx context.Context) {
+	t := time.NewTicker(time.Duration(b.conf.BudgetCheckInterval))
+	defer t.Stop()
+	for {
+		if err := b.Check(ctx, time.Now()); err != nil {
+			log.WithError(err).Error("failed to check chargeback budget")
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check alerts if the cost of the month up to now exceeds the budget and was not alerted on yet.
+func (b *BudgetMonitor) Check(ctx context.Context, now time.Time) error {
+	if b.conf.MonthlyBudget == nil {
+		return nil
+	}
+	now = now.In(b.loc)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, b.loc)
+	usages, err := db.AllocationSlotUsages(ctx, month, now)
+	if err != nil {
+		return err
+	}
+	cost := NewReport(month, now, b.conf.Currency, b.pricer, usages).Cost
+	if cost <= *b.conf.MonthlyBudget {
+		return nil
+	}
+
+	record := func(ctx context.Context, idb bun.IDB) (bool, error) {
+		return db.RecordChargebackBudgetAlertTx(ctx, idb, month, cost, *b.conf.MonthlyBudget)
+	}
+	var first bool
+	if b.conf.BudgetWebhookURL == "" {
+		first, err = record(ctx, db.Bun())
+	} else {
+		first, err = webhooks.ReportBudgetExceeded(ctx, b.conf.BudgetWebhookURL,
+			webhooks.BudgetPayload{
+				Month:    month.Format("2006-01"),
+				Cost:     cost,
+				Budget:   *b.conf.MonthlyBudget,
+				Currency: b.conf.Currency,
+			}, record)
+	}
+	if err != nil || !first {
+		return err
+	}
+	log.Warnf("cost of %s is %.2f %s, exceeding the monthly budget of %.2f %s",
+		month.Format("2006-01"), cost, b.conf.Currency, *b.conf.MonthlyBudget, b.conf.Currency)
+	return nil
+}
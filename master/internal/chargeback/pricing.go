@@ -0,0 +1,105 @@
+// Package chargeback prices the slot usage of allocations and rolls it up into cost reports.
+package chargeback
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/config"
+)
+
+type priceWindow struct {
+	start, end int // Minutes after midnight.
+	price      float64
+}
+
+// contains reports whether the window covers the time of day, given in seconds after midnight.
+func (w priceWindow) contains(seconds int) bool {
+	start, end := w.start*60, w.end*60
+	if start < end {
+		return seconds >= start && seconds < end
+	}
+	return seconds >= start || seconds < end
+}
+
+type poolPrice struct {
+	base    float64
+	windows []priceWindow
+}
+
+// Pricer computes the cost of slot usage from the slot pricing of resource pools.
+type Pricer struct {
+	loc   *time.Location
+	pools map[string]poolPrice
+}
+
+// NewPricer returns a Pricer for the given pools, with time of day prices in loc.
+func NewPricer(pools []config.ResourcePoolConfig, loc *time.Location) (*Pricer, error) {
+	p := &Pricer{loc: loc, pools: map[string]poolPrice{}}
+	for _, pool := range pools {
+		if pool.SlotPricing == nil {
+			continue
+		}
+		base, err := pool.SlotPricing.BasePrice(pool.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("pricing resource pool %s: %w", pool.PoolName, err)
+		}
+		pp := poolPrice{base: base}
+		for _, w := range pool.SlotPricing.TimeOfDay {
+			start, end, err := w.Minutes()
+			if err != nil {
+				return nil, fmt.Errorf("pricing resource pool %s: %w", pool.PoolName, err)
+			}
+			pp.windows = append(pp.windows, priceWindow{start: start, end: end, price: w.PricePerSlotHour})
+		}
+		p.pools[pool.PoolName] = pp
+	}
+	return p, nil
+}
+
+// Cost returns the cost of holding slots in a pool from start to end.
+func (p *Pricer) Cost(pool string, slots int, start, end time.Time) float64 {
+	pp, ok := p.pools[pool]
+	if !ok || !end.After(start) {
+		return 0
+	}
+	if len(pp.windows) == 0 {
+		return pp.base * end.Sub(start).Hours() * float64(slots)
+	}
+
+	var cost float64
+	for t := start; t.Before(end); {
+		next, price := pp.segment(t.In(p.loc))
+		if next.After(end) {
+			next = end
+		}
+		cost += price * next.Sub(t).Hours()
+		t = next
+	}
+	return cost * float64(slots)
+}
+
+// segment returns the end of the stretch of time from t with the same price, and that price.
+// Boundaries are built from wall clock times so windows stay put across DST changes.
+func (pp poolPrice) segment(t time.Time) (time.Time, float64) {
+	y, m, d := t.Date()
+	next := time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+	for _, w := range pp.windows {
+		for _, minutes := range []int{w.start, w.end} {
+			b := time.Date(y, m, d, minutes/60, minutes%60, 0, 0, t.Location())
+			if b.After(t) && b.Before(next) {
+				next = b
+			}
+		}
+	}
+
+	price := pp.base
+	seconds := t.Hour()*3600 + t.Minute()*60 + t.Second()
+	for _, w := range pp.windows {
+		if w.contains(seconds) {
+			price = w.price
+			break
+		}
+	}
+	return next, price
+}
@@ -0,0 +1,84 @@
+package chargeback
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func requireCost(t *testing.T, expected, actual float64) {
+	assert.Assert(t, math.Abs(expected-actual) < 1e-9, "expected %f, got %f", expected, actual)
+}
+
+func TestPricerCost(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.NilError(t, err)
+	pricer, err := NewPricer([]config.ResourcePoolConfig{
+		{
+			PoolName:    "flat",
+			SlotPricing: &config.SlotPricingConfig{PricePerSlotHour: ptrs.Ptr(2.0)},
+		},
+		{
+			PoolName: "nightly",
+			SlotPricing: &config.SlotPricingConfig{
+				PricePerSlotHour: ptrs.Ptr(3.0),
+				TimeOfDay: []config.SlotPriceWindow{
+					{Start: "22:00", End: "06:00", PricePerSlotHour: 1},
+				},
+			},
+		},
+		{PoolName: "free"},
+	}, loc)
+	assert.NilError(t, err)
+
+	start := time.Date(2023, 6, 1, 20, 0, 0, 0, loc)
+	requireCost(t, 2*2*10, pricer.Cost("flat", 2, start, start.Add(10*time.Hour)))
+	requireCost(t, 0, pricer.Cost("free", 2, start, start.Add(10*time.Hour)))
+	requireCost(t, 0, pricer.Cost("missing", 2, start, start.Add(10*time.Hour)))
+
+	// 20:00 to 06:00 is 2 hours at 3 and 8 at 1; 06:00 to 08:30 is 2.5 more hours at 3.
+	requireCost(t, 2*(2*3+8*1), pricer.Cost("nightly", 2, start, start.Add(10*time.Hour)))
+	requireCost(t, 6+8+7.5, pricer.Cost("nightly", 1, start, start.Add(12*time.Hour+30*time.Minute)))
+
+	// Windows follow the wall clock across DST changes: the night of 2023-11-05 is 9 hours long.
+	dst := time.Date(2023, 11, 4, 22, 0, 0, 0, loc)
+	requireCost(t, 9, pricer.Cost("nightly", 1, dst, time.Date(2023, 11, 5, 6, 0, 0, 0, loc)))
+}
+
+func TestNewReport(t *testing.T) {
+	pricer, err := NewPricer([]config.ResourcePoolConfig{{
+		PoolName:    "gpu",
+		SlotPricing: &config.SlotPricingConfig{PricePerSlotHour: ptrs.Ptr(1.5)},
+	}}, time.UTC)
+	assert.NilError(t, err)
+
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	usages := []db.AllocationSlotUsage{
+		{
+			ResourcePool: "gpu", Slots: 4, StartTime: start, EndTime: start.Add(time.Hour),
+			Username: "alice", WorkspaceName: ptrs.Ptr("research"), ProjectName: ptrs.Ptr("nlp"),
+			ExperimentID: ptrs.Ptr(1),
+		},
+		{
+			ResourcePool: "cpu", Slots: 1, StartTime: start, EndTime: start.Add(2 * time.Hour),
+			Username: "bob",
+		},
+	}
+	report := NewReport(start, start.AddDate(0, 0, 1), "USD", pricer, usages)
+	requireCost(t, 6, report.Cost)
+	requireCost(t, 6, report.SlotHours)
+	assert.DeepEqual(t, report.ByProject, []Rollup{{Key: "research/nlp", SlotHours: 4, Cost: 6}})
+	assert.DeepEqual(t, report.ByExperiment, []Rollup{{Key: "1", SlotHours: 4, Cost: 6}})
+	assert.DeepEqual(t, report.ByUser, []Rollup{
+		{Key: "alice", SlotHours: 4, Cost: 6},
+		{Key: "bob", SlotHours: 2, Cost: 0},
+	})
+	assert.DeepEqual(t, report.Rows()[len(report.Rows())-1],
+		[]string{"total", "total", "6.000000", "6.00"})
+}
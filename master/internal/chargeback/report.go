@@ -0,0 +1,119 @@
+package chargeback
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// Rollup is the slot usage and cost attributed to one key of a report.
+type Rollup struct {
+	Key       string  `json:"key"`
+	SlotHours float64 `json:"slot_hours"`
+	Cost      float64 `json:"cost"`
+}
+
+// Report is the slot usage and cost of a period, rolled up by workspace, project, user,
+// experiment and resource pool. Only trials are attributed to workspaces, projects and
+// experiments.
+type Report struct {
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Currency       string    `json:"currency"`
+	SlotHours      float64   `json:"slot_hours"`
+	Cost           float64   `json:"cost"`
+	ByWorkspace    []Rollup  `json:"by_workspace"`
+	ByProject      []Rollup  `json:"by_project"`
+	ByUser         []Rollup  `json:"by_user"`
+	ByExperiment   []Rollup  `json:"by_experiment"`
+	ByResourcePool []Rollup  `json:"by_resource_pool"`
+}
+
+type rollups map[string]*Rollup
+
+func (rs rollups) add(key string, slotHours, cost float64) {
+	r, ok := rs[key]
+	if !ok {
+		r = &Rollup{Key: key}
+		rs[key] = r
+	}
+	r.SlotHours += slotHours
+	r.Cost += cost
+}
+
+// sorted returns the rollups from most to least expensive.
+func (rs rollups) sorted() []Rollup {
+	out := make([]Rollup, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// NewReport prices and rolls up the slot usage of a period.
+func NewReport(
+	start, end time.Time, currency string, pricer *Pricer, usages []db.AllocationSlotUsage,
+) Report {
+	workspaces, projects, users := rollups{}, rollups{}, rollups{}
+	experiments, pools := rollups{}, rollups{}
+	report := Report{Start: start, End: end, Currency: currency}
+	for _, u := range usages {
+		slotHours := u.EndTime.Sub(u.StartTime).Hours() * float64(u.Slots)
+		cost := pricer.Cost(u.ResourcePool, u.Slots, u.StartTime, u.EndTime)
+
+		report.SlotHours += slotHours
+		report.Cost += cost
+		users.add(u.Username, slotHours, cost)
+		pools.add(u.ResourcePool, slotHours, cost)
+		if u.WorkspaceName != nil {
+			workspaces.add(*u.WorkspaceName, slotHours, cost)
+		}
+		if u.WorkspaceName != nil && u.ProjectName != nil {
+			projects.add(*u.WorkspaceName+"/"+*u.ProjectName, slotHours, cost)
+		}
+		if u.ExperimentID != nil {
+			experiments.add(strconv.Itoa(*u.ExperimentID), slotHours, cost)
+		}
+	}
+	report.ByWorkspace = workspaces.sorted()
+	report.ByProject = projects.sorted()
+	report.ByUser = users.sorted()
+	report.ByExperiment = experiments.sorted()
+	report.ByResourcePool = pools.sorted()
+	return report
+}
+
+// Rows returns the rollups of the report as aggregation_type, aggregation_key, slot_hours and
+// cost columns, with the totals last.
+func (r Report) Rows() [][]string {
+	var rows [][]string
+	for _, agg := range []struct {
+		name    string
+		rollups []Rollup
+	}{
+		{"workspace", r.ByWorkspace},
+		{"project", r.ByProject},
+		{"username", r.ByUser},
+		{"experiment", r.ByExperiment},
+		{"resource_pool", r.ByResourcePool},
+		{"total", []Rollup{{Key: "total", SlotHours: r.SlotHours, Cost: r.Cost}}},
+	} {
+		for _, rollup := range agg.rollups {
+			rows = append(rows, []string{
+				agg.name,
+				rollup.Key,
+				strconv.FormatFloat(rollup.SlotHours, 'f', 6, 64),
+				strconv.FormatFloat(rollup.Cost, 'f', 2, 64),
+			})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,373 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/pkg/checkpoints"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// parseCopyStorage parses a checkpoint storage config that checkpoints can be copied from or to,
+// filling in defaults.
+func parseCopyStorage(raw json.RawMessage) (*expconf.CheckpointStorageConfig, error) {
+	var sc expconf.CheckpointStorageConfig
+	if err := sc.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	sc = schemas.WithDefaults(sc)
+	if err := schemas.IsComplete(sc); err != nil {
+		return nil, err
+	}
+	switch sc.GetUnionMember().(type) {
+	case expconf.S3Config, expconf.GCSConfig, expconf.SharedFSConfig:
+		return &sc, nil
+	default:
+		return nil, fmt.Errorf("only s3, gcs and shared_fs checkpoint storage can be copied")
+	}
+}
+
+// printableCopyStorage hides the secrets of a stored checkpoint storage config.
+func printableCopyStorage(raw json.RawMessage) json.RawMessage {
+	if raw == nil {
+		return nil
+	}
+	var sc expconf.CheckpointStorageConfig
+	if err := sc.UnmarshalJSON(raw); err != nil {
+		return nil
+	}
+	printable, err := sc.Printable().MarshalJSON()
+	if err != nil {
+		return nil
+	}
+	return printable
+}
+
+// runCheckpointCopyJobs restarts the checkpoint copy jobs that a previous master left unfinished
+// and then runs those requested through the API, until ctx is canceled.
+func (m *Master) runCheckpointCopyJobs(ctx context.Context) {
+	jobs, err := db.RunningCheckpointCopyJobs(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to get checkpoint copy jobs to resume")
+	}
+	go func() {
+		for i := range jobs {
+			m.runCheckpointCopyJob(ctx, &jobs[i])
+		}
+	}()
+
+	for {
+		select {
+		case job := <-m.checkpointCopyJobs:
+			go m.runCheckpointCopyJob(ctx, job)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runCheckpointCopyJob copies every pending checkpoint of a job and then marks the job finished.
+// If ctx is canceled, the job is left running to be resumed by the next master.
+func (m *Master) runCheckpointCopyJob(ctx context.Context, job *model.CheckpointCopyJob) {
+	logCtx := log.WithField("checkpoint-copy-job", job.ID)
+	logCtx.Info("copying checkpoints")
+
+	var msg *string
+	if err := m.copyCheckpoints(ctx, job, logCtx); ctx.Err() != nil {
+		return
+	} else if err != nil {
+		logCtx.WithError(err).Error("checkpoint copy job failed")
+		msg = ptrs.Ptr(err.Error())
+	}
+	if err := db.FinishCheckpointCopyJob(ctx, job.ID, msg); err != nil {
+		logCtx.WithError(err).Error("failed to finish checkpoint copy job")
+		return
+	}
+	logCtx.Info("finished copying checkpoints")
+}
+
+func (m *Master) copyCheckpoints(
+	ctx context.Context, job *model.CheckpointCopyJob, logCtx *log.Entry,
+) error {
+	target, err := parseCopyStorage(job.Target)
+	if err != nil {
+		return errors.Wrap(err, "invalid target storage")
+	}
+	var source *expconf.CheckpointStorageConfig
+	if job.Source != nil {
+		if source, err = parseCopyStorage(job.Source); err != nil {
+			return errors.Wrap(err, "invalid source storage")
+		}
+	}
+
+	pending, err := db.CheckpointCopies(ctx, job.ID, ptrs.Ptr(model.CheckpointCopyPending))
+	if err != nil {
+		return err
+	}
+	for _, cc := range pending {
+		err := m.copyCheckpoint(ctx, job, source, target, cc.CheckpointUUID)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			logCtx.WithError(err).Warnf("failed to copy checkpoint %s", cc.CheckpointUUID)
+			if err := db.FailCheckpointCopy(ctx, job.ID, cc.CheckpointUUID, err.Error()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyCheckpoint copies a single checkpoint, reading it from source or, if that is nil, from the
+// storage the checkpoint currently references.
+func (m *Master) copyCheckpoint(
+	ctx context.Context,
+	job *model.CheckpointCopyJob,
+	source *expconf.CheckpointStorageConfig,
+	target *expconf.CheckpointStorageConfig,
+	id uuid.UUID,
+) error {
+	ckpt, err := db.CheckpointV2ByUUID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		if source, err = m.getCheckpointStorageConfig(id); err != nil {
+			return err
+		} else if source == nil {
+			return fmt.Errorf("no checkpoint storage config found")
+		}
+	}
+
+	size, err := checkpoints.Copy(ctx, id.String(), source, target, ckpt.Resources)
+	if err != nil {
+		return err
+	}
+	return db.CompleteCheckpointCopy(ctx, job, id, size)
+}
+
+// checkpointsToCopy resolves the checkpoints selected by a copy request.
+func (m *Master) checkpointsToCopy(
+	ctx context.Context,
+	experimentID *int,
+	modelName *string,
+	modelVersion *int32,
+	uuids []uuid.UUID,
+) ([]uuid.UUID, error) {
+	selected := append([]uuid.UUID{}, uuids...)
+	if experimentID != nil {
+		expUUIDs, err := db.CompletedCheckpointsOfExperiment(ctx, *experimentID)
+		if err != nil {
+			return nil, err
+		}
+		if len(expUUIDs) == 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("experiment %d has no completed checkpoints", *experimentID))
+		}
+		selected = append(selected, expUUIDs...)
+	}
+	if modelName != nil {
+		if modelVersion == nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"model_version is required with model_name")
+		}
+		mv, err := (&apiServer{m: m}).ModelVersionFromID(*modelName, *modelVersion)
+		if err != nil {
+			return nil, modelRegistryHTTPError(err)
+		}
+		if mv.Checkpoint == nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("model version %d of %s has no checkpoint", *modelVersion, *modelName))
+		}
+		id, err := uuid.Parse(mv.Checkpoint.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, id)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	deduped := make([]uuid.UUID, 0, len(selected))
+	for _, id := range selected {
+		if !seen[id] {
+			seen[id] = true
+			deduped = append(deduped, id)
+		}
+	}
+	return deduped, nil
+}
+
+// canDoActionOnCheckpoints returns an HTTP error unless the user may take the action on the
+// experiments of every checkpoint.
+func (m *Master) canDoActionOnCheckpoints(
+	ctx context.Context,
+	curUser model.User,
+	uuids []uuid.UUID,
+	action func(context.Context, model.User, *model.Experiment) error,
+) error {
+	for _, id := range uuids {
+		if err := m.canDoActionOnCheckpoint(ctx, curUser, id.String(), action); err != nil {
+			return checkpointAccessHTTPError(err)
+		}
+	}
+	return nil
+}
+
+func checkpointCopyJobResponse(
+	job *model.CheckpointCopyJob, copies []model.CheckpointCopy,
+) map[string]interface{} {
+	job.Source = printableCopyStorage(job.Source)
+	job.Target = printableCopyStorage(job.Target)
+	resp := map[string]interface{}{"job": job}
+	if copies != nil {
+		resp["checkpoints"] = copies
+	}
+	return resp
+}
+
+//	@Summary	Start copying checkpoints to another checkpoint storage.
+//	@Tags		Checkpoints
+//	@ID			post-checkpoint-copy
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{}	string	""
+//	@Router		/checkpoints/copies [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postCheckpointCopy(c echo.Context) (interface{}, error) {
+	curUser := c.(*detContext.DetContext).MustGetUser()
+
+	var body struct {
+		Source          json.RawMessage `json:"source"`
+		Target          json.RawMessage `json:"target"`
+		ExperimentID    *int            `json:"experiment_id"`
+		ModelName       *string         `json:"model_name"`
+		ModelVersion    *int32          `json:"model_version"`
+		CheckpointUUIDs []uuid.UUID     `json:"checkpoint_uuids"`
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+	job := model.CheckpointCopyJob{RequestedBy: curUser.ID}
+	for _, s := range []struct {
+		name     string
+		raw      json.RawMessage
+		required bool
+		dst      *json.RawMessage
+	}{
+		{"source", body.Source, false, &job.Source},
+		{"target", body.Target, true, &job.Target},
+	} {
+		if len(s.raw) == 0 || string(s.raw) == "null" {
+			if s.required {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, s.name+" is required")
+			}
+			continue
+		}
+		sc, err := parseCopyStorage(s.raw)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("invalid %s storage: %s", s.name, err))
+		}
+		if *s.dst, err = sc.MarshalJSON(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := c.Request().Context()
+	if body.ExperimentID != nil {
+		if _, _, err := echoGetExperimentAndCheckCanDoActions(
+			ctx, c, m, *body.ExperimentID,
+		); err != nil {
+			return nil, err
+		}
+	}
+	uuids, err := m.checkpointsToCopy(
+		ctx, body.ExperimentID, body.ModelName, body.ModelVersion, body.CheckpointUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	// Copying a checkpoint changes the storage its experiment references.
+	if err := m.canDoActionOnCheckpoints(
+		ctx, curUser, uuids, expauth.AuthZProvider.Get().CanEditExperiment,
+	); err != nil {
+		return nil, err
+	}
+	if err := db.CreateCheckpointCopyJob(ctx, &job, uuids); errors.Is(err, db.ErrInvalidInput) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+
+	resp := job
+	// If the master is shutting down, the job is resumed by the next master.
+	select {
+	case m.checkpointCopyJobs <- &job:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return checkpointCopyJobResponse(&resp, nil), nil
+}
+
+//	@Summary	Get the progress of copying checkpoints to another checkpoint storage.
+//	@Tags		Checkpoints
+//	@ID			get-checkpoint-copy
+//	@Produce	json
+//	@Param		job_id	path	int	true	"Checkpoint copy job ID"
+//	@Success	200		{}		string	""
+//	@Router		/checkpoints/copies/{job_id} [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getCheckpointCopy(c echo.Context) (interface{}, error) {
+	args := struct {
+		JobID int `path:"job_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	curUser := c.(*detContext.DetContext).MustGetUser()
+
+	ctx := c.Request().Context()
+	notFound := echo.NewHTTPError(http.StatusNotFound,
+		fmt.Sprintf("checkpoint copy job %d not found", args.JobID))
+	job, err := db.CheckpointCopyJobByID(ctx, args.JobID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, notFound
+	} else if err != nil {
+		return nil, err
+	}
+	copies, err := db.CheckpointCopies(ctx, job.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	uuids := make([]uuid.UUID, 0, len(copies))
+	for _, cc := range copies {
+		uuids = append(uuids, cc.CheckpointUUID)
+	}
+	// Users who cannot view every checkpoint of the job cannot tell that the job exists.
+	if err := m.canDoActionOnCheckpoints(
+		ctx, curUser, uuids, expauth.AuthZProvider.Get().CanGetExperimentArtifacts,
+	); err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return nil, notFound
+		}
+		return nil, err
+	}
+	return checkpointCopyJobResponse(job, copies), nil
+}
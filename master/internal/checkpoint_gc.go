@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -33,7 +34,13 @@ type checkpointGCTask struct {
 	jobID             model.JobID
 	jobSubmissionTime time.Time
 
-	allocation *actor.Ref
+	toDelete []uuid.UUID
+	// storageSpecs split the GC by the storage the checkpoints are kept in. Each runs in its own
+	// allocation, one after another.
+	storageSpecs []tasks.GCCkptSpec
+	resourcePool string
+	allocations  int
+	allocation   *actor.Ref
 
 	logCtx logger.Context
 }
@@ -65,8 +72,9 @@ func newCheckpointGCTask(
 			CheckpointGlobs:    checkpointGlobs,
 			DeleteTensorboards: deleteTensorboards,
 		},
-		db: db,
-		rm: rm,
+		toDelete: toDeleteCheckpoints,
+		db:       db,
+		rm:       rm,
 
 		logCtx: logCtx,
 	}
@@ -96,27 +104,11 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 			return errors.Wrapf(err, "persisting GC task %s", t.taskID)
 		}
 
-		t.allocationID = model.AllocationID(fmt.Sprintf("%s.%d", t.taskID, 1))
-
 		rp, err := t.rm.ResolveResourcePool(ctx, "", 0)
 		if err != nil {
 			return fmt.Errorf("resolving resource pool: %w", err)
 		}
-
-		allocation := task.NewAllocation(t.logCtx, sproto.AllocateRequest{
-			TaskID:            t.taskID,
-			JobID:             t.jobID,
-			JobSubmissionTime: t.jobSubmissionTime,
-			AllocationID:      t.allocationID,
-			Name:              fmt.Sprintf("Checkpoint GC (Experiment %d)", t.ExperimentID),
-			FittingRequirements: sproto.FittingRequirements{
-				SingleAgent: true,
-			},
-			AllocationRef: ctx.Self(),
-			ResourcePool:  rp,
-		}, t.db, t.rm, t.GCCkptSpec)
-
-		t.allocation, _ = ctx.ActorOf(t.allocationID, allocation)
+		t.resourcePool = rp
 
 		// t.Base is just a shallow copy of the m.taskSpec on the master, so
 		// use caution when mutating it.
@@ -127,6 +119,15 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 		if err != nil {
 			return fmt.Errorf("creating task container defaults: %v", err)
 		}
+
+		if t.storageSpecs, err = t.specsByStorage(context.TODO()); err != nil {
+			return err
+		}
+		if len(t.storageSpecs) == 0 {
+			t.completeTask(ctx)
+			return nil
+		}
+		t.startNextAllocation(ctx)
 	case *task.AllocationExited:
 		if msg.Err != nil {
 			ctx.Log().WithError(msg.Err).Error("wasn't able to delete checkpoints from checkpoint storage")
@@ -134,6 +135,10 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 			return errors.Wrapf(msg.Err, "checkpoint GC task failed because allocation failed")
 		}
 
+		if len(t.storageSpecs) > 0 {
+			t.startNextAllocation(ctx)
+			return nil
+		}
 		t.completeTask(ctx)
 	case actor.ChildStopped:
 	case actor.ChildFailed:
@@ -149,6 +154,71 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 	return nil
 }
 
+// specsByStorage splits the GC of the checkpoints by the storage they are kept in: checkpoints that
+// were copied to other storage are deleted from there rather than from the experiment's storage.
+// Tensorboards are only ever kept in the experiment's storage.
+func (t *checkpointGCTask) specsByStorage(ctx context.Context) ([]tasks.GCCkptSpec, error) {
+	copied, err := db.CopiedCheckpointStorages(ctx, t.toDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	var inExperiment []string
+	var storages []string
+	byStorage := map[string][]string{}
+	for _, id := range t.toDelete {
+		raw, ok := copied[id]
+		if !ok {
+			inExperiment = append(inExperiment, id.String())
+			continue
+		}
+		if _, ok := byStorage[string(raw)]; !ok {
+			storages = append(storages, string(raw))
+		}
+		byStorage[string(raw)] = append(byStorage[string(raw)], id.String())
+	}
+
+	var specs []tasks.GCCkptSpec
+	if len(inExperiment) > 0 || t.DeleteTensorboards {
+		spec := t.GCCkptSpec
+		spec.ToDelete = strings.Join(inExperiment, ",")
+		specs = append(specs, spec)
+	}
+	for _, raw := range storages {
+		var storage expconf.CheckpointStorageConfig
+		if err := storage.UnmarshalJSON([]byte(raw)); err != nil {
+			return nil, errors.Wrap(err, "parsing storage of copied checkpoints")
+		}
+		spec := t.GCCkptSpec
+		spec.LegacyConfig.CheckpointStorage = storage
+		spec.ToDelete = strings.Join(byStorage[raw], ",")
+		spec.DeleteTensorboards = false
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// startNextAllocation runs the GC of the next storage in its own allocation.
+func (t *checkpointGCTask) startNextAllocation(ctx *actor.Context) {
+	spec := t.storageSpecs[0]
+	t.storageSpecs = t.storageSpecs[1:]
+	t.allocations++
+	t.allocationID = model.AllocationID(fmt.Sprintf("%s.%d", t.taskID, t.allocations))
+	allocation := task.NewAllocation(t.logCtx, sproto.AllocateRequest{
+		TaskID:            t.taskID,
+		JobID:             t.jobID,
+		JobSubmissionTime: t.jobSubmissionTime,
+		AllocationID:      t.allocationID,
+		Name:              fmt.Sprintf("Checkpoint GC (Experiment %d)", t.ExperimentID),
+		FittingRequirements: sproto.FittingRequirements{
+			SingleAgent: true,
+		},
+		AllocationRef: ctx.Self(),
+		ResourcePool:  t.resourcePool,
+	}, t.db, t.rm, spec)
+	t.allocation, _ = ctx.ActorOf(t.allocationID, allocation)
+}
+
 func (t *checkpointGCTask) completeTask(ctx *actor.Context) {
 	if err := t.db.CompleteTask(t.taskID, time.Now().UTC()); err != nil {
 		ctx.Log().WithError(err).Error("marking GC task complete")
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/projectv1"
+)
+
+const checkpointStorageReconcileInterval = 10 * time.Minute
+
+// checkpointStoragePeriods are the accepted aggregation periods for usage history.
+var checkpointStoragePeriods = map[string]bool{
+	"hour": true, "day": true, "week": true, "month": true,
+}
+
+// reconcileCheckpointStorageUsage periodically recomputes checkpoint storage usage from scratch,
+// catching changes like experiment deletions and moves that incremental updates miss, and
+// refreshes the Prometheus gauges.
+func (m *Master) reconcileCheckpointStorageUsage(ctx context.Context) {
+	t := time.NewTicker(checkpointStorageReconcileInterval)
+	defer t.Stop()
+	for {
+		if err := db.RecomputeCheckpointStorageUsage(ctx); err != nil {
+			log.WithError(err).Error("failed to reconcile checkpoint storage usage")
+		} else if m.config.Observability.EnablePrometheus {
+			if err := exportCheckpointStorageUsage(ctx); err != nil {
+				log.WithError(err).Error("failed to export checkpoint storage usage")
+			}
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func exportCheckpointStorageUsage(ctx context.Context) error {
+	// Per-experiment usage is left out to keep the cardinality of the gauges bounded.
+	var usages []model.CheckpointStorageUsage
+	for _, scope := range []model.CheckpointStorageScope{
+		model.CheckpointStorageScopeProject,
+		model.CheckpointStorageScopeWorkspace,
+		model.CheckpointStorageScopeUser,
+	} {
+		scoped, err := db.CheckpointStorageUsageByScope(ctx, scope)
+		if err != nil {
+			return err
+		}
+		usages = append(usages, scoped...)
+	}
+	prom.SetCheckpointStorageUsage(usages)
+	return nil
+}
+
+// checkCheckpointStorageQuota returns an error if the workspace the project belongs to holds more
+// checkpoint storage than its configured quota allows.
+func (m *Master) checkCheckpointStorageQuota(ctx context.Context, p *projectv1.Project) error {
+	limit := m.config.CheckpointStorageQuota.WorkspaceLimit(p.WorkspaceName)
+	if limit == nil {
+		return nil
+	}
+	usage, err := db.CheckpointStorageUsageOf(
+		ctx, model.CheckpointStorageScopeWorkspace, int(p.WorkspaceId))
+	if err != nil {
+		return err
+	}
+	if usage.Size > *limit {
+		return status.Errorf(codes.ResourceExhausted,
+			"workspace %q uses %d bytes of checkpoint storage, exceeding its quota of %d bytes",
+			p.WorkspaceName, usage.Size, *limit)
+	}
+	return nil
+}
+
+//	@Summary	Get the current checkpoint storage usage of every entity of a scope.
+//	@Tags		Checkpoints
+//	@ID			get-checkpoint-storage-usage
+//	@Produce	json
+//	@Param		scope	query	string	true	"One of experiment, project, workspace or user"
+//	@Success	200		{}		string	""
+//	@Router		/resources/checkpoint-storage [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getCheckpointStorageUsage(c echo.Context) (interface{}, error) {
+	args := struct {
+		Scope string `query:"scope"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	scope, err := model.ParseCheckpointStorageScope(args.Scope)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return db.CheckpointStorageUsageByScope(c.Request().Context(), scope)
+}
+
+//	@Summary	Get the checkpoint storage usage of an entity over time.
+//	@Tags		Checkpoints
+//	@ID			get-checkpoint-storage-usage-history
+//	@Produce	json
+//	@Param		scope		path	string	true	"One of experiment, project, workspace or user"
+//	@Param		id			path	int		true	"ID of the experiment, project, workspace or user"
+//	@Param		start_date	query	string	false	"RFC 3339 start time, defaults to 30 days ago"
+//	@Param		end_date	query	string	false	"RFC 3339 end time, defaults to now"
+//	@Param		period		query	string	false	"One of hour, day, week or month"
+//	@Success	200			{}		string	""
+//	@Router		/resources/checkpoint-storage/{scope}/{id} [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getCheckpointStorageUsageHistory(c echo.Context) (interface{}, error) {
+	args := struct {
+		Scope  string `path:"scope"`
+		ID     int    `path:"id"`
+		Start  string `query:"start_date"`
+		End    string `query:"end_date"`
+		Period string `query:"period"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	scope, err := model.ParseCheckpointStorageScope(args.Scope)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	end := time.Now().UTC()
+	if args.End != "" {
+		if end, err = time.Parse(time.RFC3339, args.End); err != nil {
+			return nil, errors.Wrap(err, "invalid end time")
+		}
+	}
+	start := end.AddDate(0, 0, -30)
+	if args.Start != "" {
+		if start, err = time.Parse(time.RFC3339, args.Start); err != nil {
+			return nil, errors.Wrap(err, "invalid start time")
+		}
+	}
+	if start.After(end) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "start time cannot be after end time")
+	}
+	if args.Period == "" {
+		args.Period = "day"
+	}
+	if !checkpointStoragePeriods[args.Period] {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid period %q, must be one of hour, day, week or month", args.Period))
+	}
+
+	ctx := c.Request().Context()
+	current, err := db.CheckpointStorageUsageOf(ctx, scope, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := db.CheckpointStorageUsageHistory(ctx, scope, args.ID, start, end, args.Period)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"current": current,
+		"history": history,
+	}, nil
+}
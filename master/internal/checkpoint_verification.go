@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/pkg/checkpoints"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+// verifyCheckpoints periodically reads batches of unverified checkpoints back from storage and
+// marks them VERIFIED or CORRUPTED.
+func (m *Master) verifyCheckpoints(ctx context.Context) {
+	conf := m.config.CheckpointVerification
+	t := time.NewTicker(time.Duration(conf.Interval))
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		batch, err := db.CheckpointsToVerify(ctx, conf.BatchSize)
+		if err != nil {
+			log.WithError(err).Error("failed to get checkpoints to verify")
+			continue
+		}
+		for i := range batch {
+			if _, err := m.verifyCheckpoint(ctx, &batch[i]); err != nil {
+				log.WithError(err).Warnf("failed to verify checkpoint %s", batch[i].UUID)
+			}
+		}
+	}
+}
+
+// verifyCheckpoint reads a checkpoint back from storage and records whether it is intact. If the
+// checkpoint cannot be read at all, it stays unverified and the error is recorded.
+func (m *Master) verifyCheckpoint(
+	ctx context.Context, ckpt *model.CheckpointV2,
+) (*model.CheckpointIntegrityStatus, error) {
+	storageConfig, err := m.getCheckpointStorageConfig(ckpt.UUID)
+	if err == nil && storageConfig == nil {
+		err = fmt.Errorf("no checkpoint storage config found")
+	}
+
+	var problems []string
+	if err == nil {
+		problems, err = checkpoints.Verify(
+			ctx, ckpt.UUID.String(), storageConfig, ckpt.Resources, ckpt.Checksums)
+	}
+
+	integrity, problem := model.CheckpointVerified, (*string)(nil)
+	switch {
+	case err != nil:
+		integrity, problem = model.CheckpointUnverified, ptrs.Ptr(err.Error())
+	case len(problems) > 0:
+		integrity, problem = model.CheckpointCorrupted, ptrs.Ptr(strings.Join(problems, "; "))
+		log.Warnf("checkpoint %s is corrupted: %s", ckpt.UUID, *problem)
+	}
+	if sErr := db.SetCheckpointIntegrity(ctx, ckpt.UUID, integrity, problem); sErr != nil {
+		return nil, sErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db.CheckpointIntegrityByUUID(ctx, ckpt.UUID)
+}
+
+// checkpointUUIDWithAccess parses the checkpoint UUID of the request and checks that the user may
+// take the given action on the checkpoint's experiment.
+func (m *Master) checkpointUUIDWithAccess(
+	c echo.Context, action func(context.Context, model.User, *model.Experiment) error,
+) (uuid.UUID, error) {
+	args := struct {
+		CheckpointUUID string `path:"checkpoint_uuid"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest,
+			"invalid checkpoint_uuid: "+err.Error())
+	}
+	id, err := uuid.Parse(args.CheckpointUUID)
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("unable to parse checkpoint UUID %s: %s", args.CheckpointUUID, err))
+	}
+
+	curUser := c.(*detContext.DetContext).MustGetUser()
+	err = m.canDoActionOnCheckpoint(c.Request().Context(), curUser, args.CheckpointUUID, action)
+	if err != nil {
+		return uuid.Nil, checkpointAccessHTTPError(err)
+	}
+	return id, nil
+}
+
+// checkpointAccessHTTPError converts the gRPC errors of canDoActionOnCheckpoint to HTTP errors.
+func checkpointAccessHTTPError(err error) error {
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.NotFound:
+			return echo.NewHTTPError(http.StatusNotFound, s.Message())
+		case codes.PermissionDenied:
+			return echo.NewHTTPError(http.StatusForbidden, s.Message())
+		}
+	}
+	return err
+}
+
+//	@Summary	Get the recorded integrity of a checkpoint.
+//	@Tags		Checkpoints
+//	@ID			get-checkpoint-integrity
+//	@Produce	json
+//	@Param		checkpoint_uuid	path	string	true	"Checkpoint UUID"
+//	@Success	200				{}		string	""
+//	@Router		/checkpoints/{checkpoint_uuid}/integrity [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getCheckpointIntegrity(c echo.Context) (interface{}, error) {
+	id, err := m.checkpointUUIDWithAccess(c, expauth.AuthZProvider.Get().CanGetExperimentArtifacts)
+	if err != nil {
+		return nil, err
+	}
+	return db.CheckpointIntegrityByUUID(c.Request().Context(), id)
+}
+
+//	@Summary	Read a checkpoint back from storage and record whether it is intact.
+//	@Tags		Checkpoints
+//	@ID			verify-checkpoint
+//	@Produce	json
+//	@Param		checkpoint_uuid	path	string	true	"Checkpoint UUID"
+//	@Success	200				{}		string	""
+//	@Router		/checkpoints/{checkpoint_uuid}/verify [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postVerifyCheckpoint(c echo.Context) (interface{}, error) {
+	// Verifying records the checkpoint's integrity, so it takes permission to edit the experiment.
+	id, err := m.checkpointUUIDWithAccess(c, expauth.AuthZProvider.Get().CanEditExperiment)
+	if err != nil {
+		return nil, err
+	}
+	ckpt, err := db.CheckpointV2ByUUID(c.Request().Context(), id)
+	if err == db.ErrNotFound {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("checkpoint %s predates integrity verification", id))
+	} else if err != nil {
+		return nil, err
+	}
+	result, err := m.verifyCheckpoint(c.Request().Context(), ckpt)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError,
+			fmt.Sprintf("unable to verify checkpoint %s: %s", id, err))
+	}
+	return result, nil
+}
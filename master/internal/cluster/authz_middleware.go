@@ -25,3 +25,39 @@ func CanGetUsageDetails() echo.MiddlewareFunc {
 		}
 	})
 }
+
+// CanGetSensitiveAgentInfo returns an echo middleware that checks if the user has permission to
+// view sensitive agent info.
+func CanGetSensitiveAgentInfo() echo.MiddlewareFunc {
+	return echo.MiddlewareFunc(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			user := c.(*detContext.DetContext).MustGetUser()
+			permErr, err := AuthZProvider.Get().CanGetSensitiveAgentInfo(c.Request().Context(), &user)
+			if err != nil {
+				return err
+			}
+			if permErr != nil {
+				return echo.NewHTTPError(http.StatusForbidden, permErr.Error())
+			}
+			return next(c)
+		}
+	})
+}
+
+// CanUpdateAgents returns an echo middleware that checks if the user has permission to update
+// agents.
+func CanUpdateAgents() echo.MiddlewareFunc {
+	return echo.MiddlewareFunc(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			user := c.(*detContext.DetContext).MustGetUser()
+			permErr, err := AuthZProvider.Get().CanUpdateAgents(c.Request().Context(), &user)
+			if err != nil {
+				return err
+			}
+			if permErr != nil {
+				return echo.NewHTTPError(http.StatusForbidden, permErr.Error())
+			}
+			return next(c)
+		}
+	})
+}
@@ -268,6 +268,20 @@ func (c *command) Receive(ctx *actor.Context) error {
 				Debug:           c.Config.Debug,
 			}
 		}
+		if gpuIdle := config.GetMasterConfig().GPUIdle; gpuIdle.Enabled &&
+			c.Config.Resources.Slots > 0 {
+			if idleWatcherConfig == nil {
+				idleWatcherConfig = &sproto.IdleTimeoutConfig{
+					ServiceID: string(c.taskID),
+					Debug:     c.Config.Debug,
+				}
+			}
+			idleWatcherConfig.GPUIdle = &sproto.GPUIdleTimeoutConfig{
+				UtilizationThreshold: gpuIdle.UtilizationThreshold,
+				TimeoutDuration:      time.Duration(gpuIdle.Timeout),
+				GracePeriod:          time.Duration(gpuIdle.GracePeriod),
+			}
+		}
 
 		allocation := task.NewAllocation(c.logCtx, sproto.AllocateRequest{
 			AllocationID:      c.allocationID,
@@ -284,6 +298,7 @@ func (c *command) Receive(ctx *actor.Context) error {
 			FittingRequirements: sproto.FittingRequirements{
 				SingleAgent: true,
 			},
+			Images: sproto.NewImages(c.Config.Environment.Image),
 
 			ProxyPorts:  sproto.NewProxyPortConfig(c.GenericCommandSpec.ProxyPorts(), c.taskID),
 			IdleTimeout: idleWatcherConfig,
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ChargebackConfig configures the cost reports computed from the slot pricing of resource pools.
+type ChargebackConfig struct {
+	// Currency labels the costs in reports; it is not used for any conversion.
+	Currency string `json:"currency"`
+	// Timezone is the IANA timezone that report dates, months and time of day prices use.
+	Timezone string `json:"timezone"`
+	// MonthlyBudget triggers an alert the first time the cost of a month exceeds it.
+	MonthlyBudget *float64 `json:"monthly_budget"`
+	// BudgetWebhookURL receives the alert when the monthly budget is exceeded.
+	BudgetWebhookURL    string         `json:"budget_webhook_url"`
+	BudgetCheckInterval model.Duration `json:"budget_check_interval"`
+}
+
+// DefaultChargebackConfig returns the default chargeback config.
+func DefaultChargebackConfig() ChargebackConfig {
+	return ChargebackConfig{
+		Currency:            "USD",
+		Timezone:            "UTC",
+		BudgetCheckInterval: model.Duration(time.Hour),
+	}
+}
+
+// Location returns the timezone of the chargeback reports.
+func (c ChargebackConfig) Location() (*time.Location, error) {
+	return time.LoadLocation(c.Timezone)
+}
+
+// Validate implements the check.Validatable interface.
+func (c ChargebackConfig) Validate() []error {
+	var errs []error
+	if _, err := c.Location(); err != nil {
+		errs = append(errs, fmt.Errorf("chargeback.timezone is invalid: %w", err))
+	}
+	if c.MonthlyBudget != nil && *c.MonthlyBudget <= 0 {
+		errs = append(errs, fmt.Errorf("chargeback.monthly_budget must be positive"))
+	}
+	if c.BudgetCheckInterval <= 0 {
+		errs = append(errs, fmt.Errorf("chargeback.budget_check_interval must be positive"))
+	}
+	return errs
+}
+
+// SlotPricingConfig is the price of a slot-hour in a resource pool.
+type SlotPricingConfig struct {
+	// PricePerSlotHour is the price of a slot-hour outside of any time of day window.
+	PricePerSlotHour *float64 `json:"price_per_slot_hour"`
+	// UseSpotMaxPrice prices slot-hours at the spot_max_price of the AWS provider of the pool
+	// divided by the slots of an instance, in place of PricePerSlotHour.
+	UseSpotMaxPrice bool `json:"use_spot_max_price"`
+	// TimeOfDay overrides the price during windows of the day. The first window a time falls in
+	// applies.
+	TimeOfDay []SlotPriceWindow `json:"time_of_day"`
+}
+
+// SlotPriceWindow is the price of a slot-hour during a window of the day, given as HH:MM in the
+// chargeback timezone. A window that ends before it starts wraps past midnight.
+type SlotPriceWindow struct {
+	Start            string  `json:"start"`
+	End              string  `json:"end"`
+	PricePerSlotHour float64 `json:"price_per_slot_hour"`
+}
+
+// Minutes returns the minutes after midnight the window starts and ends at.
+func (w SlotPriceWindow) Minutes() (start, end int, err error) {
+	if start, err = parseTimeOfDay(w.Start); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimeOfDay(w.End); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func parseTimeOfDay(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("time of day %q must be formatted as HH:MM", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("time of day %q has an invalid hour", s)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("time of day %q has an invalid minute", s)
+	}
+	return h*60 + m, nil
+}
+
+// BasePrice returns the price of a slot-hour outside of any time of day window, given the
+// provider of the pool.
+func (p SlotPricingConfig) BasePrice(provider *provconfig.Config) (float64, error) {
+	if !p.UseSpotMaxPrice {
+		if p.PricePerSlotHour == nil {
+			return 0, nil
+		}
+		return *p.PricePerSlotHour, nil
+	}
+	if provider == nil || provider.AWS == nil || !provider.AWS.SpotEnabled ||
+		provider.AWS.SpotMaxPrice == provconfig.SpotPriceNotSetPlaceholder {
+		return 0, fmt.Errorf("use_spot_max_price requires an AWS provider with spot_max_price set")
+	}
+	price, err := strconv.ParseFloat(provider.AWS.SpotMaxPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing spot_max_price: %w", err)
+	}
+	slots := provider.AWS.SlotsPerInstance()
+	if slots == 0 {
+		return 0, fmt.Errorf("use_spot_max_price requires instances with slots")
+	}
+	return price / float64(slots), nil
+}
+
+// Validate implements the check.Validatable interface.
+func (p SlotPricingConfig) Validate() []error {
+	var errs []error
+	if p.PricePerSlotHour != nil && *p.PricePerSlotHour < 0 {
+		errs = append(errs, fmt.Errorf("slot_pricing.price_per_slot_hour must be non-negative"))
+	}
+	if p.UseSpotMaxPrice && p.PricePerSlotHour != nil {
+		errs = append(errs, fmt.Errorf(
+			"slot_pricing.price_per_slot_hour and use_spot_max_price are mutually exclusive"))
+	}
+	for i, w := range p.TimeOfDay {
+		start, end, err := w.Minutes()
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("slot_pricing.time_of_day[%d]: %w", i, err))
+		case start == end:
+			errs = append(errs, fmt.Errorf("slot_pricing.time_of_day[%d] is empty", i))
+		}
+		if w.PricePerSlotHour < 0 {
+			errs = append(errs, fmt.Errorf(
+				"slot_pricing.time_of_day[%d].price_per_slot_hour must be non-negative", i))
+		}
+	}
+	return errs
+}
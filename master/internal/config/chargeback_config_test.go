@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+)
+
+func TestSlotPricing(t *testing.T) {
+	raw := `
+price_per_slot_hour: 2.5
+time_of_day:
+  - start: "22:00"
+    end: "06:00"
+    price_per_slot_hour: 1
+`
+	var p SlotPricingConfig
+	assert.NilError(t, yaml.Unmarshal([]byte(raw), &p))
+	assert.Equal(t, len(p.Validate()), 0)
+	start, end, err := p.TimeOfDay[0].Minutes()
+	assert.NilError(t, err)
+	assert.Equal(t, start, 22*60)
+	assert.Equal(t, end, 6*60)
+	price, err := p.BasePrice(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, price, 2.5)
+
+	p.TimeOfDay = append(p.TimeOfDay, SlotPriceWindow{Start: "24:00", End: "01:00"})
+	assert.Equal(t, len(p.Validate()), 1)
+
+	spot := SlotPricingConfig{UseSpotMaxPrice: true}
+	_, err = spot.BasePrice(nil)
+	assert.ErrorContains(t, err, "spot_max_price")
+	price, err = spot.BasePrice(&provconfig.Config{AWS: &provconfig.AWSClusterConfig{
+		SpotEnabled:  true,
+		SpotMaxPrice: "12",
+		InstanceType: "p3.8xlarge",
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, price, 3.0)
+}
+
+func TestChargebackConfigValidate(t *testing.T) {
+	c := DefaultChargebackConfig()
+	assert.Equal(t, len(c.Validate()), 0)
+	c.Timezone = "Mars/Olympus_Mons"
+	budget := -1.0
+	c.MonthlyBudget = &budget
+	assert.Equal(t, len(c.Validate()), 2)
+}
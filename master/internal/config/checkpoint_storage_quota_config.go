@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+)
+
+// CheckpointStorageQuotaConfig limits how many bytes of checkpoints a workspace may hold before
+// new experiments in it are rejected.
+type CheckpointStorageQuotaConfig struct {
+	// DefaultWorkspaceBytes applies to every workspace without an explicit limit. Unset means
+	// unlimited.
+	DefaultWorkspaceBytes *int64 `json:"default_workspace_bytes"`
+	// WorkspaceBytes overrides the default limit for workspaces, keyed by workspace name.
+	WorkspaceBytes map[string]int64 `json:"workspace_bytes"`
+}
+
+// WorkspaceLimit returns the checkpoint storage limit for the named workspace, or nil if it is
+// unlimited.
+func (c CheckpointStorageQuotaConfig) WorkspaceLimit(workspaceName string) *int64 {
+	if limit, ok := c.WorkspaceBytes[workspaceName]; ok {
+		return &limit
+	}
+	return c.DefaultWorkspaceBytes
+}
+
+// Validate implements the check.Validatable interface.
+func (c CheckpointStorageQuotaConfig) Validate() []error {
+	var errs []error
+	if c.DefaultWorkspaceBytes != nil && *c.DefaultWorkspaceBytes < 0 {
+		errs = append(errs, fmt.Errorf("default_workspace_bytes must be non-negative"))
+	}
+	for name, limit := range c.WorkspaceBytes {
+		if limit < 0 {
+			errs = append(errs, fmt.Errorf("workspace_bytes for %q must be non-negative", name))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestCheckpointStorageQuotaWorkspaceLimit(t *testing.T) {
+	raw := `
+default_workspace_bytes: 1000
+workspace_bytes:
+  big: 5000
+  frozen: 0
+`
+	var c CheckpointStorageQuotaConfig
+	assert.NilError(t, yaml.Unmarshal([]byte(raw), &c))
+	assert.Equal(t, len(c.Validate()), 0)
+
+	assert.DeepEqual(t, c.WorkspaceLimit("big"), ptrs.Ptr(int64(5000)))
+	assert.DeepEqual(t, c.WorkspaceLimit("frozen"), ptrs.Ptr(int64(0)))
+	assert.DeepEqual(t, c.WorkspaceLimit("other"), ptrs.Ptr(int64(1000)))
+
+	var unlimited CheckpointStorageQuotaConfig
+	assert.Assert(t, unlimited.WorkspaceLimit("other") == nil)
+
+	invalid := CheckpointStorageQuotaConfig{
+		DefaultWorkspaceBytes: ptrs.Ptr(int64(-1)),
+		WorkspaceBytes:        map[string]int64{"neg": -5},
+	}
+	assert.Equal(t, len(invalid.Validate()), 2)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// CheckpointVerificationConfig configures the background job that reads checkpoints back from
+// storage to detect missing or corrupted files.
+type CheckpointVerificationConfig struct {
+	Enabled   bool           `json:"enabled"`
+	Interval  model.Duration `json:"interval"`
+	BatchSize int            `json:"batch_size"`
+}
+
+// DefaultCheckpointVerificationConfig returns the default checkpoint verification config.
+func DefaultCheckpointVerificationConfig() CheckpointVerificationConfig {
+	return CheckpointVerificationConfig{
+		Enabled:   false,
+		Interval:  model.Duration(time.Hour),
+		BatchSize: 100,
+	}
+}
+
+// Validate implements the check.Validatable interface.
+func (c CheckpointVerificationConfig) Validate() []error {
+	var errs []error
+	if c.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("checkpoint_verification.interval must be positive"))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("checkpoint_verification.batch_size must be positive"))
+	}
+	return errs
+}
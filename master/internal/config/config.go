@@ -117,8 +117,14 @@ func DefaultConfig() *Config {
 		Cache: CacheConfig{
 			CacheDir: "/var/cache/determined",
 		},
-		FeatureSwitches: []string{},
-		ResourceConfig:  *DefaultResourceConfig(),
+		FeatureSwitches:        []string{},
+		ResourceConfig:         *DefaultResourceConfig(),
+		CheckpointVerification: DefaultCheckpointVerificationConfig(),
+		LogRetention:           DefaultLogRetentionConfig(),
+		Chargeback:             DefaultChargebackConfig(),
+		MetricsDownsampling:    DefaultMetricsDownsamplingConfig(),
+		SlotQuarantine:         DefaultSlotQuarantineConfig(),
+		GPUIdle:                DefaultGPUIdleConfig(),
 	}
 }
 
@@ -127,25 +133,33 @@ func DefaultConfig() *Config {
 // It is populated, in the following order, by the master configuration file,
 // environment variables and command line arguments.
 type Config struct {
-	ConfigFile            string                            `json:"config_file"`
-	Log                   logger.Config                     `json:"log"`
-	DB                    DBConfig                          `json:"db"`
-	TensorBoardTimeout    int                               `json:"tensorboard_timeout"`
-	NotebookTimeout       *int                              `json:"notebook_timeout"`
-	Security              SecurityConfig                    `json:"security"`
-	CheckpointStorage     expconf.CheckpointStorageConfig   `json:"checkpoint_storage"`
-	TaskContainerDefaults model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
-	Port                  int                               `json:"port"`
-	Root                  string                            `json:"root"`
-	Telemetry             config.TelemetryConfig            `json:"telemetry"`
-	EnableCors            bool                              `json:"enable_cors"`
-	LaunchError           bool                              `json:"launch_error"`
-	ClusterName           string                            `json:"cluster_name"`
-	Logging               model.LoggingConfig               `json:"logging"`
-	Observability         ObservabilityConfig               `json:"observability"`
-	Cache                 CacheConfig                       `json:"cache"`
-	Webhooks              WebhooksConfig                    `json:"webhooks"`
-	FeatureSwitches       []string                          `json:"feature_switches"`
+	ConfigFile             string                            `json:"config_file"`
+	Log                    logger.Config                     `json:"log"`
+	DB                     DBConfig                          `json:"db"`
+	TensorBoardTimeout     int                               `json:"tensorboard_timeout"`
+	NotebookTimeout        *int                              `json:"notebook_timeout"`
+	Security               SecurityConfig                    `json:"security"`
+	CheckpointStorage      expconf.CheckpointStorageConfig   `json:"checkpoint_storage"`
+	CheckpointStorageQuota CheckpointStorageQuotaConfig      `json:"checkpoint_storage_quota"`
+	CheckpointVerification CheckpointVerificationConfig      `json:"checkpoint_verification"`
+	TaskContainerDefaults  model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
+	Port                   int                               `json:"port"`
+	Root                   string                            `json:"root"`
+	Telemetry              config.TelemetryConfig            `json:"telemetry"`
+	EnableCors             bool                              `json:"enable_cors"`
+	LaunchError            bool                              `json:"launch_error"`
+	ClusterName            string                            `json:"cluster_name"`
+	Logging                model.LoggingConfig               `json:"logging"`
+	LogRetention           LogRetentionConfig                `json:"log_retention"`
+	Observability          ObservabilityConfig               `json:"observability"`
+	Cache                  CacheConfig                       `json:"cache"`
+	Webhooks               WebhooksConfig                    `json:"webhooks"`
+	Chargeback             ChargebackConfig                  `json:"chargeback"`
+	MetricsDownsampling    MetricsDownsamplingConfig         `json:"metrics_downsampling"`
+	SlotQuarantine         SlotQuarantineConfig              `json:"slot_quarantine"`
+	GPUIdle                GPUIdleConfig                     `json:"gpu_idle"`
+	ModelRegistry          ModelRegistryConfig               `json:"model_registry"`
+	FeatureSwitches        []string                          `json:"feature_switches"`
 	ResourceConfig
 
 	// Internal contains "hidden" useful debugging configurations.
@@ -195,6 +209,7 @@ func (c Config) Printable() ([]byte, error) {
 	}
 
 	c.CheckpointStorage = c.CheckpointStorage.Printable()
+	c.Logging = c.Logging.Printable()
 
 	pools := make([]ResourcePoolConfig, 0, len(c.ResourcePools))
 	for _, poolConfig := range c.ResourcePools {
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// GPUIdleConfig configures terminating notebooks, shells and commands whose GPUs are idle, as
+// reported by the GPU telemetry of agents.
+type GPUIdleConfig struct {
+	Enabled bool `json:"enabled"`
+	// UtilizationThreshold is the GPU utilization, in percent, below which a GPU counts as idle.
+	UtilizationThreshold float64 `json:"utilization_threshold"`
+	// Timeout is how long every GPU of a task must stay idle before the task is warned.
+	Timeout model.Duration `json:"timeout"`
+	// GracePeriod is how long after the warning the task is terminated, unless its GPUs are used.
+	GracePeriod model.Duration `json:"grace_period"`
+	// WebhookURL, if set, is sent an event whenever a task is warned.
+	WebhookURL string `json:"webhook_url"`
+}
+
+// DefaultGPUIdleConfig returns the default GPU idle config.
+func DefaultGPUIdleConfig() GPUIdleConfig {
+	return GPUIdleConfig{
+		UtilizationThreshold: 5,
+		Timeout:              model.Duration(time.Hour),
+		GracePeriod:          model.Duration(15 * time.Minute),
+	}
+}
+
+// Validate implements the check.Validatable interface.
+func (c GPUIdleConfig) Validate() []error {
+	var errs []error
+	if c.UtilizationThreshold <= 0 || c.UtilizationThreshold > 100 {
+		errs = append(errs, fmt.Errorf("gpu_idle.utilization_threshold must be in (0, 100]"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("gpu_idle.timeout must be positive"))
+	}
+	if c.GracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("gpu_idle.grace_period must be non-negative"))
+	}
+	return errs
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// LogRetentionPolicy is how long the logs of ended tasks are kept in Postgres. Either limit may
+// be unset; a policy with neither keeps logs forever.
+type LogRetentionPolicy struct {
+	// Days deletes all logs of a task this many days after it ended.
+	Days *int `json:"days"`
+	// MaxLinesPerTask deletes all but the newest lines of a task once it ends.
+	MaxLinesPerTask *int `json:"max_lines_per_task"`
+}
+
+// IsZero reports whether the policy keeps logs forever.
+func (p LogRetentionPolicy) IsZero() bool {
+	return p.Days == nil && p.MaxLinesPerTask == nil
+}
+
+func (p LogRetentionPolicy) validate(name string) []error {
+	var errs []error
+	if p.Days != nil && *p.Days <= 0 {
+		errs = append(errs, fmt.Errorf("%s.days must be positive", name))
+	}
+	if p.MaxLinesPerTask != nil && *p.MaxLinesPerTask < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_lines_per_task must be non-negative", name))
+	}
+	return errs
+}
+
+// LogRetentionConfig configures the background job that deletes old task logs from Postgres.
+// Logs of tasks that have not ended are never deleted.
+type LogRetentionConfig struct {
+	Enabled   bool           `json:"enabled"`
+	Interval  model.Duration `json:"interval"`
+	BatchSize int            `json:"batch_size"`
+	// LogRetentionPolicy applies to every task without an override.
+	LogRetentionPolicy
+	// NTSC overrides the policy for notebooks, shells, commands and TensorBoards.
+	NTSC *LogRetentionPolicy `json:"ntsc"`
+	// Workspaces overrides the policy for trials of experiments in workspaces, keyed by
+	// workspace name. An override replaces the default policy entirely, so an empty override
+	// keeps the logs of a workspace forever.
+	Workspaces map[string]LogRetentionPolicy `json:"workspaces"`
+}
+
+// DefaultLogRetentionConfig returns the default log retention config.
+func DefaultLogRetentionConfig() LogRetentionConfig {
+	return LogRetentionConfig{
+		Enabled:   false,
+		Interval:  model.Duration(time.Hour),
+		BatchSize: 10000,
+	}
+}
+
+// NTSCPolicy returns the retention policy of notebooks, shells, commands and TensorBoards.
+func (c LogRetentionConfig) NTSCPolicy() LogRetentionPolicy {
+	if c.NTSC != nil {
+		return *c.NTSC
+	}
+	return c.LogRetentionPolicy
+}
+
+// WorkspacePolicy returns the retention policy of trials in the named workspace.
+func (c LogRetentionConfig) WorkspacePolicy(workspaceName string) LogRetentionPolicy {
+	if p, ok := c.Workspaces[workspaceName]; ok {
+		return p
+	}
+	return c.LogRetentionPolicy
+}
+
+// Validate implements the check.Validatable interface.
+func (c LogRetentionConfig) Validate() []error {
+	var errs []error
+	if c.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("log_retention.interval must be positive"))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("log_retention.batch_size must be positive"))
+	}
+	errs = append(errs, c.LogRetentionPolicy.validate("log_retention")...)
+	if c.NTSC != nil {
+		errs = append(errs, c.NTSC.validate("log_retention.ntsc")...)
+	}
+	for name, p := range c.Workspaces {
+		errs = append(errs, p.validate(fmt.Sprintf("log_retention.workspaces.%s", name))...)
+	}
+	return errs
+}
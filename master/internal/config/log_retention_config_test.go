@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestLogRetentionPolicies(t *testing.T) {
+	raw := `
+enabled: true
+interval: 30m
+batch_size: 500
+days: 30
+max_lines_per_task: 100000
+ntsc:
+  days: 7
+workspaces:
+  archive: {}
+  noisy:
+    max_lines_per_task: 1000
+`
+	c := DefaultLogRetentionConfig()
+	assert.NilError(t, yaml.Unmarshal([]byte(raw), &c))
+	assert.Equal(t, len(c.Validate()), 0)
+	assert.Equal(t, c.BatchSize, 500)
+
+	assert.DeepEqual(t, c.WorkspacePolicy("other"), LogRetentionPolicy{
+		Days: ptrs.Ptr(30), MaxLinesPerTask: ptrs.Ptr(100000),
+	})
+	assert.Assert(t, c.WorkspacePolicy("archive").IsZero())
+	assert.DeepEqual(t, c.WorkspacePolicy("noisy"), LogRetentionPolicy{
+		MaxLinesPerTask: ptrs.Ptr(1000),
+	})
+	assert.DeepEqual(t, c.NTSCPolicy(), LogRetentionPolicy{Days: ptrs.Ptr(7)})
+
+	c.NTSC = nil
+	assert.DeepEqual(t, c.NTSCPolicy(), c.LogRetentionPolicy)
+
+	invalid := DefaultLogRetentionConfig()
+	invalid.BatchSize = 0
+	invalid.Days = ptrs.Ptr(0)
+	invalid.NTSC = &LogRetentionPolicy{MaxLinesPerTask: ptrs.Ptr(-1)}
+	invalid.Workspaces = map[string]LogRetentionPolicy{"neg": {Days: ptrs.Ptr(-1)}}
+	assert.Equal(t, len(invalid.Validate()), 4)
+}
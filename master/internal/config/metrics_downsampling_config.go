@@ -0,0 +1,14 @@
+package config
+
+import "github.com/determined-ai/determined/master/pkg/downsample"
+
+// MetricsDownsamplingConfig configures how metric series are reduced to the number of points
+// requested by the metrics sampling APIs.
+type MetricsDownsamplingConfig struct {
+	Algorithm downsample.Algorithm `json:"algorithm"`
+}
+
+// DefaultMetricsDownsamplingConfig returns the default metrics downsampling config.
+func DefaultMetricsDownsamplingConfig() MetricsDownsamplingConfig {
+	return MetricsDownsamplingConfig{Algorithm: downsample.LTTB}
+}
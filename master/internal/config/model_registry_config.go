@@ -0,0 +1,8 @@
+package config
+
+// ModelRegistryConfig configures the model registry.
+type ModelRegistryConfig struct {
+	// RequireProductionApproval requires a second user to approve moving a model version to
+	// the production stage.
+	RequireProductionApproval bool `json:"require_production_approval"`
+}
@@ -37,6 +37,21 @@ type ResourcePoolConfig struct {
 	// which in most cases will be the namespace the helm deployment is in.
 	KubernetesNamespace string `json:"kubernetes_namespace"`
 
+	// PrePullImages are images the agents of the pool pull ahead of the tasks that need them and
+	// keep in their image cache.
+	PrePullImages []string `json:"prepull_images"`
+
+	// SlotPricing is the price of slot-hours in the pool for chargeback reports. Usage of pools
+	// without pricing costs nothing.
+	SlotPricing *SlotPricingConfig `json:"slot_pricing"`
+
+	// SlotResourceLimits overrides the slot resource limits of the agents in the pool.
+	SlotResourceLimits *model.SlotResourceLimitsConfig `json:"slot_resource_limits"`
+
+	// MIGProfiles are the MIG instances that the agents of the pool partition each of their
+	// MIG-enabled GPUs into while they are idle.
+	MIGProfiles []model.MIGProfileConfig `json:"mig_profiles"`
+
 	// Deprecated: Use MaxAuxContainersPerAgent instead.
 	MaxCPUContainersPerAgent int `json:"max_cpu_containers_per_agent,omitempty"`
 }
@@ -60,10 +75,15 @@ func (r *ResourcePoolConfig) UnmarshalJSON(data []byte) error {
 
 // Validate implements the check.Validatable interface.
 func (r ResourcePoolConfig) Validate() []error {
+	var slotPricingErr error
+	if r.SlotPricing != nil {
+		_, slotPricingErr = r.SlotPricing.BasePrice(r.Provider)
+	}
 	return []error{
 		check.True(len(r.PoolName) != 0, "resource pool name cannot be empty"),
 		check.True(r.MaxAuxContainersPerAgent >= 0,
 			"resource pool max cpu containers per agent should be >= 0"),
+		slotPricingErr,
 	}
 }
 
@@ -0,0 +1,15 @@
+package config
+
+// SlotQuarantineConfig configures how the master reacts to agents reporting unhealthy GPUs.
+type SlotQuarantineConfig struct {
+	// Enabled disables the slots of GPUs with fatal health problems, as if an admin had disabled
+	// them with draining, until an admin enables them again.
+	Enabled bool `json:"enabled"`
+	// WebhookURL, if set, is sent an event whenever a slot is quarantined.
+	WebhookURL string `json:"webhook_url"`
+}
+
+// DefaultSlotQuarantineConfig returns the default slot quarantine config.
+func DefaultSlotQuarantineConfig() SlotQuarantineConfig {
+	return SlotQuarantineConfig{}
+}
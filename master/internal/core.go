@@ -47,6 +47,11 @@ import (
 	"github.com/determined-ai/determined/master/internal/elastic"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/job"
+	"github.com/determined-ai/determined/master/internal/logarchive"
+	"github.com/determined-ai/determined/master/internal/logretention"
+	"github.com/determined-ai/determined/master/internal/loki"
+	"github.com/determined-ai/determined/master/internal/masterlogs"
+	"github.com/determined-ai/determined/master/internal/metricstream"
 	"github.com/determined-ai/determined/master/internal/plugin/sso"
 	"github.com/determined-ai/determined/master/internal/portregistry"
 	"github.com/determined-ai/determined/master/internal/prom"
@@ -92,11 +97,16 @@ type Master struct {
 	config   *config.Config
 	taskSpec *tasks.TaskSpec
 
-	logs   *logger.LogBuffer
-	system *actor.System
-	echo   *echo.Echo
-	db     *db.PgDB
-	rm     rm.ResourceManager
+	logs      *logger.LogBuffer
+	logStore  masterlogs.Store
+	system    *actor.System
+	echo      *echo.Echo
+	db        *db.PgDB
+	rm        rm.ResourceManager
+	metricHub *metricstream.Hub
+
+	// checkpointCopyJobs hands requested checkpoint copy jobs to runCheckpointCopyJobs.
+	checkpointCopyJobs chan *model.CheckpointCopyJob
 
 	trialLogBackend TrialLogBackend
 	taskLogBackend  TaskLogBackend
@@ -109,6 +119,8 @@ func New(logStore *logger.LogBuffer, config *config.Config) *Master {
 		MasterID: uuid.New().String(),
 		logs:     logStore,
 		config:   config,
+
+		checkpointCopyJobs: make(chan *model.CheckpointCopyJob),
 	}
 }
 
@@ -732,13 +744,15 @@ func closeWithErrCheck(name string, closer io.Closer) {
 	}
 }
 
-func (m *Master) tryRestoreExperiment(sema chan struct{}, wg *sync.WaitGroup, e *model.Experiment) {
+func (m *Master) tryRestoreExperiment(
+	ctx context.Context, sema chan struct{}, wg *sync.WaitGroup, e *model.Experiment,
+) {
 	sema <- struct{}{}
 	defer func() { <-sema }()
 	defer func() { wg.Done() }()
 
 	// restoreExperiments waits for experiment allocations to be initialized.
-	if err := m.restoreExperiment(e); err != nil {
+	if err := m.restoreExperiment(ctx, e); err != nil {
 		log.WithError(err).Errorf("failed to restore experiment: %d", e.ID)
 		e.State = model.ErrorState
 		if err := m.db.TerminateExperimentInRestart(e.ID, e.State); err != nil {
@@ -764,7 +778,7 @@ func (m *Master) tryRestoreExperiment(sema chan struct{}, wg *sync.WaitGroup, e
 // starting any scheduling. This path is better for scheduling fairness.
 // Alternatively, we could wait for experiments with restorable allocations only.
 // This would potentially speed up the startup when there're lots of these.
-func (m *Master) restoreNonTerminalExperiments() error {
+func (m *Master) restoreNonTerminalExperiments(ctx context.Context) error {
 	// Restore non-terminal experiments from the database.
 	// Limit the number of concurrent restores at any time within the system to maxConcurrentRestores.
 	// This has avoided resource exhaustion in the past (on the db connection pool) and probably is
@@ -778,7 +792,7 @@ func (m *Master) restoreNonTerminalExperiments() error {
 	wg := sync.WaitGroup{}
 	for _, exp := range toRestore {
 		wg.Add(1)
-		go m.tryRestoreExperiment(sema, &wg, exp)
+		go m.tryRestoreExperiment(ctx, sema, &wg, exp)
 	}
 
 	wg.Wait()
@@ -902,6 +916,15 @@ func (m *Master) Run(ctx context.Context) error {
 	}()
 
 	switch {
+	case m.config.Logging.DefaultLoggingConfig != nil &&
+		m.config.Logging.DefaultLoggingConfig.Archive != nil:
+		archive, aErr := logarchive.New(m.db, *m.config.Logging.DefaultLoggingConfig.Archive)
+		if aErr != nil {
+			return aErr
+		}
+		go archive.Run(ctx)
+		m.trialLogBackend = archive
+		m.taskLogBackend = archive
 	case m.config.Logging.DefaultLoggingConfig != nil:
 		m.trialLogBackend = m.db
 		m.taskLogBackend = m.db
@@ -912,6 +935,14 @@ func (m *Master) Run(ctx context.Context) error {
 		}
 		m.trialLogBackend = es
 		m.taskLogBackend = es
+	case m.config.Logging.LokiLoggingConfig != nil:
+		lk, lErr := loki.Setup(*m.config.Logging.LokiLoggingConfig)
+		if lErr != nil {
+			return lErr
+		}
+		// Trial logs from before task logs existed were only ever stored in Postgres.
+		m.trialLogBackend = m.db
+		m.taskLogBackend = lk
 	default:
 		panic("unsupported logging backend")
 	}
@@ -1014,10 +1045,11 @@ func (m *Master) Run(ctx context.Context) error {
 
 	tasksGroup := m.echo.Group("/tasks")
 	tasksGroup.GET("", api.Route(m.getTasks))
+	tasksGroup.GET("/logs/search", api.Route(m.getTaskLogSearch))
 
 	m.system.ActorOf(actor.Addr("experiments"), &actors.Group{})
 
-	if err = m.restoreNonTerminalExperiments(); err != nil {
+	if err = m.restoreNonTerminalExperiments(ctx); err != nil {
 		return err
 	}
 
@@ -1048,6 +1080,26 @@ func (m *Master) Run(ctx context.Context) error {
 	// This ensures that in the scenario where a cluster fails all open allocations are
 	// set to the last cluster heartbeat when the cluster was running.
 	go updateClusterHeartbeat(ctx, m.db)
+	go m.reconcileCheckpointStorageUsage(ctx)
+	if m.config.CheckpointVerification.Enabled {
+		go m.verifyCheckpoints(ctx)
+	}
+	go m.runCheckpointCopyJobs(ctx)
+	if m.config.LogRetention.Enabled {
+		if m.config.Logging.DefaultLoggingConfig != nil {
+			go logretention.New(m.config.LogRetention).Run(ctx)
+		} else {
+			log.Warn("log_retention only applies to task logs stored in Postgres, ignoring it")
+		}
+	}
+	if m.config.Chargeback.MonthlyBudget != nil {
+		go m.monitorChargebackBudget(ctx)
+	}
+	if err := m.persistMasterLogs(ctx); err != nil {
+		return errors.Wrap(err, "failed to persist master logs")
+	}
+	m.metricHub = metricstream.New(m.db)
+	go m.metricHub.Run(ctx)
 
 	// Docs and WebUI.
 	webuiRoot := filepath.Join(m.config.Root, "webui")
@@ -1117,9 +1169,28 @@ func (m *Master) Run(ctx context.Context) error {
 	experimentsGroup.GET("/:experiment_id/model_def", m.getExperimentModelDefinition)
 	experimentsGroup.GET("/:experiment_id/file/download", m.getExperimentModelFile)
 	experimentsGroup.GET("/:experiment_id/preview_gc", api.Route(m.getExperimentCheckpointsToGC))
+	experimentsGroup.GET("/:experiment_id/export", m.getExperimentExport)
+	experimentsGroup.POST("/import", api.Route(m.postExperimentImport))
+
+	m.echo.GET("/trials/metrics/stream", m.getTrialMetricsStream)
 
 	checkpointsGroup := m.echo.Group("/checkpoints")
 	checkpointsGroup.GET("/:checkpoint_uuid", m.getCheckpoint)
+	checkpointsGroup.GET("/:checkpoint_uuid/integrity", api.Route(m.getCheckpointIntegrity))
+	checkpointsGroup.POST("/:checkpoint_uuid/verify", api.Route(m.postVerifyCheckpoint))
+	checkpointsGroup.POST("/copies", api.Route(m.postCheckpointCopy))
+	checkpointsGroup.GET("/copies/:job_id", api.Route(m.getCheckpointCopy))
+
+	modelsGroup := m.echo.Group("/models")
+	modelsGroup.GET("/:model_name/versions/:model_version_num/stage",
+		api.Route(m.getModelVersionStage))
+	modelsGroup.POST("/:model_name/versions/:model_version_num/transitions",
+		api.Route(m.postModelVersionTransition))
+	modelsGroup.POST("/:model_name/transitions/:transition_id/approve",
+		api.Route(m.postApproveModelVersionTransition))
+	modelsGroup.POST("/:model_name/transitions/:transition_id/reject",
+		api.Route(m.postRejectModelVersionTransition))
+	modelsGroup.GET("/:model_name/stages/:stage", api.Route(m.getModelVersionInStage))
 
 	searcherGroup := m.echo.Group("/searcher")
 	searcherGroup.POST("/preview", api.Route(m.getSearcherPreview))
@@ -1128,6 +1199,20 @@ func (m *Master) Run(ctx context.Context) error {
 	resourcesGroup.GET("/allocation/raw", m.getRawResourceAllocation)
 	resourcesGroup.GET("/allocation/allocations-csv", m.getResourceAllocations)
 	resourcesGroup.GET("/allocation/aggregated", m.getAggregatedResourceAllocation)
+	resourcesGroup.GET("/checkpoint-storage", api.Route(m.getCheckpointStorageUsage))
+	resourcesGroup.GET("/checkpoint-storage/:scope/:id",
+		api.Route(m.getCheckpointStorageUsageHistory))
+	resourcesGroup.GET("/chargeback", api.Route(m.getChargebackReport))
+	resourcesGroup.GET("/chargeback/csv", m.getChargebackReportCSV)
+
+	agentMaintenanceGroup := m.echo.Group("/agents/:agent_id/maintenance")
+	agentMaintenanceGroup.GET("", api.Route(m.getAgentMaintenance),
+		cluster.CanGetSensitiveAgentInfo())
+	agentMaintenanceGroup.POST("", api.Route(m.postAgentMaintenance), cluster.CanUpdateAgents())
+	agentMaintenanceGroup.DELETE("", api.Route(m.deleteAgentMaintenance), cluster.CanUpdateAgents())
+	m.echo.POST("/agents/maintenance", api.Route(m.postLabelMaintenance), cluster.CanUpdateAgents())
+	m.echo.POST("/resource-pools/:resource_pool/maintenance",
+		api.Route(m.postResourcePoolMaintenance), cluster.CanUpdateAgents())
 
 	m.echo.POST("/task-logs", api.Route(m.postTaskLogs))
 
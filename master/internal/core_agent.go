@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// agentMaintenanceRequest is the body of the requests that schedule agent maintenance.
+type agentMaintenanceRequest struct {
+	// StartTime is when to drain the agents; it defaults to now.
+	StartTime *time.Time `json:"start_time"`
+	// Timeout is how long to wait for the tasks on the agents before killing them; it is
+	// required, so that a request never kills the tasks as soon as the maintenance starts.
+	Timeout model.Duration `json:"timeout"`
+}
+
+func bindAgentMaintenanceRequest(c echo.Context) (sproto.ScheduleAgentMaintenance, error) {
+	var body agentMaintenanceRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return sproto.ScheduleAgentMaintenance{}, echo.NewHTTPError(http.StatusBadRequest,
+			"invalid maintenance request: "+err.Error())
+	}
+	if body.Timeout <= 0 {
+		return sproto.ScheduleAgentMaintenance{}, echo.NewHTTPError(http.StatusBadRequest,
+			"timeout must be positive")
+	}
+
+	msg := sproto.ScheduleAgentMaintenance{
+		StartTime: time.Now().UTC(),
+		Timeout:   time.Duration(body.Timeout),
+	}
+	if body.StartTime != nil {
+		msg.StartTime = body.StartTime.UTC()
+	}
+	return msg, nil
+}
+
+// askAgents asks the agent or agents actor for the result of a maintenance request.
+func (m *Master) askAgents(addr actor.Address, msg interface{}, notFound error) (interface{}, error) {
+	resp := m.system.AskAt(addr, msg)
+	switch {
+	case resp.Source() == nil:
+		return nil, notFound
+	case resp.Error() != nil:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, resp.Error().Error())
+	}
+	return resp.Get(), nil
+}
+
+//	@Summary	Get the maintenance of an agent.
+//	@Tags		Agents
+//	@ID			get-agent-maintenance
+//	@Produce	json
+//	@Param		agent_id	path	string	true	"Agent to get the maintenance of"
+//	@Success	200			{}		string	""
+//	@Router		/agents/{agent_id}/maintenance [get]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getAgentMaintenance(c echo.Context) (interface{}, error) {
+	agentID := c.Param("agent_id")
+	resp, err := m.askAgents(
+		agentAddr(agentID), model.AgentSummary{}, api.NotFoundErrs("agent", agentID, false))
+	if err != nil {
+		return nil, err
+	}
+	maintenance := resp.(model.AgentSummary).Maintenance
+	if maintenance == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			"agent "+agentID+" has no maintenance scheduled")
+	}
+	return maintenance, nil
+}
+
+//	@Summary	Schedule the maintenance of an agent.
+//	@Tags		Agents
+//	@ID			post-agent-maintenance
+//	@Accept		json
+//	@Produce	json
+//	@Param		agent_id	path	string	true	"Agent to schedule the maintenance of"
+//	@Success	200			{}		string	""
+//	@Router		/agents/{agent_id}/maintenance [post]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postAgentMaintenance(c echo.Context) (interface{}, error) {
+	msg, err := bindAgentMaintenanceRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	agentID := c.Param("agent_id")
+	return m.askAgents(agentAddr(agentID), msg, api.NotFoundErrs("agent", agentID, false))
+}
+
+//	@Summary	Cancel the maintenance of an agent and put it back in service.
+//	@Tags		Agents
+//	@ID			delete-agent-maintenance
+//	@Produce	json
+//	@Param		agent_id	path	string	true	"Agent to cancel the maintenance of"
+//	@Success	200			{}		string	""
+//	@Router		/agents/{agent_id}/maintenance [delete]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) deleteAgentMaintenance(c echo.Context) (interface{}, error) {
+	agentID := c.Param("agent_id")
+	return m.askAgents(agentAddr(agentID), sproto.CancelAgentMaintenance{},
+		api.NotFoundErrs("agent", agentID, false))
+}
+
+//	@Summary	Schedule the maintenance of every agent with a label.
+//	@Tags		Agents
+//	@ID			post-label-maintenance
+//	@Accept		json
+//	@Produce	json
+//	@Param		label	query	string	true	"Label of the agents to schedule the maintenance of"
+//	@Success	200		{}		string	""
+//	@Router		/agents/maintenance [post]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postLabelMaintenance(c echo.Context) (interface{}, error) {
+	label := c.QueryParam("label")
+	if label == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "label is required")
+	}
+
+	msg, err := bindAgentMaintenanceRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	msg.Label = label
+	return m.askAgents(sproto.AgentsAddr, msg, api.NotFoundErrs("agents", "", false))
+}
+
+//	@Summary	Schedule the maintenance of every agent in a resource pool.
+//	@Tags		Agents
+//	@ID			post-resource-pool-maintenance
+//	@Accept		json
+//	@Produce	json
+//	@Param		resource_pool	path	string	true	"Resource pool to schedule the maintenance of"
+//	@Success	200				{}		string	""
+//	@Router		/resource-pools/{resource_pool}/maintenance [post]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postResourcePoolMaintenance(c echo.Context) (interface{}, error) {
+	pool := c.Param("resource_pool")
+	notFound := api.NotFoundErrs("resource pool", pool, false)
+	if err := m.rm.ValidateResourcePool(m.system, pool); err != nil {
+		return nil, notFound
+	}
+
+	msg, err := bindAgentMaintenanceRequest(c)
+	if err != nil {
+		return nil, err
+	}
+	msg.ResourcePool = pool
+	return m.askAgents(sproto.AgentsAddr, msg, notFound)
+}
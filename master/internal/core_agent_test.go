@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindAgentMaintenanceRequest(t *testing.T) {
+	bind := func(body string) (time.Time, time.Duration, error) {
+		req := httptest.NewRequest(http.MethodPost, "/agents/a/maintenance",
+			strings.NewReader(body))
+		msg, err := bindAgentMaintenanceRequest(echo.New().NewContext(req, httptest.NewRecorder()))
+		return msg.StartTime, msg.Timeout, err
+	}
+
+	// A request without a timeout would kill the tasks as soon as the maintenance starts.
+	for _, body := range []string{
+		`{}`,
+		`{"start_time": "2030-01-01T00:00:00Z"}`,
+		`{"timeout": "0s"}`,
+		`{"timeout": "-1h"}`,
+	} {
+		_, _, err := bind(body)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr, body)
+		require.Equal(t, http.StatusBadRequest, httpErr.Code, body)
+	}
+
+	start, timeout, err := bind(`{"start_time": "2030-01-01T00:00:00Z", "timeout": "1h"}`)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Hour, timeout)
+}
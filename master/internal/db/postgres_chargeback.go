@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// AllocationSlotUsage is the time an allocation held slots, clipped to a report period.
+type AllocationSlotUsage struct {
+	ResourcePool string    `bun:"resource_pool"`
+	Slots        int       `bun:"slots"`
+	StartTime    time.Time `bun:"start_time"`
+	EndTime      time.Time `bun:"end_time"`
+	Username     string    `bun:"username"`
+	// WorkspaceName, ProjectName and ExperimentID are set for the allocations of trials only.
+	WorkspaceName *string `bun:"workspace_name"`
+	ProjectName   *string `bun:"project_name"`
+	ExperimentID  *int    `bun:"experiment_id"`
+}
+
+// AllocationSlotUsages returns the slot usage of every allocation that held slots between start
+// and end, with its times clipped to that period. Allocations that have not ended are counted up
+// to now.
+func AllocationSlotUsages(
+	ctx context.Context, start, end time.Time,
+) ([]AllocationSlotUsage, error) {
+	var usages []AllocationSlotUsage
+	if err := Bun().NewSelect().
+		TableExpr("allocations a").
+		Join("JOIN tasks tk ON tk.task_id = a.task_id").
+		Join("LEFT JOIN jobs j ON j.job_id = tk.job_id").
+		Join("LEFT JOIN users u ON u.id = j.owner_id").
+		Join("LEFT JOIN trials t ON t.task_id = a.task_id").
+		Join("LEFT JOIN experiments e ON e.id = t.experiment_id").
+		Join("LEFT JOIN projects p ON p.id = e.project_id").
+		Join("LEFT JOIN workspaces w ON w.id = p.workspace_id").
+		ColumnExpr("a.resource_pool, a.slots").
+		ColumnExpr("greatest(a.start_time, ?) AS start_time", start).
+		ColumnExpr("least(coalesce(a.end_time, now()), ?) AS end_time", end).
+		ColumnExpr("coalesce(u.username, '') AS username").
+		ColumnExpr("w.name AS workspace_name, p.name AS project_name, e.id AS experiment_id").
+		Where("a.start_time IS NOT NULL").
+		Where("a.slots > 0").
+		Where("a.start_time < ?", end).
+		Where("coalesce(a.end_time, now()) > ?", start).
+		Scan(ctx, &usages); err != nil {
+		return nil, errors.Wrap(err, "querying allocation slot usage")
+	}
+	return usages, nil
+}
+
+// RecordChargebackBudgetAlertTx records that the budget of the month starting at month was
+// exceeded, returning false if that was already recorded.
+func RecordChargebackBudgetAlertTx(
+	ctx context.Context, idb bun.IDB, month time.Time, cost, budget float64,
+) (bool, error) {
+	res, err := idb.ExecContext(ctx, `
+INSERT INTO chargeback_budget_alerts (month, cost, budget) VALUES (?, ?, ?)
+ON CONFLICT (month) DO NOTHING`, month.Format("2006-01-02"), cost, budget)
+	if err != nil {
+		return false, errors.Wrap(err, "recording chargeback budget alert")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// CompletedCheckpointsOfExperiment returns the UUIDs of the completed checkpoints of an
+// experiment.
+func CompletedCheckpointsOfExperiment(ctx context.Context, expID int) ([]uuid.UUID, error) {
+	var uuids []uuid.UUID
+	if err := Bun().NewSelect().Table("checkpoints_v2").
+		ColumnExpr("checkpoints_v2.uuid").
+		Join("JOIN trials t ON t.task_id = checkpoints_v2.task_id").
+		Where("t.experiment_id = ?", expID).
+		Where("checkpoints_v2.state = ?", model.CompletedState).
+		Order("checkpoints_v2.report_time").
+		Scan(ctx, &uuids); err != nil {
+		return nil, errors.Wrapf(err, "error getting checkpoints of experiment %d", expID)
+	}
+	return uuids, nil
+}
+
+// CopiedCheckpointStorages returns, for those of the given checkpoints that were copied to other
+// storage, the storage they were copied to. The others are kept in their experiment's storage.
+func CopiedCheckpointStorages(
+	ctx context.Context, uuids []uuid.UUID,
+) (map[uuid.UUID]json.RawMessage, error) {
+	storages := map[uuid.UUID]json.RawMessage{}
+	if len(uuids) == 0 {
+		return storages, nil
+	}
+	var rows []struct {
+		UUID    uuid.UUID
+		Storage json.RawMessage
+	}
+	if err := Bun().NewSelect().Table("checkpoints_v2").
+		Column("uuid", "storage").
+		Where("uuid IN (?)", bun.In(uuids)).
+		Where("storage IS NOT NULL").
+		Scan(ctx, &rows); err != nil {
+		return nil, errors.Wrap(err, "error getting storage of copied checkpoints")
+	}
+	for _, r := range rows {
+		storages[r.UUID] = r.Storage
+	}
+	return storages, nil
+}
+
+// CreateCheckpointCopyJob records a new running job that copies the given checkpoints. Every
+// checkpoint must be a completed checkpoint that no other running job is copying.
+func CreateCheckpointCopyJob(
+	ctx context.Context, job *model.CheckpointCopyJob, uuids []uuid.UUID,
+) error {
+	if len(uuids) == 0 {
+		return errors.Wrap(ErrInvalidInput, "no checkpoints to copy")
+	}
+	return Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var ckpts []model.CheckpointV2
+		if err := tx.NewSelect().Model(&ckpts).
+			Column("uuid", "size").
+			Where("uuid IN (?)", bun.In(uuids)).
+			Where("state = ?", model.CompletedState).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return errors.Wrap(err, "error getting checkpoints to copy")
+		}
+		found := make(map[uuid.UUID]bool, len(ckpts))
+		for _, c := range ckpts {
+			found[c.UUID] = true
+		}
+		var missing []string
+		for _, id := range uuids {
+			if !found[id] {
+				missing = append(missing, id.String())
+			}
+		}
+		if len(missing) > 0 {
+			return errors.Wrapf(ErrInvalidInput, "not completed checkpoints: %s",
+				strings.Join(missing, ", "))
+		}
+
+		var busy []uuid.UUID
+		if err := tx.NewSelect().Table("checkpoint_copies").
+			ColumnExpr("checkpoint_copies.checkpoint_uuid").
+			Join("JOIN checkpoint_copy_jobs j ON j.id = checkpoint_copies.job_id").
+			Where("j.state = ?", model.CheckpointCopyRunning).
+			Where("checkpoint_copies.checkpoint_uuid IN (?)", bun.In(uuids)).
+			Limit(1).
+			Scan(ctx, &busy); err != nil {
+			return errors.Wrap(err, "error checking for running checkpoint copies")
+		}
+		if len(busy) > 0 {
+			return errors.Wrapf(ErrInvalidInput,
+				"checkpoint %s is already being copied by another job", busy[0])
+		}
+
+		job.State = model.CheckpointCopyRunning
+		job.StartTime = time.Now().UTC()
+		job.TotalCheckpoints = len(ckpts)
+		copies := make([]model.CheckpointCopy, 0, len(ckpts))
+		for _, c := range ckpts {
+			job.TotalBytes += c.Size
+			copies = append(copies, model.CheckpointCopy{
+				CheckpointUUID: c.UUID,
+				State:          model.CheckpointCopyPending,
+				Size:           c.Size,
+			})
+		}
+		if _, err := tx.NewInsert().Model(job).Exec(ctx); err != nil {
+			return errors.Wrap(err, "error inserting checkpoint copy job")
+		}
+		for i := range copies {
+			copies[i].JobID = job.ID
+		}
+		if _, err := tx.NewInsert().Model(&copies).Exec(ctx); err != nil {
+			return errors.Wrap(err, "error inserting checkpoint copies")
+		}
+		return nil
+	})
+}
+
+// CheckpointCopyJobByID returns a checkpoint copy job.
+func CheckpointCopyJobByID(ctx context.Context, id int) (*model.CheckpointCopyJob, error) {
+	var job model.CheckpointCopyJob
+	err := Bun().NewSelect().Model(&job).Where("id = ?", id).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error getting checkpoint copy job %d", id)
+	}
+	return &job, nil
+}
+
+// RunningCheckpointCopyJobs returns the checkpoint copy jobs that have not finished.
+func RunningCheckpointCopyJobs(ctx context.Context) ([]model.CheckpointCopyJob, error) {
+	var jobs []model.CheckpointCopyJob
+	if err := Bun().NewSelect().Model(&jobs).
+		Where("state = ?", model.CheckpointCopyRunning).
+		Order("id").
+		Scan(ctx); err != nil {
+		return nil, errors.Wrap(err, "error getting running checkpoint copy jobs")
+	}
+	return jobs, nil
+}
+
+// CheckpointCopies returns the checkpoints of a copy job, optionally only those in a given state.
+func CheckpointCopies(
+	ctx context.Context, jobID int, state *model.CheckpointCopyState,
+) ([]model.CheckpointCopy, error) {
+	copies := []model.CheckpointCopy{}
+	q := Bun().NewSelect().Model(&copies).Where("job_id = ?", jobID)
+	if state != nil {
+		q = q.Where("state = ?", *state)
+	}
+	if err := q.Order("checkpoint_uuid").Scan(ctx); err != nil {
+		return nil, errors.Wrapf(err, "error getting checkpoints of copy job %d", jobID)
+	}
+	return copies, nil
+}
+
+// CompleteCheckpointCopy records that a checkpoint was copied, and in the same transaction points
+// the checkpoint at the storage it was copied to.
+func CompleteCheckpointCopy(
+	ctx context.Context, job *model.CheckpointCopyJob, ckpt uuid.UUID, size int64,
+) error {
+	return Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().Table("checkpoints_v2").
+			Set("storage = ?::jsonb", string(job.Target)).
+			Where("uuid = ?", ckpt).
+			Exec(ctx); err != nil {
+			return errors.Wrapf(err, "error updating storage of checkpoint %s", ckpt)
+		}
+		return finishCheckpointCopy(ctx, tx, job.ID, ckpt, model.CheckpointCopyCompleted, size, nil)
+	})
+}
+
+// FailCheckpointCopy records that a checkpoint could not be copied. The checkpoint keeps the
+// storage it referenced before.
+func FailCheckpointCopy(ctx context.Context, jobID int, ckpt uuid.UUID, msg string) error {
+	return Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return finishCheckpointCopy(ctx, tx, jobID, ckpt, model.CheckpointCopyFailed, 0, &msg)
+	})
+}
+
+func finishCheckpointCopy(
+	ctx context.Context,
+	tx bun.Tx,
+	jobID int,
+	ckpt uuid.UUID,
+	state model.CheckpointCopyState,
+	size int64,
+	msg *string,
+) error {
+	if _, err := tx.NewUpdate().Table("checkpoint_copies").
+		Set("state = ?", state).
+		Set("end_time = now()").
+		Set("error = ?", msg).
+		Where("job_id = ?", jobID).
+		Where("checkpoint_uuid = ?", ckpt).
+		Exec(ctx); err != nil {
+		return errors.Wrapf(err, "error updating copy of checkpoint %s", ckpt)
+	}
+
+	counter := "copied_checkpoints"
+	if state == model.CheckpointCopyFailed {
+		counter = "failed_checkpoints"
+	}
+	if _, err := tx.NewUpdate().Table("checkpoint_copy_jobs").
+		Set(fmt.Sprintf("%[1]s = %[1]s + 1", counter)).
+		Set("copied_bytes = copied_bytes + ?", size).
+		Where("id = ?", jobID).
+		Exec(ctx); err != nil {
+		return errors.Wrapf(err, "error updating progress of checkpoint copy job %d", jobID)
+	}
+	return nil
+}
+
+// FinishCheckpointCopyJob marks a checkpoint copy job as finished. It fails if any checkpoint
+// could not be copied or if msg is set.
+func FinishCheckpointCopyJob(ctx context.Context, jobID int, msg *string) error {
+	state := "CASE WHEN failed_checkpoints > 0 THEN 'FAILED' ELSE 'COMPLETED' END"
+	if msg != nil {
+		state = "'FAILED'"
+	}
+	if _, err := Bun().NewUpdate().Table("checkpoint_copy_jobs").
+		Set(fmt.Sprintf("state = (%s)::checkpoint_copy_state", state)).
+		Set("end_time = now()").
+		Set("error = ?", msg).
+		Where("id = ?", jobID).
+		Exec(ctx); err != nil {
+		return errors.Wrapf(err, "error finishing checkpoint copy job %d", jobID)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+//go:build integration
+// +build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestCheckpointCopyJob(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, etc.SetRootPath(RootFromDB))
+	db := MustResolveTestPostgres(t)
+	MustMigrateTestPostgres(t, db, MigrationsFromDB)
+
+	user := RequireMockUser(t, db)
+	exp := RequireMockExperiment(t, db, user)
+	tr := RequireMockTrial(t, db, exp)
+	a := RequireMockAllocation(t, db, tr.TaskID)
+
+	var uuids []uuid.UUID
+	for i := 0; i < 2; i++ {
+		ckpt := MockModelCheckpoint(uuid.New(), tr, a)
+		require.NoError(t, AddCheckpointMetadata(ctx, &ckpt))
+		uuids = append(uuids, ckpt.UUID)
+	}
+	expUUIDs, err := CompletedCheckpointsOfExperiment(ctx, exp.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, uuids, expUUIDs)
+
+	target := []byte(`{"type":"shared_fs","host_path":"/new/ckpts"}`)
+	job := model.CheckpointCopyJob{Target: target, RequestedBy: user.ID}
+	require.ErrorIs(t, CreateCheckpointCopyJob(ctx, &job, []uuid.UUID{uuid.New()}),
+		ErrInvalidInput, "unknown checkpoints cannot be copied")
+	require.NoError(t, CreateCheckpointCopyJob(ctx, &job, uuids))
+	require.Equal(t, 2, job.TotalCheckpoints)
+
+	other := model.CheckpointCopyJob{Target: target, RequestedBy: user.ID}
+	require.ErrorIs(t, CreateCheckpointCopyJob(ctx, &other, uuids[:1]), ErrInvalidInput,
+		"a checkpoint is copied by one running job at a time")
+
+	require.NoError(t, CompleteCheckpointCopy(ctx, &job, uuids[0], 100))
+	require.NoError(t, FailCheckpointCopy(ctx, job.ID, uuids[1], "unreachable"))
+	require.NoError(t, FinishCheckpointCopyJob(ctx, job.ID, nil))
+
+	actual, err := CheckpointCopyJobByID(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.CheckpointCopyFailed, actual.State)
+	require.Equal(t, 1, actual.CopiedCheckpoints)
+	require.Equal(t, 1, actual.FailedCheckpoints)
+	require.Equal(t, int64(100), actual.CopiedBytes)
+	require.NotNil(t, actual.EndTime)
+
+	failed, err := CheckpointCopies(ctx, job.ID, ptrs.Ptr(model.CheckpointCopyFailed))
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.Equal(t, uuids[1], failed[0].CheckpointUUID)
+	require.Equal(t, "unreachable", *failed[0].Error)
+
+	// The copied checkpoint is now read from the target storage; the other is unchanged.
+	copied, err := db.CheckpointByUUID(uuids[0])
+	require.NoError(t, err)
+	storage := copied.CheckpointTrainingMetadata.ExperimentConfig["checkpoint_storage"]
+	require.Equal(t, "/new/ckpts", storage.(map[string]interface{})["host_path"])
+	untouched, err := db.CheckpointByUUID(uuids[1])
+	require.NoError(t, err)
+	storage = untouched.CheckpointTrainingMetadata.ExperimentConfig["checkpoint_storage"]
+	require.Equal(t, "/home/ckpts", storage.(map[string]interface{})["host_path"])
+
+	running, err := RunningCheckpointCopyJobs(ctx)
+	require.NoError(t, err)
+	for _, j := range running {
+		require.NotEqual(t, job.ID, j.ID)
+	}
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// checkpointStorageRollup computes the checkpoint storage usage of every scope touched by the
+// experiments selected in the `affected` CTE, which callers prepend to this query.
+const checkpointStorageRollup = `
+usage AS (
+	SELECT 'EXPERIMENT' AS scope, e.id AS scope_id,
+		e.checkpoint_size AS size, e.checkpoint_count::bigint AS count
+	FROM experiments e
+	WHERE e.id IN (SELECT id FROM affected)
+	UNION ALL
+	SELECT 'PROJECT', e.project_id, SUM(e.checkpoint_size), SUM(e.checkpoint_count)
+	FROM experiments e
+	WHERE e.project_id IN (SELECT project_id FROM affected)
+	GROUP BY e.project_id
+	UNION ALL
+	SELECT 'WORKSPACE', p.workspace_id, SUM(e.checkpoint_size), SUM(e.checkpoint_count)
+	FROM experiments e JOIN projects p ON e.project_id = p.id
+	WHERE p.workspace_id IN (SELECT workspace_id FROM affected)
+	GROUP BY p.workspace_id
+	UNION ALL
+	SELECT 'USER', e.owner_id, SUM(e.checkpoint_size), SUM(e.checkpoint_count)
+	FROM experiments e
+	WHERE e.owner_id IN (SELECT owner_id FROM affected)
+	GROUP BY e.owner_id
+), upserted AS (
+	INSERT INTO checkpoint_storage_usage AS u (scope, scope_id, size, count, updated_at)
+	SELECT scope::checkpoint_storage_scope, scope_id, size, count, now() FROM usage
+	ON CONFLICT (scope, scope_id) DO UPDATE
+	SET size = EXCLUDED.size, count = EXCLUDED.count, updated_at = EXCLUDED.updated_at
+	WHERE u.size != EXCLUDED.size OR u.count != EXCLUDED.count
+	RETURNING u.scope, u.scope_id, u.size, u.count, u.updated_at
+)`
+
+// UpdateCheckpointStorageUsageTx recomputes the checkpoint storage usage of the given experiments
+// and of the projects, workspaces and users they belong to, recording a history sample for every
+// scope whose usage changed. It expects experiment checkpoint sizes to already be up to date.
+func UpdateCheckpointStorageUsageTx(ctx context.Context, idb bun.IDB, experimentIDs []int) error {
+	if len(experimentIDs) == 0 {
+		return nil
+	}
+	if idb == nil {
+		idb = Bun()
+	}
+
+	var res []bool
+	if err := idb.NewRaw(`
+WITH affected AS (
+	SELECT e.id, e.project_id, p.workspace_id, e.owner_id
+	FROM experiments e JOIN projects p ON e.project_id = p.id
+	WHERE e.id IN (?)
+), `+checkpointStorageRollup+`
+INSERT INTO checkpoint_storage_usage_history (scope, scope_id, size, count, recorded_at)
+SELECT scope, scope_id, size, count, updated_at FROM upserted
+RETURNING true`, bun.In(experimentIDs)).Scan(ctx, &res); err != nil {
+		return errors.Wrap(err, "error updating checkpoint storage usage")
+	}
+	return nil
+}
+
+// RecomputeCheckpointStorageUsage recomputes checkpoint storage usage for every scope. Scopes that
+// no longer hold any experiments, e.g. because they were deleted or moved, are reset to zero. It is
+// used to reconcile drift that incremental updates do not capture.
+func RecomputeCheckpointStorageUsage(ctx context.Context) error {
+	return Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var res []bool
+		if err := tx.NewRaw(`
+WITH affected AS (
+	SELECT e.id, e.project_id, p.workspace_id, e.owner_id
+	FROM experiments e JOIN projects p ON e.project_id = p.id
+), `+checkpointStorageRollup+`, emptied AS (
+	UPDATE checkpoint_storage_usage u SET size = 0, count = 0, updated_at = now()
+	WHERE (u.size != 0 OR u.count != 0)
+	AND NOT EXISTS (
+		SELECT 1 FROM usage WHERE usage.scope::checkpoint_storage_scope = u.scope
+		AND usage.scope_id = u.scope_id
+	)
+	RETURNING u.scope, u.scope_id, u.size, u.count, u.updated_at
+)
+INSERT INTO checkpoint_storage_usage_history (scope, scope_id, size, count, recorded_at)
+SELECT scope, scope_id, size, count, updated_at FROM upserted
+UNION ALL
+SELECT scope, scope_id, size, count, updated_at FROM emptied
+RETURNING true`).Scan(ctx, &res); err != nil {
+			return errors.Wrap(err, "error recomputing checkpoint storage usage")
+		}
+		return nil
+	})
+}
+
+// CheckpointStorageUsageByScope returns the current checkpoint storage usage of every entity of
+// the given scope, largest first.
+func CheckpointStorageUsageByScope(
+	ctx context.Context, scope model.CheckpointStorageScope,
+) ([]model.CheckpointStorageUsage, error) {
+	var usages []model.CheckpointStorageUsage
+	if err := Bun().NewSelect().Model(&usages).
+		Where("scope = ?", scope).
+		Order("size DESC", "scope_id ASC").
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("getting checkpoint storage usage for %s: %w", scope, err)
+	}
+	return usages, nil
+}
+
+// CheckpointStorageUsageOf returns the current checkpoint storage usage of a single entity. An
+// entity that has never stored a checkpoint has zero usage.
+func CheckpointStorageUsageOf(
+	ctx context.Context, scope model.CheckpointStorageScope, scopeID int,
+) (*model.CheckpointStorageUsage, error) {
+	usage := model.CheckpointStorageUsage{Scope: scope, ScopeID: scopeID}
+	err := Bun().NewSelect().Model(&usage).WherePK().Scan(ctx)
+	if err != nil && MatchSentinelError(err) != ErrNotFound {
+		return nil, fmt.Errorf("getting checkpoint storage usage for %s %d: %w", scope, scopeID, err)
+	}
+	return &usage, nil
+}
+
+// CheckpointStorageUsageHistory returns the checkpoint storage usage of an entity at the end of
+// each period (as understood by Postgres' date_trunc) between start and end.
+func CheckpointStorageUsageHistory(
+	ctx context.Context, scope model.CheckpointStorageScope, scopeID int,
+	start, end time.Time, period string,
+) ([]model.CheckpointStorageUsageSample, error) {
+	var samples []model.CheckpointStorageUsageSample
+	if err := Bun().NewRaw(`
+SELECT DISTINCT ON (period_start) period_start, size, count
+FROM (
+	SELECT date_trunc(?, recorded_at) AS period_start, size, count, recorded_at
+	FROM checkpoint_storage_usage_history
+	WHERE scope = ? AND scope_id = ? AND recorded_at >= ? AND recorded_at < ?
+) h
+ORDER BY period_start, recorded_at DESC`,
+		period, scope, scopeID, start, end).Scan(ctx, &samples); err != nil {
+		return nil, fmt.Errorf(
+			"getting checkpoint storage usage history for %s %d: %w", scope, scopeID, err)
+	}
+	return samples, nil
+}
@@ -0,0 +1,66 @@
+//go:build integration
+// +build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestCheckpointStorageUsage(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(RootFromDB))
+	db := MustResolveTestPostgres(t)
+	MustMigrateTestPostgres(t, db, MigrationsFromDB)
+	user := RequireMockUser(t, db)
+
+	exp := RequireMockExperiment(t, db, user)
+	tr := RequireMockTrial(t, db, exp)
+	allocation := RequireMockAllocation(t, db, tr.TaskID)
+
+	var checkpointIDs []uuid.UUID
+	for i := 1; i <= 2; i++ {
+		ckpt := MockModelCheckpoint(uuid.New(), tr, allocation)
+		ckpt.Resources = map[string]int64{"TEST": int64(i * 10)}
+		require.NoError(t, AddCheckpointMetadata(ctx, &ckpt))
+		checkpointIDs = append(checkpointIDs, ckpt.UUID)
+	}
+
+	requireUsage := func(scope model.CheckpointStorageScope, id int, size, count int64) {
+		usage, err := CheckpointStorageUsageOf(ctx, scope, id)
+		require.NoError(t, err)
+		require.Equal(t, size, usage.Size, "size of %s %d", scope, id)
+		require.Equal(t, count, usage.Count, "count of %s %d", scope, id)
+	}
+
+	requireUsage(model.CheckpointStorageScopeExperiment, exp.ID, 30, 2)
+	requireUsage(model.CheckpointStorageScopeUser, int(user.ID), 30, 2)
+
+	require.NoError(t, MarkCheckpointsDeleted(ctx, checkpointIDs[:1]))
+	requireUsage(model.CheckpointStorageScopeExperiment, exp.ID, 20, 1)
+	requireUsage(model.CheckpointStorageScopeUser, int(user.ID), 20, 1)
+
+	history, err := CheckpointStorageUsageHistory(ctx, model.CheckpointStorageScopeExperiment,
+		exp.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "day")
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+	require.Equal(t, int64(20), history[len(history)-1].Size)
+
+	// Changes made behind the back of incremental updates are caught by a recompute.
+	_, err = Bun().NewUpdate().Table("experiments").
+		Set("checkpoint_size = 0").Set("checkpoint_count = 0").
+		Where("id = ?", exp.ID).Exec(ctx)
+	require.NoError(t, err)
+	require.NoError(t, RecomputeCheckpointStorageUsage(ctx))
+	requireUsage(model.CheckpointStorageScopeExperiment, exp.ID, 0, 0)
+	requireUsage(model.CheckpointStorageScopeUser, int(user.ID), 0, 0)
+}
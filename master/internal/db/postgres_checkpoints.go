@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -82,25 +83,7 @@ func MarkCheckpointsDeleted(ctx context.Context, deleteCheckpoints []uuid.UUID)
 	}
 
 	err := Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		if _, err := tx.NewUpdate().Model(&model.CheckpointV1{}).
-			Set("state = ?", model.DeletedState).
-			Where("uuid IN (?)", bun.In(deleteCheckpoints)).
-			Exec(ctx); err != nil {
-			return fmt.Errorf("deleting checkpoints from raw_checkpoints: %w", err)
-		}
-
-		if _, err := tx.NewUpdate().Model(&model.CheckpointV2{}).
-			Set("state = ?", model.DeletedState).
-			Where("uuid IN (?)", bun.In(deleteCheckpoints)).
-			Exec(ctx); err != nil {
-			return fmt.Errorf("deleting checkpoints from checkpoints_v2: %w", err)
-		}
-
-		if err := UpdateCheckpointSizeTx(ctx, tx, deleteCheckpoints); err != nil {
-			return fmt.Errorf("updating checkpoints size: %w", err)
-		}
-
-		return nil
+		return MarkCheckpointsDeletedTx(ctx, tx, deleteCheckpoints)
 	})
 	if err != nil {
 		return fmt.Errorf("error adding checkpoint metadata: %w", err)
@@ -109,6 +92,34 @@ func MarkCheckpointsDeleted(ctx context.Context, deleteCheckpoints []uuid.UUID)
 	return nil
 }
 
+// MarkCheckpointsDeletedTx updates the provided delete checkpoints to DELETED state in the given
+// transaction.
+func MarkCheckpointsDeletedTx(ctx context.Context, tx bun.Tx, deleteCheckpoints []uuid.UUID) error {
+	if len(deleteCheckpoints) == 0 {
+		return nil
+	}
+
+	if _, err := tx.NewUpdate().Model(&model.CheckpointV1{}).
+		Set("state = ?", model.DeletedState).
+		Where("uuid IN (?)", bun.In(deleteCheckpoints)).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("deleting checkpoints from raw_checkpoints: %w", err)
+	}
+
+	if _, err := tx.NewUpdate().Model(&model.CheckpointV2{}).
+		Set("state = ?", model.DeletedState).
+		Where("uuid IN (?)", bun.In(deleteCheckpoints)).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("deleting checkpoints from checkpoints_v2: %w", err)
+	}
+
+	if err := UpdateCheckpointSizeTx(ctx, tx, deleteCheckpoints); err != nil {
+		return fmt.Errorf("updating checkpoints size: %w", err)
+	}
+
+	return nil
+}
+
 // ExperimentCheckpointGrouping represents a mapping of checkpoint uuids to experiment id.
 type ExperimentCheckpointGrouping struct {
 	ExperimentID       int
@@ -146,7 +157,8 @@ func (db *PgDB) GroupCheckpointUUIDsByExperimentID(checkpoints []uuid.UUID) (
 	return groupeIDcUUIDS, nil
 }
 
-// UpdateCheckpointSizeTx updates checkpoint size and count to experiment and trial.
+// UpdateCheckpointSizeTx updates checkpoint size and count to experiment and trial, and rolls the
+// change up into checkpoint storage usage.
 func UpdateCheckpointSizeTx(ctx context.Context, idb bun.IDB, checkpoints []uuid.UUID) error {
 	if idb == nil {
 		idb = Bun()
@@ -203,5 +215,65 @@ RETURNING true`, bun.In(uniqueExpIDs)).Scan(ctx, &res)
 		return errors.Wrap(err, "errors updating experiment checkpoint sizes and counts")
 	}
 
+	if err := UpdateCheckpointStorageUsageTx(ctx, idb, uniqueExpIDs); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckpointsToVerify returns up to limit completed experiment checkpoints that have not been
+// verified yet, preferring those that have never been attempted and then the oldest.
+func CheckpointsToVerify(ctx context.Context, limit int) ([]model.CheckpointV2, error) {
+	var checkpoints []model.CheckpointV2
+	if err := Bun().NewSelect().Model(&checkpoints).
+		Where("state = ?", model.CompletedState).
+		Where("integrity = ?", model.CheckpointUnverified).
+		Where("task_id IN (SELECT task_id FROM trials)").
+		OrderExpr("integrity_checked_at ASC NULLS FIRST, report_time ASC").
+		Limit(limit).
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("getting checkpoints to verify: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// CheckpointV2ByUUID looks up a checkpoint from the `checkpoints_v2` table by UUID.
+func CheckpointV2ByUUID(ctx context.Context, id uuid.UUID) (*model.CheckpointV2, error) {
+	var checkpoint model.CheckpointV2
+	if err := Bun().NewSelect().Model(&checkpoint).Where("uuid = ?", id).Scan(ctx); err != nil {
+		return nil, MatchSentinelError(err)
+	}
+	return &checkpoint, nil
+}
+
+// SetCheckpointIntegrity records the outcome of verifying a checkpoint.
+func SetCheckpointIntegrity(
+	ctx context.Context, id uuid.UUID, integrity model.CheckpointIntegrity, problem *string,
+) error {
+	if _, err := Bun().NewUpdate().Table("checkpoints_v2").
+		Set("integrity = ?", integrity).
+		Set("integrity_checked_at = ?", time.Now().UTC()).
+		Set("integrity_error = ?", problem).
+		Where("uuid = ?", id).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("setting integrity of checkpoint %s: %w", id, err)
+	}
 	return nil
 }
+
+// CheckpointIntegrityByUUID returns the recorded integrity of a checkpoint. Legacy checkpoints,
+// which cannot be verified, are reported as unverified.
+func CheckpointIntegrityByUUID(
+	ctx context.Context, id uuid.UUID,
+) (*model.CheckpointIntegrityStatus, error) {
+	status := model.CheckpointIntegrityStatus{UUID: id, Integrity: model.CheckpointUnverified}
+	err := Bun().NewSelect().Table("checkpoints_v2").
+		Column("uuid", "integrity", "integrity_checked_at", "integrity_error").
+		Where("uuid = ?", id).
+		Scan(ctx, &status)
+	if err != nil && MatchSentinelError(err) != ErrNotFound {
+		return nil, fmt.Errorf("getting integrity of checkpoint %s: %w", id, err)
+	}
+	return &status, nil
+}
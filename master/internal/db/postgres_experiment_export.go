@@ -0,0 +1,264 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// experimentImportBatchSize bounds the number of rows inserted per statement during an import.
+const experimentImportBatchSize = 1000
+
+// ExportExperiment collects the rows that make up the history of an experiment: the experiment
+// itself, its trials, their unarchived metrics and their checkpoints.
+func ExportExperiment(ctx context.Context, id int) (*model.ExperimentExport, error) {
+	export := &model.ExperimentExport{
+		FormatVersion: model.ExperimentExportFormatVersion,
+		Trials:        []model.TrialExportRecord{},
+		Metrics:       []model.MetricsExportRecord{},
+		Checkpoints:   []model.CheckpointExportRecord{},
+	}
+	err := Bun().NewSelect().Model(&export.Experiment).Where("id = ?", id).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error exporting experiment %d", id)
+	}
+
+	if err := Bun().NewSelect().Model(&export.Trials).
+		Where("experiment_id = ?", id).
+		Order("id").
+		Scan(ctx); err != nil {
+		return nil, errors.Wrapf(err, "error exporting trials of experiment %d", id)
+	}
+
+	if err := Bun().NewSelect().Model(&export.Metrics).
+		Where("trial_id IN (SELECT id FROM trials WHERE experiment_id = ?)", id).
+		Where("archived = false").
+		Order("id").
+		Scan(ctx); err != nil {
+		return nil, errors.Wrapf(err, "error exporting metrics of experiment %d", id)
+	}
+
+	if err := Bun().NewSelect().Model(&export.Checkpoints).
+		Column("uuid", "task_id", "report_time", "state", "resources", "metadata", "size",
+			"checksums", "storage").
+		ColumnExpr("t.id AS trial_id").
+		Join("JOIN trials t ON t.task_id = c.task_id").
+		Where("t.experiment_id = ?", id).
+		Order("c.report_time").
+		Scan(ctx); err != nil {
+		return nil, errors.Wrapf(err, "error exporting checkpoints of experiment %d", id)
+	}
+
+	return export, nil
+}
+
+// ImportExperiment recreates an exported experiment in the given project, owned by the given user.
+// Every experiment, trial, task, metric and checkpoint gets a new ID; the returned result maps
+// the IDs in the export to the new ones. The rows are inserted in the caller's transaction, so
+// that the import is rolled back if restoring the rest of the export fails.
+func ImportExperiment(
+	ctx context.Context,
+	tx bun.Tx,
+	export *model.ExperimentExport,
+	projectID int,
+	owner model.UserID,
+) (*model.ExperimentImportResult, error) {
+	if export.FormatVersion != model.ExperimentExportFormatVersion {
+		return nil, errors.Wrapf(ErrInvalidInput,
+			"unsupported experiment export format version %d", export.FormatVersion)
+	}
+	if !model.TerminalStates[export.Experiment.State] {
+		return nil, errors.Wrapf(ErrInvalidInput,
+			"cannot import experiment in non-terminal state %s", export.Experiment.State)
+	}
+
+	result := &model.ExperimentImportResult{
+		TrialIDs:    map[int]int{},
+		Checkpoints: map[uuid.UUID]uuid.UUID{},
+	}
+	exp := export.Experiment
+	exp.ID = 0
+	exp.JobID = model.NewJobID()
+	exp.ProjectID = projectID
+	exp.OwnerID = &owner
+
+	job := model.Job{JobID: exp.JobID, JobType: model.JobTypeExperiment, OwnerID: &owner}
+	if _, err := tx.NewInsert().Model(&job).Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "error inserting job")
+	}
+	if _, err := tx.NewInsert().Model(&exp).Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "error inserting experiment")
+	}
+	result.ExperimentID = exp.ID
+	if err := AddProjectHyperparameters(
+		ctx, tx, int32(projectID), []int32{int32(exp.ID)}); err != nil {
+		return nil, errors.Wrap(err, "error updating hyperparameters")
+	}
+
+	taskIDs, err := importTrials(ctx, tx, exp, export.Trials, result.TrialIDs)
+	if err != nil {
+		return nil, err
+	}
+	metricIDs, err := importMetrics(ctx, tx, export.Metrics, result.TrialIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = remapTrialValidations(ctx, tx, export.Trials, result.TrialIDs, metricIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = importCheckpoints(ctx, tx, export.Checkpoints, taskIDs, result.Checkpoints)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importTrials inserts a task and a trial for each exported trial, recording the new trial IDs
+// in trialIDs and returning the new task ID of each old trial ID.
+func importTrials(
+	ctx context.Context,
+	tx bun.Tx,
+	exp model.ExperimentExportRecord,
+	trials []model.TrialExportRecord,
+	trialIDs map[int]int,
+) (map[int]model.TaskID, error) {
+	taskIDs := map[int]model.TaskID{}
+	for _, tr := range trials {
+		oldID := tr.ID
+		tr.ID = 0
+		tr.ExperimentID = exp.ID
+		tr.TaskID = model.NewTaskID()
+		// Validation IDs refer to metrics rows which are only known once those are imported.
+		tr.LatestValidationID, tr.BestValidationID = nil, nil
+
+		task := model.Task{
+			TaskID:     tr.TaskID,
+			JobID:      &exp.JobID,
+			TaskType:   model.TaskTypeTrial,
+			StartTime:  tr.StartTime,
+			EndTime:    tr.EndTime,
+			LogVersion: model.CurrentTaskLogVersion,
+		}
+		if _, err := tx.NewInsert().Model(&task).Exec(ctx); err != nil {
+			return nil, errors.Wrapf(err, "error inserting task for trial %d", oldID)
+		}
+		if _, err := tx.NewInsert().Model(&tr).Exec(ctx); err != nil {
+			return nil, errors.Wrapf(err, "error inserting trial %d", oldID)
+		}
+		trialIDs[oldID] = tr.ID
+		taskIDs[oldID] = tr.TaskID
+	}
+	return taskIDs, nil
+}
+
+// importMetrics inserts the exported metrics under their new trial IDs and returns the new ID of
+// each old metrics row ID.
+func importMetrics(
+	ctx context.Context, tx bun.Tx, metrics []model.MetricsExportRecord, trialIDs map[int]int,
+) (map[int]int, error) {
+	metricIDs := map[int]int{}
+	for start := 0; start < len(metrics); start += experimentImportBatchSize {
+		end := start + experimentImportBatchSize
+		if end > len(metrics) {
+			end = len(metrics)
+		}
+
+		oldIDs := make([]int, 0, end-start)
+		batch := make([]model.MetricsExportRecord, 0, end-start)
+		for _, m := range metrics[start:end] {
+			trialID, ok := trialIDs[m.TrialID]
+			if !ok {
+				return nil, errors.Wrapf(ErrInvalidInput,
+					"metrics %d belong to unknown trial %d", m.ID, m.TrialID)
+			}
+			oldIDs = append(oldIDs, m.ID)
+			m.ID = 0
+			m.TrialID = trialID
+			batch = append(batch, m)
+		}
+		if _, err := tx.NewInsert().Model(&batch).Exec(ctx); err != nil {
+			return nil, errors.Wrap(err, "error inserting metrics")
+		}
+		for i, m := range batch {
+			metricIDs[oldIDs[i]] = m.ID
+		}
+	}
+	return metricIDs, nil
+}
+
+func remapTrialValidations(
+	ctx context.Context,
+	tx bun.Tx,
+	trials []model.TrialExportRecord,
+	trialIDs map[int]int,
+	metricIDs map[int]int,
+) error {
+	remap := func(id *int) *int {
+		if id == nil {
+			return nil
+		}
+		if newID, ok := metricIDs[*id]; ok {
+			return &newID
+		}
+		return nil
+	}
+	for _, tr := range trials {
+		latest, best := remap(tr.LatestValidationID), remap(tr.BestValidationID)
+		if latest == nil && best == nil {
+			continue
+		}
+		if _, err := tx.NewUpdate().Table("trials").
+			Set("latest_validation_id = ?", latest).
+			Set("best_validation_id = ?", best).
+			Where("id = ?", trialIDs[tr.ID]).
+			Exec(ctx); err != nil {
+			return errors.Wrapf(err, "error updating validations of trial %d", trialIDs[tr.ID])
+		}
+	}
+	return nil
+}
+
+// importCheckpoints inserts the exported checkpoints under new UUIDs, recording the mapping in
+// uuids. Callers restore the checkpoint files under the new UUIDs, or mark the checkpoints deleted.
+func importCheckpoints(
+	ctx context.Context,
+	tx bun.Tx,
+	checkpoints []model.CheckpointExportRecord,
+	taskIDs map[int]model.TaskID,
+	uuids map[uuid.UUID]uuid.UUID,
+) error {
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	newUUIDs := make([]uuid.UUID, 0, len(checkpoints))
+	batch := make([]model.CheckpointExportRecord, 0, len(checkpoints))
+	for _, c := range checkpoints {
+		taskID, ok := taskIDs[c.TrialID]
+		if !ok {
+			return errors.Wrapf(ErrInvalidInput,
+				"checkpoint %s belongs to unknown trial %d", c.UUID, c.TrialID)
+		}
+		newUUID := uuid.New()
+		uuids[c.UUID] = newUUID
+		newUUIDs = append(newUUIDs, newUUID)
+
+		c.ID = 0
+		c.UUID = newUUID
+		c.TaskID = taskID
+		// The files are restored into the experiment's storage, never where the archive says.
+		c.Storage = nil
+		batch = append(batch, c)
+	}
+	if _, err := tx.NewInsert().Model(&batch).Exec(ctx); err != nil {
+		return errors.Wrap(err, "error inserting checkpoints")
+	}
+	return UpdateCheckpointSizeTx(ctx, tx, newUUIDs)
+}
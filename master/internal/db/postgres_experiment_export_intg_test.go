@@ -0,0 +1,93 @@
+//go:build integration
+// +build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/commonv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func TestExportImportExperiment(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, etc.SetRootPath(RootFromDB))
+	db := MustResolveTestPostgres(t)
+	MustMigrateTestPostgres(t, db, MigrationsFromDB)
+
+	user := RequireMockUser(t, db)
+	exp := RequireMockExperiment(t, db, user)
+	tr := RequireMockTrial(t, db, exp)
+	a := RequireMockAllocation(t, db, tr.TaskID)
+
+	require.NoError(t, db.AddValidationMetrics(ctx, &trialv1.TrialMetrics{
+		TrialId:        int32(tr.ID),
+		StepsCompleted: 10,
+		Metrics: &commonv1.Metrics{
+			AvgMetrics: &structpb.Struct{Fields: map[string]*structpb.Value{
+				defaultSearcherMetric: structpb.NewNumberValue(0.5),
+			}},
+			BatchMetrics: []*structpb.Struct{},
+		},
+	}))
+	ckpt := MockModelCheckpoint(uuid.New(), tr, a)
+	require.NoError(t, AddCheckpointMetadata(ctx, &ckpt))
+
+	// The export records the storage a checkpoint was copied to.
+	storage := `{"type": "shared_fs", "host_path": "/tmp/copied"}`
+	_, err := Bun().NewUpdate().Table("checkpoints_v2").
+		Set("storage = ?::jsonb", storage).
+		Where("uuid = ?", ckpt.UUID).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	export, err := ExportExperiment(ctx, exp.ID)
+	require.NoError(t, err)
+	require.Len(t, export.Trials, 1)
+	require.Len(t, export.Metrics, 1)
+	require.Len(t, export.Checkpoints, 1)
+	require.Equal(t, tr.ID, export.Checkpoints[0].TrialID)
+	require.JSONEq(t, storage, string(export.Checkpoints[0].Storage))
+
+	importExperiment := func() (result *model.ExperimentImportResult, err error) {
+		err = Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			result, err = ImportExperiment(ctx, tx, export, exp.ProjectID, user.ID)
+			return err
+		})
+		return result, err
+	}
+
+	// Only finished experiments can be imported.
+	_, err = importExperiment()
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	export.Experiment.State = model.CompletedState
+	result, err := importExperiment()
+	require.NoError(t, err)
+	require.NotEqual(t, exp.ID, result.ExperimentID)
+	require.Contains(t, result.TrialIDs, tr.ID)
+	require.NotEqual(t, tr.ID, result.TrialIDs[tr.ID])
+	require.Contains(t, result.Checkpoints, ckpt.UUID)
+
+	reexport, err := ExportExperiment(ctx, result.ExperimentID)
+	require.NoError(t, err)
+	require.Len(t, reexport.Trials, 1)
+	require.Len(t, reexport.Metrics, 1)
+	require.Len(t, reexport.Checkpoints, 1)
+	require.Equal(t, result.TrialIDs[tr.ID], reexport.Metrics[0].TrialID)
+	require.Equal(t, result.Checkpoints[ckpt.UUID], reexport.Checkpoints[0].UUID)
+	require.Nil(t, reexport.Checkpoints[0].Storage)
+	require.Equal(t, export.Experiment.ModelDefinition, reexport.Experiment.ModelDefinition)
+	if export.Trials[0].LatestValidationID != nil {
+		require.Equal(t, &reexport.Metrics[0].ID, reexport.Trials[0].LatestValidationID)
+	}
+}
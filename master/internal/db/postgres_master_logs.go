@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+// masterLog corresponds to a row in the "master_logs" DB table.
+type masterLog struct {
+	bun.BaseModel `bun:"table:master_logs"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	Time      time.Time `bun:"time"`
+	Level     int       `bun:"level"`
+	Component *string   `bun:"component"`
+	Message   string    `bun:"message"`
+}
+
+// AddMasterLogs persists master log entries.
+func AddMasterLogs(ctx context.Context, entries []*logger.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	rows := make([]masterLog, 0, len(entries))
+	for _, e := range entries {
+		row := masterLog{Time: e.Time, Level: int(e.Level), Message: e.Message}
+		if e.Component != "" {
+			component := e.Component
+			row.Component = &component
+		}
+		rows = append(rows, row)
+	}
+	if _, err := Bun().NewInsert().Model(&rows).Exec(ctx); err != nil {
+		return errors.Wrap(err, "adding master logs")
+	}
+	return nil
+}
+
+// MasterLogs returns the newest limit persisted master logs that match the filter, oldest first,
+// or the oldest limit of them if the filter has an AfterID.
+func MasterLogs(ctx context.Context, filter logger.EntryFilter, limit int) ([]*logger.Entry, error) {
+	var rows []masterLog
+	order := "id DESC"
+	if filter.AfterID != nil {
+		order = "id ASC"
+	}
+	q := Bun().NewSelect().Model(&rows).Order(order).Limit(limit)
+	if filter.AfterID != nil {
+		q = q.Where("id > ?", *filter.AfterID)
+	}
+	if filter.Level != nil {
+		q = q.Where("level <= ?", int(*filter.Level))
+	}
+	if filter.Component != "" {
+		q = q.Where("component = ?", filter.Component)
+	}
+	if filter.Since != nil {
+		q = q.Where("time >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		q = q.Where("time < ?", *filter.Until)
+	}
+	if filter.Text != "" {
+		q = q.Where("strpos(lower(message), lower(?)) > 0", filter.Text)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, errors.Wrap(err, "querying master logs")
+	}
+
+	entries := make([]*logger.Entry, len(rows))
+	for i, row := range rows {
+		e := &logger.Entry{
+			ID:      row.ID,
+			Time:    row.Time,
+			Level:   logrus.Level(row.Level),
+			Message: row.Message,
+		}
+		if row.Component != nil {
+			e.Component = *row.Component
+		}
+		if filter.AfterID != nil {
+			entries[i] = e
+		} else {
+			entries[len(rows)-1-i] = e
+		}
+	}
+	return entries, nil
+}
+
+// DeleteMasterLogsBefore deletes persisted master logs older than t.
+func DeleteMasterLogsBefore(ctx context.Context, t time.Time) (int64, error) {
+	res, err := Bun().NewDelete().Table("master_logs").Where("time < ?", t).Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "deleting master logs")
+	}
+	return res.RowsAffected()
+}
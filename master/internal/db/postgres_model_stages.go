@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func selectModelVersionTransitions(idb bun.IDB) *bun.SelectQuery {
+	return idb.NewSelect().Model((*model.ModelVersionTransition)(nil)).
+		ColumnExpr("mvt.*").
+		ColumnExpr("mv.model_id, mv.version AS model_version_num").
+		ColumnExpr("ru.username AS requested_by_username").
+		ColumnExpr("vu.username AS reviewed_by_username").
+		Join("JOIN model_versions mv ON mv.id = mvt.model_version_id").
+		Join("JOIN users ru ON ru.id = mvt.requested_by").
+		Join("LEFT JOIN users vu ON vu.id = mvt.reviewed_by")
+}
+
+// lockModelVersionStage returns the current stage of a model version and locks its row until the
+// transaction ends.
+func lockModelVersionStage(
+	ctx context.Context, tx bun.Tx, modelVersionID int,
+) (model.ModelVersionStage, error) {
+	var stage model.ModelVersionStage
+	err := tx.NewSelect().Table("model_versions").Column("stage").
+		Where("id = ?", modelVersionID).
+		For("UPDATE").
+		Scan(ctx, &stage)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return stage, err
+}
+
+// setModelVersionStage moves a model version to a stage. A model has at most one version in
+// production, so moving a version there fails while another version of its model is in it.
+func setModelVersionStage(
+	ctx context.Context, tx bun.Tx, modelVersionID int, stage model.ModelVersionStage,
+) error {
+	if stage == model.ModelVersionStageProduction {
+		var versions []int
+		err := tx.NewSelect().Table("model_versions").Column("version").
+			Where("model_id = (SELECT model_id FROM model_versions WHERE id = ?)", modelVersionID).
+			Where("id != ?", modelVersionID).
+			Where("stage = ?", model.ModelVersionStageProduction).
+			Scan(ctx, &versions)
+		if err != nil {
+			return err
+		}
+		if len(versions) > 0 {
+			return errors.Wrapf(ErrInvalidInput,
+				"version %d of the model is already in production; move it out of production first",
+				versions[0])
+		}
+	}
+
+	_, err := tx.NewUpdate().Table("model_versions").
+		Set("stage = ?", stage).
+		Set("stage_changed_at = now()").
+		Set("last_updated_time = now()").
+		Where("id = ?", modelVersionID).
+		Exec(ctx)
+	return err
+}
+
+// TransitionModelVersionStage records a request by user to move a model version to stage to. The
+// move is applied immediately unless it is to production and requireApproval is set, in which
+// case the transition stays pending until another user reviews it.
+func TransitionModelVersionStage(
+	ctx context.Context,
+	modelVersionID int,
+	to model.ModelVersionStage,
+	user model.UserID,
+	comment string,
+	requireApproval bool,
+) (*model.ModelVersionTransition, error) {
+	t := &model.ModelVersionTransition{
+		ModelVersionID: modelVersionID,
+		ToStage:        to,
+		Status:         model.ModelVersionTransitionApplied,
+		RequestedBy:    user,
+		RequestedAt:    time.Now().UTC(),
+		Comment:        comment,
+	}
+	if requireApproval && to == model.ModelVersionStageProduction {
+		t.Status = model.ModelVersionTransitionPending
+	}
+
+	err := Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		from, err := lockModelVersionStage(ctx, tx, modelVersionID)
+		if err != nil {
+			return err
+		}
+		if !from.CanTransitionTo(to) {
+			return errors.Wrapf(ErrInvalidInput,
+				"model version cannot move from stage %s to %s", from, to)
+		}
+		t.FromStage = from
+
+		pending, err := tx.NewSelect().Model((*model.ModelVersionTransition)(nil)).
+			Where("model_version_id = ?", modelVersionID).
+			Where("status = ?", model.ModelVersionTransitionPending).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return errors.Wrap(ErrInvalidInput,
+				"model version already has a transition waiting for approval")
+		}
+
+		if _, err := tx.NewInsert().Model(t).Exec(ctx); err != nil {
+			return errors.Wrap(err, "error recording model version transition")
+		}
+		if t.Status == model.ModelVersionTransitionApplied {
+			return setModelVersionStage(ctx, tx, modelVersionID, to)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ModelVersionTransitionByID(ctx, t.ID)
+}
+
+// ReviewModelVersionTransition approves or rejects a pending transition. A transition cannot be
+// reviewed by the user who requested it, nor approved if the model version has since moved to
+// another stage.
+func ReviewModelVersionTransition(
+	ctx context.Context, transitionID int, reviewer model.UserID, approve bool, comment string,
+) (*model.ModelVersionTransition, error) {
+	err := Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var t model.ModelVersionTransition
+		err := tx.NewSelect().Model(&t).Where("id = ?", transitionID).For("UPDATE").Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		if t.Status != model.ModelVersionTransitionPending {
+			return errors.Wrapf(ErrInvalidInput, "transition %d is already %s", t.ID, t.Status)
+		}
+		if t.RequestedBy == reviewer {
+			return errors.Wrapf(ErrInvalidInput,
+				"transition %d must be reviewed by a user other than its requester", t.ID)
+		}
+
+		t.Status = model.ModelVersionTransitionRejected
+		if approve {
+			stage, err := lockModelVersionStage(ctx, tx, t.ModelVersionID)
+			if err != nil {
+				return err
+			}
+			if stage != t.FromStage {
+				return errors.Wrapf(ErrInvalidInput,
+					"model version moved to stage %s after transition %d was requested",
+					stage, t.ID)
+			}
+			if err := setModelVersionStage(ctx, tx, t.ModelVersionID, t.ToStage); err != nil {
+				return err
+			}
+			t.Status = model.ModelVersionTransitionApplied
+		}
+
+		_, err = tx.NewUpdate().Model(&t).
+			Set("status = ?", t.Status).
+			Set("reviewed_by = ?", reviewer).
+			Set("reviewed_at = now()").
+			Set("review_comment = ?", comment).
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ModelVersionTransitionByID(ctx, transitionID)
+}
+
+// ModelVersionTransitionByID returns a single model version transition.
+func ModelVersionTransitionByID(
+	ctx context.Context, id int,
+) (*model.ModelVersionTransition, error) {
+	var t model.ModelVersionTransition
+	err := selectModelVersionTransitions(Bun()).Where("mvt.id = ?", id).Scan(ctx, &t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &t, err
+}
+
+// ModelVersionTransitions returns the transition history of a model version, oldest first.
+func ModelVersionTransitions(
+	ctx context.Context, modelVersionID int,
+) ([]model.ModelVersionTransition, error) {
+	transitions := []model.ModelVersionTransition{}
+	err := selectModelVersionTransitions(Bun()).
+		Where("mvt.model_version_id = ?", modelVersionID).
+		Order("mvt.requested_at ASC", "mvt.id ASC").
+		Scan(ctx, &transitions)
+	return transitions, err
+}
+
+// ModelVersionStageByID returns the stage of a model version and when it entered that stage.
+func ModelVersionStageByID(
+	ctx context.Context, modelVersionID int,
+) (model.ModelVersionStage, *time.Time, error) {
+	var res struct {
+		Stage          model.ModelVersionStage `bun:"stage"`
+		StageChangedAt *time.Time              `bun:"stage_changed_at"`
+	}
+	err := Bun().NewSelect().Table("model_versions").
+		Column("stage", "stage_changed_at").
+		Where("id = ?", modelVersionID).
+		Scan(ctx, &res)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, ErrNotFound
+	}
+	return res.Stage, res.StageChangedAt, err
+}
+
+// CurrentModelVersionInStage returns the version number of the model version that most recently
+// entered the given stage.
+func CurrentModelVersionInStage(
+	ctx context.Context, modelID int, stage model.ModelVersionStage,
+) (int, error) {
+	var version int
+	err := Bun().NewSelect().Table("model_versions").Column("version").
+		Where("model_id = ?", modelID).
+		Where("stage = ?", stage).
+		OrderExpr("stage_changed_at DESC NULLS LAST, version DESC").
+		Limit(1).
+		Scan(ctx, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return version, err
+}
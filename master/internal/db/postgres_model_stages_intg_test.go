@@ -0,0 +1,108 @@
+//go:build integration
+// +build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/modelv1"
+)
+
+func TestModelVersionStages(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, etc.SetRootPath(RootFromDB))
+	db := MustResolveTestPostgres(t)
+	MustMigrateTestPostgres(t, db, MigrationsFromDB)
+
+	user := RequireMockUser(t, db)
+	reviewer := RequireMockUser(t, db)
+	exp := RequireMockExperiment(t, db, user)
+	tr := RequireMockTrial(t, db, exp)
+	a := RequireMockAllocation(t, db, tr.TaskID)
+
+	var pmdl modelv1.Model
+	require.NoError(t, db.QueryProto(
+		"insert_model", &pmdl, uuid.NewString(), "", emptyMetadata, "", "", user.ID, 1,
+	))
+	var versions []*modelv1.ModelVersion
+	for i := 0; i < 2; i++ {
+		ckpt := MockModelCheckpoint(uuid.New(), tr, a)
+		require.NoError(t, AddCheckpointMetadata(ctx, &ckpt))
+		var mv modelv1.ModelVersion
+		require.NoError(t, db.QueryProto(
+			"insert_model_version", &mv, pmdl.Id, ckpt.UUID, "", "", emptyMetadata, "", "", user.ID,
+		))
+		versions = append(versions, &mv)
+	}
+	first, second := int(versions[0].Id), int(versions[1].Id)
+
+	// Disallowed transitions are refused.
+	_, err := TransitionModelVersionStage(
+		ctx, first, model.ModelVersionStageProduction, user.ID, "", false)
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	// Without approval, transitions apply immediately.
+	tn, err := TransitionModelVersionStage(
+		ctx, first, model.ModelVersionStageStaging, user.ID, "looks good", false)
+	require.NoError(t, err)
+	require.Equal(t, model.ModelVersionTransitionApplied, tn.Status)
+	require.Equal(t, model.ModelVersionStageNone, tn.FromStage)
+	require.Equal(t, user.Username, tn.RequestedByUsername)
+	_, err = TransitionModelVersionStage(
+		ctx, first, model.ModelVersionStageProduction, user.ID, "", false)
+	require.NoError(t, err)
+
+	version, err := CurrentModelVersionInStage(ctx, int(pmdl.Id), model.ModelVersionStageProduction)
+	require.NoError(t, err)
+	require.Equal(t, int(versions[0].Version), version)
+
+	// Promotions to production wait for a second user when approval is required.
+	_, err = TransitionModelVersionStage(
+		ctx, second, model.ModelVersionStageStaging, user.ID, "", true)
+	require.NoError(t, err)
+	tn, err = TransitionModelVersionStage(
+		ctx, second, model.ModelVersionStageProduction, user.ID, "", true)
+	require.NoError(t, err)
+	require.Equal(t, model.ModelVersionTransitionPending, tn.Status)
+	stage, _, err := ModelVersionStageByID(ctx, second)
+	require.NoError(t, err)
+	require.Equal(t, model.ModelVersionStageStaging, stage)
+
+	_, err = TransitionModelVersionStage(
+		ctx, second, model.ModelVersionStageArchived, user.ID, "", true)
+	require.ErrorIs(t, err, ErrInvalidInput, "only one pending transition at a time")
+	_, err = ReviewModelVersionTransition(ctx, tn.ID, user.ID, true, "")
+	require.ErrorIs(t, err, ErrInvalidInput, "requester cannot approve")
+
+	// Only one version of a model can be in production.
+	_, err = ReviewModelVersionTransition(ctx, tn.ID, reviewer.ID, true, "")
+	require.ErrorIs(t, err, ErrInvalidInput, "first version is still in production")
+	_, err = TransitionModelVersionStage(
+		ctx, first, model.ModelVersionStageArchived, user.ID, "", true)
+	require.NoError(t, err)
+
+	tn, err = ReviewModelVersionTransition(ctx, tn.ID, reviewer.ID, true, "approved")
+	require.NoError(t, err)
+	require.Equal(t, model.ModelVersionTransitionApplied, tn.Status)
+	require.NotNil(t, tn.ReviewedByUsername)
+	require.Equal(t, reviewer.Username, *tn.ReviewedByUsername)
+
+	version, err = CurrentModelVersionInStage(ctx, int(pmdl.Id), model.ModelVersionStageProduction)
+	require.NoError(t, err)
+	require.Equal(t, int(versions[1].Version), version)
+	version, err = CurrentModelVersionInStage(ctx, int(pmdl.Id), model.ModelVersionStageArchived)
+	require.NoError(t, err)
+	require.Equal(t, int(versions[0].Version), version)
+
+	history, err := ModelVersionTransitions(ctx, second)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, model.ModelVersionStageProduction, history[1].ToStage)
+}
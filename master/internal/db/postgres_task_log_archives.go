@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// TasksToArchiveLogs returns up to limit tasks that ended before endedBefore and still have all
+// of their logs in Postgres, oldest first.
+func TasksToArchiveLogs(
+	ctx context.Context, endedBefore time.Time, limit int,
+) ([]model.TaskID, error) {
+	var taskIDs []model.TaskID
+	if err := Bun().NewSelect().Table("tasks").
+		Column("task_id").
+		Where("end_time < ?", endedBefore).
+		Where("NOT EXISTS (SELECT 1 FROM task_log_archives a WHERE a.task_id = tasks.task_id)").
+		Where("EXISTS (SELECT 1 FROM task_logs l WHERE l.task_id = tasks.task_id)").
+		Order("end_time").
+		Limit(limit).
+		Scan(ctx, &taskIDs); err != nil {
+		return nil, errors.Wrap(err, "error getting tasks to archive logs of")
+	}
+	return taskIDs, nil
+}
+
+// AddTaskLogArchive records an archive of a task's logs and, in the same transaction, deletes
+// the archived logs from Postgres.
+func AddTaskLogArchive(ctx context.Context, archive *model.TaskLogArchive) error {
+	return Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		archive.ArchivedAt = time.Now().UTC()
+		if _, err := tx.NewInsert().Model(archive).Exec(ctx); err != nil {
+			return errors.Wrapf(err, "error recording log archive of task %s", archive.TaskID)
+		}
+		if _, err := tx.NewDelete().Table("task_logs").
+			Where("task_id = ?", archive.TaskID).
+			Where("id <= ?", archive.MaxLogID).
+			Exec(ctx); err != nil {
+			return errors.Wrapf(err, "error deleting archived logs of task %s", archive.TaskID)
+		}
+		return nil
+	})
+}
+
+// TaskLogArchiveByTaskID returns the log archive of a task, or ErrNotFound if its logs have not
+// been archived.
+func TaskLogArchiveByTaskID(
+	ctx context.Context, taskID model.TaskID,
+) (*model.TaskLogArchive, error) {
+	var archive model.TaskLogArchive
+	err := Bun().NewSelect().Model(&archive).Where("task_id = ?", taskID).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error getting log archive of task %s", taskID)
+	}
+	return &archive, nil
+}
+
+// DeleteTaskLogArchives forgets the log archives of the given tasks, returning those that
+// existed so that their objects can be removed from storage.
+func DeleteTaskLogArchives(
+	ctx context.Context, taskIDs []model.TaskID,
+) ([]model.TaskLogArchive, error) {
+	var archives []model.TaskLogArchive
+	if len(taskIDs) == 0 {
+		return archives, nil
+	}
+	if _, err := Bun().NewDelete().Model(&archives).
+		Where("task_id IN (?)", bun.In(taskIDs)).
+		Returning("*").
+		Exec(ctx); err != nil {
+		return nil, errors.Wrapf(err, "error deleting log archives of tasks %v", taskIDs)
+	}
+	return archives, nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// taskEndedCondition holds for a task that has ended and has no allocations still open, so that
+// log retention never touches the logs of a task that is running or could still write logs.
+const taskEndedCondition = `EXISTS (
+    SELECT 1 FROM tasks WHERE tasks.task_id = ?0 AND tasks.end_time IS NOT NULL
+) AND NOT EXISTS (
+    SELECT 1 FROM allocations a WHERE a.task_id = ?0 AND a.end_time IS NULL
+)`
+
+// TaskLogRetentionCandidate is an ended task with logs in Postgres that a log retention policy
+// may apply to.
+type TaskLogRetentionCandidate struct {
+	TaskID   model.TaskID   `bun:"task_id"`
+	TaskType model.TaskType `bun:"task_type"`
+	EndTime  time.Time      `bun:"end_time"`
+	// WorkspaceName is the workspace of the experiment of a trial, or nil for other tasks.
+	WorkspaceName *string `bun:"workspace_name"`
+	// LogRetentionMaxLines is the line limit the logs of the task were last trimmed to.
+	LogRetentionMaxLines *int `bun:"log_retention_max_lines"`
+}
+
+// TaskLogRetentionCandidates returns up to limit ended tasks without open allocations after
+// afterTaskID, in task ID order, that still have logs and either ended before endedBefore or
+// have not been trimmed to maxLines or fewer lines. Either bound may be nil to not select tasks
+// by it.
+func TaskLogRetentionCandidates(
+	ctx context.Context, afterTaskID model.TaskID, endedBefore *time.Time, maxLines *int, limit int,
+) ([]TaskLogRetentionCandidate, error) {
+	var candidates []TaskLogRetentionCandidate
+	if endedBefore == nil && maxLines == nil {
+		return candidates, nil
+	}
+	if err := Bun().NewSelect().
+		TableExpr("tasks tk").
+		Join("LEFT JOIN trials t ON t.task_id = tk.task_id").
+		Join("LEFT JOIN experiments e ON e.id = t.experiment_id").
+		Join("LEFT JOIN projects p ON p.id = e.project_id").
+		Join("LEFT JOIN workspaces w ON w.id = p.workspace_id").
+		ColumnExpr("tk.task_id, tk.task_type, tk.end_time, tk.log_retention_max_lines").
+		ColumnExpr("w.name AS workspace_name").
+		Where("tk.task_id > ?", afterTaskID).
+		Where("tk.end_time IS NOT NULL").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			if endedBefore != nil {
+				q = q.WhereOr("tk.end_time < ?", *endedBefore)
+			}
+			if maxLines != nil {
+				q = q.WhereOr("tk.log_retention_max_lines IS NULL").
+					WhereOr("tk.log_retention_max_lines > ?", *maxLines)
+			}
+			return q
+		}).
+		Where(`NOT EXISTS (
+    SELECT 1 FROM allocations a WHERE a.task_id = tk.task_id AND a.end_time IS NULL
+)`).
+		Where("EXISTS (SELECT 1 FROM task_logs l WHERE l.task_id = tk.task_id)").
+		Order("tk.task_id").
+		Limit(limit).
+		Scan(ctx, &candidates); err != nil {
+		return nil, errors.Wrap(err, "error getting tasks to apply log retention to")
+	}
+	return candidates, nil
+}
+
+// DeleteEndedTaskLogs deletes up to batchSize of the oldest logs of a task, keeping its newest
+// keep lines, and returns how many were deleted. Nothing is deleted unless the task has ended and
+// none of its allocations are open. Deleting in small batches keeps each transaction short.
+func DeleteEndedTaskLogs(
+	ctx context.Context, taskID model.TaskID, keep, batchSize int,
+) (int64, error) {
+	res, err := Bun().NewDelete().TableExpr("task_logs").
+		Where("id IN (?)", Bun().NewSelect().
+			Table("task_logs").
+			Column("id").
+			Where("task_id = ?", taskID).
+			Where(taskEndedCondition, taskID).
+			Order("id DESC").
+			Offset(keep).
+			Limit(batchSize)).
+		Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error deleting logs of task %s", taskID)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "error counting deleted logs of task %s", taskID)
+	}
+	return n, nil
+}
+
+// SetTaskLogRetentionMaxLines records that the logs of a task were trimmed to maxLines lines.
+func SetTaskLogRetentionMaxLines(ctx context.Context, taskID model.TaskID, maxLines int) error {
+	if _, err := Bun().NewUpdate().Table("tasks").
+		Set("log_retention_max_lines = ?", maxLines).
+		Where("task_id = ?", taskID).
+		Exec(ctx); err != nil {
+		return errors.Wrapf(err, "error recording log retention of task %s", taskID)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// TrialLogSearchScopeQuery returns a query for the task, trial and experiment IDs of the trials
+// whose logs a task log search covers. Every scope that is set narrows the trials, and since and
+// until skip trials whose tasks ran entirely outside of that time range. The query exposes
+// "workspace_id" so that it can be filtered by experiment permissions.
+func TrialLogSearchScopeQuery(
+	experimentID, projectID, userID *int, since, until *time.Time,
+) *bun.SelectQuery {
+	q := Bun().NewSelect().
+		TableExpr("trials t").
+		Join("JOIN tasks tk ON tk.task_id = t.task_id").
+		Join("JOIN experiments e ON e.id = t.experiment_id").
+		Join("JOIN projects p ON p.id = e.project_id").
+		ColumnExpr("t.task_id").
+		ColumnExpr("t.id AS trial_id").
+		ColumnExpr("e.id AS experiment_id").
+		ColumnExpr("p.workspace_id")
+	if experimentID != nil {
+		q = q.Where("e.id = ?", *experimentID)
+	}
+	if projectID != nil {
+		q = q.Where("e.project_id = ?", *projectID)
+	}
+	if userID != nil {
+		q = q.Where("e.owner_id = ?", *userID)
+	}
+	if since != nil {
+		q = q.Where("tk.end_time IS NULL OR tk.end_time > ?", *since)
+	}
+	if until != nil {
+		q = q.Where("tk.start_time <= ?", *until)
+	}
+	return q
+}
+
+// SearchTaskLogs returns the newest logs of the given tasks that match a search, along with
+// their context. Text searches use the task_logs tsvector index, which only covers the first 64K
+// characters of each line, and regular expression searches the trigram index.
+func (db *PgDB) SearchTaskLogs(
+	ctx context.Context, s model.TaskLogSearch,
+) ([]*model.TaskLogSearchMatch, error) {
+	if len(s.TaskIDs) == 0 {
+		return nil, nil
+	}
+
+	params := []interface{}{s.TaskIDs, s.Query, s.Limit}
+	var conds []string
+	switch s.Mode {
+	case model.TaskLogSearchText:
+		conds = append(conds,
+			"to_tsvector('simple', left(encode(l.log, 'escape'), 65536)) @@ "+
+				"plainto_tsquery('simple', $2)")
+	case model.TaskLogSearchRegex:
+		conds = append(conds, "encode(l.log, 'escape') ~ $2")
+	default:
+		return nil, fmt.Errorf("unsupported search mode: %s", s.Mode)
+	}
+	if s.Since != nil {
+		params = append(params, *s.Since)
+		conds = append(conds, fmt.Sprintf("l.timestamp > $%d", len(params)))
+	}
+	if s.Until != nil {
+		params = append(params, *s.Until)
+		conds = append(conds, fmt.Sprintf("l.timestamp <= $%d", len(params)))
+	}
+
+	query := fmt.Sprintf(`
+SELECT
+    l.id,
+    l.task_id,
+    l.allocation_id,
+    l.agent_id,
+    l.container_id,
+    l.rank_id,
+    l.timestamp,
+    l.level,
+    l.stdtype,
+    l.source,
+    l.log
+FROM task_logs l
+WHERE l.task_id = ANY($1::text [])
+AND %s
+ORDER BY l.id DESC LIMIT $3
+`, strings.Join(conds, "\nAND "))
+
+	var logs []*model.TaskLog
+	if err := db.sql.SelectContext(ctx, &logs, query, params...); err != nil {
+		return nil, errors.Wrap(err, "error searching task logs")
+	}
+
+	matches := make([]*model.TaskLogSearchMatch, len(logs))
+	byID := make(map[int]*model.TaskLogSearchMatch, len(logs))
+	ids := make([]int64, len(logs))
+	for i, l := range logs {
+		matches[i] = &model.TaskLogSearchMatch{Log: l}
+		byID[*l.ID] = matches[i]
+		ids[i] = int64(*l.ID)
+	}
+	if s.ContextLines == 0 || len(logs) == 0 {
+		return matches, nil
+	}
+
+	var contextLogs []struct {
+		MatchID int `db:"match_id"`
+		model.TaskLog
+	}
+	if err := db.sql.SelectContext(ctx, &contextLogs, `
+SELECT m.id AS match_id, c.*
+FROM task_logs m
+CROSS JOIN LATERAL (
+    (
+        SELECT b.id, b.task_id, b.allocation_id, b.agent_id, b.container_id, b.rank_id,
+            b.timestamp, b.level, b.stdtype, b.source, b.log
+        FROM task_logs b
+        WHERE b.task_id = m.task_id AND b.rank_id IS NOT DISTINCT FROM m.rank_id AND b.id < m.id
+        ORDER BY b.id DESC LIMIT $2
+    )
+    UNION ALL
+    (
+        SELECT a.id, a.task_id, a.allocation_id, a.agent_id, a.container_id, a.rank_id,
+            a.timestamp, a.level, a.stdtype, a.source, a.log
+        FROM task_logs a
+        WHERE a.task_id = m.task_id AND a.rank_id IS NOT DISTINCT FROM m.rank_id AND a.id > m.id
+        ORDER BY a.id LIMIT $2
+    )
+) c
+WHERE m.id = ANY($1::bigint [])
+ORDER BY m.id, c.id
+`, ids, s.ContextLines); err != nil {
+		return nil, errors.Wrap(err, "error getting context of task log search matches")
+	}
+	for i := range contextLogs {
+		c := &contextLogs[i]
+		m := byID[c.MatchID]
+		if *c.ID < *m.Log.ID {
+			m.Before = append(m.Before, &c.TaskLog)
+		} else {
+			m.After = append(m.After, &c.TaskLog)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,95 @@
+//go:build integration
+// +build integration
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestSearchTaskLogs(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, etc.SetRootPath(RootFromDB))
+	db := MustResolveTestPostgres(t)
+	MustMigrateTestPostgres(t, db, MigrationsFromDB)
+
+	user := RequireMockUser(t, db)
+	exp := RequireMockExperiment(t, db, user)
+	trials := []*model.Trial{RequireMockTrial(t, db, exp), RequireMockTrial(t, db, exp)}
+
+	var scope []struct {
+		TaskID       model.TaskID
+		TrialID      int
+		ExperimentID int
+		WorkspaceID  int
+	}
+	require.NoError(t, TrialLogSearchScopeQuery(&exp.ID, nil, nil, nil, nil).Scan(ctx, &scope))
+	require.Len(t, scope, 2)
+
+	start := time.Now().UTC().Add(-time.Hour)
+	var taskIDs []model.TaskID
+	for i, tr := range trials {
+		taskIDs = append(taskIDs, tr.TaskID)
+		var logs []*model.TaskLog
+		for j := 0; j < 10; j++ {
+			line := fmt.Sprintf("step %d of trial %d\n", j, i)
+			if j == 5 {
+				line = fmt.Sprintf("RuntimeError: CUDA out of memory on trial %d\n", i)
+			}
+			logs = append(logs, &model.TaskLog{
+				TaskID:    string(tr.TaskID),
+				RankID:    ptrs.Ptr(0),
+				Timestamp: ptrs.Ptr(start.Add(time.Duration(j) * time.Minute)),
+				Log:       line,
+			})
+		}
+		// Logs of another rank are not context.
+		logs = append(logs, &model.TaskLog{
+			TaskID: string(tr.TaskID), RankID: ptrs.Ptr(1), Log: "other rank\n",
+		})
+		require.NoError(t, db.AddTaskLogs(logs))
+	}
+
+	search := model.TaskLogSearch{
+		Query:        "cuda memory",
+		Mode:         model.TaskLogSearchText,
+		TaskIDs:      taskIDs,
+		ContextLines: 2,
+		Limit:        10,
+	}
+	matches, err := db.SearchTaskLogs(ctx, search)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	for _, m := range matches {
+		require.Contains(t, m.Log.Log, "CUDA out of memory")
+		require.Len(t, m.Before, 2)
+		require.Len(t, m.After, 2)
+		require.Contains(t, m.Before[0].Log, "step 3")
+		require.Contains(t, m.Before[1].Log, "step 4")
+		require.Contains(t, m.After[0].Log, "step 6")
+	}
+
+	search.Mode = model.TaskLogSearchRegex
+	search.Query = `out of memory on trial [1-9]`
+	search.ContextLines = 0
+	matches, err = db.SearchTaskLogs(ctx, search)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, string(trials[1].TaskID), matches[0].Log.TaskID)
+	require.Empty(t, matches[0].Before)
+
+	search.Query = `^step [0-9]`
+	search.Since = ptrs.Ptr(start.Add(7 * time.Minute))
+	matches, err = db.SearchTaskLogs(ctx, search)
+	require.NoError(t, err)
+	require.Len(t, matches, 4, "steps 8 and 9 of each trial")
+}
@@ -268,7 +268,8 @@ type taskLogsFollowState struct {
 
 // TaskLogs takes a task ID and log offset, limit and filters and returns matching logs.
 func (db *PgDB) TaskLogs(
-	taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy, followState interface{},
+	ctx context.Context, taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy,
+	followState interface{},
 ) ([]*model.TaskLog, interface{}, error) {
 	if followState != nil {
 		fs = append(fs, api.Filter{
@@ -300,7 +301,7 @@ ORDER BY l.id %s LIMIT $2
 `, fragment, OrderByToSQL(order))
 
 	var b []*model.TaskLog
-	if err := db.queryRows(query, &b, params...); err != nil {
+	if err := db.sql.SelectContext(ctx, &b, query, params...); err != nil {
 		return nil, nil, err
 	}
 
@@ -347,8 +348,8 @@ VALUES
 }
 
 // DeleteTaskLogs deletes the logs for the given tasks.
-func (db *PgDB) DeleteTaskLogs(ids []model.TaskID) error {
-	if _, err := db.sql.Exec(`
+func (db *PgDB) DeleteTaskLogs(ctx context.Context, ids []model.TaskID) error {
+	if _, err := db.sql.ExecContext(ctx, `
 DELETE FROM task_logs
 WHERE task_id IN (SELECT unnest($1::text [])::text);
 `, ids); err != nil {
@@ -358,7 +359,9 @@ WHERE task_id IN (SELECT unnest($1::text [])::text);
 }
 
 // TaskLogsCount returns the number of logs in postgres for the given task.
-func (db *PgDB) TaskLogsCount(taskID model.TaskID, fs []api.Filter) (int, error) {
+func (db *PgDB) TaskLogsCount(
+	ctx context.Context, taskID model.TaskID, fs []api.Filter,
+) (int, error) {
 	params := []interface{}{taskID}
 	fragment, params := filtersToSQL(fs, params, taskLogsFieldMap)
 	query := fmt.Sprintf(`
@@ -368,7 +371,7 @@ WHERE task_id = $1
 %s
 `, fragment)
 	var count int
-	if err := db.sql.QueryRow(query, params...).Scan(&count); err != nil {
+	if err := db.sql.QueryRowContext(ctx, query, params...).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -410,7 +413,9 @@ AND task_stats.end_time IS NULL`)
 }
 
 // TaskLogsFields returns the unique fields that can be filtered on for the given task.
-func (db *PgDB) TaskLogsFields(taskID model.TaskID) (*apiv1.TaskLogsFieldsResponse, error) {
+func (db *PgDB) TaskLogsFields(
+	ctx context.Context, taskID model.TaskID,
+) (*apiv1.TaskLogsFieldsResponse, error) {
 	var fields apiv1.TaskLogsFieldsResponse
 	err := db.QueryProto("get_task_logs_fields", &fields, taskID)
 	return &fields, err
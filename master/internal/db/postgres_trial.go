@@ -53,6 +53,16 @@ WHERE id = $1`, &trial, id)
 	return &trial, errors.Wrapf(err, "error querying for trial %v", id)
 }
 
+// TrialIDByTaskID looks up the ID of the trial of a task, returning an error if none exists.
+func TrialIDByTaskID(ctx context.Context, taskID model.TaskID) (int, error) {
+	var id int
+	if err := Bun().NewSelect().Table("trials").Column("id").
+		Where("task_id = ?", taskID).Scan(ctx, &id); err != nil {
+		return 0, MatchSentinelError(err)
+	}
+	return id, nil
+}
+
 // TrialByExperimentAndRequestID looks up a trial, returning an error if none exists.
 func (db *PgDB) TrialByExperimentAndRequestID(
 	experimentID int, requestID model.RequestID,
@@ -354,6 +364,10 @@ WHERE id = $1;
 			return rollbacks, errors.Wrap(err, "updating trial best validation")
 		}
 	}
+
+	if err := notifyTrialMetrics(ctx, tx, m.TrialId, metricRowID, mType); err != nil {
+		return rollbacks, err
+	}
 	return rollbacks, nil
 }
 
@@ -366,6 +380,8 @@ func (db *PgDB) addTrialMetrics(
 	default:
 		return 0, fmt.Errorf("cannot add metric with non numeric 'epoch' value got %v", v)
 	}
+	trialMetricsStreamedMu.RLock()
+	defer trialMetricsStreamedMu.RUnlock()
 	return rollbacks, db.withTransaction(fmt.Sprintf("add trial metrics %s", mType),
 		func(tx *sqlx.Tx) error {
 			rollbacks, err = db._addTrialMetricsTx(ctx, tx, m, mType)
@@ -602,14 +618,17 @@ WHERE c.trial_id = $1 AND c.steps_completed = $2`, &checkpoint, trialID, totalBa
 	return &checkpoint, nil
 }
 
-// LatestCheckpointForTrial finds the latest completed checkpoint for a trial, returning nil if
-// none exists.
+// LatestCheckpointForTrial finds the latest completed checkpoint for a trial that is not known to
+// be corrupted, returning nil if none exists.
 func (db *PgDB) LatestCheckpointForTrial(trialID int) (*model.Checkpoint, error) {
 	var checkpoint model.Checkpoint
 	if err := db.query(`
 SELECT *
 FROM checkpoints_view c
 WHERE c.trial_id = $1 AND c.state = 'COMPLETED'
+AND NOT EXISTS (
+	SELECT 1 FROM checkpoints_v2 v2 WHERE v2.uuid = c.uuid AND v2.integrity = 'CORRUPTED'
+)
 ORDER BY c.steps_completed DESC
 LIMIT 1`, &checkpoint, trialID); errors.Cause(err) == ErrNotFound {
 		return nil, nil
@@ -1068,3 +1068,35 @@ func TestConcurrentMetricUpdate(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestInsertTrialProfilerMetricsBatches(t *testing.T) {
+	require.NoError(t, etc.SetRootPath(RootFromDB))
+	db := MustResolveTestPostgres(t)
+	MustMigrateTestPostgres(t, db, MigrationsFromDB)
+
+	ctx := context.Background()
+	require.NoError(t, InsertTrialProfilerMetricsBatches(ctx, nil))
+
+	name := uuid.NewString()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var rows []TrialProfilerMetricsRow
+	for _, gpu := range []string{"GPU-a", "GPU-b"} {
+		rows = append(rows, TrialProfilerMetricsRow{
+			Values:     []float32{1, 2},
+			Batches:    []int32{0, 0},
+			Timestamps: []time.Time{now, now.Add(time.Second)},
+			Labels:     []byte(fmt.Sprintf(`{"name": %q, "gpuUuid": %q}`, name, gpu)),
+		})
+	}
+	require.NoError(t, InsertTrialProfilerMetricsBatches(ctx, rows))
+
+	batches, err := db.GetTrialProfilerMetricsBatches(
+		[]byte(fmt.Sprintf(`{"name": %q}`, name)), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	for i, b := range batches {
+		require.Equal(t, rows[i].Values, b.Values)
+		require.Equal(t, rows[i].Batches, b.Batches)
+		require.Equal(t, now, b.Timestamps[0].AsTime())
+	}
+}
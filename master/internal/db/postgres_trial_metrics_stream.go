@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+// TrialMetricsChannel is the Postgres notification channel on which persisted trial metrics are
+// announced.
+const TrialMetricsChannel = "trial_metrics"
+
+var (
+	// trialMetricsStreamed is whether persisted trial metrics are announced.
+	trialMetricsStreamed bool
+	// trialMetricsStreamedMu is held for reading by every transaction that adds trial metrics,
+	// from deciding whether to announce them until it commits.
+	trialMetricsStreamedMu sync.RWMutex
+)
+
+// StreamTrialMetrics sets whether persisted trial metrics are announced on TrialMetricsChannel,
+// which they only need to be while something is subscribed to them. It waits for the
+// transactions adding trial metrics to finish, so once it returns, every report that was
+// persisted without being announced can be read.
+func StreamTrialMetrics(streamed bool) {
+	trialMetricsStreamedMu.Lock()
+	defer trialMetricsStreamedMu.Unlock()
+	trialMetricsStreamed = streamed
+}
+
+// TrialMetricsNotification announces that a metrics report of a trial was persisted.
+type TrialMetricsNotification struct {
+	TrialID      int              `json:"trial_id"`
+	ExperimentID int              `json:"experiment_id"`
+	MetricID     int              `json:"metric_id"`
+	Group        model.MetricType `json:"group"`
+}
+
+// notifyTrialMetrics announces a persisted metrics report if trial metrics are streamed. The
+// notification is only delivered once tx commits. It must be called with trialMetricsStreamedMu
+// held for reading until then.
+func notifyTrialMetrics(
+	ctx context.Context, tx *sqlx.Tx, trialID int32, metricID int, mType model.MetricType,
+) error {
+	if !trialMetricsStreamed {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+SELECT pg_notify($1, json_build_object(
+	'trial_id', t.id, 'experiment_id', t.experiment_id, 'metric_id', $3::int, 'group', $4::text
+)::text)
+FROM trials t WHERE t.id = $2`, TrialMetricsChannel, trialID, metricID, mType); err != nil {
+		return errors.Wrap(err, "notifying trial metrics")
+	}
+	return nil
+}
+
+// ListenTrialMetrics calls f with every TrialMetricsNotification until ctx is canceled or the
+// connection fails. It calls listening once notifications are being received.
+func (db *PgDB) ListenTrialMetrics(
+	ctx context.Context, listening func(), f func(TrialMetricsNotification),
+) error {
+	conn, err := pgx.Connect(ctx, db.url)
+	if err != nil {
+		return errors.Wrap(err, "connecting to listen for trial metrics")
+	}
+	defer func() {
+		if err := conn.Close(context.Background()); err != nil {
+			log.WithError(err).Debug("failed to close trial metrics listener")
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+TrialMetricsChannel); err != nil {
+		return errors.Wrap(err, "listening for trial metrics")
+	}
+	listening()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "waiting for trial metrics")
+		}
+		var notification TrialMetricsNotification
+		if err := json.Unmarshal([]byte(n.Payload), &notification); err != nil {
+			log.WithError(err).Errorf("invalid trial metrics notification %q", n.Payload)
+			continue
+		}
+		f(notification)
+	}
+}
+
+// MetricsReportByID returns the metrics report with the given ID in the given group.
+func MetricsReportByID(
+	ctx context.Context, id int, mType model.MetricType,
+) (*trialv1.MetricsReport, error) {
+	var res trialv1.MetricsReport
+	query := Bun().NewSelect().Table("metrics").
+		Column("trial_id", "metrics", "total_batches", "archived", "id", "trial_run_id").
+		ColumnExpr("proto_time(end_time) AS end_time").
+		Where("partition_type = ?", customMetricTypeToPartitionType(mType)).
+		Where("id = ?", id)
+	if err := query.Scan(ctx, &res); err != nil {
+		return nil, errors.Wrapf(err, "getting metrics report %d", id)
+	}
+	return &res, nil
+}
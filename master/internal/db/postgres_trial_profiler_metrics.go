@@ -1,9 +1,12 @@
 package db
 
 import (
+	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
 
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/trialv1"
@@ -20,6 +23,27 @@ VALUES ($1, $2, $3, $4)
 	return err
 }
 
+// TrialProfilerMetricsRow is a batch of metrics to insert into the `trial_profiler_metrics`
+// table.
+type TrialProfilerMetricsRow struct {
+	bun.BaseModel `bun:"table:trial_profiler_metrics"`
+
+	Values     []float32       `bun:"values,array"`
+	Batches    []int32         `bun:"batches,array"`
+	Timestamps []time.Time     `bun:"ts,array"`
+	Labels     json.RawMessage `bun:"labels,type:jsonb"`
+}
+
+// InsertTrialProfilerMetricsBatches inserts batches of metrics into the database in one
+// statement.
+func InsertTrialProfilerMetricsBatches(ctx context.Context, rows []TrialProfilerMetricsRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	_, err := Bun().NewInsert().Model(&rows).Exec(ctx)
+	return err
+}
+
 // GetTrialProfilerMetricsBatches gets a batch of profiler metric batches from the database.
 func (db *PgDB) GetTrialProfilerMetricsBatches(
 	labelsJSON []byte, offset, limit int,
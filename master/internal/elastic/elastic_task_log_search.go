@@ -0,0 +1,193 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type taskLogHits struct {
+	Hits struct {
+		Hits []struct {
+			ID     string         `json:"_id"`
+			Source *model.TaskLog `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func (h taskLogHits) logs() []*model.TaskLog {
+	var logs []*model.TaskLog
+	for i := range h.Hits.Hits {
+		// See the note in TaskLogs on why this doesn't range over values.
+		hit := h.Hits.Hits[i]
+		hit.Source.StringID = &hit.ID
+		logs = append(logs, hit.Source)
+	}
+	return logs
+}
+
+// SearchTaskLogs returns the newest logs of the given tasks that match a search, along with
+// their context. Text searches are match queries on the analyzed log; regular expression
+// searches use Lucene's regular expression syntax against the whole log line, and so only find
+// lines short enough to be indexed as keywords.
+func (e *Elastic) SearchTaskLogs(
+	ctx context.Context, s model.TaskLogSearch,
+) ([]*model.TaskLogSearchMatch, error) {
+	if len(s.TaskIDs) == 0 {
+		return nil, nil
+	}
+
+	var match jsonObj
+	switch s.Mode {
+	case model.TaskLogSearchText:
+		match = jsonObj{
+			"match": jsonObj{
+				"log": jsonObj{
+					"query":    s.Query,
+					"operator": "and",
+				},
+			},
+		}
+	case model.TaskLogSearchRegex:
+		match = jsonObj{
+			"regexp": jsonObj{
+				// See the notes on FilterOperationIn in filtersToElastic.
+				"log.keyword": jsonObj{
+					"value": fmt.Sprintf(".*(%s).*", s.Query),
+				},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported search mode: %s", s.Mode)
+	}
+
+	filters := []jsonObj{
+		{
+			"terms": jsonObj{
+				"task_id.keyword": s.TaskIDs,
+			},
+		},
+	}
+	timeRange := jsonObj{}
+	if s.Since != nil {
+		timeRange["gt"] = *s.Since
+	}
+	if s.Until != nil {
+		timeRange["lte"] = *s.Until
+	}
+	if len(timeRange) > 0 {
+		filters = append(filters, jsonObj{"range": jsonObj{"timestamp": timeRange}})
+	}
+
+	var resp taskLogHits
+	if err := e.search(jsonObj{
+		"size": s.Limit,
+		"query": jsonObj{
+			"bool": jsonObj{
+				"filter": filters,
+				"must":   []jsonObj{match},
+			},
+		},
+		"sort": []jsonObj{{"timestamp": "desc"}},
+	}, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to search task logs")
+	}
+
+	var matches []*model.TaskLogSearchMatch
+	for _, l := range resp.logs() {
+		matches = append(matches, &model.TaskLogSearchMatch{Log: l})
+	}
+	if s.ContextLines == 0 || len(matches) == 0 {
+		return matches, nil
+	}
+	if err := e.addSearchContext(matches, s.ContextLines); err != nil {
+		return nil, errors.Wrap(err, "failed to get context of task log search matches")
+	}
+	return matches, nil
+}
+
+// addSearchContext fills in the logs of the same task and rank around each match, using one
+// multi search request with a search on each side of each match.
+func (e *Elastic) addSearchContext(matches []*model.TaskLogSearchMatch, lines int) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, m := range matches {
+		sameStream := []jsonObj{
+			{
+				"term": jsonObj{
+					"task_id.keyword": m.Log.TaskID,
+				},
+			},
+		}
+		if m.Log.RankID != nil {
+			sameStream = append(sameStream, jsonObj{"term": jsonObj{"rank_id": *m.Log.RankID}})
+		} else {
+			sameStream = append(sameStream, jsonObj{
+				"bool": jsonObj{
+					"must_not": jsonObj{
+						"exists": jsonObj{
+							"field": "rank_id",
+						},
+					},
+				},
+			})
+		}
+
+		for _, side := range []struct{ op, order string }{{"lt", "desc"}, {"gt", "asc"}} {
+			query := jsonObj{
+				"size": lines,
+				"query": jsonObj{
+					"bool": jsonObj{
+						"filter": append(append([]jsonObj{}, sameStream...), jsonObj{
+							"range": jsonObj{
+								"timestamp": jsonObj{
+									side.op: m.Log.Timestamp,
+								},
+							},
+						}),
+					},
+				},
+				"sort": []jsonObj{{"timestamp": side.order}},
+			}
+			if err := enc.Encode(jsonObj{}); err != nil {
+				return err
+			}
+			if err := enc.Encode(query); err != nil {
+				return errors.Wrap(err, "failed to encode query")
+			}
+		}
+	}
+
+	res, err := e.client.Msearch(&buf)
+	if err != nil {
+		return errors.Wrap(err, "failed to perform multi search")
+	}
+	defer closeWithErrCheck(res.Body)
+	if err = checkResponse(res); err != nil {
+		return errors.Wrap(err, "failed to perform multi search")
+	}
+	var resp struct {
+		Responses []taskLogHits `json:"responses"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to decode multi search api response")
+	}
+	if len(resp.Responses) != 2*len(matches) {
+		return fmt.Errorf("expected %d multi search responses, got %d",
+			2*len(matches), len(resp.Responses))
+	}
+
+	for i, m := range matches {
+		before := resp.Responses[2*i].logs()
+		for j := len(before) - 1; j >= 0; j-- {
+			m.Before = append(m.Before, before[j])
+		}
+		m.After = resp.Responses[2*i+1].logs()
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package elastic
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -63,7 +64,9 @@ func (e *Elastic) AddTaskLogs(logs []*model.TaskLog) error {
 }
 
 // TaskLogsCount returns the number of logs for the given task.
-func (e *Elastic) TaskLogsCount(taskID model.TaskID, fs []api.Filter) (int, error) {
+func (e *Elastic) TaskLogsCount(
+	ctx context.Context, taskID model.TaskID, fs []api.Filter,
+) (int, error) {
 	count, err := e.count(jsonObj{
 		"query": jsonObj{
 			"bool": jsonObj{
@@ -88,7 +91,7 @@ func (e *Elastic) TaskLogsCount(taskID model.TaskID, fs []api.Filter) (int, erro
 // search after over itself.
 // https://www.elastic.co/guide/en/elasticsearch/reference/6.8/search-request-search-after.html
 func (e *Elastic) TaskLogs(
-	taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy,
+	ctx context.Context, taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy,
 	searchAfter interface{},
 ) ([]*model.TaskLog, interface{}, error) {
 	if limit > elasticMaxQuerySize {
@@ -173,7 +176,7 @@ func (e *Elastic) TaskLogs(
 }
 
 // DeleteTaskLogs deletes the logs for the given tasks.
-func (e *Elastic) DeleteTaskLogs(ids []model.TaskID) error {
+func (e *Elastic) DeleteTaskLogs(ctx context.Context, ids []model.TaskID) error {
 	taskIDterms := make([]jsonObj, len(ids))
 	for i, id := range ids {
 		taskIDterms[i] = jsonObj{
@@ -204,7 +207,7 @@ func (e *Elastic) DeleteTaskLogs(ids []model.TaskID) error {
 
 	// TODO(brad): Here and elsewhere, we really should just hit indices that could possibly have
 	// logs for a given trial.
-	res, err := e.client.DeleteByQuery([]string{"*"}, &buf)
+	res, err := e.client.DeleteByQuery([]string{"*"}, &buf, e.client.DeleteByQuery.WithContext(ctx))
 	if err != nil {
 		return errors.Wrap(err, "failed to perform delete")
 	}
@@ -217,7 +220,9 @@ func (e *Elastic) DeleteTaskLogs(ids []model.TaskID) error {
 }
 
 // TaskLogsFields returns the unique fields that can be filtered on for the given task.
-func (e *Elastic) TaskLogsFields(taskID model.TaskID) (*apiv1.TaskLogsFieldsResponse, error) {
+func (e *Elastic) TaskLogsFields(
+	ctx context.Context, taskID model.TaskID,
+) (*apiv1.TaskLogsFieldsResponse, error) {
 	query := jsonObj{
 		"size": 0,
 		"query": jsonObj{
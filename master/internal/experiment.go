@@ -15,6 +15,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -45,6 +48,8 @@ import (
 	"github.com/determined-ai/determined/proto/pkg/jobv1"
 )
 
+var tracer = otel.Tracer("github.com/determined-ai/determined/master/internal")
+
 // Experiment-specific actor messages.
 type (
 	// Searcher-related messages.
@@ -118,6 +123,9 @@ type (
 		restored              bool
 
 		logCtx logger.Context
+		// span traces the experiment from when its actor starts until it stops, as the parent of
+		// the spans of its trials.
+		span trace.Span
 	}
 )
 
@@ -125,6 +133,7 @@ type (
 // and log. If the input object has no ID set, also create a new experiment in the database and set
 // the returned object's ID appropriately.
 func newExperiment(
+	ctx context.Context,
 	m *Master,
 	expModel *model.Experiment,
 	activeConfig expconf.ExperimentConfig,
@@ -166,7 +175,7 @@ func newExperiment(
 
 	// Retrieve the warm start checkpoint, if provided.
 	checkpoint, err := checkpointFromTrialIDOrUUID(
-		m.db, activeConfig.Searcher().SourceTrialID(), activeConfig.Searcher().SourceCheckpointUUID())
+		ctx, m.db, activeConfig.Searcher().SourceTrialID(), activeConfig.Searcher().SourceCheckpointUUID())
 	if err != nil {
 		return nil, launchWarnings, err
 	}
@@ -240,6 +249,11 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 	// Searcher-related messages.
 	case actor.PreStart:
 		ctx.AddLabels(e.logCtx)
+		_, e.span = tracer.Start(context.Background(), "experiment", trace.WithAttributes(
+			attribute.Int("experiment.id", e.ID),
+			attribute.String("job.id", e.JobID.String()),
+			attribute.Bool("experiment.restored", e.restored),
+		))
 		e.rm.SetGroupMaxSlots(ctx, sproto.SetGroupMaxSlots{
 			MaxSlots: e.activeConfig.Resources().MaxSlots(),
 			Handler:  ctx.Self(),
@@ -409,6 +423,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 
 	// Experiment shutdown logic.
 	case actor.PostStop:
+		defer e.span.End()
 		if e.State == model.CompletedState || e.State == model.StoppingCompletedState {
 			if err := e.db.SaveExperimentProgress(e.ID, ptrs.Ptr(1.0)); err != nil {
 				ctx.Log().Error(err)
@@ -677,10 +692,12 @@ func (e *experiment) processOperations(
 			config := schemas.Copy(e.activeConfig)
 			state := trialSearcherState{Create: op, Complete: true}
 			e.TrialSearcherState[op.RequestID] = state
-			ctx.ActorOf(op.RequestID, newTrial(
+			t := newTrial(
 				e.logCtx, trialTaskID(e.ID, op.RequestID), e.JobID, e.StartTime, e.ID, e.State,
 				state, e.rm, e.db, config, checkpoint, e.taskSpec, e.generatedKeys, false,
-			))
+			)
+			t.traceParent = e.span.SpanContext()
+			ctx.ActorOf(op.RequestID, t)
 		case searcher.ValidateAfter:
 			state := e.TrialSearcherState[op.RequestID]
 			state.Op = op
@@ -761,9 +778,12 @@ func (e *experiment) checkpointForCreate(op searcher.Create) (*model.Checkpoint,
 			return nil, errors.Wrapf(err,
 				"invalid request ID in Create operation: %d", op.Checkpoint.RequestID)
 		}
-		checkpointModel, err := checkpointFromTrialIDOrUUID(e.db, &trial.ID, nil)
+		checkpointModel, err := e.db.LatestCheckpointForTrial(trial.ID)
 		if err != nil {
-			return nil, errors.Wrap(err, "checkpoint not found")
+			return nil, errors.Wrapf(err, "checkpoint not found for trial %d", trial.ID)
+		}
+		if checkpointModel == nil {
+			return nil, errors.Errorf("checkpoint not found for trial %d", trial.ID)
 		}
 		checkpoint = checkpointModel
 	}
@@ -821,14 +841,14 @@ func (e *experiment) Restore(experimentSnapshot json.RawMessage) error {
 }
 
 func checkpointFromTrialIDOrUUID(
-	db *db.PgDB, trialID *int, checkpointUUIDStr *string,
+	ctx context.Context, pgDB *db.PgDB, trialID *int, checkpointUUIDStr *string,
 ) (*model.Checkpoint, error) {
 	var checkpoint *model.Checkpoint
 	var err error
 
 	// Attempt to find a Checkpoint object from the given IDs.
 	if trialID != nil {
-		checkpoint, err = db.LatestCheckpointForTrial(*trialID)
+		checkpoint, err = pgDB.LatestCheckpointForTrial(*trialID)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get checkpoint for source trial %d", *trialID)
 		}
@@ -840,13 +860,20 @@ func checkpointFromTrialIDOrUUID(
 		if err != nil {
 			return nil, errors.Wrap(err, "invalid source checkpoint UUID")
 		}
-		checkpoint, err = db.CheckpointByUUID(checkpointUUID)
+		checkpoint, err = pgDB.CheckpointByUUID(checkpointUUID)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get source checkpoint %v", checkpointUUID)
 		}
 		if checkpoint == nil {
 			return nil, errors.Errorf("no checkpoint found with UUID %v", checkpointUUID)
 		}
+		integrity, err := db.CheckpointIntegrityByUUID(ctx, checkpointUUID)
+		if err != nil {
+			return nil, err
+		}
+		if integrity.Integrity == model.CheckpointCorrupted {
+			return nil, errors.Errorf("source checkpoint %v is corrupted", checkpointUUID)
+		}
 	}
 	return checkpoint, nil
 }
@@ -0,0 +1,416 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/pkg/checkpoints"
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/master/version"
+)
+
+// Paths of the entries in an experiment export archive.
+const (
+	experimentExportDocument        = "experiment.json"
+	experimentExportModelDefinition = "model_definition.tar.gz"
+	experimentExportCheckpointsDir  = "checkpoints/"
+)
+
+func writeArchiveFile(aw archive.ArchiveWriter, path string, content []byte) error {
+	if err := aw.WriteHeader(path, int64(len(content))); err != nil {
+		return err
+	}
+	_, err := aw.Write(content)
+	return err
+}
+
+//	@Summary	Export an experiment's history as a tgz file.
+//	@Tags		Experiments
+//	@ID			get-experiment-export
+//	@Produce	application/gzip
+//	@Param		experiment_id		path	int		true	"Experiment ID"
+//	@Param		include_checkpoints	query	bool	false	"Include the files of checkpoints"
+//	@Success	200					{}		string	""
+//	@Router		/experiments/{experiment_id}/export [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getExperimentExport(c echo.Context) error {
+	args := struct {
+		ExperimentID       int  `path:"experiment_id"`
+		IncludeCheckpoints bool `query:"include_checkpoints"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	exp, _, err := echoGetExperimentAndCheckCanDoActions(ctx, c, m, args.ExperimentID,
+		expauth.AuthZProvider.Get().CanGetExperimentArtifacts)
+	if err != nil {
+		return err
+	}
+	if !model.TerminalStates[exp.State] {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("experiment %d is %s; only finished experiments can be exported",
+				exp.ID, exp.State))
+	}
+
+	export, err := db.ExportExperiment(ctx, exp.ID)
+	if err != nil {
+		return err
+	}
+	export.DeterminedVersion = version.Version
+	export.ExportedAt = time.Now().UTC()
+	document, err := json.Marshal(export)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationGZip)
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=experiment-%d.tar.gz", exp.ID))
+	// As with checkpoint downloads, delay the response until some of the archive is ready.
+	dw := newDelayWriter(c.Response(), 16*1024)
+	aw, err := archive.NewArchiveWriter(dw, archive.ArchiveTgz)
+	if err != nil {
+		return err
+	}
+	if err := writeArchiveFile(aw, experimentExportDocument, document); err != nil {
+		return err
+	}
+	err = writeArchiveFile(aw, experimentExportModelDefinition, export.Experiment.ModelDefinition)
+	if err != nil {
+		return err
+	}
+
+	if args.IncludeCheckpoints {
+		for _, ckpt := range export.Checkpoints {
+			if ckpt.State != model.CompletedState {
+				continue
+			}
+			id := ckpt.UUID.String()
+			// Checkpoints copied to other storage are read from there, as the export records.
+			storage := exp.Config.CheckpointStorage
+			if ckpt.Storage != nil {
+				if err := storage.UnmarshalJSON(ckpt.Storage); err != nil {
+					return errors.Wrapf(err, "parsing storage of checkpoint %s", id)
+				}
+			}
+			downloader, err := checkpoints.NewArchiveDownloader(
+				archive.NewPrefixWriter(aw, experimentExportCheckpointsDir+id+"/"),
+				id, &storage)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			if err := downloader.Download(ctx); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError,
+					fmt.Sprintf("unable to download checkpoint %s: %s", id, err))
+			}
+		}
+	}
+
+	for _, v := range []io.Closer{aw, dw} {
+		if err := v.Close(); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError,
+				fmt.Sprintf("failed to complete experiment export: %s", err))
+		}
+	}
+	return nil
+}
+
+// experimentImport tracks the progress of reading an experiment export archive.
+type experimentImport struct {
+	tx        bun.Tx
+	export    *model.ExperimentExport
+	result    *model.ExperimentImportResult
+	storage   expconf.CheckpointStorageConfig
+	projectID int
+	owner     model.UserID
+
+	// uploader writes the files of the checkpoint being restored, which has the new UUID current.
+	uploader archive.ArchiveWriter
+	current  uuid.UUID
+	uploaded map[uuid.UUID]bool
+	restored int
+	skipped  int
+}
+
+// restoreCheckpointFile writes a checkpoint file from the archive into the master's checkpoint
+// storage under the checkpoint's new UUID. The files of a checkpoint are contiguous in the archive,
+// so each checkpoint is uploaded in turn.
+func (i *experimentImport) restoreCheckpointFile(
+	ctx context.Context, name string, size int64, content io.Reader,
+) error {
+	oldID, rel, ok := strings.Cut(strings.TrimPrefix(name, experimentExportCheckpointsDir), "/")
+	if !ok || rel == "" {
+		return nil
+	}
+	oldUUID, err := uuid.Parse(oldID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid checkpoint directory %q in archive", oldID))
+	}
+	newUUID, ok := i.result.Checkpoints[oldUUID]
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("archive holds files of unknown checkpoint %s", oldUUID))
+	}
+	if rel = path.Clean(rel); !filepath.IsLocal(rel) {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("invalid checkpoint file path %q in archive", name))
+	}
+
+	if newUUID != i.current {
+		if err := i.closeUploader(); err != nil {
+			return err
+		}
+		i.current = newUUID
+		uploader, err := checkpoints.NewUploader(ctx, newUUID.String(), &i.storage)
+		if err != nil {
+			log.WithError(err).Warnf("not restoring files of imported checkpoint %s", newUUID)
+		}
+		i.uploader = uploader
+	}
+	if i.uploader == nil {
+		i.skipped++
+		return nil
+	}
+
+	if err := i.uploader.WriteHeader(rel, size); err != nil {
+		return err
+	}
+	if _, err := io.Copy(i.uploader, content); err != nil {
+		return err
+	}
+	i.uploaded[newUUID] = true
+	i.restored++
+	return nil
+}
+
+func (i *experimentImport) closeUploader() error {
+	if i.uploader == nil {
+		return nil
+	}
+	err := i.uploader.Close()
+	i.uploader = nil
+	return err
+}
+
+// finish completes the upload of checkpoint files and marks every imported checkpoint whose files
+// were not restored as deleted, since nothing in this cluster's storage backs it. If the import was
+// aborted, the checkpoint being uploaded at the time is incomplete and counts as not restored.
+func (i *experimentImport) finish(ctx context.Context, aborted bool) error {
+	uploadErr := i.closeUploader()
+	if uploadErr != nil || aborted {
+		delete(i.uploaded, i.current)
+	}
+	if i.result == nil {
+		return uploadErr
+	}
+	var missing []uuid.UUID
+	for _, newUUID := range i.result.Checkpoints {
+		if !i.uploaded[newUUID] {
+			missing = append(missing, newUUID)
+		}
+	}
+	if err := db.MarkCheckpointsDeletedTx(ctx, i.tx, missing); err != nil {
+		return err
+	}
+	return uploadErr
+}
+
+// readArchiveFile handles a single file of an experiment export archive. The experiment is
+// recreated when the first checkpoint file is reached, by which point the experiment document and
+// model definition that precede checkpoint files have been read.
+func (i *experimentImport) readArchiveFile(
+	c echo.Context, name string, size int64, content io.Reader,
+) error {
+	switch {
+	case name == experimentExportDocument:
+		var export model.ExperimentExport
+		if err := json.NewDecoder(content).Decode(&export); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("invalid %s: %s", experimentExportDocument, err))
+		}
+		i.export = &export
+	case name == experimentExportModelDefinition && i.export != nil:
+		modelDef, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		i.export.Experiment.ModelDefinition = modelDef
+	case strings.HasPrefix(name, experimentExportCheckpointsDir):
+		if err := i.run(c); err != nil {
+			return err
+		}
+		return i.restoreCheckpointFile(c.Request().Context(), name, size, content)
+	}
+	return nil
+}
+
+// importedConfig returns the experiment config with its checkpoint storage replaced by the
+// master's, keeping the experiment's checkpoint retention settings. Storage named by the archive
+// is never used: it would let the importer read and write wherever the master can.
+func importedConfig(
+	config json.RawMessage, storage expconf.CheckpointStorageConfig,
+) (json.RawMessage, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(config, &doc); err != nil {
+		return nil, errors.Wrapf(db.ErrInvalidInput, "invalid experiment config: %s", err)
+	}
+	rawStorage, err := json.Marshal(storage)
+	if err != nil {
+		return nil, err
+	}
+	var newStorage map[string]interface{}
+	if err := json.Unmarshal(rawStorage, &newStorage); err != nil {
+		return nil, err
+	}
+	if oldStorage, ok := doc["checkpoint_storage"].(map[string]interface{}); ok {
+		for _, k := range []string{"save_experiment_best", "save_trial_best", "save_trial_latest"} {
+			if v, ok := oldStorage[k]; ok {
+				newStorage[k] = v
+			}
+		}
+	}
+	doc["checkpoint_storage"] = newStorage
+	return json.Marshal(doc)
+}
+
+// run recreates the experiment from the records read so far, unless that has already happened.
+func (i *experimentImport) run(c echo.Context) error {
+	if i.result != nil {
+		return nil
+	}
+	if i.export == nil {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("archive does not start with %s", experimentExportDocument))
+	}
+	config, err := importedConfig(i.export.Experiment.Config, i.storage)
+	if err != nil {
+		return err
+	}
+	i.export.Experiment.Config = config
+
+	result, err := db.ImportExperiment(
+		c.Request().Context(), i.tx, i.export, i.projectID, i.owner)
+	if errors.Is(err, db.ErrInvalidInput) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
+		return err
+	}
+	i.result = result
+	return nil
+}
+
+//	@Summary	Import an experiment exported from another cluster.
+//	@Tags		Experiments
+//	@ID			post-experiment-import
+//	@Accept		application/gzip
+//	@Produce	json
+//	@Param		project_id	query	int	true	"ID of the project to import the experiment into"
+//	@Success	200			{}		string	""
+//	@Router		/experiments/import [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postExperimentImport(c echo.Context) (interface{}, error) {
+	args := struct {
+		ProjectID int `query:"project_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	ctx := c.Request().Context()
+	curUser := c.(*detContext.DetContext).MustGetUser()
+
+	p, err := (&apiServer{m: m}).GetProjectByID(ctx, int32(args.ProjectID), curUser)
+	if err != nil {
+		_, err = api.GrpcErrToEcho(err)
+		return nil, err
+	}
+	if p.Archived {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("project %d is archived", p.Id))
+	}
+	if err := expauth.AuthZProvider.Get().CanCreateExperiment(ctx, curUser, p); err != nil {
+		return nil, echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+	if err := m.checkCheckpointStorageQuota(ctx, p); err != nil {
+		if status.Code(err) == codes.ResourceExhausted {
+			return nil, echo.NewHTTPError(http.StatusForbidden, status.Convert(err).Message())
+		}
+		return nil, err
+	}
+
+	if m.config.CheckpointStorage.RawSharedFSConfig == nil &&
+		m.config.CheckpointStorage.RawS3Config == nil &&
+		m.config.CheckpointStorage.RawGCSConfig == nil &&
+		m.config.CheckpointStorage.RawAzureConfig == nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"experiments can only be imported into a master with checkpoint_storage configured")
+	}
+
+	// The experiment is inserted in a transaction that is only committed once the whole archive
+	// is read, so that a failed import leaves no partial experiment behind.
+	var imp *experimentImport
+	var importErr, readErr error
+	err = db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		imp = &experimentImport{
+			tx:        tx,
+			storage:   m.config.CheckpointStorage,
+			projectID: args.ProjectID,
+			owner:     curUser.ID,
+			uploaded:  map[uuid.UUID]bool{},
+		}
+		readErr = archive.WalkTgz(c.Request().Body,
+			func(name string, size int64, content io.Reader) error {
+				importErr = imp.readArchiveFile(c, name, size, content)
+				return importErr
+			})
+		if readErr == nil {
+			readErr = imp.run(c)
+			importErr = readErr
+		}
+		if finishErr := imp.finish(ctx, readErr != nil); finishErr != nil && readErr == nil {
+			return finishErr
+		}
+		return readErr
+	})
+	switch {
+	case importErr != nil:
+		return nil, importErr
+	case readErr != nil:
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("unable to read experiment archive: %s", readErr))
+	case err != nil:
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"experiment_id":             imp.result.ExperimentID,
+		"trial_ids":                 imp.result.TrialIDs,
+		"checkpoints":               imp.result.Checkpoints,
+		"checkpoint_files_restored": imp.restored,
+		"checkpoint_files_skipped":  imp.skipped,
+	}, nil
+}
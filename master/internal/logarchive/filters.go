@@ -0,0 +1,172 @@
+package logarchive
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// fieldValue returns the value of a task log field that filters may refer to, normalized so that
+// integers are int64, or nil if the field is unset.
+func fieldValue(l *model.TaskLog, field string) (interface{}, error) {
+	str := func(s *string) interface{} {
+		if s == nil {
+			return nil
+		}
+		return *s
+	}
+	switch field {
+	case "id":
+		if l.ID == nil {
+			return nil, nil
+		}
+		return int64(*l.ID), nil
+	case "allocation_id":
+		return str(l.AllocationID), nil
+	case "agent_id":
+		return str(l.AgentID), nil
+	case "container_id":
+		return str(l.ContainerID), nil
+	case "rank_id":
+		if l.RankID == nil {
+			return nil, nil
+		}
+		return int64(*l.RankID), nil
+	case "timestamp":
+		if l.Timestamp == nil {
+			return nil, nil
+		}
+		return *l.Timestamp, nil
+	case "level":
+		return str(l.Level), nil
+	case "stdtype":
+		return str(l.StdType), nil
+	case "source":
+		return str(l.Source), nil
+	case "log":
+		return l.Log, nil
+	default:
+		return nil, fmt.Errorf("unsupported filter field: %s", field)
+	}
+}
+
+// normalize converts integer filter values to int64 so they compare equal to field values.
+func normalize(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { //nolint:exhaustive // Only integers need converting.
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	}
+	return v
+}
+
+// filterValues returns the values of a set membership filter.
+func filterValues(x interface{}) ([]interface{}, error) {
+	if x == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(x)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("set membership filter takes a slice, not %T", x)
+	}
+	values := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		values = append(values, normalize(rv.Index(i).Interface()))
+	}
+	return values, nil
+}
+
+// compare returns -1, 0 or 1 as a is less than, equal to or greater than b.
+func compare(a, b interface{}) (int, error) {
+	switch a := a.(type) {
+	case int64:
+		b, ok := normalize(b).(int64)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare integer field to %T", b)
+		}
+		switch {
+		case a < b:
+			return -1, nil
+		case a > b:
+			return 1, nil
+		}
+		return 0, nil
+	case time.Time:
+		b, ok := b.(time.Time)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare timestamp field to %T", b)
+		}
+		return a.Compare(b), nil
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare string field to %T", b)
+		}
+		return strings.Compare(a, b), nil
+	default:
+		return 0, fmt.Errorf("cannot compare field of type %T", a)
+	}
+}
+
+// matchesFilter reports whether a task log matches a filter, with the same semantics as the
+// SQL filters of the Postgres backend: unset fields never match, except for IN OR NULL.
+func matchesFilter(l *model.TaskLog, f api.Filter) (bool, error) {
+	v, err := fieldValue(l, f.Field)
+	if err != nil {
+		return false, err
+	}
+	switch f.Operation {
+	case api.FilterOperationIn, api.FilterOperationInOrNull:
+		if v == nil {
+			return f.Operation == api.FilterOperationInOrNull, nil
+		}
+		values, err := filterValues(f.Values)
+		if err != nil {
+			return false, err
+		}
+		for _, fv := range values {
+			if fv == v {
+				return true, nil
+			}
+		}
+		return false, nil
+	case api.FilterOperationGreaterThan, api.FilterOperationLessThanEqual:
+		if v == nil {
+			return false, nil
+		}
+		c, err := compare(v, f.Values)
+		if err != nil {
+			return false, err
+		}
+		if f.Operation == api.FilterOperationGreaterThan {
+			return c > 0, nil
+		}
+		return c <= 0, nil
+	case api.FilterOperationStringContainment:
+		s, ok := v.(string)
+		if !ok {
+			return false, nil
+		}
+		return strings.Contains(
+			strings.ToLower(s), strings.ToLower(fmt.Sprint(f.Values))), nil
+	default:
+		return false, fmt.Errorf("unsupported filter operation: %d", f.Operation)
+	}
+}
+
+// matchesFilters reports whether a task log matches every filter.
+func matchesFilters(l *model.TaskLog, fs []api.Filter) (bool, error) {
+	for _, f := range fs {
+		ok, err := matchesFilter(l, f)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package logarchive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestMatchesFilters(t *testing.T) {
+	now := time.Now().UTC()
+	logs := []*model.TaskLog{
+		{
+			ID:        ptrs.Ptr(1),
+			RankID:    ptrs.Ptr(0),
+			Timestamp: ptrs.Ptr(now.Add(-time.Minute)),
+			Level:     ptrs.Ptr("INFO"),
+			Log:       "Starting training",
+		},
+		{
+			ID:        ptrs.Ptr(2),
+			RankID:    ptrs.Ptr(1),
+			Timestamp: ptrs.Ptr(now),
+			Level:     ptrs.Ptr("ERROR"),
+			Log:       "CUDA out of memory",
+		},
+		{ID: ptrs.Ptr(3), Timestamp: ptrs.Ptr(now), Log: "no rank or level"},
+	}
+	ids := func(fs ...api.Filter) []int {
+		var ids []int
+		for _, l := range logs {
+			ok, err := matchesFilters(l, fs)
+			require.NoError(t, err)
+			if ok {
+				ids = append(ids, *l.ID)
+			}
+		}
+		return ids
+	}
+
+	require.Equal(t, []int{1, 2, 3}, ids())
+	require.Equal(t, []int{2}, ids(api.Filter{
+		Field: "rank_id", Operation: api.FilterOperationIn, Values: []int32{1},
+	}))
+	require.Equal(t, []int{2, 3}, ids(api.Filter{
+		Field: "rank_id", Operation: api.FilterOperationInOrNull, Values: []int32{1},
+	}))
+	require.Equal(t, []int{1}, ids(api.Filter{
+		Field: "level", Operation: api.FilterOperationIn, Values: []string{"INFO", "DEBUG"},
+	}))
+	require.Equal(t, []int{2, 3}, ids(api.Filter{
+		Field: "id", Operation: api.FilterOperationGreaterThan, Values: int64(1),
+	}))
+	require.Equal(t, []int{1}, ids(api.Filter{
+		Field: "timestamp", Operation: api.FilterOperationLessThanEqual, Values: now.Add(-time.Second),
+	}))
+	require.Equal(t, []int{2}, ids(api.Filter{
+		Field: "log", Operation: api.FilterOperationStringContainment, Values: "cuda",
+	}, api.Filter{
+		Field: "timestamp", Operation: api.FilterOperationGreaterThan, Values: now.Add(-time.Second),
+	}))
+
+	_, err := matchesFilters(logs[0], []api.Filter{{
+		Field: "nonexistent", Operation: api.FilterOperationIn, Values: []string{"x"},
+	}})
+	require.Error(t, err)
+}
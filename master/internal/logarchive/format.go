@@ -0,0 +1,81 @@
+package logarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// maxArchivedLogLine bounds the length of a single line of an archive when reading it back.
+const maxArchivedLogLine = 16 * 1024 * 1024
+
+// logWriter writes task logs as gzip-compressed newline-delimited JSON.
+type logWriter struct {
+	gz  *gzip.Writer
+	enc *json.Encoder
+}
+
+func newLogWriter(w io.Writer) *logWriter {
+	gz := gzip.NewWriter(w)
+	return &logWriter{gz: gz, enc: json.NewEncoder(gz)}
+}
+
+func (w *logWriter) Write(logs []*model.TaskLog) error {
+	for _, l := range logs {
+		if err := w.enc.Encode(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *logWriter) Close() error {
+	return w.gz.Close()
+}
+
+// logReader reads back, one at a time, task logs written by a logWriter.
+type logReader struct {
+	r       io.ReadCloser
+	gz      *gzip.Reader
+	scanner *bufio.Scanner
+}
+
+// newLogReader returns a logReader over r, which it closes when it is closed.
+func newLogReader(r io.ReadCloser) (*logReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		_ = r.Close()
+		return nil, errors.Wrap(err, "invalid task log archive")
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(nil, maxArchivedLogLine)
+	return &logReader{r: r, gz: gz, scanner: scanner}, nil
+}
+
+// Next returns the next log, or io.EOF once every log has been read.
+func (r *logReader) Next() (*model.TaskLog, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read task log archive")
+		}
+		return nil, io.EOF
+	}
+	var l model.TaskLog
+	if err := json.Unmarshal(r.scanner.Bytes(), &l); err != nil {
+		return nil, errors.Wrap(err, "invalid line in task log archive")
+	}
+	return &l, nil
+}
+
+func (r *logReader) Close() error {
+	gzErr := r.gz.Close()
+	if err := r.r.Close(); err != nil {
+		return err
+	}
+	return gzErr
+}
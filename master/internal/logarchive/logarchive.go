@@ -0,0 +1,508 @@
+// Package logarchive implements a tiered task log backend: recent task logs stay in Postgres,
+// while the logs of tasks that finished long ago are compacted into compressed objects in
+// archive storage and read back from there transparently.
+package logarchive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// archivePageSize is the number of logs read from Postgres at a time while archiving.
+const archivePageSize = 10000
+
+type followState struct {
+	// The ID of the last log returned.
+	id int64
+	// The archive being read in ascending order, while logs remain in it.
+	cursor *archiveCursor
+}
+
+// Backend is a task and trial log backend that keeps logs in Postgres until they are archived.
+type Backend struct {
+	db    *db.PgDB
+	store Store
+	conf  model.TaskLogArchiveConfig
+}
+
+// New returns a Backend that archives logs according to conf.
+func New(pgDB *db.PgDB, conf model.TaskLogArchiveConfig) (*Backend, error) {
+	store, err := NewStore(conf.Storage)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{db: pgDB, store: store, conf: conf}, nil
+}
+
+func archiveKey(taskID model.TaskID) string {
+	return fmt.Sprintf("task-logs/%s.ndjson.gz", taskID)
+}
+
+// Run archives the logs of finished tasks every interval until ctx is canceled.
+func (b *Backend) Run(ctx context.Context) {
+	t := time.NewTicker(time.Duration(b.conf.Interval))
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		endedBefore := time.Now().Add(-time.Duration(b.conf.ArchiveAfter))
+		taskIDs, err := db.TasksToArchiveLogs(ctx, endedBefore, b.conf.BatchSize)
+		if err != nil {
+			log.WithError(err).Error("failed to get tasks to archive logs of")
+			continue
+		}
+		for _, taskID := range taskIDs {
+			if err := b.ArchiveTaskLogs(ctx, taskID); err != nil {
+				log.WithError(err).Warnf("failed to archive logs of task %s", taskID)
+			}
+		}
+	}
+}
+
+// ArchiveTaskLogs moves the logs of a task from Postgres into archive storage. The archive is
+// compressed and uploaded as the logs are read, so it is never held in memory.
+func (b *Backend) ArchiveTaskLogs(ctx context.Context, taskID model.TaskID) error {
+	logs, state, err := b.db.TaskLogs(
+		ctx, taskID, archivePageSize, nil, apiv1.OrderBy_ORDER_BY_ASC, nil)
+	if err != nil {
+		return err
+	} else if len(logs) == 0 {
+		return nil
+	}
+
+	archive := model.TaskLogArchive{TaskID: taskID, Key: archiveKey(taskID)}
+	pr, pw := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		size := &countingWriter{w: pw}
+		err := func() error {
+			w := newLogWriter(size)
+			for len(logs) > 0 {
+				if err := w.Write(logs); err != nil {
+					return err
+				}
+				archive.LogCount += len(logs)
+				archive.MaxLogID = int64(*logs[len(logs)-1].ID)
+
+				var err error
+				logs, state, err = b.db.TaskLogs(
+					ctx, taskID, archivePageSize, nil, apiv1.OrderBy_ORDER_BY_ASC, state)
+				if err != nil {
+					return err
+				}
+			}
+			return w.Close()
+		}()
+		archive.Size = size.n
+		written <- err
+		_ = pw.CloseWithError(err)
+	}()
+
+	putErr := b.store.Put(ctx, archive.Key, pr)
+	// Unblock the writer if the upload stopped reading early.
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+	writeErr := <-written
+	if putErr != nil {
+		return errors.Wrapf(putErr, "failed to store log archive of task %s", taskID)
+	} else if writeErr != nil {
+		return errors.Wrapf(writeErr, "failed to write log archive of task %s", taskID)
+	}
+
+	if err := db.AddTaskLogArchive(ctx, &archive); err != nil {
+		return err
+	}
+	log.Debugf("archived %d logs of task %s", archive.LogCount, taskID)
+	return nil
+}
+
+// readArchive opens the archive of a task for reading. Logs are read back in ID order.
+func (b *Backend) readArchive(
+	ctx context.Context, archive *model.TaskLogArchive,
+) (*logReader, error) {
+	r, err := b.store.Get(ctx, archive.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch log archive of task %s", archive.TaskID)
+	}
+	return newLogReader(r)
+}
+
+// scanArchive calls fn with every log in the archive of a task, in ID order.
+func (b *Backend) scanArchive(
+	ctx context.Context, archive *model.TaskLogArchive, fn func(*model.TaskLog) error,
+) error {
+	r, err := b.readArchive(ctx, archive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	for {
+		l, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+}
+
+// archiveCursor is an archive held open while a follower pages through it in ascending order, so
+// that each page picks up where the last one stopped. It is closed once it is read to the end or
+// once the context it was opened with is done.
+type archiveCursor struct {
+	ctx context.Context
+
+	mu     sync.Mutex
+	r      *logReader
+	closed bool
+}
+
+func (b *Backend) openCursor(
+	ctx context.Context, archive *model.TaskLogArchive,
+) (*archiveCursor, error) {
+	r, err := b.readArchive(ctx, archive)
+	if err != nil {
+		return nil, err
+	}
+	c := &archiveCursor{ctx: ctx, r: r}
+	go func() {
+		<-ctx.Done()
+		c.close()
+	}()
+	return c, nil
+}
+
+func (c *archiveCursor) open() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.closed
+}
+
+// next returns the next log in the archive, or io.EOF once every log has been read.
+func (c *archiveCursor) next() (*model.TaskLog, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		if err := c.ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return c.r.Next()
+}
+
+func (c *archiveCursor) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		_ = c.r.Close()
+	}
+}
+
+// lateLogFilters restricts fs to the logs that arrived in Postgres after the task was archived.
+func lateLogFilters(archive *model.TaskLogArchive, fs []api.Filter) []api.Filter {
+	return append(slices.Clone(fs), api.Filter{
+		Field:     "id",
+		Operation: api.FilterOperationGreaterThan,
+		Values:    archive.MaxLogID,
+	})
+}
+
+// TaskLogs implements TaskLogBackend. Archived logs are streamed from storage, so that no more
+// than a page of them is held in memory; logs that arrived after the task was archived are read
+// from Postgres.
+func (b *Backend) TaskLogs(
+	ctx context.Context, taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy,
+	state interface{},
+) ([]*model.TaskLog, interface{}, error) {
+	archive, err := db.TaskLogArchiveByTaskID(ctx, taskID)
+	if errors.Is(err, db.ErrNotFound) {
+		return b.db.TaskLogs(ctx, taskID, limit, fs, order, state)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	after, _ := state.(*followState)
+	var page []*model.TaskLog
+	var cursor *archiveCursor
+	if order == apiv1.OrderBy_ORDER_BY_DESC {
+		page, err = b.archivedLogsDesc(ctx, archive, limit, fs, after)
+	} else {
+		page, cursor, err = b.archivedLogsAsc(ctx, archive, limit, fs, after)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(page) > 0 {
+		state = &followState{id: int64(*page[len(page)-1].ID), cursor: cursor}
+	}
+	return page, state, nil
+}
+
+// archivedLogsAsc returns the next page of logs in ascending order, along with the cursor to
+// continue reading the archive from if logs may remain in it.
+func (b *Backend) archivedLogsAsc(
+	ctx context.Context, archive *model.TaskLogArchive, limit int, fs []api.Filter,
+	after *followState,
+) ([]*model.TaskLog, *archiveCursor, error) {
+	var page []*model.TaskLog
+	lastID := archive.MaxLogID
+	if after != nil && after.id > lastID {
+		lastID = after.id
+	}
+
+	if after == nil || after.id < archive.MaxLogID {
+		var cursor *archiveCursor
+		if after != nil && after.cursor != nil && after.cursor.open() {
+			cursor = after.cursor
+		} else {
+			var err error
+			if cursor, err = b.openCursor(ctx, archive); err != nil {
+				return nil, nil, err
+			}
+		}
+		for len(page) < limit {
+			l, err := cursor.next()
+			if errors.Is(err, io.EOF) {
+				cursor.close()
+				break
+			} else if err != nil {
+				cursor.close()
+				return nil, nil, err
+			}
+			if after != nil && int64(*l.ID) <= after.id {
+				continue
+			}
+			ok, err := matchesFilters(l, fs)
+			if err != nil {
+				cursor.close()
+				return nil, nil, err
+			}
+			if ok {
+				page = append(page, l)
+			}
+		}
+		if len(page) == limit {
+			return page, cursor, nil
+		}
+	}
+
+	late, _, err := b.db.TaskLogs(ctx, archive.TaskID, limit-len(page), append(slices.Clone(fs),
+		api.Filter{Field: "id", Operation: api.FilterOperationGreaterThan, Values: lastID},
+	), apiv1.OrderBy_ORDER_BY_ASC, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(page, late...), nil, nil
+}
+
+// archivedLogsDesc returns the next page of logs in descending order. Since archives can only be
+// read forwards, each page scans the archive up to the last log returned, keeping only the logs
+// that will be on the page.
+func (b *Backend) archivedLogsDesc(
+	ctx context.Context, archive *model.TaskLogArchive, limit int, fs []api.Filter,
+	after *followState,
+) ([]*model.TaskLog, error) {
+	lateFilters := lateLogFilters(archive, fs)
+	if after != nil {
+		lateFilters = append(lateFilters, api.Filter{
+			Field:     "id",
+			Operation: api.FilterOperationLessThanEqual,
+			Values:    after.id - 1,
+		})
+	}
+	page, _, err := b.db.TaskLogs(
+		ctx, archive.TaskID, limit, lateFilters, apiv1.OrderBy_ORDER_BY_DESC, nil)
+	if err != nil {
+		return nil, err
+	}
+	remaining := limit - len(page)
+	if remaining <= 0 {
+		return page, nil
+	}
+
+	// The last remaining matching logs before the follow state, oldest first.
+	ring := make([]*model.TaskLog, 0, remaining)
+	next := 0
+	err = b.scanArchive(ctx, archive, func(l *model.TaskLog) error {
+		if after != nil && int64(*l.ID) >= after.id {
+			return nil
+		}
+		ok, err := matchesFilters(l, fs)
+		if err != nil || !ok {
+			return err
+		}
+		if len(ring) < remaining {
+			ring = append(ring, l)
+		} else {
+			ring[next] = l
+			next = (next + 1) % remaining
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := len(ring) - 1; i >= 0; i-- {
+		page = append(page, ring[(next+i)%len(ring)])
+	}
+	return page, nil
+}
+
+// AddTaskLogs implements TaskLogBackend.
+func (b *Backend) AddTaskLogs(logs []*model.TaskLog) error {
+	return b.db.AddTaskLogs(logs)
+}
+
+// TaskLogsCount implements TaskLogBackend.
+func (b *Backend) TaskLogsCount(
+	ctx context.Context, taskID model.TaskID, fs []api.Filter,
+) (int, error) {
+	archive, err := db.TaskLogArchiveByTaskID(ctx, taskID)
+	if errors.Is(err, db.ErrNotFound) {
+		return b.db.TaskLogsCount(ctx, taskID, fs)
+	} else if err != nil {
+		return 0, err
+	}
+
+	count, err := b.db.TaskLogsCount(ctx, taskID, lateLogFilters(archive, fs))
+	if err != nil {
+		return 0, err
+	}
+	err = b.scanArchive(ctx, archive, func(l *model.TaskLog) error {
+		ok, err := matchesFilters(l, fs)
+		if ok {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// TaskLogsFields implements TaskLogBackend.
+func (b *Backend) TaskLogsFields(
+	ctx context.Context, taskID model.TaskID,
+) (*apiv1.TaskLogsFieldsResponse, error) {
+	archive, err := db.TaskLogArchiveByTaskID(ctx, taskID)
+	if errors.Is(err, db.ErrNotFound) {
+		return b.db.TaskLogsFields(ctx, taskID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Only logs that arrived after the task was archived remain in Postgres.
+	fields, err := b.db.TaskLogsFields(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	add := func(values *[]string, v *string) {
+		if v != nil && !slices.Contains(*values, *v) {
+			*values = append(*values, *v)
+		}
+	}
+	err = b.scanArchive(ctx, archive, func(l *model.TaskLog) error {
+		add(&fields.AllocationIds, l.AllocationID)
+		add(&fields.AgentIds, l.AgentID)
+		add(&fields.ContainerIds, l.ContainerID)
+		add(&fields.Stdtypes, l.StdType)
+		add(&fields.Sources, l.Source)
+		if l.RankID != nil && !slices.Contains(fields.RankIds, int32(*l.RankID)) {
+			fields.RankIds = append(fields.RankIds, int32(*l.RankID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// DeleteTaskLogs implements TaskLogBackend. Archived logs are removed from storage as well.
+func (b *Backend) DeleteTaskLogs(ctx context.Context, taskIDs []model.TaskID) error {
+	if err := b.db.DeleteTaskLogs(ctx, taskIDs); err != nil {
+		return err
+	}
+	archives, err := db.DeleteTaskLogArchives(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+	for _, a := range archives {
+		if err := b.store.Delete(ctx, a.Key); err != nil {
+			return errors.Wrapf(err, "failed to delete log archive of task %s", a.TaskID)
+		}
+	}
+	return nil
+}
+
+// SearchTaskLogs searches the logs still in Postgres. Archived logs are not searched, since that
+// would mean reading back the archive of every matching task; they can still be read and filtered
+// through TaskLogs.
+func (b *Backend) SearchTaskLogs(
+	ctx context.Context, s model.TaskLogSearch,
+) ([]*model.TaskLogSearchMatch, error) {
+	return b.db.SearchTaskLogs(ctx, s)
+}
+
+// MaxTerminationDelay implements TaskLogBackend.
+func (b *Backend) MaxTerminationDelay() time.Duration {
+	return b.db.MaxTerminationDelay()
+}
+
+// TrialLogs implements TrialLogBackend. Legacy trial logs are never archived.
+func (b *Backend) TrialLogs(
+	trialID, limit int, fs []api.Filter, order apiv1.OrderBy, state interface{},
+) ([]*model.TrialLog, interface{}, error) {
+	return b.db.TrialLogs(trialID, limit, fs, order, state)
+}
+
+// TrialLogsCount implements TrialLogBackend.
+func (b *Backend) TrialLogsCount(trialID int, fs []api.Filter) (int, error) {
+	return b.db.TrialLogsCount(trialID, fs)
+}
+
+// TrialLogsFields implements TrialLogBackend.
+func (b *Backend) TrialLogsFields(trialID int) (*apiv1.TrialLogsFieldsResponse, error) {
+	return b.db.TrialLogsFields(trialID)
+}
+
+// DeleteTrialLogs implements TrialLogBackend.
+func (b *Backend) DeleteTrialLogs(trialIDs []int) error {
+	return b.db.DeleteTrialLogs(trialIDs)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,108 @@
+//go:build integration
+// +build integration
+
+package logarchive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestArchiveTaskLogs(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB := db.MustResolveTestPostgres(t)
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+
+	user := db.RequireMockUser(t, pgDB)
+	task := db.RequireMockTask(t, pgDB, &user.ID)
+	require.NoError(t, pgDB.CompleteTask(task.TaskID, time.Now().Add(-48*time.Hour)))
+
+	var logs []*model.TaskLog
+	for i := 0; i < 10; i++ {
+		logs = append(logs, &model.TaskLog{
+			TaskID:    string(task.TaskID),
+			RankID:    ptrs.Ptr(i % 2),
+			Timestamp: ptrs.Ptr(time.Now().UTC()),
+			StdType:   ptrs.Ptr("stdout"),
+			Log:       fmt.Sprintf("line %d\n", i),
+		})
+	}
+	require.NoError(t, pgDB.AddTaskLogs(logs))
+
+	b, err := New(pgDB, model.TaskLogArchiveConfig{
+		ArchiveAfter: model.Duration(24 * time.Hour),
+		Interval:     model.Duration(time.Hour),
+		BatchSize:    100,
+		Storage: model.TaskLogArchiveStorageConfig{
+			SharedFS: &model.SharedFSLogArchiveConfig{HostPath: t.TempDir()},
+		},
+	})
+	require.NoError(t, err)
+
+	rankOne := []api.Filter{{
+		Field: "rank_id", Operation: api.FilterOperationIn, Values: []int32{1},
+	}}
+	before, _, err := b.TaskLogs(ctx, task.TaskID, 100, rankOne, apiv1.OrderBy_ORDER_BY_ASC, nil)
+	require.NoError(t, err)
+	require.Len(t, before, 5)
+
+	taskIDs, err := db.TasksToArchiveLogs(ctx, time.Now().Add(-24*time.Hour), 100)
+	require.NoError(t, err)
+	require.Contains(t, taskIDs, task.TaskID)
+	require.NoError(t, b.ArchiveTaskLogs(ctx, task.TaskID))
+	count, err := pgDB.TaskLogsCount(ctx, task.TaskID, nil)
+	require.NoError(t, err)
+	require.Zero(t, count, "archived logs are removed from Postgres")
+
+	// A log that arrives after archival is read back along with the archive.
+	require.NoError(t, pgDB.AddTaskLogs([]*model.TaskLog{{
+		TaskID: string(task.TaskID), RankID: ptrs.Ptr(1), Log: "late\n",
+	}}))
+
+	count, err = b.TaskLogsCount(ctx, task.TaskID, rankOne)
+	require.NoError(t, err)
+	require.Equal(t, 6, count)
+
+	// Paging through the archive with follow state returns every log once, in order.
+	var paged []*model.TaskLog
+	var state interface{}
+	for {
+		page, next, err := b.TaskLogs(ctx, task.TaskID, 2, rankOne, apiv1.OrderBy_ORDER_BY_ASC, state)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		paged, state = append(paged, page...), next
+	}
+	require.Len(t, paged, 6)
+	require.Equal(t, before, paged[:5])
+	require.Equal(t, "late\n", paged[5].Log)
+
+	desc, _, err := b.TaskLogs(ctx, task.TaskID, 1, nil, apiv1.OrderBy_ORDER_BY_DESC, nil)
+	require.NoError(t, err)
+	require.Equal(t, "late\n", desc[0].Log)
+
+	fields, err := b.TaskLogsFields(ctx, task.TaskID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int32{0, 1}, fields.RankIds)
+	require.Equal(t, []string{"stdout"}, fields.Stdtypes)
+
+	require.NoError(t, b.DeleteTaskLogs(ctx, []model.TaskID{task.TaskID}))
+	_, err = db.TaskLogArchiveByTaskID(ctx, task.TaskID)
+	require.ErrorIs(t, err, db.ErrNotFound)
+	count, err = b.TaskLogsCount(ctx, task.TaskID, nil)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
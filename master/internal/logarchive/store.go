@@ -0,0 +1,167 @@
+package logarchive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// defaultS3Region is used for S3-compatible storage that does not care about regions.
+const defaultS3Region = "us-east-1"
+
+// Store is where archived task logs are kept, as objects named by keys.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore returns the Store described by conf.
+func NewStore(conf model.TaskLogArchiveStorageConfig) (Store, error) {
+	switch {
+	case conf.SharedFS != nil:
+		return &sharedFSStore{root: conf.SharedFS.HostPath}, nil
+	case conf.S3 != nil:
+		return newS3Store(*conf.S3)
+	default:
+		return nil, fmt.Errorf("no task log archive storage configured")
+	}
+}
+
+// sharedFSStore keeps objects as files under a directory.
+type sharedFSStore struct {
+	root string
+}
+
+func (s *sharedFSStore) path(key string) (string, error) {
+	rel := filepath.FromSlash(key)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("invalid task log archive key %q", key)
+	}
+	return filepath.Join(s.root, rel), nil
+}
+
+func (s *sharedFSStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never see a partial archive.
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *sharedFSStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path) //nolint:gosec // The path is checked to be within the root.
+}
+
+func (s *sharedFSStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// s3Store keeps objects under a prefix of an S3 bucket. Setting an endpoint URL allows using
+// S3-compatible storage such as MinIO.
+type s3Store struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+func newS3Store(conf model.S3LogArchiveConfig) (*s3Store, error) {
+	awsConf := &aws.Config{Region: aws.String(defaultS3Region)}
+	if conf.Region != nil {
+		awsConf.Region = conf.Region
+	}
+	if conf.EndpointURL != nil {
+		awsConf.Endpoint = conf.EndpointURL
+		awsConf.S3ForcePathStyle = aws.Bool(true)
+	}
+	if conf.AccessKey != nil && conf.SecretKey != nil {
+		awsConf.Credentials = credentials.NewStaticCredentials(
+			*conf.AccessKey, *conf.SecretKey, "")
+	}
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create S3 session for task log archive")
+	}
+
+	prefix := strings.Trim(conf.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &s3Store{
+		client:   s3.New(sess),
+		uploader: s3manager.NewUploader(sess),
+		bucket:   conf.Bucket,
+		prefix:   prefix,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader) error {
+	_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: &s.bucket,
+		Key:    aws.String(s.prefix + key),
+		Body:   r,
+	})
+	return err
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    aws.String(s.prefix + key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    aws.String(s.prefix + key),
+	})
+	return err
+}
@@ -0,0 +1,56 @@
+package logarchive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestSharedFSStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(model.TaskLogArchiveStorageConfig{
+		SharedFS: &model.SharedFSLogArchiveConfig{HostPath: t.TempDir()},
+	})
+	require.NoError(t, err)
+
+	logs := []*model.TaskLog{
+		{ID: ptrs.Ptr(1), TaskID: "task", Log: "first\n", StdType: ptrs.Ptr("stdout")},
+		{ID: ptrs.Ptr(2), TaskID: "task", Log: "second\n", RankID: ptrs.Ptr(3)},
+	}
+	var buf bytes.Buffer
+	w := newLogWriter(&buf)
+	require.NoError(t, w.Write(logs))
+	require.NoError(t, w.Close())
+
+	key := archiveKey("task")
+	require.NoError(t, store.Put(ctx, key, &buf))
+	r, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	lr, err := newLogReader(r)
+	require.NoError(t, err)
+	var actual []*model.TaskLog
+	for {
+		l, err := lr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		actual = append(actual, l)
+	}
+	require.NoError(t, lr.Close())
+	require.Equal(t, logs, actual)
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	require.Error(t, err)
+	require.NoError(t, store.Delete(ctx, key), "deleting twice is fine")
+
+	require.Error(t, store.Put(ctx, "../escape", &buf))
+}
@@ -0,0 +1,162 @@
+// Package logretention deletes the logs of ended tasks from Postgres according to the retention
+// policies in the master config.
+package logretention
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	defaultPolicyName = "default"
+	ntscPolicyName    = "ntsc"
+
+	reasonAge   = "age"
+	reasonLines = "lines"
+)
+
+// Reaper periodically applies log retention policies.
+type Reaper struct {
+	conf config.LogRetentionConfig
+}
+
+// New returns a Reaper that applies the policies of conf.
+func New(conf config.LogRetentionConfig) *Reaper {
+	return &Reaper{conf: conf}
+}
+
+// Run applies the retention policies every interval until ctx is canceled.
+func (r *Reaper) Run(ctx context.Context) {
+	t := time.NewTicker(time.Duration(r.conf.Interval))
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if err := r.Reap(ctx, time.Now()); err != nil {
+			log.WithError(err).Error("failed to apply log retention")
+		}
+	}
+}
+
+// Reap deletes the logs that the retention policies no longer keep as of now.
+func (r *Reaper) Reap(ctx context.Context, now time.Time) error {
+	endedBefore, maxLines := r.bounds(now)
+	var after model.TaskID
+	for {
+		candidates, err := db.TaskLogRetentionCandidates(
+			ctx, after, endedBefore, maxLines, r.conf.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, c := range candidates {
+			if err := r.apply(ctx, now, c); err != nil {
+				log.WithError(err).Warnf("failed to apply log retention to task %s", c.TaskID)
+			}
+		}
+		if len(candidates) < r.conf.BatchSize {
+			return nil
+		}
+		after = candidates[len(candidates)-1].TaskID
+	}
+}
+
+// policies returns every policy in the config by the name it is reported under.
+func (r *Reaper) policies() map[string]config.LogRetentionPolicy {
+	policies := map[string]config.LogRetentionPolicy{
+		defaultPolicyName: r.conf.LogRetentionPolicy,
+	}
+	if r.conf.NTSC != nil {
+		policies[ntscPolicyName] = *r.conf.NTSC
+	}
+	for name, p := range r.conf.Workspaces {
+		policies[workspacePolicyName(name)] = p
+	}
+	return policies
+}
+
+// bounds returns the loosest bounds that select every task some policy may delete logs of.
+func (r *Reaper) bounds(now time.Time) (endedBefore *time.Time, maxLines *int) {
+	var minDays *int
+	for _, p := range r.policies() {
+		if p.Days != nil && (minDays == nil || *p.Days < *minDays) {
+			minDays = p.Days
+		}
+		if p.MaxLinesPerTask != nil && (maxLines == nil || *p.MaxLinesPerTask < *maxLines) {
+			maxLines = p.MaxLinesPerTask
+		}
+	}
+	if minDays != nil {
+		t := now.Add(-time.Duration(*minDays) * 24 * time.Hour)
+		endedBefore = &t
+	}
+	return endedBefore, maxLines
+}
+
+func workspacePolicyName(workspaceName string) string {
+	return "workspace:" + workspaceName
+}
+
+// policyFor returns the policy that applies to a task and the name it is reported under.
+func (r *Reaper) policyFor(c db.TaskLogRetentionCandidate) (string, config.LogRetentionPolicy) {
+	switch c.TaskType {
+	case model.TaskTypeNotebook, model.TaskTypeShell, model.TaskTypeCommand,
+		model.TaskTypeTensorboard:
+		if r.conf.NTSC != nil {
+			return ntscPolicyName, *r.conf.NTSC
+		}
+	case model.TaskTypeTrial:
+		if c.WorkspaceName == nil {
+			break
+		}
+		if p, ok := r.conf.Workspaces[*c.WorkspaceName]; ok {
+			return workspacePolicyName(*c.WorkspaceName), p
+		}
+	}
+	return defaultPolicyName, r.conf.LogRetentionPolicy
+}
+
+func (r *Reaper) apply(ctx context.Context, now time.Time, c db.TaskLogRetentionCandidate) error {
+	name, p := r.policyFor(c)
+	switch {
+	case p.Days != nil && c.EndTime.Before(now.Add(-time.Duration(*p.Days)*24*time.Hour)):
+		return r.deleteLogs(ctx, c.TaskID, 0, name, reasonAge)
+	case p.MaxLinesPerTask != nil &&
+		(c.LogRetentionMaxLines == nil || *c.LogRetentionMaxLines > *p.MaxLinesPerTask):
+		if err := r.deleteLogs(ctx, c.TaskID, *p.MaxLinesPerTask, name, reasonLines); err != nil {
+			return err
+		}
+		return db.SetTaskLogRetentionMaxLines(ctx, c.TaskID, *p.MaxLinesPerTask)
+	default:
+		return nil
+	}
+}
+
+// deleteLogs deletes all but the newest keep logs of a task, a batch at a time.
+func (r *Reaper) deleteLogs(
+	ctx context.Context, taskID model.TaskID, keep int, policy, reason string,
+) error {
+	var total int64
+	for {
+		n, err := db.DeleteEndedTaskLogs(ctx, taskID, keep, r.conf.BatchSize)
+		total += n
+		if err != nil || n < int64(r.conf.BatchSize) {
+			if total > 0 {
+				prom.AddTaskLogRetentionDeletions(policy, reason, total)
+				log.Infof("deleted %d logs of task %s under the %s log retention policy (%s)",
+					total, taskID, policy, reason)
+			}
+			return err
+		}
+	}
+}
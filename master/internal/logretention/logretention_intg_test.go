@@ -0,0 +1,92 @@
+//go:build integration
+// +build integration
+
+package logretention
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestReap(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB := db.MustResolveTestPostgres(t)
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+
+	user := db.RequireMockUser(t, pgDB)
+	addLogs := func(taskID model.TaskID) {
+		var logs []*model.TaskLog
+		for i := 0; i < 10; i++ {
+			logs = append(logs, &model.TaskLog{
+				TaskID: string(taskID), RankID: ptrs.Ptr(0), Log: fmt.Sprintf("line %d\n", i),
+			})
+		}
+		require.NoError(t, pgDB.AddTaskLogs(logs))
+	}
+	requireLogs := func(taskID model.TaskID, expected int) {
+		count, err := pgDB.TaskLogsCount(context.Background(), taskID, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, count, "logs of task %s", taskID)
+	}
+	ended := time.Now().Add(-48 * time.Hour)
+
+	// A trial in the default workspace, which keeps only its last 3 lines.
+	exp := db.RequireMockExperiment(t, pgDB, user)
+	trial := db.RequireMockTrial(t, pgDB, exp)
+	require.NoError(t, pgDB.CompleteTask(trial.TaskID, time.Now()))
+	addLogs(trial.TaskID)
+
+	// A task under the default policy that ended before the retention period.
+	old := db.RequireMockTask(t, pgDB, &user.ID)
+	require.NoError(t, pgDB.CompleteTask(old.TaskID, ended))
+	addLogs(old.TaskID)
+
+	// Tasks that are still running or have an open allocation are never touched.
+	running := db.RequireMockTask(t, pgDB, &user.ID)
+	addLogs(running.TaskID)
+	allocated := db.RequireMockTask(t, pgDB, &user.ID)
+	require.NoError(t, pgDB.CompleteTask(allocated.TaskID, ended))
+	db.RequireMockAllocation(t, pgDB, allocated.TaskID)
+	addLogs(allocated.TaskID)
+
+	conf := config.DefaultLogRetentionConfig()
+	conf.Enabled = true
+	conf.BatchSize = 2
+	conf.Days = ptrs.Ptr(1)
+	conf.Workspaces = map[string]config.LogRetentionPolicy{
+		"Uncategorized": {MaxLinesPerTask: ptrs.Ptr(3)},
+	}
+	r := New(conf)
+	require.NoError(t, r.Reap(ctx, time.Now()))
+
+	requireLogs(trial.TaskID, 3)
+	requireLogs(old.TaskID, 0)
+	requireLogs(running.TaskID, 10)
+	requireLogs(allocated.TaskID, 10)
+
+	logs, _, err := pgDB.TaskLogs(context.Background(), trial.TaskID, 10, nil, apiv1.OrderBy_ORDER_BY_ASC, nil)
+	require.NoError(t, err)
+	require.Equal(t, "line 7\n", logs[0].Log, "the newest lines are kept")
+
+	// Trimmed tasks are not trimmed again unless the limit is lowered.
+	candidates, err := db.TaskLogRetentionCandidates(ctx, "", nil, ptrs.Ptr(3), 1000)
+	require.NoError(t, err)
+	for _, c := range candidates {
+		require.NotEqual(t, trial.TaskID, c.TaskID)
+	}
+	conf.Workspaces["Uncategorized"] = config.LogRetentionPolicy{MaxLinesPerTask: ptrs.Ptr(1)}
+	require.NoError(t, New(conf).Reap(ctx, time.Now()))
+	requireLogs(trial.TaskID, 1)
+}
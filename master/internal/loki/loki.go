@@ -0,0 +1,156 @@
+// Package loki implements a task log backend that stores task logs in Grafana Loki.
+package loki
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// requestTimeout bounds every request made to Loki.
+	requestTimeout = time.Minute
+	// maxConnectTries is how many times Setup checks that Loki is ready before giving up.
+	maxConnectTries = 30
+)
+
+// Loki is a client for the HTTP API of a Loki cluster that stores task logs.
+type Loki struct {
+	client   *http.Client
+	addr     string
+	username *string
+	password *string
+	tenantID *string
+	lookback time.Duration
+}
+
+// Setup sets up a new Loki client with the given configuration.
+func Setup(conf model.LokiLoggingConfig) (*Loki, error) {
+	l, err := newLoki(conf)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("connecting to loki %s", l.addr)
+
+	// Try to connect to Loki - we'd rather fail hard here than on first log write.
+	numTries := 0
+	for {
+		err := l.do(context.Background(), http.MethodGet, "/ready", nil, nil, nil)
+		if err == nil {
+			log.Infof("connected to loki at %s", l.addr)
+			return l, nil
+		}
+		numTries++
+		if numTries >= maxConnectTries {
+			return nil, errors.Wrapf(err, "could not connect to loki after %v tries", numTries)
+		}
+		toWait := 4 * time.Second
+		time.Sleep(toWait)
+		log.WithError(err).Warnf("failed to connect to loki, trying again in %s", toWait)
+	}
+}
+
+func newLoki(conf model.LokiLoggingConfig) (*Loki, error) {
+	tlsCfg, err := lokiTLSConfig(conf.Security.TLS)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make loki tls config")
+	}
+
+	scheme := "http://"
+	if tlsCfg != nil {
+		scheme = "https://"
+	}
+	return &Loki{
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+			Timeout:   requestTimeout,
+		},
+		addr:     fmt.Sprintf("%s%s:%d", scheme, conf.Host, conf.Port),
+		username: conf.Security.Username,
+		password: conf.Security.Password,
+		tenantID: conf.TenantID,
+		lookback: time.Duration(conf.QueryLookback),
+	}, nil
+}
+
+func lokiTLSConfig(conf model.TLSClientConfig) (*tls.Config, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	var pool *x509.CertPool
+	if conf.CertBytes != nil {
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(conf.CertBytes) {
+			return nil, errors.New("certificate file contains no certificates")
+		}
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: conf.SkipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            pool,
+		ServerName:         conf.CertificateName,
+	}, nil
+}
+
+// do makes a request to the Loki API and, if resp is not nil, decodes the response into it.
+func (l *Loki) do(
+	ctx context.Context, method, path string, params url.Values, body io.Reader, resp interface{},
+) error {
+	u := l.addr + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if l.username != nil && l.password != nil {
+		req.SetBasicAuth(*l.username, *l.password)
+	}
+	if l.tenantID != nil {
+		req.Header.Set("X-Scope-OrgID", *l.tenantID)
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeWithErrCheck(res.Body)
+	if res.StatusCode > 299 || res.StatusCode < 200 {
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body with code %d", res.StatusCode)
+		}
+		return fmt.Errorf("request failed with code %d: %s", res.StatusCode, b)
+	}
+	if resp == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return errors.Wrap(err, "failed to decode loki response")
+	}
+	return nil
+}
+
+func closeWithErrCheck(closer io.Closer) {
+	err := closer.Close()
+	if err != nil {
+		log.Errorf("error closing closer: %s", err)
+	}
+}
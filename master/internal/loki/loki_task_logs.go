@@ -0,0 +1,524 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+const (
+	// The maximum number of entries returned by a query on a cluster with default configurations
+	// (limits_config.max_entries_limit_per_query).
+	lokiMaxQuerySize = 5000
+	// LokiTimeWindowDelay is the time buffer to allow logs to come in before we try to serve them
+	// up. Like with Elasticsearch, we page through logs by timestamp, so a log posted late with
+	// an earlier timestamp would otherwise be missed.
+	LokiTimeWindowDelay = 10 * time.Second
+
+	taskIDLabel = "task_id"
+	// The fields of a task log that filters may refer to which are not labels.
+	timestampField = "timestamp"
+	logField       = "log"
+)
+
+var (
+	// labelFields are the task log fields stored as stream labels, alongside the task ID. Each
+	// has few distinct values per task, so this keeps the number of streams small while letting
+	// filters use Loki's label index.
+	labelFields = []string{"agent_id", "rank_id", "level", "stdtype", "source"}
+	// metadataFields are the task log fields stored as structured metadata of each entry, since
+	// every allocation and container would otherwise create new streams.
+	metadataFields = []string{"allocation_id", "container_id"}
+)
+
+type (
+	// stream is a set of log entries sharing the same labels, as returned by Loki, which merges
+	// the structured metadata of the entries into the labels.
+	stream struct {
+		Labels map[string]string `json:"stream"`
+		// Values are pairs of a timestamp, in nanoseconds since the epoch, and a log line.
+		Values [][2]string `json:"values"`
+	}
+
+	// pushStream is a set of log entries sharing the same labels, as pushed to Loki.
+	pushStream struct {
+		Labels map[string]string `json:"stream"`
+		// Values are a timestamp, in nanoseconds since the epoch, a log line and, if it has any,
+		// the structured metadata of the entry.
+		Values [][]interface{} `json:"values"`
+	}
+
+	// searchAfter is the state for paging through task logs: the timestamp of the last log
+	// returned, in nanoseconds since the epoch, and the IDs of the logs returned with that
+	// timestamp. Timestamps may tie, so the next page starts at the same timestamp again and
+	// skips those logs.
+	searchAfter struct {
+		timestamp int64
+		ids       []string
+	}
+)
+
+// labelsOf returns the stream labels and the structured metadata of a task log.
+func labelsOf(l *model.TaskLog) (labels, metadata map[string]string) {
+	labels = map[string]string{taskIDLabel: l.TaskID}
+	metadata = map[string]string{}
+	set := func(m map[string]string, label string, v *string) {
+		if v != nil && *v != "" {
+			m[label] = *v
+		}
+	}
+	set(metadata, "allocation_id", l.AllocationID)
+	set(labels, "agent_id", l.AgentID)
+	set(metadata, "container_id", l.ContainerID)
+	if l.RankID != nil {
+		labels["rank_id"] = strconv.Itoa(*l.RankID)
+	}
+	set(labels, "level", l.Level)
+	set(labels, "stdtype", l.StdType)
+	set(labels, "source", l.Source)
+	return labels, metadata
+}
+
+// taskLogFromEntry reconstructs a task log from a log entry of a stream.
+func taskLogFromEntry(labels map[string]string, ts, line string) (*model.TaskLog, error) {
+	ns, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid loki timestamp %q", ts)
+	}
+	timestamp := time.Unix(0, ns).UTC()
+
+	h := fnv.New32a()
+	if err := json.NewEncoder(h).Encode(labels); err != nil {
+		return nil, err
+	}
+	_, _ = h.Write([]byte(line))
+	id := fmt.Sprintf("%s-%08x", ts, h.Sum32())
+
+	get := func(label string) *string {
+		if v, ok := labels[label]; ok {
+			return &v
+		}
+		return nil
+	}
+	l := &model.TaskLog{
+		StringID:     &id,
+		TaskID:       labels[taskIDLabel],
+		AllocationID: get("allocation_id"),
+		AgentID:      get("agent_id"),
+		ContainerID:  get("container_id"),
+		Timestamp:    &timestamp,
+		Level:        get("level"),
+		Log:          line,
+		Source:       get("source"),
+		StdType:      get("stdtype"),
+	}
+	if v, ok := labels["rank_id"]; ok {
+		rankID, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rank_id label %q", v)
+		}
+		l.RankID = &rankID
+	}
+	return l, nil
+}
+
+// AddTaskLogs pushes a batch of task logs to Loki, grouped into streams by their labels.
+func (l *Loki) AddTaskLogs(logs []*model.TaskLog) error {
+	var streams []*pushStream
+	byLabels := map[string]*pushStream{}
+	for _, tl := range logs {
+		labels, metadata := labelsOf(tl)
+		key, err := json.Marshal(labels)
+		if err != nil {
+			return errors.Wrap(err, "failed to make stream key")
+		}
+		s, ok := byLabels[string(key)]
+		if !ok {
+			s = &pushStream{Labels: labels}
+			byLabels[string(key)] = s
+			streams = append(streams, s)
+		}
+
+		ts := time.Now()
+		if tl.Timestamp != nil {
+			ts = *tl.Timestamp
+		}
+		entry := []interface{}{strconv.FormatInt(ts.UnixNano(), 10), tl.Log}
+		if len(metadata) > 0 {
+			entry = append(entry, metadata)
+		}
+		s.Values = append(s.Values, entry)
+	}
+	if len(streams) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(struct {
+		Streams []*pushStream `json:"streams"`
+	}{streams}); err != nil {
+		return errors.Wrap(err, "failed to make push request body")
+	}
+	if err := l.do(context.Background(), http.MethodPost, "/loki/api/v1/push", nil, &buf, nil); err != nil {
+		return errors.Wrap(err, "failed to push task logs")
+	}
+	return nil
+}
+
+// TaskLogsCount returns the number of logs for the given task.
+func (l *Loki) TaskLogsCount(
+	ctx context.Context, taskID model.TaskID, fs []api.Filter,
+) (int, error) {
+	q, err := l.newQuery(taskID, fs, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if !q.start.Before(q.end) {
+		return 0, nil
+	}
+
+	result, err := l.countQuery(ctx, q, "sum")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get task log count")
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	count, ok := result[0].Value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected task log count %v", result[0].Value[1])
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid task log count %q", count)
+	}
+	return int(n), nil
+}
+
+// sample is a sample of an instant vector returned by Loki.
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	// Value is a pair of the evaluation time and the value, as a string.
+	Value [2]interface{} `json:"value"`
+}
+
+// countQuery counts the logs of the query over its time range and aggregates the counts, e.g.
+// with "sum" or "sum by (rank_id)".
+func (l *Loki) countQuery(ctx context.Context, q *query, aggregation string) ([]sample, error) {
+	// Range vectors are given in whole seconds, so round the range up; the query's own start
+	// can't be given to an instant query.
+	rangeSeconds := int64(math.Ceil(q.end.Sub(q.start).Seconds()))
+	var resp struct {
+		Data struct {
+			Result []sample `json:"result"`
+		} `json:"data"`
+	}
+	if err := l.do(ctx, http.MethodGet, "/loki/api/v1/query", url.Values{
+		"query": {fmt.Sprintf("%s(count_over_time(%s [%ds]))", aggregation, q, rangeSeconds)},
+		"time":  {strconv.FormatInt(q.end.UnixNano(), 10)},
+	}, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data.Result, nil
+}
+
+// TaskLogs return a set of logs matching the provided criteria from the task. Logs are paged
+// through by timestamp, since Loki has no other ordering, skipping the logs with the timestamp
+// the previous page ended at that it already returned.
+func (l *Loki) TaskLogs(
+	ctx context.Context, taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy, state interface{},
+) ([]*model.TaskLog, interface{}, error) {
+	if limit > lokiMaxQuerySize {
+		limit = lokiMaxQuerySize
+	}
+
+	// Only look at logs posted more than 10 seconds ago; see LokiTimeWindowDelay.
+	q, err := l.newQuery(taskID, fs, time.Now().Add(-LokiTimeWindowDelay))
+	if err != nil {
+		return nil, nil, err
+	}
+	desc := order == apiv1.OrderBy_ORDER_BY_DESC
+	after, _ := state.(searchAfter)
+	if after.ids != nil {
+		// The start of a query is inclusive and its end exclusive.
+		ts := time.Unix(0, after.timestamp)
+		if desc && ts.Before(q.end) {
+			q.end = ts.Add(time.Nanosecond)
+		} else if !desc && !ts.Before(q.start) {
+			q.start = ts
+		}
+	}
+	if !q.start.Before(q.end) {
+		return nil, state, nil
+	}
+
+	// Ask for the logs already returned too, since they are only skipped once received.
+	size := limit + len(after.ids)
+	if size > lokiMaxQuerySize {
+		size = lokiMaxQuerySize
+	}
+	direction := "forward"
+	if desc {
+		direction = "backward"
+	}
+	var resp struct {
+		Data struct {
+			Result []stream `json:"result"`
+		} `json:"data"`
+	}
+	if err := l.do(ctx, http.MethodGet, "/loki/api/v1/query_range", url.Values{
+		"query":     {q.String()},
+		"start":     {strconv.FormatInt(q.start.UnixNano(), 10)},
+		"end":       {strconv.FormatInt(q.end.UnixNano(), 10)},
+		"limit":     {strconv.Itoa(size)},
+		"direction": {direction},
+	}, nil, &resp); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to query task logs")
+	}
+
+	var logs []*model.TaskLog
+	for _, s := range resp.Data.Result {
+		for _, v := range s.Values {
+			tl, err := taskLogFromEntry(s.Labels, v[0], v[1])
+			if err != nil {
+				return nil, nil, err
+			}
+			if tl.Timestamp.UnixNano() == after.timestamp && slices.Contains(after.ids, *tl.StringID) {
+				continue
+			}
+			logs = append(logs, tl)
+		}
+	}
+	// Entries are only ordered within each stream, so merge them, breaking ties by ID so pages
+	// agree on the order.
+	sort.Slice(logs, func(i, j int) bool {
+		ti, tj := *logs[i].Timestamp, *logs[j].Timestamp
+		if !ti.Equal(tj) {
+			return ti.Before(tj) != desc
+		}
+		return *logs[i].StringID < *logs[j].StringID
+	})
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+
+	if len(logs) > 0 {
+		next := searchAfter{timestamp: logs[len(logs)-1].Timestamp.UnixNano()}
+		if next.timestamp == after.timestamp {
+			next.ids = after.ids
+		}
+		for _, tl := range logs {
+			if tl.Timestamp.UnixNano() == next.timestamp {
+				next.ids = append(next.ids, *tl.StringID)
+			}
+		}
+		state = next
+	}
+	return logs, state, nil
+}
+
+// DeleteTaskLogs deletes the logs for the given tasks. This requires deletion to be enabled in
+// the compactor of the Loki cluster; deletes are processed asynchronously by Loki.
+func (l *Loki) DeleteTaskLogs(ctx context.Context, ids []model.TaskID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = string(id)
+	}
+	if err := l.do(ctx, http.MethodPost, "/loki/api/v1/delete", url.Values{
+		"query": {fmt.Sprintf("{%s=~%s}", taskIDLabel, strconv.Quote(anyOf(values)))},
+	}, nil, nil); err != nil {
+		return errors.Wrap(err, "failed to perform delete")
+	}
+	return nil
+}
+
+// TaskLogsFields returns the unique fields that can be filtered on for the given task.
+func (l *Loki) TaskLogsFields(
+	ctx context.Context, taskID model.TaskID,
+) (*apiv1.TaskLogsFieldsResponse, error) {
+	q, err := l.newQuery(taskID, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// Structured metadata isn't part of any series, but counts can be grouped by it.
+	result, err := l.countQuery(ctx, q, fmt.Sprintf("sum by (%s)",
+		strings.Join(append(slices.Clone(labelFields), metadataFields...), ", ")))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get task log fields")
+	}
+
+	var fields apiv1.TaskLogsFieldsResponse
+	add := func(values *[]string, labels map[string]string, label string) {
+		if v, ok := labels[label]; ok && !slices.Contains(*values, v) {
+			*values = append(*values, v)
+		}
+	}
+	for _, r := range result {
+		labels := r.Metric
+		add(&fields.AllocationIds, labels, "allocation_id")
+		add(&fields.AgentIds, labels, "agent_id")
+		add(&fields.ContainerIds, labels, "container_id")
+		add(&fields.Stdtypes, labels, "stdtype")
+		add(&fields.Sources, labels, "source")
+		if v, ok := labels["rank_id"]; ok {
+			rankID, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid rank_id label %q", v)
+			}
+			if !slices.Contains(fields.RankIds, int32(rankID)) {
+				fields.RankIds = append(fields.RankIds, int32(rankID))
+			}
+		}
+	}
+	return &fields, nil
+}
+
+// MaxTerminationDelay is the max delay before a consumer can be sure all logs have been recevied.
+// This _must_ be greater than LokiTimeWindowDelay or else following terminates before all logs
+// are delivered.
+func (l *Loki) MaxTerminationDelay() time.Duration {
+	return LokiTimeWindowDelay + time.Second
+}
+
+// query is a LogQL log query over the logs of a task within a time range.
+type query struct {
+	matchers []string
+	pipeline []string
+	start    time.Time
+	end      time.Time
+}
+
+// newQuery returns a query for the logs of a task that match the filters, looking back from end.
+func (l *Loki) newQuery(taskID model.TaskID, fs []api.Filter, end time.Time) (*query, error) {
+	q := &query{
+		matchers: []string{fmt.Sprintf("%s=%s", taskIDLabel, strconv.Quote(string(taskID)))},
+		start:    end.Add(-l.lookback),
+		end:      end,
+	}
+	if err := filtersToLogQL(fs, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *query) selector() string {
+	return "{" + strings.Join(q.matchers, ", ") + "}"
+}
+
+func (q *query) String() string {
+	return strings.Join(append([]string{q.selector()}, q.pipeline...), " ")
+}
+
+// filtersToLogQL adds the filters to a query. Filters on labels become stream selector matchers
+// or label filters, filters on structured metadata become label filters, filters on the
+// timestamp narrow the query's time range and filters on the log become line filters.
+func filtersToLogQL(fs []api.Filter, q *query) error {
+	for _, f := range fs {
+		isStreamLabel := slices.Contains(labelFields, f.Field)
+		isLabel := isStreamLabel || slices.Contains(metadataFields, f.Field)
+		switch f.Operation {
+		case api.FilterOperationIn, api.FilterOperationInOrNull:
+			if !isLabel {
+				return fmt.Errorf("unsupported filter field for %d: %s", f.Operation, f.Field)
+			}
+			values, err := interfaceToStrings(f.Values)
+			if err != nil {
+				return fmt.Errorf("invalid IN filter values: %w", err)
+			}
+			re := anyOf(values)
+			if f.Operation == api.FilterOperationInOrNull {
+				// Logs without the field lack its label, which matches the empty string.
+				re += "|"
+			}
+			matcher := fmt.Sprintf("%s=~%s", f.Field, strconv.Quote(re))
+			if isStreamLabel {
+				q.matchers = append(q.matchers, matcher)
+			} else {
+				// Structured metadata can only be matched by label filters.
+				q.pipeline = append(q.pipeline, "| "+matcher)
+			}
+		case api.FilterOperationGreaterThan, api.FilterOperationLessThanEqual:
+			gt := f.Operation == api.FilterOperationGreaterThan
+			switch {
+			case f.Field == timestampField:
+				t, ok := f.Values.(time.Time)
+				if !ok {
+					return fmt.Errorf("invalid timestamp filter value: %v", f.Values)
+				}
+				// The start of a query is inclusive and its end exclusive.
+				t = t.Add(time.Nanosecond)
+				if gt && t.After(q.start) {
+					q.start = t
+				} else if !gt && t.Before(q.end) {
+					q.end = t
+				}
+			case isLabel:
+				op := "<="
+				if gt {
+					op = ">"
+				}
+				q.pipeline = append(q.pipeline, fmt.Sprintf("| %s %s %v", f.Field, op, f.Values))
+			default:
+				return fmt.Errorf("unsupported filter field for %d: %s", f.Operation, f.Field)
+			}
+		case api.FilterOperationStringContainment:
+			re := "(?i)" + regexp.QuoteMeta(fmt.Sprint(f.Values))
+			switch {
+			case f.Field == logField:
+				q.pipeline = append(q.pipeline, "|~ "+strconv.Quote(re))
+			case isLabel:
+				q.pipeline = append(q.pipeline,
+					fmt.Sprintf("| %s=~%s", f.Field, strconv.Quote(".*"+re+".*")))
+			default:
+				return fmt.Errorf("unsupported filter field for %d: %s", f.Operation, f.Field)
+			}
+		default:
+			return fmt.Errorf("unsupported filter operation: %d", f.Operation)
+		}
+	}
+	return nil
+}
+
+// anyOf returns a regular expression matching exactly any of the values.
+func anyOf(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	return strings.Join(quoted, "|")
+}
+
+// interfaceToStrings accepts an interface{} whose underlying type is []T for any T and returns
+// its elements formatted as strings, the way they appear in labels.
+func interfaceToStrings(x interface{}) ([]string, error) {
+	s := reflect.ValueOf(x)
+	if s.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("interfaceToStrings only accepts slice, not %T", x)
+	}
+	values := make([]string, 0, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		values = append(values, fmt.Sprint(s.Index(i).Interface()))
+	}
+	return values, nil
+}
@@ -0,0 +1,283 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// fakeLoki is just enough of the Loki HTTP API to test against: it stores pushed streams, with
+// structured metadata merged into their labels, and answers every query with all of them within
+// the query's time range and limit, recording the queries it was asked.
+type fakeLoki struct {
+	mu      sync.Mutex
+	pushed  []map[string]string
+	streams []stream
+	queries []url.Values
+	deletes []string
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != "/loki/api/v1/push" {
+		f.queries = append(f.queries, r.URL.Query())
+	}
+
+	var resp interface{}
+	switch r.URL.Path {
+	case "/ready":
+	case "/loki/api/v1/push":
+		var req struct {
+			Streams []struct {
+				Labels map[string]string   `json:"stream"`
+				Values [][]json.RawMessage `json:"values"`
+			} `json:"streams"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, s := range req.Streams {
+			f.pushed = append(f.pushed, s.Labels)
+			for _, v := range s.Values {
+				// Each entry becomes its own stream, with its structured metadata as labels.
+				labels := map[string]string{}
+				for k, l := range s.Labels {
+					labels[k] = l
+				}
+				var entry [2]string
+				_ = json.Unmarshal(v[0], &entry[0])
+				_ = json.Unmarshal(v[1], &entry[1])
+				if len(v) > 2 {
+					_ = json.Unmarshal(v[2], &labels)
+				}
+				f.streams = append(f.streams, stream{Labels: labels, Values: [][2]string{entry}})
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "/loki/api/v1/query_range":
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		ts := func(s stream) int64 {
+			ns, _ := strconv.ParseInt(s.Values[0][0], 10, 64)
+			return ns
+		}
+		var result []stream
+		for _, s := range f.streams {
+			if ts(s) >= start && ts(s) < end {
+				result = append(result, s)
+			}
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			if q.Get("direction") == "backward" {
+				return ts(result[i]) > ts(result[j])
+			}
+			return ts(result[i]) < ts(result[j])
+		})
+		if len(result) > limit {
+			result = result[:limit]
+		}
+		resp = jsonObj{"data": jsonObj{"resultType": "streams", "result": result}}
+	case "/loki/api/v1/query":
+		var result []jsonObj
+		if strings.HasPrefix(r.URL.Query().Get("query"), "sum by") {
+			for _, s := range f.streams {
+				result = append(result, jsonObj{"metric": s.Labels, "value": []interface{}{1, "1"}})
+			}
+		} else {
+			result = append(result, jsonObj{
+				"metric": jsonObj{}, "value": []interface{}{1, strconv.Itoa(len(f.streams))},
+			})
+		}
+		resp = jsonObj{"data": jsonObj{"resultType": "vector", "result": result}}
+	case "/loki/api/v1/delete":
+		f.deletes = append(f.deletes, r.URL.Query().Get("query"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+	if resp != nil {
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+type jsonObj = map[string]interface{}
+
+func setupFakeLoki(t *testing.T) (*Loki, *fakeLoki) {
+	fake := &fakeLoki{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	l, err := Setup(model.LokiLoggingConfig{
+		Host:          u.Hostname(),
+		Port:          port,
+		TenantID:      ptrs.Ptr("tenant"),
+		QueryLookback: model.Duration(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return l, fake
+}
+
+func TestTaskLogs(t *testing.T) {
+	ctx := context.Background()
+	l, fake := setupFakeLoki(t)
+	taskID := model.TaskID("task-1")
+
+	start := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	var logs []*model.TaskLog
+	for i := 0; i < 4; i++ {
+		logs = append(logs, &model.TaskLog{
+			TaskID:       string(taskID),
+			AllocationID: ptrs.Ptr("task-1.1"),
+			AgentID:      ptrs.Ptr("agent"),
+			RankID:       ptrs.Ptr(i % 2),
+			Timestamp:    ptrs.Ptr(start.Add(time.Duration(i) * time.Second)),
+			Level:        ptrs.Ptr("INFO"),
+			StdType:      ptrs.Ptr("stdout"),
+			Log:          "line " + strconv.Itoa(i) + "\n",
+		})
+	}
+	require.NoError(t, l.AddTaskLogs(logs))
+	require.Len(t, fake.pushed, 2, "one stream per rank")
+	require.NotContains(t, fake.pushed[0], "allocation_id", "allocations don't make streams")
+
+	rankOne := []api.Filter{{
+		Field: "rank_id", Operation: api.FilterOperationIn, Values: []int32{1},
+	}}
+	actual, state, err := l.TaskLogs(ctx, taskID, 10, rankOne, apiv1.OrderBy_ORDER_BY_ASC, nil)
+	require.NoError(t, err)
+	require.Len(t, actual, 4)
+	for i, a := range actual {
+		require.NotNil(t, a.StringID)
+		withoutID := *a
+		withoutID.StringID = nil
+		require.Equal(t, logs[i], &withoutID, "logs are merged across streams in order")
+	}
+	require.Equal(t, searchAfter{
+		timestamp: logs[3].Timestamp.UnixNano(), ids: []string{*actual[3].StringID},
+	}, state)
+
+	q := fake.queries[len(fake.queries)-1]
+	require.Equal(t, `{task_id="task-1", rank_id=~"1"}`, q.Get("query"))
+	require.Equal(t, "forward", q.Get("direction"))
+
+	_, _, err = l.TaskLogs(ctx, taskID, 2, nil, apiv1.OrderBy_ORDER_BY_DESC, state)
+	require.NoError(t, err)
+	q = fake.queries[len(fake.queries)-1]
+	require.Equal(t, "backward", q.Get("direction"))
+	require.Equal(t, strconv.FormatInt(logs[3].Timestamp.UnixNano()+1, 10), q.Get("end"),
+		"paging backward ends just after the last log returned")
+
+	count, err := l.TaskLogsCount(ctx, taskID, nil)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.True(t, strings.HasPrefix(
+		fake.queries[len(fake.queries)-1].Get("query"), `sum(count_over_time({task_id="task-1"} [`))
+
+	fields, err := l.TaskLogsFields(ctx, taskID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int32{0, 1}, fields.RankIds)
+	require.Equal(t, []string{"agent"}, fields.AgentIds)
+	require.Equal(t, []string{"task-1.1"}, fields.AllocationIds)
+	require.Equal(t, []string{"stdout"}, fields.Stdtypes)
+	require.Empty(t, fields.ContainerIds)
+
+	require.NoError(t, l.DeleteTaskLogs(ctx, []model.TaskID{taskID, "task.2"}))
+	require.Equal(t, []string{`{task_id=~"task-1|task\\.2"}`}, fake.deletes)
+}
+
+func TestTaskLogsTiedTimestamps(t *testing.T) {
+	ctx := context.Background()
+	l, _ := setupFakeLoki(t)
+	taskID := model.TaskID("task-1")
+
+	ts := time.Now().UTC().Add(-time.Minute)
+	var logs []*model.TaskLog
+	var expected []string
+	for i := 0; i < 5; i++ {
+		logs = append(logs, &model.TaskLog{
+			TaskID:    string(taskID),
+			Timestamp: ptrs.Ptr(ts),
+			Log:       "line " + strconv.Itoa(i) + "\n",
+		})
+		expected = append(expected, logs[i].Log)
+	}
+	logs = append(logs, &model.TaskLog{
+		TaskID:    string(taskID),
+		Timestamp: ptrs.Ptr(ts.Add(time.Second)),
+		Log:       "last\n",
+	})
+	expected = append(expected, "last\n")
+	require.NoError(t, l.AddTaskLogs(logs))
+
+	for _, order := range []apiv1.OrderBy{apiv1.OrderBy_ORDER_BY_ASC, apiv1.OrderBy_ORDER_BY_DESC} {
+		var actual []string
+		var state interface{}
+		for i := 0; i < 10; i++ {
+			page, next, err := l.TaskLogs(ctx, taskID, 2, nil, order, state)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			for _, tl := range page {
+				actual = append(actual, tl.Log)
+			}
+			state = next
+		}
+		require.ElementsMatch(t, expected, actual, "every log is returned once across pages")
+	}
+}
+
+func TestFiltersToLogQL(t *testing.T) {
+	now := time.Now()
+	q := &query{
+		matchers: []string{`task_id="t"`},
+		start:    now.Add(-time.Hour),
+		end:      now,
+	}
+	require.NoError(t, filtersToLogQL([]api.Filter{
+		{Field: "rank_id", Operation: api.FilterOperationInOrNull, Values: []int32{0, -1}},
+		{Field: "level", Operation: api.FilterOperationIn, Values: []string{"ERROR", "WARNING"}},
+		{Field: "allocation_id", Operation: api.FilterOperationIn, Values: []string{"a.1"}},
+		{Field: "rank_id", Operation: api.FilterOperationGreaterThan, Values: 0},
+		{Field: "log", Operation: api.FilterOperationStringContainment, Values: `a "b".c`},
+		{
+			Field:     "timestamp",
+			Operation: api.FilterOperationGreaterThan,
+			Values:    now.Add(-time.Minute),
+		},
+	}, q))
+	require.Equal(t,
+		`{task_id="t", rank_id=~"0|-1|", level=~"ERROR|WARNING"} | allocation_id=~"a\\.1" `+
+			`| rank_id > 0 `+
+			`|~ "(?i)a \"b\"\\.c"`,
+		q.String())
+	require.Equal(t, now.Add(-time.Minute).Add(time.Nanosecond), q.start)
+	require.Equal(t, now, q.end)
+
+	require.Error(t, filtersToLogQL([]api.Filter{
+		{Field: "id", Operation: api.FilterOperationGreaterThan, Values: 1},
+	}, q))
+}
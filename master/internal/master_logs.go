@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/masterlogs"
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+const (
+	masterLogsPageSize       = 1000
+	masterLogsFollowInterval = 500 * time.Millisecond
+)
+
+// persistMasterLogs starts persisting master logs, if configured to.
+func (m *Master) persistMasterLogs(ctx context.Context) error {
+	if m.config.Log.Persistence == nil {
+		return nil
+	}
+	store, err := masterlogs.New(*m.config.Log.Persistence)
+	if err != nil {
+		return err
+	}
+	log.AddHook(store)
+	go store.Run(ctx)
+	m.logStore = store
+	return nil
+}
+
+// masterLogs returns the newest limit master logs that match the filter, or the oldest limit of
+// them if the filter has an AfterID, oldest first, from the persisted logs if there are any and
+// from the in-memory buffer otherwise.
+func (m *Master) masterLogs(
+	ctx context.Context, filter logger.EntryFilter, limit int,
+) ([]*logger.Entry, error) {
+	if m.logStore != nil {
+		return m.logStore.Entries(ctx, filter, limit)
+	}
+	startID := -1
+	if filter.AfterID != nil {
+		startID = *filter.AfterID + 1
+	}
+	var entries []*logger.Entry
+	for _, e := range m.logs.Entries(startID, -1, -1) {
+		if filter.Matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) > limit {
+		if filter.AfterID != nil {
+			return entries[:limit], nil
+		}
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// streamMasterLogs sends the master logs that match the filter, oldest first, skipping offset of
+// them or, if offset is negative, starting -offset logs from the end, until limit logs are sent
+// if limit is positive. If follow is set, it then keeps sending new logs as they are written.
+func (m *Master) streamMasterLogs(
+	ctx context.Context, filter logger.EntryFilter, offset, limit int, follow bool,
+	send func(*logger.Entry) error,
+) error {
+	sent := 0
+	// emit sends entries until the limit is reached, which it reports as done.
+	emit := func(entries []*logger.Entry) (done bool, err error) {
+		for _, e := range entries {
+			if err := send(e); err != nil {
+				return true, err
+			}
+			if sent++; limit > 0 && sent >= limit {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	// Logs are read by ID from where the previous read ended, so that logs that were still being
+	// persisted during a read are neither missed nor sent twice.
+	lastID := -1
+	skip := 0
+	if offset < 0 {
+		entries, err := m.masterLogs(ctx, filter, -offset)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			lastID = entries[len(entries)-1].ID
+		}
+		if done, err := emit(entries); done {
+			return err
+		}
+	} else {
+		skip = offset
+	}
+	drain := func() (done bool, err error) {
+		for {
+			next := filter
+			next.AfterID = &lastID
+			entries, err := m.masterLogs(ctx, next, masterLogsPageSize)
+			if err != nil {
+				return true, err
+			}
+			if len(entries) == 0 {
+				return false, nil
+			}
+			lastID = entries[len(entries)-1].ID
+			if skip >= len(entries) {
+				skip -= len(entries)
+				continue
+			}
+			if done, err := emit(entries[skip:]); done {
+				return true, err
+			}
+			skip = 0
+			if len(entries) < masterLogsPageSize {
+				return false, nil
+			}
+		}
+	}
+	if offset >= 0 {
+		if done, err := drain(); done || !follow {
+			return err
+		}
+	} else if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(masterLogsFollowInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+		if done, err := drain(); done {
+			return err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestStreamMasterLogs(t *testing.T) {
+	m := &Master{logs: logger.NewLogBuffer(100)}
+	fire := func(i int) {
+		level := logrus.InfoLevel
+		if i%2 == 1 {
+			level = logrus.ErrorLevel
+		}
+		require.NoError(t, m.logs.Fire(&logrus.Entry{
+			Time: time.Now(), Level: level, Message: fmt.Sprintf("line %d", i),
+		}))
+	}
+	for i := 0; i < 10; i++ {
+		fire(i)
+	}
+
+	errors := logger.EntryFilter{Level: ptrs.Ptr(logrus.ErrorLevel)}
+	stream := func(
+		ctx context.Context, filter logger.EntryFilter, offset, limit int, follow bool,
+	) []string {
+		var messages []string
+		require.NoError(t, m.streamMasterLogs(ctx, filter, offset, limit, follow,
+			func(e *logger.Entry) error {
+				messages = append(messages, e.Message)
+				return nil
+			}))
+		return messages
+	}
+
+	ctx := context.Background()
+	require.Equal(t, []string{"line 7", "line 9"}, stream(ctx, errors, -2, 0, false))
+	require.Equal(t, []string{"line 3", "line 5"}, stream(ctx, errors, 1, 2, false))
+	require.Len(t, stream(ctx, logger.EntryFilter{}, 0, 0, false), 10)
+	require.Equal(t, []string{"line 4"},
+		stream(ctx, logger.EntryFilter{Text: "LINE 4"}, 0, 0, false))
+
+	// Following sends new logs that match the filter until the limit is reached.
+	messages := make(chan string)
+	done := make(chan error)
+	go func() {
+		done <- m.streamMasterLogs(ctx, errors, -1, 3, true, func(e *logger.Entry) error {
+			messages <- e.Message
+			return nil
+		})
+	}()
+	require.Equal(t, "line 9", <-messages)
+	for i := 10; i < 14; i++ {
+		fire(i)
+	}
+	require.Equal(t, "line 11", <-messages)
+	require.Equal(t, "line 13", <-messages)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("following master logs did not stop at the limit")
+	}
+}
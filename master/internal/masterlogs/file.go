@@ -0,0 +1,194 @@
+package masterlogs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+// maxFileLineSize bounds the size of a log line read back from a file.
+const maxFileLineSize = 1 << 20
+
+// fileStore persists master logs as JSON lines to a file that is rotated once it exceeds a size,
+// keeping a number of old files as <path>.1 (the newest) to <path>.<max_backups>.
+type fileStore struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	// next is the ID of the next entry. IDs keep increasing across rotations and restarts, so
+	// that readers can follow new entries by ID.
+	next int
+}
+
+func newFileStore(conf logger.PersistenceConfig) (*fileStore, error) {
+	s := &fileStore{
+		path:       conf.Path,
+		maxSize:    int64(conf.MaxSizeMB) << 20,
+		maxBackups: conf.MaxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	// Continue the IDs of the newest file that has entries.
+	for i := 0; i <= s.maxBackups && s.next == 0; i++ {
+		path := s.path
+		if i > 0 {
+			path = s.backup(i)
+		}
+		if err := readEntries(path, func(e *logger.Entry) {
+			s.next = e.ID + 1
+		}); err != nil {
+			_ = s.f.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *fileStore) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "opening master log file")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "opening master log file")
+	}
+	s.f, s.size = f, info.Size()
+	return nil
+}
+
+func (s *fileStore) backup(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+func (s *fileStore) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+		return s.open()
+	}
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(s.backup(i), s.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, s.backup(1)); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Levels implements the logrus.Hook interface.
+func (s *fileStore) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements the logrus.Hook interface.
+func (s *fileStore) Fire(entry *logrus.Entry) error {
+	e := logger.NewEntry(entry)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.ID = s.next
+	s.next++
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return errors.Wrap(err, "rotating master log file")
+		}
+	}
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// Entries implements Store. It reads every file, oldest first, so it is slow for large files.
+func (s *fileStore) Entries(
+	ctx context.Context, filter logger.EntryFilter, limit int,
+) ([]*logger.Entry, error) {
+	// Hold the lock so that files are not rotated while they are read.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the newest limit matches in a ring, or the oldest limit matches after filter.AfterID.
+	ring := make([]*logger.Entry, limit)
+	var n int
+	for i := s.maxBackups; i >= 0; i-- {
+		path := s.path
+		if i > 0 {
+			path = s.backup(i)
+		}
+		err := readEntries(path, func(e *logger.Entry) {
+			if !filter.Matches(e) || (filter.AfterID != nil && n == limit) {
+				return
+			}
+			ring[n%limit] = e
+			n++
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if n <= limit {
+		return ring[:n], nil
+	}
+	return append(ring[n%limit:], ring[:n%limit]...), nil
+}
+
+func readEntries(path string, f func(*logger.Entry)) error {
+	file, err := os.Open(path) // #nosec G304
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "reading master log file")
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileLineSize)
+	for scanner.Scan() {
+		var e logger.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip lines cut off by a crash.
+			continue
+		}
+		f(&e)
+	}
+	return scanner.Err()
+}
+
+// Run implements Store.
+func (s *fileStore) Run(ctx context.Context) {
+	<-ctx.Done()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.f.Close()
+}
@@ -0,0 +1,75 @@
+package masterlogs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.log")
+	s, err := newFileStore(logger.PersistenceConfig{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	assert.NilError(t, err)
+	// Rotate every few entries.
+	s.maxSize = 1000
+
+	for i := 0; i < 100; i++ {
+		level := logrus.InfoLevel
+		if i%10 == 0 {
+			level = logrus.ErrorLevel
+		}
+		assert.NilError(t, s.Fire(&logrus.Entry{
+			Message: fmt.Sprintf("message %d", i),
+			Time:    time.Now(),
+			Level:   level,
+			Data:    logrus.Fields{},
+		}))
+	}
+	_, err = os.Stat(s.backup(2))
+	assert.NilError(t, err)
+	_, err = os.Stat(s.backup(3))
+	assert.Assert(t, os.IsNotExist(err))
+
+	entries, err := s.Entries(context.Background(), logger.EntryFilter{}, 3)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 3)
+	assert.Equal(t, entries[0].Message, "message 97")
+	assert.Equal(t, entries[2].Message, "message 99")
+
+	errorLevel := logrus.ErrorLevel
+	entries, err = s.Entries(context.Background(), logger.EntryFilter{Level: &errorLevel}, 100)
+	assert.NilError(t, err)
+	for _, e := range entries {
+		assert.Equal(t, e.Level, logrus.ErrorLevel)
+	}
+	assert.Equal(t, entries[len(entries)-1].Message, "message 90")
+
+	// IDs stay the same across rotations, so new entries can be followed by ID.
+	after := 96
+	entries, err = s.Entries(context.Background(), logger.EntryFilter{AfterID: &after}, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].ID, 97)
+	assert.Equal(t, entries[0].Message, "message 97")
+	assert.Equal(t, entries[1].ID, 98)
+
+	// A reopened store continues the IDs.
+	s.Run(canceledContext())
+	s, err = newFileStore(logger.PersistenceConfig{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, s.next, 100)
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
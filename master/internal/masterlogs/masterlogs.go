@@ -0,0 +1,36 @@
+// Package masterlogs persists master logs beyond the in-memory log buffer, to a rotating file or
+// to Postgres, and reads them back.
+package masterlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+// Store persists master logs as a logrus hook.
+type Store interface {
+	logrus.Hook
+	// Entries returns the newest limit persisted entries that match the filter, oldest first. If
+	// the filter has an AfterID, it returns the oldest limit entries after it instead, so that
+	// callers can follow new entries by ID.
+	Entries(ctx context.Context, filter logger.EntryFilter, limit int) ([]*logger.Entry, error)
+	// Run maintains the store until ctx is canceled.
+	Run(ctx context.Context)
+}
+
+// New returns the store conf configures.
+func New(conf logger.PersistenceConfig) (Store, error) {
+	conf = conf.WithDefaults()
+	switch conf.Type {
+	case logger.PersistenceTypeFile:
+		return newFileStore(conf)
+	case logger.PersistenceTypePostgres:
+		return newPostgresStore(conf), nil
+	default:
+		return nil, fmt.Errorf("unknown master log persistence type %q", conf.Type)
+	}
+}
@@ -0,0 +1,103 @@
+package masterlogs
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+const (
+	postgresQueueSize     = 10000
+	postgresBatchSize     = 1000
+	postgresFlushInterval = time.Second
+	postgresPruneInterval = time.Hour
+	// postgresShutdownTimeout bounds how long persisting the last entries delays shutdown.
+	postgresShutdownTimeout = 5 * time.Second
+)
+
+// postgresStore persists master logs to Postgres in batches. Logging never waits on Postgres:
+// entries are dropped, and the drops reported, when the queue is full.
+type postgresStore struct {
+	retention time.Duration
+	queue     chan *logger.Entry
+	dropped   atomic.Int64
+}
+
+func newPostgresStore(conf logger.PersistenceConfig) *postgresStore {
+	return &postgresStore{
+		retention: time.Duration(conf.RetentionDays) * 24 * time.Hour,
+		queue:     make(chan *logger.Entry, postgresQueueSize),
+	}
+}
+
+// Levels implements the logrus.Hook interface.
+func (s *postgresStore) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements the logrus.Hook interface.
+func (s *postgresStore) Fire(entry *logrus.Entry) error {
+	select {
+	case s.queue <- logger.NewEntry(entry):
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Entries implements Store.
+func (s *postgresStore) Entries(
+	ctx context.Context, filter logger.EntryFilter, limit int,
+) ([]*logger.Entry, error) {
+	return db.MasterLogs(ctx, filter, limit)
+}
+
+// Run implements Store.
+func (s *postgresStore) Run(ctx context.Context) {
+	flush := time.NewTicker(postgresFlushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(postgresPruneInterval)
+	defer prune.Stop()
+
+	var batch []*logger.Entry
+	for {
+		select {
+		case e := <-s.queue:
+			if batch = append(batch, e); len(batch) < postgresBatchSize {
+				continue
+			}
+		case <-flush.C:
+		case <-prune.C:
+			if _, err := db.DeleteMasterLogsBefore(ctx, time.Now().Add(-s.retention)); err != nil {
+				logrus.WithError(err).Error("failed to delete old master logs")
+			}
+			continue
+		case <-ctx.Done():
+			// Persist the pending and queued entries before stopping.
+			for len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), postgresShutdownTimeout)
+			defer cancel()
+			s.add(ctx, batch)
+			return
+		}
+
+		s.add(ctx, batch)
+		batch = nil
+	}
+}
+
+func (s *postgresStore) add(ctx context.Context, batch []*logger.Entry) {
+	if err := db.AddMasterLogs(ctx, batch); err != nil {
+		s.dropped.Add(int64(len(batch)))
+	}
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		logrus.Warnf("dropped %d master logs that could not be persisted", dropped)
+	}
+}
@@ -0,0 +1,221 @@
+// Package metricstream pushes trial metrics to subscribers as they are persisted. One Postgres
+// notification listener and one read of each report are shared by every subscriber.
+package metricstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+const (
+	// subscriptionBufferSize is the number of reports a following subscriber may fall behind by
+	// before it is dropped.
+	subscriptionBufferSize = 256
+	listenRetryInterval    = 5 * time.Second
+)
+
+var (
+	// ErrNotListening is returned when subscribing while the hub cannot receive notifications.
+	ErrNotListening = errors.New("trial metrics are not being streamed, try again later")
+	// ErrMissedReports ends subscriptions that may have missed reports, which should resume.
+	ErrMissedReports = errors.New("trial metrics stream interrupted, resume to catch up")
+	// ErrTooSlow ends subscriptions that fell too far behind, which should resume.
+	ErrTooSlow = errors.New("trial metrics subscriber fell behind, resume to catch up")
+)
+
+// Filter selects the reports a subscription receives.
+type Filter struct {
+	TrialIDs map[int]bool
+	// ExperimentID, if set, selects every trial of the experiment, including future trials.
+	ExperimentID *int
+	Groups       map[model.MetricType]bool
+}
+
+func (f Filter) matches(n db.TrialMetricsNotification) bool {
+	if !f.Groups[n.Group] {
+		return false
+	}
+	return f.TrialIDs[n.TrialID] || (f.ExperimentID != nil && *f.ExperimentID == n.ExperimentID)
+}
+
+// Report is a persisted metrics report of a group.
+type Report struct {
+	Group model.MetricType
+	*trialv1.MetricsReport
+}
+
+// Subscription receives the reports that match its filter, in the order they were persisted.
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	// held are the reports persisted before the subscriber started following, however many.
+	held      []Report
+	following bool
+	c         chan Report
+	err       error
+}
+
+// Follow returns the reports held since the subscription started and from then on delivers
+// reports on Reports. Until then, reports are held however many there are, so that subscribers
+// can first catch up on the reports persisted before they subscribed without being dropped.
+func (s *Subscription) Follow() []Report {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	held := s.held
+	s.held, s.following = nil, true
+	return held
+}
+
+// Reports returns the channel of reports, which is closed once the subscription ends.
+func (s *Subscription) Reports() <-chan Report {
+	return s.c
+}
+
+// Err returns why the hub ended the subscription, once Reports is closed.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.unsubscribe(s, nil)
+}
+
+// Hub fans out persisted trial metrics to subscriptions.
+type Hub struct {
+	listen func(context.Context, func(), func(db.TrialMetricsNotification)) error
+	fetch  func(context.Context, int, model.MetricType) (*trialv1.MetricsReport, error)
+	// stream sets whether persisted trial metrics are announced, which they only need to be while
+	// there are subscriptions.
+	stream func(bool)
+
+	mu        sync.Mutex
+	listening bool
+	subs      map[*Subscription]bool
+}
+
+// New returns a hub that listens for the trial metrics persisted to pg.
+func New(pg *db.PgDB) *Hub {
+	return &Hub{
+		listen: pg.ListenTrialMetrics,
+		fetch:  db.MetricsReportByID,
+		stream: db.StreamTrialMetrics,
+		subs:   map[*Subscription]bool{},
+	}
+}
+
+// Subscribe returns a subscription to the reports that match the filter from now on. They are
+// held until Follow is called.
+func (h *Hub) Subscribe(filter Filter) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.listening {
+		return nil, ErrNotListening
+	}
+	if len(h.subs) == 0 {
+		h.stream(true)
+	}
+	s := &Subscription{hub: h, filter: filter, c: make(chan Report, subscriptionBufferSize)}
+	h.subs[s] = true
+	return s, nil
+}
+
+// Run listens for persisted trial metrics until ctx is canceled.
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		err := h.listen(ctx, h.setListening, func(n db.TrialMetricsNotification) {
+			h.publish(ctx, n)
+		})
+		h.stopListening(ErrMissedReports)
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Warnf("trial metrics listener failed, retrying in %s",
+			listenRetryInterval)
+		select {
+		case <-time.After(listenRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Hub) setListening() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listening = true
+}
+
+func (h *Hub) stopListening(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listening = false
+	for s := range h.subs {
+		h.unsubscribe(s, err)
+	}
+}
+
+// unsubscribe ends a subscription. It must be called with mu held.
+func (h *Hub) unsubscribe(s *Subscription, err error) {
+	if !h.subs[s] {
+		return
+	}
+	delete(h.subs, s)
+	s.err = err
+	close(s.c)
+	if len(h.subs) == 0 {
+		h.stream(false)
+	}
+}
+
+func (h *Hub) publish(ctx context.Context, n db.TrialMetricsNotification) {
+	h.mu.Lock()
+	var interested bool
+	for s := range h.subs {
+		if interested = s.filter.matches(n); interested {
+			break
+		}
+	}
+	h.mu.Unlock()
+	if !interested {
+		return
+	}
+
+	report, err := h.fetch(ctx, n.MetricID, n.Group)
+	if err != nil {
+		log.WithError(err).Errorf("failed to read metrics of trial %d to stream", n.TrialID)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if !s.filter.matches(n) {
+			continue
+		}
+		if err != nil {
+			h.unsubscribe(s, ErrMissedReports)
+			continue
+		}
+		if !s.following {
+			s.held = append(s.held, Report{Group: n.Group, MetricsReport: report})
+			continue
+		}
+		select {
+		case s.c <- Report{Group: n.Group, MetricsReport: report}:
+		default:
+			h.unsubscribe(s, ErrTooSlow)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package metricstream
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func TestHub(t *testing.T) {
+	notifications := make(chan db.TrialMetricsNotification)
+	published := make(chan struct{})
+	notify := func(trialID, expID, metricID int, group model.MetricType) {
+		notifications <- db.TrialMetricsNotification{
+			TrialID: trialID, ExperimentID: expID, MetricID: metricID, Group: group,
+		}
+		<-published
+	}
+	var fetches int
+	var streamed []bool
+	h := &Hub{
+		listen: func(
+			ctx context.Context, listening func(), f func(db.TrialMetricsNotification),
+		) error {
+			listening()
+			for {
+				select {
+				case n := <-notifications:
+					f(n)
+					published <- struct{}{}
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		},
+		fetch: func(
+			ctx context.Context, id int, mType model.MetricType,
+		) (*trialv1.MetricsReport, error) {
+			fetches++
+			return &trialv1.MetricsReport{Id: int32(id), TotalBatches: int32(id * 100)}, nil
+		},
+		stream: func(b bool) { streamed = append(streamed, b) },
+		subs:   map[*Subscription]bool{},
+	}
+
+	_, err := h.Subscribe(Filter{})
+	assert.Equal(t, err, ErrNotListening)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listening := make(chan struct{})
+	listen := h.listen
+	h.listen = func(
+		ctx context.Context, l func(), f func(db.TrialMetricsNotification),
+	) error {
+		return listen(ctx, func() { l(); close(listening) }, f)
+	}
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+	<-listening
+
+	expID := 1
+	training := map[model.MetricType]bool{model.TrainingMetricType: true}
+	byTrial, err := h.Subscribe(Filter{TrialIDs: map[int]bool{10: true}, Groups: training})
+	assert.NilError(t, err)
+	byExp, err := h.Subscribe(Filter{ExperimentID: &expID, Groups: training})
+	assert.NilError(t, err)
+	assert.Equal(t, len(byTrial.Follow()), 0)
+	assert.Equal(t, len(byExp.Follow()), 0)
+
+	notify(10, 1, 1, model.TrainingMetricType)
+	notify(11, 1, 2, model.TrainingMetricType)
+	notify(10, 1, 3, model.ValidationMetricType)
+	notify(20, 2, 4, model.TrainingMetricType)
+
+	r := <-byTrial.Reports()
+	assert.Equal(t, r.Id, int32(1))
+	assert.Equal(t, len(byTrial.Reports()), 0)
+	r = <-byExp.Reports()
+	assert.Equal(t, r.Id, int32(1))
+	r = <-byExp.Reports()
+	assert.Equal(t, r.Id, int32(2))
+	assert.Equal(t, len(byExp.Reports()), 0)
+	// Reports nobody subscribed to are not read, and each report is read once.
+	assert.Equal(t, fetches, 2)
+
+	byTrial.Close()
+	_, ok := <-byTrial.Reports()
+	assert.Assert(t, !ok)
+	assert.NilError(t, byTrial.Err())
+
+	// Subscribers that fall behind are dropped, but reports are held for as long as subscribers
+	// catch up before following.
+	catchingUp, err := h.Subscribe(Filter{ExperimentID: &expID, Groups: training})
+	assert.NilError(t, err)
+	for i := 0; i <= subscriptionBufferSize; i++ {
+		notify(11, 1, 5, model.TrainingMetricType)
+	}
+	for range byExp.Reports() {
+	}
+	assert.Equal(t, byExp.Err(), ErrTooSlow)
+	assert.Equal(t, len(catchingUp.Follow()), subscriptionBufferSize+1)
+	notify(11, 1, 6, model.TrainingMetricType)
+	r = <-catchingUp.Reports()
+	assert.Equal(t, r.Id, int32(6))
+
+	// Subscribers are dropped when the hub stops listening.
+	last, err := h.Subscribe(Filter{ExperimentID: &expID, Groups: training})
+	assert.NilError(t, err)
+	cancel()
+	<-done
+	_, ok = <-last.Reports()
+	assert.Assert(t, !ok)
+	assert.Equal(t, last.Err(), ErrMissedReports)
+
+	// Metrics are only announced while there are subscribers.
+	assert.DeepEqual(t, streamed, []bool{true, false})
+}
@@ -0,0 +1,268 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/api/apiutils"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	modelauth "github.com/determined-ai/determined/master/internal/model"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/modelv1"
+)
+
+// modelRegistryHTTPError converts gRPC status and database errors into echo HTTP errors.
+func modelRegistryHTTPError(err error) error {
+	if _, ok := status.FromError(err); !ok {
+		err = apiutils.MapAndFilterErrors(err, nil, nil)
+	}
+	_, err = api.GrpcErrToEcho(err)
+	return err
+}
+
+// modelWithAccess looks up a model and checks that the current user may view it or, if edit is
+// set, change it.
+func (m *Master) modelWithAccess(
+	c echo.Context, modelName string, edit bool,
+) (*modelv1.Model, model.User, error) {
+	curUser := c.(*detContext.DetContext).MustGetUser()
+	mdl, err := (&apiServer{m: m}).ModelFromIdentifier(modelName)
+	if err != nil {
+		return nil, curUser, modelRegistryHTTPError(err)
+	}
+	canAccess := modelauth.AuthZProvider.Get().CanGetModel
+	if edit {
+		canAccess = modelauth.AuthZProvider.Get().CanEditModel
+	}
+	if err := canAccess(c.Request().Context(), curUser, mdl, mdl.WorkspaceId); err != nil {
+		return nil, curUser, modelRegistryHTTPError(err)
+	}
+	return mdl, curUser, nil
+}
+
+func (m *Master) modelVersionWithAccess(
+	c echo.Context, modelName string, versionNum int32, edit bool,
+) (*modelv1.ModelVersion, model.User, error) {
+	_, curUser, err := m.modelWithAccess(c, modelName, edit)
+	if err != nil {
+		return nil, curUser, err
+	}
+	mv, err := (&apiServer{m: m}).ModelVersionFromID(modelName, versionNum)
+	if err != nil {
+		return nil, curUser, modelRegistryHTTPError(err)
+	}
+	return mv, curUser, nil
+}
+
+//	@Summary	Get the stage of a model version and the history of its transitions.
+//	@Tags		Models
+//	@ID			get-model-version-stage
+//	@Produce	json
+//	@Param		model_name			path	string	true	"Model name or ID"
+//	@Param		model_version_num	path	int		true	"Model version number"
+//	@Success	200					{}		string	""
+//	@Router		/models/{model_name}/versions/{model_version_num}/stage [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getModelVersionStage(c echo.Context) (interface{}, error) {
+	args := struct {
+		ModelName       string `path:"model_name"`
+		ModelVersionNum int32  `path:"model_version_num"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	mv, _, err := m.modelVersionWithAccess(c, args.ModelName, args.ModelVersionNum, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.Request().Context()
+	stage, changedAt, err := db.ModelVersionStageByID(ctx, int(mv.Id))
+	if err != nil {
+		return nil, modelRegistryHTTPError(err)
+	}
+	transitions, err := db.ModelVersionTransitions(ctx, int(mv.Id))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"stage":            stage,
+		"stage_changed_at": changedAt,
+		"transitions":      transitions,
+	}, nil
+}
+
+//	@Summary	Request moving a model version to another stage.
+//	@Tags		Models
+//	@ID			post-model-version-transition
+//	@Accept		json
+//	@Produce	json
+//	@Param		model_name			path	string	true	"Model name or ID"
+//	@Param		model_version_num	path	int		true	"Model version number"
+//	@Success	200					{}		string	""
+//	@Router		/models/{model_name}/versions/{model_version_num}/transitions [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postModelVersionTransition(c echo.Context) (interface{}, error) {
+	args := struct {
+		ModelName       string `path:"model_name"`
+		ModelVersionNum int32  `path:"model_version_num"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	var body struct {
+		Stage   string `json:"stage"`
+		Comment string `json:"comment"`
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid request body: "+err.Error())
+	}
+	stage, err := model.ParseModelVersionStage(body.Stage)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	mv, curUser, err := m.modelVersionWithAccess(c, args.ModelName, args.ModelVersionNum, true)
+	if err != nil {
+		return nil, err
+	}
+	t, err := db.TransitionModelVersionStage(c.Request().Context(), int(mv.Id), stage,
+		curUser.ID, body.Comment, m.config.ModelRegistry.RequireProductionApproval)
+	if err != nil {
+		return nil, modelRegistryHTTPError(err)
+	}
+	return t, nil
+}
+
+func (m *Master) reviewModelVersionTransition(c echo.Context, approve bool) (interface{}, error) {
+	args := struct {
+		ModelName    string `path:"model_name"`
+		TransitionID int    `path:"transition_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	if c.Request().ContentLength != 0 {
+		if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"invalid request body: "+err.Error())
+		}
+	}
+
+	mdl, curUser, err := m.modelWithAccess(c, args.ModelName, true)
+	if err != nil {
+		return nil, err
+	}
+	ctx := c.Request().Context()
+	t, err := db.ModelVersionTransitionByID(ctx, args.TransitionID)
+	if err != nil {
+		return nil, modelRegistryHTTPError(err)
+	}
+	// Make sure the transition belongs to the model the user was authorized against.
+	if t.ModelID != int(mdl.Id) {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("transition %d not found for model %s", t.ID, mdl.Name))
+	}
+
+	t, err = db.ReviewModelVersionTransition(ctx, t.ID, curUser.ID, approve, body.Comment)
+	if err != nil {
+		return nil, modelRegistryHTTPError(err)
+	}
+	return t, nil
+}
+
+//	@Summary	Approve a pending model version transition.
+//	@Tags		Models
+//	@ID			post-approve-model-version-transition
+//	@Accept		json
+//	@Produce	json
+//	@Param		model_name		path	string	true	"Model name or ID"
+//	@Param		transition_id	path	int		true	"Transition ID"
+//	@Success	200				{}		string	""
+//	@Router		/models/{model_name}/transitions/{transition_id}/approve [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postApproveModelVersionTransition(c echo.Context) (interface{}, error) {
+	return m.reviewModelVersionTransition(c, true)
+}
+
+//	@Summary	Reject a pending model version transition.
+//	@Tags		Models
+//	@ID			post-reject-model-version-transition
+//	@Accept		json
+//	@Produce	json
+//	@Param		model_name		path	string	true	"Model name or ID"
+//	@Param		transition_id	path	int		true	"Transition ID"
+//	@Success	200				{}		string	""
+//	@Router		/models/{model_name}/transitions/{transition_id}/reject [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) postRejectModelVersionTransition(c echo.Context) (interface{}, error) {
+	return m.reviewModelVersionTransition(c, false)
+}
+
+//	@Summary	Get the model version currently in a stage.
+//	@Tags		Models
+//	@ID			get-model-version-in-stage
+//	@Produce	json
+//	@Param		model_name	path	string	true	"Model name or ID"
+//	@Param		stage		path	string	true	"One of none, staging, production or archived"
+//	@Success	200			{}		string	""
+//	@Router		/models/{model_name}/stages/{stage} [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getModelVersionInStage(c echo.Context) (interface{}, error) {
+	args := struct {
+		ModelName string `path:"model_name"`
+		Stage     string `path:"stage"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	stage, err := model.ParseModelVersionStage(args.Stage)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	mdl, _, err := m.modelWithAccess(c, args.ModelName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.Request().Context()
+	version, err := db.CurrentModelVersionInStage(ctx, int(mdl.Id), stage)
+	if err == db.ErrNotFound {
+		return nil, echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("no version of model %s is in stage %s", mdl.Name, stage))
+	} else if err != nil {
+		return nil, err
+	}
+	mv, err := (&apiServer{m: m}).ModelVersionFromID(args.ModelName, int32(version))
+	if err != nil {
+		return nil, modelRegistryHTTPError(err)
+	}
+	_, changedAt, err := db.ModelVersionStageByID(ctx, int(mv.Id))
+	if err != nil {
+		return nil, err
+	}
+	mvJSON, err := protojson.Marshal(mv)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"stage":            stage,
+		"stage_changed_at": changedAt,
+		"model_version":    json.RawMessage(mvJSON),
+	}, nil
+}
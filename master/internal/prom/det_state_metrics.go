@@ -1,6 +1,10 @@
 package prom
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
@@ -55,6 +59,109 @@ Exposes mapping of Determined's container ID to the GPU UUID/device ID as given
 `,
 	}, []string{"gpu_uuid", "container_id"})
 
+	checkpointStorageBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "checkpoint_storage_bytes",
+		Help:      "total size of non-deleted checkpoints, by workspace, project or user",
+	}, []string{"scope", "scope_id"})
+
+	checkpointStorageCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "checkpoint_storage_count",
+		Help:      "number of non-deleted checkpoints, by workspace, project or user",
+	}, []string{"scope", "scope_id"})
+
+	taskLogRetentionLogs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "task_log_retention_deleted_logs_total",
+		Help:      "task logs deleted by log retention, by policy and whether for age or lines",
+	}, []string{"policy", "reason"})
+
+	taskLogRetentionTasks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "task_log_retention_tasks_total",
+		Help:      "tasks whose logs log retention deleted, by policy and whether for age or lines",
+	}, []string{"policy", "reason"})
+
+	schedulingLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: "det",
+		Name:      "scheduling_latency_seconds",
+		Help:      "time from a resource request to its allocation, by resource pool and priority",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 10),
+	}, []string{"resource_pool", "priority"})
+
+	preemptions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "preemptions_total",
+		Help:      "allocations the scheduler preempted, by resource pool",
+	}, []string{"resource_pool"})
+
+	trialRestarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "trial_restarts_total",
+		Help:      "trial restarts after a failure, by resource pool",
+	}, []string{"resource_pool"})
+
+	allocationExits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "allocation_exits_total",
+		Help:      "allocations that exited, by resource pool and exit reason",
+	}, []string{"resource_pool", "reason"})
+
+	queuedJobs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "queued_jobs",
+		Help:      "allocations waiting for resources, by resource pool",
+	}, []string{"resource_pool"})
+
+	slotsDemanded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "slots_demanded",
+		Help:      "slots requested by queued and running allocations, by resource pool",
+	}, []string{"resource_pool"})
+
+	slotsAvailable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "slots_available",
+		Help:      "slots of enabled agents, by resource pool",
+	}, []string{"resource_pool"})
+
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "webhook_deliveries_total",
+		Help:      "webhook event deliveries, by whether they succeeded or failed after retries",
+	}, []string{"result"})
+
+	deviceProblems = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "device_health_problems",
+		Help:      "health problems agents report for their devices",
+	}, []string{"agent_id", "device_uuid"})
+
+	slotQuarantines = promauto.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "det",
+		Name:      "slot_quarantines_total",
+		Help:      "slots disabled because their device is unhealthy, by resource pool and problem",
+	}, []string{"resource_pool", "problem"})
+
+	gpuUtilization = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "gpu_utilization_percent",
+		Help:      "latest GPU utilization sampled by agents, by allocation and device",
+	}, []string{"allocation_id", "agent_id", "gpu_uuid"})
+
+	gpuMemoryUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "gpu_memory_used_bytes",
+		Help:      "latest GPU memory used sampled by agents, by allocation and device",
+	}, []string{"allocation_id", "agent_id", "gpu_uuid"})
+
+	gpuPower = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "det",
+		Name:      "gpu_power_watts",
+		Help:      "latest GPU power draw sampled by agents, by allocation and device",
+	}, []string{"allocation_id", "agent_id", "gpu_uuid"})
+
 	// DetStateMetrics is a prometheus registry containing all exported user-facing metrics.
 	DetStateMetrics = prometheus.NewRegistry()
 )
@@ -86,6 +193,23 @@ func init() { //nolint: gochecknoinits
 	DetStateMetrics.MustRegister(experimentIDToLabels)
 	DetStateMetrics.MustRegister(allocationIDToTask)
 	DetStateMetrics.MustRegister(jobIDToExperimentID)
+	DetStateMetrics.MustRegister(checkpointStorageBytes)
+	DetStateMetrics.MustRegister(checkpointStorageCount)
+	DetStateMetrics.MustRegister(taskLogRetentionLogs)
+	DetStateMetrics.MustRegister(taskLogRetentionTasks)
+	DetStateMetrics.MustRegister(schedulingLatency)
+	DetStateMetrics.MustRegister(preemptions)
+	DetStateMetrics.MustRegister(trialRestarts)
+	DetStateMetrics.MustRegister(allocationExits)
+	DetStateMetrics.MustRegister(queuedJobs)
+	DetStateMetrics.MustRegister(slotsDemanded)
+	DetStateMetrics.MustRegister(slotsAvailable)
+	DetStateMetrics.MustRegister(webhookDeliveries)
+	DetStateMetrics.MustRegister(deviceProblems)
+	DetStateMetrics.MustRegister(slotQuarantines)
+	DetStateMetrics.MustRegister(gpuUtilization)
+	DetStateMetrics.MustRegister(gpuMemoryUsed)
+	DetStateMetrics.MustRegister(gpuPower)
 }
 
 // AssociateAllocationContainer associates an allocation with its container ID.
@@ -208,3 +332,90 @@ func DisassociateContainerGPU(cID cproto.ID, d device.Device) {
 	gpuUUIDToContainerID.WithLabelValues(d.UUID, cID.String()).Dec()
 	gpuUUIDToContainerID.DeleteLabelValues(d.UUID, cID.String())
 }
+
+// SetCheckpointStorageUsage replaces the checkpoint storage gauges with the given usage.
+func SetCheckpointStorageUsage(usages []model.CheckpointStorageUsage) {
+	checkpointStorageBytes.Reset()
+	checkpointStorageCount.Reset()
+	for _, u := range usages {
+		scope, id := strings.ToLower(string(u.Scope)), strconv.Itoa(u.ScopeID)
+		checkpointStorageBytes.WithLabelValues(scope, id).Set(float64(u.Size))
+		checkpointStorageCount.WithLabelValues(scope, id).Set(float64(u.Count))
+	}
+}
+
+// AddTaskLogRetentionDeletions counts the logs of a task deleted by a log retention policy.
+func AddTaskLogRetentionDeletions(policy, reason string, logs int64) {
+	taskLogRetentionLogs.WithLabelValues(policy, reason).Add(float64(logs))
+	taskLogRetentionTasks.WithLabelValues(policy, reason).Inc()
+}
+
+// ObserveSchedulingLatency records how long an allocation request waited for resources.
+func ObserveSchedulingLatency(pool string, priority *int, latency time.Duration) {
+	p := ""
+	if priority != nil {
+		p = strconv.Itoa(*priority)
+	}
+	schedulingLatency.WithLabelValues(pool, p).Observe(latency.Seconds())
+}
+
+// IncPreemptions counts an allocation preempted by the scheduler.
+func IncPreemptions(pool string) {
+	preemptions.WithLabelValues(pool).Inc()
+}
+
+// IncTrialRestarts counts a trial restarted after a failure.
+func IncTrialRestarts(pool string) {
+	trialRestarts.WithLabelValues(pool).Inc()
+}
+
+// IncAllocationExits counts an allocation that exited for the given reason.
+func IncAllocationExits(pool, reason string) {
+	allocationExits.WithLabelValues(pool, reason).Inc()
+}
+
+// SetResourcePoolDemand replaces the queue and slot gauges of a resource pool.
+func SetResourcePoolDemand(pool string, queued, demanded, available int) {
+	queuedJobs.WithLabelValues(pool).Set(float64(queued))
+	slotsDemanded.WithLabelValues(pool).Set(float64(demanded))
+	slotsAvailable.WithLabelValues(pool).Set(float64(available))
+}
+
+// IncWebhookDeliveries counts a webhook event delivery that succeeded or, if err is set, failed.
+func IncWebhookDeliveries(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+// SetDeviceProblems sets the number of health problems an agent reports for a device.
+func SetDeviceProblems(agentID, deviceUUID string, problems int) {
+	if problems == 0 {
+		deviceProblems.DeleteLabelValues(agentID, deviceUUID)
+		return
+	}
+	deviceProblems.WithLabelValues(agentID, deviceUUID).Set(float64(problems))
+}
+
+// IncSlotQuarantines counts a slot quarantined because of the given device health problem.
+func IncSlotQuarantines(pool, problem string) {
+	slotQuarantines.WithLabelValues(pool, problem).Inc()
+}
+
+// SetGPUTelemetry sets the latest utilization, memory used and power of a device of an allocation.
+func SetGPUTelemetry(
+	aID model.AllocationID, agentID, deviceUUID string, utilization, memoryUsed, power float64,
+) {
+	gpuUtilization.WithLabelValues(aID.String(), agentID, deviceUUID).Set(utilization)
+	gpuMemoryUsed.WithLabelValues(aID.String(), agentID, deviceUUID).Set(memoryUsed)
+	gpuPower.WithLabelValues(aID.String(), agentID, deviceUUID).Set(power)
+}
+
+// DeleteGPUTelemetry removes the telemetry gauges of a device of an allocation.
+func DeleteGPUTelemetry(aID model.AllocationID, agentID, deviceUUID string) {
+	gpuUtilization.DeleteLabelValues(aID.String(), agentID, deviceUUID)
+	gpuMemoryUsed.DeleteLabelValues(aID.String(), agentID, deviceUUID)
+	gpuPower.DeleteLabelValues(aID.String(), agentID, deviceUUID)
+}
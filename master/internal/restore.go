@@ -53,7 +53,7 @@ const experimentSnapshotVersion = 5
 // state that doesn't flow from trial to experiment; experiments can never push non-ephemeral state
 // updates to trials without special consideration. searcher.Operations are an example of this (and
 // the experiment snapshots them and re-sends them).
-func (m *Master) restoreExperiment(expModel *model.Experiment) error {
+func (m *Master) restoreExperiment(ctx context.Context, expModel *model.Experiment) error {
 	// Experiments which were trying to stop need to be marked as terminal in the database.
 	activeConfig, err := m.db.ActiveExperimentConfig(expModel.ID)
 	if err != nil {
@@ -66,7 +66,7 @@ func (m *Master) restoreExperiment(expModel *model.Experiment) error {
 		expModel.State = terminal
 		telemetry.ReportExperimentStateChanged(m.system, m.db, *expModel)
 		if err := webhooks.ReportExperimentStateChanged(
-			context.TODO(), *expModel, activeConfig,
+			ctx, *expModel, activeConfig,
 		); err != nil {
 			log.WithError(err).Error("failed to send experiment state change webhook in restore")
 		}
@@ -117,7 +117,7 @@ func (m *Master) restoreExperiment(expModel *model.Experiment) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to restore experiment %d", expModel.ID)
 	}
-	e, _, err := newExperiment(m, expModel, activeConfig, &taskSpec)
+	e, _, err := newExperiment(ctx, m, expModel, activeConfig, &taskSpec)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create experiment %d from model", expModel.ID)
 	}
@@ -179,6 +179,7 @@ func (e *experiment) restoreTrial(
 		e.logCtx, trialTaskID(e.ID, searcher.Create.RequestID), e.JobID, e.StartTime, e.ID, e.State,
 		searcher, e.rm, e.db, config, ckpt, e.taskSpec, e.generatedKeys, true,
 	)
+	t.traceParent = e.span.SpanContext()
 	if trialID != nil {
 		t.id = *trialID
 		t.idSet = true
@@ -1,6 +1,7 @@
 package agentrm
 
 import (
+	"context"
 	"net/http"
 	"reflect"
 	"sort"
@@ -11,9 +12,13 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 
+	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	ws "github.com/determined-ai/determined/master/pkg/actor/api"
@@ -37,6 +42,8 @@ type (
 		// started tracks if we have received the AgentStarted message.
 		started bool
 		version string
+		// label is the label the agent was started with, used to select agents for maintenance.
+		label string
 
 		// TODO(ilia): Maybe maxZeroSlotContainers should be an attribute of a resource pool,
 		// and not be copied to agents.
@@ -69,6 +76,15 @@ type (
 
 		// opts are additional agent options the master sends to the agent.
 		opts *aproto.MasterSetAgentOptions
+		// prePullImages are the images the agent was last told to pre-pull.
+		prePullImages []string
+		// maintenance is the scheduled maintenance of the agent, if any, and maintenanceID
+		// identifies it to its ticks.
+		maintenance   *model.AgentMaintenance
+		maintenanceID int
+		// maintenanceRestored is set when the maintenance was restored after a restart of the
+		// master, until the agent reconnects and its allocations are signaled again.
+		maintenanceRestored bool
 
 		agentState *agentState
 	}
@@ -91,6 +107,7 @@ type (
 	// allocateFreeDevices calls agentState.allocateFreeDevices.
 	allocateFreeDevices struct {
 		slots       int
+		migProfile  string
 		containerID cproto.ID
 	}
 	// allocateFreeDevicesResponse is a response to allocateFreeDevices.
@@ -101,6 +118,10 @@ type (
 	deallocateContainer struct {
 		containerID cproto.ID
 	}
+	// prePullImages tells the agent which images to pre-pull, if they changed.
+	prePullImages struct {
+		images []string
+	}
 )
 
 var errRecovering = errors.New("agent disconnected, wait for recovery")
@@ -123,6 +144,7 @@ func (a *agent) receive(ctx *actor.Context, msg interface{}) error {
 			// Ensure RP is aware of the agent.
 			ctx.Ask(a.resourcePool, sproto.AddAgent{Agent: ctx.Self()}).Get()
 			a.socketDisconnected(ctx)
+			a.restoreMaintenance(ctx, false)
 		}
 		a.slots, _ = ctx.ActorOf("slots", &slots{})
 	case model.AgentSummary:
@@ -133,6 +155,8 @@ func (a *agent) receive(ctx *actor.Context, msg interface{}) error {
 		check.Panic(check.True(ok, "failed to accept websocket connection"))
 		a.socket = socket
 		a.version = msg.Ctx.QueryParam("version")
+		a.label = msg.Ctx.QueryParam("label")
+		a.prePullImages = nil
 
 		lastColonIndex := strings.LastIndex(msg.Ctx.Request().RemoteAddr, ":")
 		if lastColonIndex == -1 {
@@ -194,6 +218,11 @@ func (a *agent) receive(ctx *actor.Context, msg interface{}) error {
 			}
 			a.reconnectBacklog = nil
 			ctx.Tell(a.resourcePool, sproto.UpdateAgent{Agent: ctx.Self()})
+			if a.maintenance != nil {
+				// The agents actor rejects connections with an invalid reconnect parameter.
+				reconnect, _ := msg.IsReconnect()
+				a.maintenanceReconnected(ctx, !reconnect)
+			}
 		}
 
 	case sproto.KillTaskContainer:
@@ -303,6 +332,22 @@ func (a *agent) receive(ctx *actor.Context, msg interface{}) error {
 		}
 		ctx.Respond(&proto.DisableAgentResponse{Agent: a.summarize(ctx).ToProto()})
 		ctx.Tell(a.resourcePool, sproto.UpdateAgent{Agent: ctx.Self()})
+	case sproto.ScheduleAgentMaintenance:
+		if err := a.scheduleMaintenance(ctx, msg); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		ctx.Respond(a.summarize(ctx))
+	case sproto.CancelAgentMaintenance:
+		if err := a.cancelMaintenance(ctx); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		ctx.Respond(a.summarize(ctx))
+	case maintenanceTick:
+		if a.maintenance != nil && msg.id == a.maintenanceID {
+			a.advanceMaintenance(ctx)
+		}
 	case echo.Context:
 		a.handleAPIRequest(ctx, msg)
 	case actor.ChildFailed:
@@ -371,7 +416,8 @@ func (a *agent) receive(ctx *actor.Context, msg interface{}) error {
 			ctx.Respond(errors.New("can't allocate free devices: agent not started"))
 			return nil
 		}
-		devices, err := a.agentState.allocateFreeDevices(msg.slots, msg.containerID)
+		devices, err := a.agentState.allocateFreeDevices(
+			msg.slots, msg.migProfile, msg.containerID)
 		if err != nil {
 			ctx.Respond(err)
 		} else {
@@ -385,6 +431,19 @@ func (a *agent) receive(ctx *actor.Context, msg interface{}) error {
 			return nil
 		}
 		a.agentState.deallocateContainer(msg.containerID)
+	case prePullImages:
+		if !a.started || a.awaitingReconnect || slices.Equal(msg.images, a.prePullImages) {
+			return nil
+		}
+
+		wsm := ws.WriteMessage{Message: aproto.AgentMessage{
+			PrePullImages: &aproto.PrePullImages{Images: msg.images},
+		}}
+		if err := ctx.Ask(a.socket, wsm).Error(); err != nil {
+			ctx.Log().WithError(err).Error("failed to write pre-pull images message")
+			return nil
+		}
+		a.prePullImages = msg.images
 	case model.SlotsSummary:
 		if !a.started {
 			ctx.Respond(model.SlotsSummary{})
@@ -477,6 +536,7 @@ func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMes
 			}
 		} else {
 			a.agentStarted(ctx, msg.AgentStarted)
+			a.restoreMaintenance(ctx, true)
 		}
 
 		a.started = true
@@ -518,12 +578,90 @@ func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMes
 				log.Errorf("error recording task stats %s", err)
 			}
 		}
+	case msg.DevicesHealthChanged != nil:
+		a.devicesHealthChanged(ctx, msg.DevicesHealthChanged)
+	case msg.ImageCacheChanged != nil:
+		if !a.started {
+			log.Warn("received ImageCacheChanged on non-started agent")
+			return
+		}
+		a.agentState.images = make(map[string]bool, len(msg.ImageCacheChanged.Images))
+		for _, image := range msg.ImageCacheChanged.Images {
+			a.agentState.images[image] = true
+		}
+	case msg.ContainerGPUTelemetry != nil:
+		ref, ok := a.agentState.containerAllocation[msg.ContainerGPUTelemetry.ContainerID]
+		if !ok {
+			// The last samples of a container may arrive after its allocation released it.
+			log.WithField("container-id", msg.ContainerGPUTelemetry.ContainerID).Debug(
+				"dropping GPU telemetry from container not allocated to agent")
+			return
+		}
+		ctx.Tell(ref, sproto.ContainerGPUTelemetry{
+			ContainerID: msg.ContainerGPUTelemetry.ContainerID,
+			AgentID:     string(a.agentState.agentID()),
+			Samples:     msg.ContainerGPUTelemetry.Samples,
+		})
 
 	default:
 		check.Panic(errors.Errorf("error parsing incoming message"))
 	}
 }
 
+// devicesHealthChanged quarantines the slots of devices with fatal health problems.
+func (a *agent) devicesHealthChanged(ctx *actor.Context, msg *aproto.DevicesHealthChanged) {
+	if !a.started {
+		ctx.Log().Warn("received DevicesHealthChanged on non-started agent")
+		return
+	}
+
+	conf := config.GetMasterConfig().SlotQuarantine
+	var quarantined bool
+	for _, h := range msg.Devices {
+		prom.SetDeviceProblems(string(a.agentState.agentID()), h.Device.UUID, len(h.Problems))
+		fatal := h.Fatal()
+		if len(fatal) == 0 {
+			continue
+		}
+		reasons := make([]string, 0, len(fatal))
+		for _, p := range fatal {
+			reasons = append(reasons, p.String())
+		}
+		reason := strings.Join(reasons, "; ")
+		if !conf.Enabled {
+			ctx.Log().Warnf("device %s is unhealthy, not quarantining its slot: %s",
+				h.Device.UUID, reason)
+			continue
+		}
+		if !a.agentState.quarantineSlot(ctx, h.Device.ID, reason) {
+			continue
+		}
+
+		quarantined = true
+		ctx.Log().Warnf("quarantined slot %d of device %s: %s", h.Device.ID, h.Device.UUID, reason)
+		prom.IncSlotQuarantines(a.resourcePoolName, string(fatal[0].Kind))
+		if conf.WebhookURL == "" {
+			continue
+		}
+		if err := webhooks.ReportSlotQuarantined(context.TODO(), conf.WebhookURL,
+			webhooks.SlotPayload{
+				AgentID:      string(a.agentState.agentID()),
+				ResourcePool: a.resourcePoolName,
+				SlotID:       int(h.Device.ID),
+				DeviceUUID:   h.Device.UUID,
+				Reason:       reason,
+			}); err != nil {
+			ctx.Log().WithError(err).Error("failed to report quarantined slot")
+		}
+	}
+
+	if quarantined {
+		if err := a.agentState.persist(); err != nil {
+			ctx.Log().WithError(err).Warn("failed to persist quarantined slots")
+		}
+	}
+}
+
 func (a *agent) taskNeedsRecording(record *aproto.ContainerStatsRecord) bool {
 	return record.TaskType == model.TaskTypeTrial
 }
@@ -587,6 +725,7 @@ func (a *agent) summarize(ctx *actor.Context) model.AgentSummary {
 		Draining:      false,
 		NumContainers: 0,
 		Version:       a.version,
+		Label:         a.label,
 	}
 
 	if a.agentState != nil {
@@ -595,6 +734,10 @@ func (a *agent) summarize(ctx *actor.Context) model.AgentSummary {
 		result.Draining = a.agentState.draining
 		result.NumContainers = len(a.agentState.containerAllocation)
 	}
+	if a.maintenance != nil {
+		maintenance := *a.maintenance
+		result.Maintenance = &maintenance
+	}
 
 	return result
 }
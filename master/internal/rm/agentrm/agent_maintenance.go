@@ -0,0 +1,287 @@
+package agentrm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	ws "github.com/determined-ai/determined/master/pkg/actor/api"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// maintenanceCheckInterval is how often the agent checks on the tasks it waits for during its
+// maintenance.
+const maintenanceCheckInterval = 10 * time.Second
+
+// maintenanceTick advances the maintenance of the agent. It carries the ID of the maintenance it
+// was scheduled for, so that ticks of a canceled or replaced maintenance are dropped.
+type maintenanceTick struct {
+	id int
+}
+
+func (a *agent) scheduleMaintenance(ctx *actor.Context, msg sproto.ScheduleAgentMaintenance) error {
+	switch {
+	case a.awaitingReconnect:
+		return errRecovering
+	case !a.started:
+		return errors.New("can't schedule maintenance: agent not started")
+	case a.maintenance != nil && a.maintenance.Phase != model.MaintenanceScheduled:
+		return errors.Errorf(
+			"can't schedule maintenance: maintenance already %s", a.maintenance.Phase)
+	}
+
+	a.maintenanceID++
+	a.maintenance = &model.AgentMaintenance{
+		Phase:          model.MaintenanceScheduled,
+		StartTime:      msg.StartTime,
+		Deadline:       msg.StartTime.Add(msg.Timeout),
+		NumContainers:  len(a.agentState.containerAllocation),
+		PhaseChangedAt: time.Now().UTC(),
+	}
+	ctx.Log().Infof("scheduled maintenance starting at %s with deadline %s",
+		a.maintenance.StartTime, a.maintenance.Deadline)
+	a.notifyMaintenance(ctx)
+	a.advanceMaintenance(ctx)
+	a.persistMaintenance(ctx)
+	return nil
+}
+
+func (a *agent) cancelMaintenance(ctx *actor.Context) error {
+	switch {
+	case a.awaitingReconnect:
+		return errRecovering
+	case a.maintenance == nil:
+		return errors.New("can't cancel maintenance: no maintenance scheduled")
+	}
+
+	ctx.Log().Infof("canceled maintenance in phase %s", a.maintenance.Phase)
+	a.maintenance.Phase = model.MaintenanceCanceled
+	a.maintenance.PhaseChangedAt = time.Now().UTC()
+	a.notifyMaintenance(ctx)
+	a.endMaintenance(ctx)
+	return nil
+}
+
+// endMaintenance forgets the maintenance of the agent and puts the agent back in service.
+func (a *agent) endMaintenance(ctx *actor.Context) {
+	if a.maintenance.Phase != model.MaintenanceScheduled {
+		a.agentState.enable(ctx)
+		a.agentState.patchAllSlotsState(ctx, patchAllSlotsState{
+			enabled: &a.agentState.enabled,
+			drain:   &a.agentState.draining,
+		})
+		ctx.Tell(a.resourcePool, sproto.UpdateAgent{Agent: ctx.Self()})
+	}
+
+	a.maintenance = nil
+	a.maintenanceID++
+	if err := deleteAgentMaintenance(agentID(ctx.Self().Address().Local())); err != nil {
+		ctx.Log().WithError(err).Error("failed to delete maintenance")
+	}
+}
+
+// restoreMaintenance resumes the maintenance the agent had before the master restarted. A
+// maintenance that was safe to reboot is complete once the agent process restarts, so that the
+// agent is put back in service rather than told to reboot again.
+func (a *agent) restoreMaintenance(ctx *actor.Context, agentRestarted bool) {
+	m, err := loadAgentMaintenance(agentID(ctx.Self().Address().Local()))
+	switch {
+	case err != nil:
+		ctx.Log().WithError(err).Error("failed to restore maintenance")
+		return
+	case m == nil:
+		return
+	}
+
+	a.maintenanceID++
+	a.maintenance = m
+	a.maintenanceRestored = true
+	if agentRestarted && m.Phase == model.MaintenanceSafeToReboot {
+		a.completeMaintenance(ctx)
+		return
+	}
+
+	ctx.Log().Infof("restored maintenance in phase %s", m.Phase)
+	if m.Phase != model.MaintenanceScheduled {
+		// A restored agent goes back to its state before the restart once it reconnects.
+		a.preDisconnectEnabled, a.preDisconnectDraining = false, true
+		a.agentState.disable(ctx, true)
+		a.agentState.patchAllSlotsState(ctx, patchAllSlotsState{
+			enabled: &a.agentState.enabled,
+			drain:   &a.agentState.draining,
+		})
+		ctx.Tell(a.resourcePool, sproto.UpdateAgent{Agent: ctx.Self()})
+	}
+	a.notifyMaintenance(ctx)
+	ctx.Tell(ctx.Self(), maintenanceTick{id: a.maintenanceID})
+}
+
+// maintenanceReconnected brings an agent that reconnects up to date with its maintenance. The
+// allocations of a restored maintenance are signaled again, since the master restarted since they
+// were last signaled.
+func (a *agent) maintenanceReconnected(ctx *actor.Context, agentRestarted bool) {
+	if agentRestarted && a.maintenance.Phase == model.MaintenanceSafeToReboot {
+		a.completeMaintenance(ctx)
+		return
+	}
+
+	if a.maintenanceRestored {
+		a.maintenanceRestored = false
+		a.signalMaintenancePhase(ctx, a.maintenance.Phase)
+	}
+	a.notifyMaintenance(ctx)
+}
+
+// completeMaintenance ends a maintenance that was safe to reboot once the agent restarts.
+func (a *agent) completeMaintenance(ctx *actor.Context) {
+	ctx.Log().Info("completed maintenance after the agent restarted")
+	a.endMaintenance(ctx)
+}
+
+// advanceMaintenance moves the maintenance of the agent along and schedules the next tick until
+// the agent is safe to reboot. Once the maintenance starts, the agent is drained and its
+// preemptible tasks are asked to checkpoint so that they are rescheduled elsewhere; the tasks
+// still running at the deadline are killed.
+func (a *agent) advanceMaintenance(ctx *actor.Context) {
+	m := a.maintenance
+	now := time.Now().UTC()
+	if a.awaitingReconnect {
+		// The containers on the agent are unknown until it reconnects.
+		actors.NotifyAfter(ctx, maintenanceCheckInterval, maintenanceTick{id: a.maintenanceID})
+		return
+	}
+
+	prev := m.Phase
+	m.NumContainers = len(a.agentState.containerAllocation)
+	for {
+		next := nextMaintenancePhase(*m, now)
+		if next == m.Phase {
+			break
+		}
+		if m.Phase == model.MaintenanceScheduled {
+			a.agentState.disable(ctx, true)
+			a.agentState.patchAllSlotsState(ctx, patchAllSlotsState{
+				enabled: &a.agentState.enabled,
+				drain:   &a.agentState.draining,
+			})
+			ctx.Tell(a.resourcePool, sproto.UpdateAgent{Agent: ctx.Self()})
+		}
+		a.signalMaintenancePhase(ctx, next)
+		m.Phase = next
+	}
+
+	switch m.Phase {
+	case model.MaintenanceScheduled:
+		actors.NotifyAfter(ctx, m.StartTime.Sub(now), maintenanceTick{id: a.maintenanceID})
+	case model.MaintenanceSafeToReboot:
+	default:
+		next := maintenanceCheckInterval
+		if untilDeadline := m.Deadline.Sub(now); untilDeadline > 0 && untilDeadline < next {
+			next = untilDeadline
+		}
+		actors.NotifyAfter(ctx, next, maintenanceTick{id: a.maintenanceID})
+	}
+
+	if m.Phase != prev {
+		ctx.Log().Infof("maintenance moved from %s to %s with %d containers left",
+			prev, m.Phase, m.NumContainers)
+		m.PhaseChangedAt = now
+		a.notifyMaintenance(ctx)
+		a.persistMaintenance(ctx)
+	}
+}
+
+// nextMaintenancePhase returns the phase a maintenance moves to at the given time.
+func nextMaintenancePhase(m model.AgentMaintenance, now time.Time) model.AgentMaintenancePhase {
+	switch {
+	case m.Phase == model.MaintenanceScheduled && now.Before(m.StartTime):
+		return model.MaintenanceScheduled
+	case m.NumContainers == 0:
+		return model.MaintenanceSafeToReboot
+	case m.Phase == model.MaintenanceScheduled:
+		return model.MaintenanceDraining
+	case m.Phase == model.MaintenanceDraining && !now.Before(m.Deadline):
+		return model.MaintenanceTerminating
+	default:
+		return m.Phase
+	}
+}
+
+// signalMaintenancePhase signals the allocations on the agent as the maintenance phase requires.
+func (a *agent) signalMaintenancePhase(ctx *actor.Context, phase model.AgentMaintenancePhase) {
+	switch phase {
+	case model.MaintenanceDraining:
+		a.signalAllocations(ctx, sproto.PreemptAllocation, "agent maintenance")
+	case model.MaintenanceTerminating:
+		a.signalAllocations(ctx, sproto.KillAllocation, "agent maintenance deadline reached")
+	}
+}
+
+func (a *agent) signalAllocations(
+	ctx *actor.Context, signal sproto.AllocationSignal, reason string,
+) {
+	for _, ref := range a.agentState.containerAllocation {
+		ctx.Tell(ref, sproto.AllocationSignalWithReason{
+			AllocationSignal:    signal,
+			InformationalReason: reason,
+		})
+	}
+}
+
+// notifyMaintenance tells the agent about its maintenance, so that it runs its maintenance hook.
+// An agent that is reconnecting is told once it reconnects.
+func (a *agent) notifyMaintenance(ctx *actor.Context) {
+	if a.socket == nil || a.awaitingReconnect {
+		return
+	}
+
+	wsm := ws.WriteMessage{Message: aproto.AgentMessage{
+		MaintenanceChanged: &aproto.MaintenanceChanged{Maintenance: *a.maintenance},
+	}}
+	if err := ctx.Ask(a.socket, wsm).Error(); err != nil {
+		ctx.Log().WithError(err).Error("failed to write maintenance changed message")
+	}
+}
+
+// persistMaintenance saves the maintenance of the agent, so that it survives a restart of the
+// master.
+func (a *agent) persistMaintenance(ctx *actor.Context) {
+	snapshot := agentMaintenanceSnapshot{
+		AgentID:          agentID(ctx.Self().Address().Local()),
+		AgentMaintenance: *a.maintenance,
+	}
+	_, err := db.Bun().NewInsert().Model(&snapshot).
+		On("CONFLICT (agent_id) DO UPDATE").
+		Exec(context.TODO())
+	if err != nil {
+		ctx.Log().WithError(err).Error("failed to persist maintenance")
+	}
+}
+
+func loadAgentMaintenance(id agentID) (*model.AgentMaintenance, error) {
+	var snapshot agentMaintenanceSnapshot
+	err := db.Bun().NewSelect().Model(&snapshot).
+		Where("agent_id = ?", id).
+		Scan(context.TODO())
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return &snapshot.AgentMaintenance, nil
+}
+
+func deleteAgentMaintenance(id agentID) error {
+	_, err := db.Bun().NewDelete().Model((*agentMaintenanceSnapshot)(nil)).
+		Where("agent_id = ?", id).
+		Exec(context.TODO())
+	return err
+}
@@ -0,0 +1,75 @@
+package agentrm
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestNextMaintenancePhase(t *testing.T) {
+	start := time.Now()
+	m := model.AgentMaintenance{
+		Phase:         model.MaintenanceScheduled,
+		StartTime:     start,
+		Deadline:      start.Add(time.Hour),
+		NumContainers: 2,
+	}
+
+	cases := []struct {
+		name  string
+		phase model.AgentMaintenancePhase
+		now   time.Time
+		left  int
+		want  model.AgentMaintenancePhase
+	}{
+		{"before start", model.MaintenanceScheduled, start.Add(-time.Minute), 2,
+			model.MaintenanceScheduled},
+		{"before start without tasks", model.MaintenanceScheduled, start.Add(-time.Minute), 0,
+			model.MaintenanceScheduled},
+		{"at start", model.MaintenanceScheduled, start, 2, model.MaintenanceDraining},
+		{"at start without tasks", model.MaintenanceScheduled, start, 0,
+			model.MaintenanceSafeToReboot},
+		{"draining", model.MaintenanceDraining, start.Add(time.Minute), 1,
+			model.MaintenanceDraining},
+		{"drained", model.MaintenanceDraining, start.Add(time.Minute), 0,
+			model.MaintenanceSafeToReboot},
+		{"deadline", model.MaintenanceDraining, start.Add(time.Hour), 1,
+			model.MaintenanceTerminating},
+		{"terminating", model.MaintenanceTerminating, start.Add(2 * time.Hour), 1,
+			model.MaintenanceTerminating},
+		{"terminated", model.MaintenanceTerminating, start.Add(2 * time.Hour), 0,
+			model.MaintenanceSafeToReboot},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m.Phase = tc.phase
+			m.NumContainers = tc.left
+			assert.Equal(t, nextMaintenancePhase(m, tc.now), tc.want)
+		})
+	}
+}
+
+func TestMaintenanceSelects(t *testing.T) {
+	agent := model.AgentSummary{ResourcePool: []string{"gpu"}, Label: "rack-1"}
+	cases := []struct {
+		name string
+		msg  sproto.ScheduleAgentMaintenance
+		want bool
+	}{
+		{"pool", sproto.ScheduleAgentMaintenance{ResourcePool: "gpu"}, true},
+		{"other pool", sproto.ScheduleAgentMaintenance{ResourcePool: "cpu"}, false},
+		{"label", sproto.ScheduleAgentMaintenance{Label: "rack-1"}, true},
+		{"other label", sproto.ScheduleAgentMaintenance{Label: "rack-2"}, false},
+		{"pool and label", sproto.ScheduleAgentMaintenance{ResourcePool: "gpu", Label: "rack-1"}, true},
+		{"neither", sproto.ScheduleAgentMaintenance{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, maintenanceSelects(tc.msg, agent), tc.want)
+		})
+	}
+}
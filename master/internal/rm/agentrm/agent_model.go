@@ -8,13 +8,15 @@ import (
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 // slotData is a database representation of slot state.
 type slotData struct {
-	Device      device.Device
-	UserEnabled bool
-	ContainerID *cproto.ID
+	Device         device.Device
+	UserEnabled    bool
+	ContainerID    *cproto.ID
+	DisabledReason string `json:",omitempty"`
 }
 
 // agentID is the agent id type.
@@ -36,6 +38,14 @@ type agentSnapshot struct {
 	Containers            []cproto.ID `bun:"containers"`
 }
 
+// agentMaintenanceSnapshot is a database representation of the maintenance of an agent.
+type agentMaintenanceSnapshot struct {
+	bun.BaseModel `bun:"table:resourcemanagers_agent_maintenance,alias:rmam"`
+
+	AgentID agentID `bun:"agent_id,pk"`
+	model.AgentMaintenance
+}
+
 // containerSnapshot is a database representation of `containerResources`.
 type containerSnapshot struct {
 	bun.BaseModel `bun:"table:resourcemanagers_agent_containers,alias:rmac"`
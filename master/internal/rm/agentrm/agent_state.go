@@ -20,6 +20,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 )
 
 type slotEnabled struct {
@@ -37,6 +38,8 @@ type slot struct {
 	device      device.Device
 	enabled     slotEnabled
 	containerID *cproto.ID
+	// disabledReason is why the master disabled the slot on its own, if it did.
+	disabledReason string
 }
 
 // agentState holds the scheduler state for an agent. The implementation of agent-related operations
@@ -55,6 +58,9 @@ type agentState struct {
 	slotStates          map[device.ID]*slot
 	containerAllocation map[cproto.ID]*actor.Ref
 	containerState      map[cproto.ID]*cproto.Container
+
+	// images are the names of the images on the agent, as last reported by it.
+	images map[string]bool
 }
 
 // newAgentState returns a new agent empty agent state backed by the handler.
@@ -91,6 +97,23 @@ func (a *agentState) numSlots() int {
 	}
 }
 
+// deviceType returns the type of the devices a container for the request gets on the agent, which
+// determines its image.
+func (a *agentState) deviceType(req *sproto.AllocateRequest) device.Type {
+	if req.SlotsNeeded > 0 {
+		for d := range a.Devices {
+			return d.Type
+		}
+	}
+	return device.CPU
+}
+
+// hasImage returns true if the agent has the image of the request.
+func (a *agentState) hasImage(req *sproto.AllocateRequest) bool {
+	image, ok := req.Images[a.deviceType(req)]
+	return ok && a.images[image]
+}
+
 // numEmptySlots returns the number of slots that have not been allocated to containers.
 func (a *agentState) numEmptySlots() (slots int) {
 	switch {
@@ -150,8 +173,28 @@ func (a *agentState) idle() bool {
 	return a.numUsedZeroSlots() == 0 && a.numUsedSlots() == 0
 }
 
-// allocateFreeDevices allocates container.
-func (a *agentState) allocateFreeDevices(slots int, cid cproto.ID) ([]device.Device, error) {
+// numEmptyProfileSlots returns the number of empty slots with the MIG profile, or of any empty
+// slots if the profile is empty.
+func (a *agentState) numEmptyProfileSlots(profile string) (slots int) {
+	switch {
+	case profile == "":
+		return a.numEmptySlots()
+	case a.draining, !a.enabled:
+		return 0
+	}
+	for d, id := range a.Devices {
+		if id == nil && d.Profile == profile {
+			slots++
+		}
+	}
+	return slots
+}
+
+// allocateFreeDevices allocates container. If the MIG profile is set, only slots with the profile
+// are allocated.
+func (a *agentState) allocateFreeDevices(
+	slots int, migProfile string, cid cproto.ID,
+) ([]device.Device, error) {
 	// TODO(ilia): Rename to AllocateContainer.
 	a.containerState[cid] = &cproto.Container{ID: cid}
 	if slots == 0 {
@@ -160,7 +203,7 @@ func (a *agentState) allocateFreeDevices(slots int, cid cproto.ID) ([]device.Dev
 
 	devices := make([]device.Device, 0, slots)
 	for d, dcid := range a.Devices {
-		if dcid == nil {
+		if dcid == nil && (migProfile == "" || d.Profile == migProfile) {
 			devices = append(devices, d)
 		}
 		if len(devices) == slots {
@@ -200,6 +243,7 @@ func (a *agentState) deepCopy() *agentState {
 		enabled:               a.enabled,
 		draining:              a.draining,
 		containerState:        maps.Clone(a.containerState),
+		images:                maps.Clone(a.images),
 		// TODO(ilia): Deepcopy of `slotStates` may be necessary one day.
 		slotStates: a.slotStates,
 	}
@@ -374,11 +418,12 @@ func (a *agentState) getSlotSummary(deviceID device.ID) model.SlotSummary {
 	}
 
 	return model.SlotSummary{
-		ID:        strconv.Itoa(int(s.device.ID)),
-		Device:    s.device,
-		Enabled:   s.enabled.enabled(),
-		Container: container,
-		Draining:  s.enabled.draining,
+		ID:             strconv.Itoa(int(s.device.ID)),
+		Device:         s.device,
+		Enabled:        s.enabled.enabled(),
+		Container:      container,
+		Draining:       s.enabled.draining,
+		DisabledReason: s.disabledReason,
 	}
 }
 
@@ -417,6 +462,9 @@ func (a *agentState) patchSlotStateInner(
 ) model.SlotSummary {
 	if msg.enabled != nil {
 		slotState.enabled.userEnabled = *msg.enabled
+		if *msg.enabled {
+			slotState.disabledReason = ""
+		}
 	}
 	if msg.drain != nil {
 		slotState.enabled.draining = *msg.drain
@@ -459,13 +507,28 @@ func (a *agentState) patchSlotState(
 	return a.patchSlotStateInner(ctx, msg, s), nil
 }
 
+// quarantineSlot disables and drains the slot of an unhealthy device, as DisableSlot does, and
+// records why. It returns false if the slot is unknown or already disabled.
+func (a *agentState) quarantineSlot(ctx *actor.Context, id device.ID, reason string) bool {
+	s, ok := a.slotStates[id]
+	if !ok || !s.enabled.userEnabled {
+		return false
+	}
+	s.disabledReason = reason
+	a.patchSlotStateInner(ctx, patchSlotState{
+		id: id, enabled: ptrs.Ptr(false), drain: ptrs.Ptr(true),
+	}, s)
+	return true
+}
+
 func (a *agentState) snapshot() *agentSnapshot {
 	slots := make([]slotData, 0, len(a.slotStates))
 	for _, slotState := range a.slotStates {
 		slots = append(slots, slotData{
-			Device:      slotState.device,
-			UserEnabled: slotState.enabled.userEnabled,
-			ContainerID: slotState.containerID,
+			Device:         slotState.device,
+			UserEnabled:    slotState.enabled.userEnabled,
+			ContainerID:    slotState.containerID,
+			DisabledReason: slotState.disabledReason,
 		})
 	}
 
@@ -620,9 +683,11 @@ func newAgentStateFromSnapshot(as agentSnapshot) (*agentState, error) {
 			enabled: slotEnabled{
 				deviceAdded:  true,
 				agentEnabled: as.UserEnabled,
-				userEnabled:  as.UserEnabled,
-				draining:     as.UserDraining,
+				// Quarantined slots stay disabled until an admin enables them.
+				userEnabled: as.UserEnabled && sd.DisabledReason == "",
+				draining:    as.UserDraining,
 			},
+			disabledReason: sd.DisabledReason,
 		}
 		if sd.ContainerID != nil {
 			devices[sd.Device] = sd.ContainerID
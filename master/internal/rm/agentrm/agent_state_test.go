@@ -0,0 +1,76 @@
+package agentrm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+// funcActor runs the functions it is sent with its context, as the agent's methods on its state
+// expect.
+var funcActor = actor.ActorFunc(func(ctx *actor.Context) error {
+	if f, ok := ctx.Message().(func(ctx *actor.Context)); ok {
+		f(ctx)
+		ctx.Respond(true)
+	}
+	return nil
+})
+
+func TestQuarantineSlot(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	ref, created := system.ActorOf(actor.Addr("agent"), &MockAgent{ID: "agent", Slots: 2})
+	assert.Assert(t, created)
+	runner, created := system.ActorOf(actor.Addr("runner"), funcActor)
+	assert.Assert(t, created)
+	inActor := func(f func(ctx *actor.Context)) {
+		assert.Assert(t, system.Ask(runner, f).Get() != nil)
+	}
+
+	state := newAgentState(sproto.AddAgent{Agent: ref}, 0)
+	for i := 0; i < 2; i++ {
+		d := device.Device{ID: device.ID(i), Type: device.CUDA}
+		state.slotStates[d.ID] = &slot{
+			device:  d,
+			enabled: slotEnabled{agentEnabled: true, userEnabled: true, deviceAdded: true},
+		}
+		state.Devices[d] = nil
+	}
+
+	inActor(func(ctx *actor.Context) {
+		assert.Assert(t, state.quarantineSlot(ctx, 0, "Xid 79, GPU has fallen off the bus"))
+		// Slots that are unknown or already disabled are not quarantined again.
+		assert.Assert(t, !state.quarantineSlot(ctx, 0, "Xid 48, double bit ECC error"))
+		assert.Assert(t, !state.quarantineSlot(ctx, 2, "Xid 79, GPU has fallen off the bus"))
+	})
+
+	summary := state.getSlotSummary(0)
+	assert.Assert(t, !summary.Enabled)
+	assert.Assert(t, summary.Draining)
+	assert.Equal(t, summary.DisabledReason, "Xid 79, GPU has fallen off the bus")
+	assert.Assert(t, state.getSlotSummary(1).Enabled)
+
+	// The quarantine is kept across restoring the agent from its snapshot.
+	restored, err := newAgentStateFromSnapshot(*state.snapshot())
+	assert.NilError(t, err)
+	restored.Handler = ref
+	summary = restored.getSlotSummary(0)
+	assert.Assert(t, !summary.Enabled)
+	assert.Equal(t, summary.DisabledReason, "Xid 79, GPU has fallen off the bus")
+	summary = restored.getSlotSummary(1)
+	assert.Assert(t, summary.Enabled)
+	assert.Equal(t, summary.DisabledReason, "")
+
+	// Enabling the slot lifts the quarantine.
+	inActor(func(ctx *actor.Context) {
+		summary = restored.patchSlotStateInner(ctx, patchSlotState{
+			id: 0, enabled: ptrs.Ptr(true), drain: ptrs.Ptr(false),
+		}, restored.slotStates[0])
+	})
+	assert.Assert(t, summary.Enabled)
+	assert.Equal(t, summary.DisabledReason, "")
+}
@@ -135,6 +135,8 @@ func (a *agents) Receive(ctx *actor.Context) error {
 			response.Agents = append(response.Agents, a.ToProto())
 		}
 		ctx.Respond(response)
+	case sproto.ScheduleAgentMaintenance:
+		ctx.Respond(a.scheduleMaintenance(ctx, msg))
 	case echo.Context:
 		a.handleAPIRequest(ctx, msg)
 	case actor.PostStop:
@@ -169,6 +171,12 @@ func (a *agents) createAgentActor(
 	}
 
 	rpConfig := ctx.Ask(resourcePoolRef, aproto.GetRPConfig{}).Get().(aproto.GetRPResponse)
+	if rpConfig.SlotResourceLimits != nil || len(rpConfig.MIGProfiles) > 0 {
+		poolOpts := *opts
+		poolOpts.SlotResourceLimits = rpConfig.SlotResourceLimits
+		poolOpts.MIGProfiles = rpConfig.MIGProfiles
+		opts = &poolOpts
+	}
 	ref, ok := ctx.ActorOf(id, &agent{
 		resourcePool:          resourcePoolRef,
 		resourcePoolName:      resourcePool,
@@ -192,6 +200,49 @@ func (a *agents) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	}
 }
 
+// scheduleMaintenance schedules the maintenance of every agent in the resource pool or with the
+// label and returns the summaries of the agents it was scheduled for. Agents that refuse it, such
+// as agents that are reconnecting, are left out.
+func (a *agents) scheduleMaintenance(
+	ctx *actor.Context, msg sproto.ScheduleAgentMaintenance,
+) model.AgentsSummary {
+	agents := a.summarize(ctx)
+	var refs []*actor.Ref
+	for _, ref := range ctx.Children() {
+		if maintenanceSelects(msg, agents[ref.Address().String()]) {
+			refs = append(refs, ref)
+		}
+	}
+
+	summary := model.AgentsSummary{}
+	for ref, result := range ctx.AskAll(msg, refs...).GetAll() {
+		switch result := result.(type) {
+		case model.AgentSummary:
+			summary[ref.Address().String()] = result
+		case error:
+			ctx.Log().WithError(result).Warnf("failed to schedule maintenance of %s", ref.Address())
+		}
+	}
+	return summary
+}
+
+// maintenanceSelects returns whether a maintenance request for a resource pool or label applies
+// to the agent.
+func maintenanceSelects(msg sproto.ScheduleAgentMaintenance, agent model.AgentSummary) bool {
+	if msg.Label != "" && msg.Label != agent.Label {
+		return false
+	}
+	if msg.ResourcePool == "" {
+		return msg.Label != ""
+	}
+	for _, pool := range agent.ResourcePool {
+		if pool == msg.ResourcePool {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *agents) summarize(ctx *actor.Context) model.AgentsSummary {
 	results := ctx.AskAll(model.AgentSummary{}, ctx.Children()...).GetAll()
 	summary := make(map[string]model.AgentSummary, len(results))
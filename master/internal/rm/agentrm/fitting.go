@@ -28,7 +28,9 @@ type fittingState struct {
 	// for which agent they go onto if the scores are tied. Use hash distance
 	// as an deterministic pseudorandom function for load balance.
 	HashDistance uint64
-	Slots        int
+	// HasImage is whether the agent already has the image of the task, which saves pulling it.
+	HasImage bool
+	Slots    int
 }
 
 type candidateList []*fittingState
@@ -46,7 +48,7 @@ func (c candidateList) Len() int {
 func (c candidateList) Less(i, j int) bool {
 	// Multiple zero-slot tasks will all end up on the same agent if we only consider the fitting
 	// score. To combat this, break ties by selecting the agent whose hashed ID is closest to the
-	// hashed ID of the task.
+	// hashed ID of the task. Before that, prefer agents that already have the image of the task.
 
 	a := c[i]
 	b := c[j]
@@ -55,6 +57,10 @@ func (c candidateList) Less(i, j int) bool {
 		return true
 	case a.Score < b.Score:
 		return false
+	case a.HasImage && !b.HasImage:
+		return true
+	case !a.HasImage && b.HasImage:
+		return false
 	case a.HashDistance < b.HashDistance:
 		return true
 	case a.HashDistance > b.HashDistance:
@@ -122,10 +128,8 @@ func findDedicatedAgentFits(
 	for _, agent := range agentStates {
 		constraints := []HardConstraint{agentSlotUnusedSatisfied}
 		if isViable(req, agent, constraints...) {
-			agentsByNumSlots[agent.numEmptySlots()] = append(
-				agentsByNumSlots[agent.numEmptySlots()],
-				agent,
-			)
+			n := agent.numEmptyProfileSlots(req.FittingRequirements.MIGProfile)
+			agentsByNumSlots[n] = append(agentsByNumSlots[n], agent)
 		}
 	}
 
@@ -150,6 +154,7 @@ func findDedicatedAgentFits(
 				Agent:        agent,
 				Score:        fittingMethod(req, agent),
 				HashDistance: hashDistance(req, agent),
+				HasImage:     agent.hasImage(req),
 				Slots:        n,
 			})
 		}
@@ -232,6 +237,7 @@ func findSharedAgentFit(
 			Agent:        agent,
 			Score:        fittingMethod(req, agent),
 			HashDistance: hashDistance(req, agent),
+			HasImage:     agent.hasImage(req),
 		})
 	}
 
@@ -9,7 +9,7 @@ import (
 // Hard Constraints
 
 func slotsSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
-	return req.SlotsNeeded <= agent.numEmptySlots()
+	return req.SlotsNeeded <= agent.numEmptyProfileSlots(req.FittingRequirements.MIGProfile)
 }
 
 func maxZeroSlotContainersSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
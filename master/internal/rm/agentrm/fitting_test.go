@@ -9,6 +9,8 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/device"
 )
 
 func TestIsViable(t *testing.T) {
@@ -472,6 +474,71 @@ func TestFindDedicatedAgentFits(t *testing.T) {
 	}
 }
 
+func TestFindFitsPrefersCachedImage(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	req := &sproto.AllocateRequest{
+		AllocationID: "task1",
+		Images:       map[device.Type]string{device.CPU: "determinedai/env:tag"},
+	}
+	agents, index := byHandler(
+		newFakeAgentState(t, system, "agent1", 4, 0, 100, 0),
+		newFakeAgentState(t, system, "agent2", 4, 0, 100, 0),
+	)
+
+	// Without cached images, the hash distance picks the agent.
+	fits := findFits(req, agents, BestFit, false)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent, index[0])
+
+	// Otherwise, the agent that has the image is preferred among equally good fits.
+	index[1].images = map[string]bool{"determinedai/env:tag": true}
+	fits = findFits(req, agents, BestFit, false)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent, index[1])
+}
+
+func TestFindFitsMatchesMIGProfile(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	withProfiles := func(agent *agentState, profiles ...string) *agentState {
+		agent.Devices = map[device.Device]*cproto.ID{}
+		for i, p := range profiles {
+			agent.Devices[device.Device{ID: device.ID(i), Type: device.CUDA, Profile: p}] = nil
+		}
+		return agent
+	}
+	agents, index := byHandler(
+		withProfiles(newFakeAgentState(t, system, "agent1", 0, 0, 100, 0),
+			"3g.40gb", "1g.10gb", "1g.10gb"),
+		withProfiles(newFakeAgentState(t, system, "agent2", 0, 0, 100, 0),
+			"3g.40gb", "3g.40gb"),
+	)
+
+	req := &sproto.AllocateRequest{
+		AllocationID:        "task1",
+		SlotsNeeded:         2,
+		FittingRequirements: sproto.FittingRequirements{MIGProfile: "3g.40gb"},
+	}
+	fits := findFits(req, agents, BestFit, false)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent, index[1])
+
+	req.FittingRequirements.MIGProfile = "1g.10gb"
+	fits = findFits(req, agents, BestFit, false)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent, index[0])
+
+	devices, err := index[0].allocateFreeDevices(2, "1g.10gb", cproto.NewID())
+	assert.NilError(t, err)
+	for _, d := range devices {
+		assert.Equal(t, d.Profile, "1g.10gb")
+	}
+
+	// Neither agent has two free instances of the profile left.
+	assert.Equal(t, len(findFits(req, agents, BestFit, false)), 0)
+	_, err = index[0].allocateFreeDevices(1, "1g.10gb", cproto.NewID())
+	assert.ErrorContains(t, err, "not enough devices")
+}
+
 func byHandler(
 	handlers ...*agentState,
 ) (map[*actor.Ref]*agentState, []*agentState) {
@@ -172,6 +172,7 @@ func (m *MockAgent) Receive(ctx *actor.Context) error {
 	case actor.PostStop:
 	case sproto.StartTaskContainer:
 	case sproto.KillTaskContainer:
+	case prePullImages:
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
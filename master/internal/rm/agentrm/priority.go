@@ -155,7 +155,7 @@ func (p priorityScheduler) prioritySchedulerWithFilter(
 					log.Debugf(
 						"Not preempting tasks for task %s as it will be able to launch "+
 							"once already scheduled preemptions complete", prioritizedAllocation.Name)
-					addTaskToAgents(fits)
+					addTaskToAgents(prioritizedAllocation, fits)
 					continue
 				}
 
@@ -234,7 +234,7 @@ func (p priorityScheduler) trySchedulingTaskViaPreemption(
 				fittingMethod,
 				p.allowHeterogeneousFits,
 			); len(fits) > 0 {
-				addTaskToAgents(fits)
+				addTaskToAgents(allocationRequest, fits)
 				return true, localAgentsState, preemptedTasks
 			}
 		}
@@ -260,7 +260,7 @@ func (p priorityScheduler) trySchedulingPendingTasksInPriority(
 			unSuccessfulAllocations = append(unSuccessfulAllocations, allocationRequest)
 			continue
 		}
-		addTaskToAgents(fits)
+		addTaskToAgents(allocationRequest, fits)
 		successfulAllocations = append(successfulAllocations, allocationRequest)
 	}
 
@@ -311,9 +311,11 @@ func deepCopyAgents(agents map[*actor.Ref]*agentState) map[*actor.Ref]*agentStat
 	return copiedAgents
 }
 
-func addTaskToAgents(fits []*fittingState) {
+func addTaskToAgents(req *sproto.AllocateRequest, fits []*fittingState) {
 	for _, fit := range fits {
-		if _, err := fit.Agent.allocateFreeDevices(fit.Slots, cproto.NewID()); err != nil {
+		if _, err := fit.Agent.allocateFreeDevices(
+			fit.Slots, req.FittingRequirements.MIGProfile, cproto.NewID(),
+		); err != nil {
 			panic(errors.Wrap(err, "can't add task to agents"))
 		}
 	}
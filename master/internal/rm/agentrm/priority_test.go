@@ -771,7 +771,7 @@ func AllocateTasks(
 
 		for _, fit := range fits {
 			containerID := cproto.NewID()
-			devices, err := fit.Agent.allocateFreeDevices(fit.Slots, containerID)
+			devices, err := fit.Agent.allocateFreeDevices(fit.Slots, "", containerID)
 			if err != nil {
 				panic(err)
 			}
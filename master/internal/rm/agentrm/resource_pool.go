@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"sort"
 	"time"
 
 	"golang.org/x/exp/maps"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/task/taskmodel"
 	"github.com/determined-ai/determined/master/pkg/actor"
@@ -129,6 +131,7 @@ func (rp *resourcePool) allocateRequest(ctx *actor.Context, msg sproto.AllocateR
 	if len(msg.Name) == 0 {
 		msg.Name = "Unnamed Task"
 	}
+	msg.RequestTime = time.Now()
 
 	log.Infof(
 		"resources are requested by %s (Allocation ID: %s)",
@@ -264,6 +267,7 @@ func (rp *resourcePool) allocateResources(ctx *actor.Context, req *sproto.Alloca
 		containerID := cproto.NewID()
 		rr := ctx.Ask(fit.Agent.Handler, allocateFreeDevices{
 			slots:       fit.Slots,
+			migProfile:  req.FittingRequirements.MIGProfile,
 			containerID: containerID,
 		})
 		var resp actor.Message
@@ -323,6 +327,9 @@ func (rp *resourcePool) allocateResources(ctx *actor.Context, req *sproto.Alloca
 	}
 	rp.taskList.AddAllocation(req.AllocationID, &allocated)
 	ctx.Tell(req.AllocationRef, allocated)
+	if g, ok := rp.groups[req.Group]; ok && !req.Restore {
+		prom.ObserveSchedulingLatency(rp.config.PoolName, g.Priority, time.Since(req.RequestTime))
+	}
 
 	// Refresh state for the updated agents.
 	allocatedAgents := make([]*actor.Ref, 0, len(resources))
@@ -420,6 +427,92 @@ func (rp *resourcePool) sendScalingInfo(ctx *actor.Context) {
 	}
 }
 
+// reportDemand updates the queue and slot metrics of the pool. It must be called while the agent
+// states are cached.
+func (rp *resourcePool) reportDemand() {
+	var queued, demanded, available int
+	for it := rp.taskList.Iterator(); it.Next(); {
+		req := it.Value()
+		demanded += req.SlotsNeeded
+		if !rp.taskList.IsScheduled(req.AllocationID) {
+			queued++
+		}
+	}
+	for _, agent := range rp.agentStatesCache {
+		available += agent.numSlots()
+	}
+	prom.SetResourcePoolDemand(rp.config.PoolName, queued, demanded, available)
+}
+
+// sendPrePullImages tells each agent which images to pre-pull: those configured for the pool and
+// the images of the queued requests targeted at it. It must be called while the agent states are
+// cached.
+func (rp *resourcePool) sendPrePullImages(ctx *actor.Context) {
+	var queued []*sproto.AllocateRequest
+	for it := rp.taskList.Iterator(); it.Next(); {
+		if req := it.Value(); !rp.taskList.IsScheduled(req.AllocationID) {
+			queued = append(queued, req)
+		}
+	}
+	for ref, images := range prePullImagesByAgent(rp.agentStatesCache, queued, rp.config.PrePullImages) {
+		ctx.Tell(ref, prePullImages{images: images})
+	}
+}
+
+// prePullImagesByAgent returns the images each agent should pre-pull. The image of each queued
+// request is pulled by only one agent that can run it, so that the queue does not pin every image
+// on every agent and overflow their image caches: one that already has the image if there is one,
+// otherwise the one with the fewest images to pre-pull.
+func prePullImagesByAgent(
+	agents map[*actor.Ref]*agentState, queued []*sproto.AllocateRequest, poolImages []string,
+) map[*actor.Ref][]string {
+	refs := maps.Keys(agents)
+	sort.Slice(refs, func(i, j int) bool {
+		return agents[refs[i]].agentID() < agents[refs[j]].agentID()
+	})
+
+	sets := make(map[*actor.Ref]map[string]bool, len(agents))
+	for _, ref := range refs {
+		sets[ref] = map[string]bool{}
+		for _, image := range poolImages {
+			sets[ref][image] = true
+		}
+	}
+
+	for _, req := range queued {
+		var target *actor.Ref
+		var targetImage string
+		for _, ref := range refs {
+			agent := agents[ref]
+			if !agent.enabled || agent.draining || req.SlotsNeeded > agent.numSlots() ||
+				(req.SlotsNeeded > 0 && len(agent.Devices) == 0) {
+				continue
+			}
+			image, ok := req.Images[agent.deviceType(req)]
+			if !ok {
+				continue
+			}
+			if agent.images[image] || sets[ref][image] {
+				target, targetImage = ref, image
+				break
+			}
+			if target == nil || len(sets[ref]) < len(sets[target]) {
+				target, targetImage = ref, image
+			}
+		}
+		if target != nil {
+			sets[target][targetImage] = true
+		}
+	}
+
+	images := make(map[*actor.Ref][]string, len(sets))
+	for ref, set := range sets {
+		images[ref] = maps.Keys(set)
+		sort.Strings(images[ref])
+	}
+	return images
+}
+
 // Receive implements the actor.Actor interface.
 func (rp *resourcePool) Receive(ctx *actor.Context) error {
 	ctx.AddLabel("resource-pool", rp.config.PoolName)
@@ -517,6 +610,8 @@ func (rp *resourcePool) Receive(ctx *actor.Context) error {
 		ctx.Respond(aproto.GetRPResponse{
 			AgentReconnectWait:    rp.config.AgentReconnectWait,
 			MaxZeroSlotContainers: rp.config.MaxAuxContainersPerAgent,
+			SlotResourceLimits:    rp.config.SlotResourceLimits,
+			MIGProfiles:           rp.config.MIGProfiles,
 		})
 
 	case schedulerTick:
@@ -550,6 +645,8 @@ func (rp *resourcePool) Receive(ctx *actor.Context) error {
 				rp.releaseResource(ctx, taskActor)
 			}
 			rp.sendScalingInfo(ctx)
+			rp.reportDemand()
+			rp.sendPrePullImages(ctx)
 		}
 		rp.reschedule = false
 		reschedule = false
@@ -19,6 +19,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/device"
 )
 
 func TestCleanUpTaskWhenTaskActorStopsWithError(t *testing.T) {
@@ -514,3 +515,28 @@ func TestMoveMessagesAcrossPrioLanesBehind(t *testing.T) {
 	assert.Equal(t, secondAnchor, model.JobID("job3"))
 	assert.Equal(t, anchorPriority, 50)
 }
+
+func TestPrePullImagesByAgent(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents, index := byHandler(
+		newFakeAgentState(t, system, "agent1", 4, 0, 100, 0),
+		newFakeAgentState(t, system, "agent2", 4, 0, 100, 0),
+	)
+	index[1].images = map[string]bool{"cached": true}
+	image := func(name string) map[device.Type]string {
+		return map[device.Type]string{device.CPU: name}
+	}
+	queued := []*sproto.AllocateRequest{
+		{AllocationID: "task1", Images: image("a")},
+		{AllocationID: "task2", Images: image("b")},
+		{AllocationID: "task3", Images: image("a")},
+		{AllocationID: "task4", Images: image("cached")},
+		{AllocationID: "task5", Images: image("c"), SlotsNeeded: 8},
+	}
+
+	// Each image is pre-pulled by one agent, preferring agents that have it, and images of
+	// requests that fit no agent are not pre-pulled.
+	images := prePullImagesByAgent(agents, queued, []string{"pool"})
+	assert.DeepEqual(t, images[index[0].Handler], []string{"a", "pool"})
+	assert.DeepEqual(t, images[index[1].Handler], []string{"b", "cached", "pool"})
+}
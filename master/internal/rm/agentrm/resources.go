@@ -68,7 +68,8 @@ func (c containerResources) Start(
 				Devices:     c.devices,
 				Description: c.req.AllocationRef.Address().String(),
 			},
-			Spec: spec.ToDockerSpec(),
+			Spec:         spec.ToDockerSpec(),
+			TraceContext: rri.TraceContext,
 		},
 		LogContext: logCtx,
 	}).Error()
@@ -85,7 +85,7 @@ func forceAddAgent(
 		}
 	}
 	for i := 0; i < numZeroSlotContainers; i++ {
-		_, err := state.allocateFreeDevices(0, cproto.NewID())
+		_, err := state.allocateFreeDevices(0, "", cproto.NewID())
 		assert.NilError(t, err)
 	}
 	agents[state.Handler] = state
@@ -113,14 +113,14 @@ func newFakeAgentState(
 			SlotsNeeded: slotsUsed,
 			Preemptible: true,
 		}
-		if _, err := state.allocateFreeDevices(req.SlotsNeeded, cproto.NewID()); err != nil {
+		if _, err := state.allocateFreeDevices(req.SlotsNeeded, "", cproto.NewID()); err != nil {
 			panic(err)
 		}
 	}
 
 	for i := 0; i < zeroSlotContainers; i++ {
 		req := &sproto.AllocateRequest{}
-		if _, err := state.allocateFreeDevices(req.SlotsNeeded, cproto.NewID()); err != nil {
+		if _, err := state.allocateFreeDevices(req.SlotsNeeded, "", cproto.NewID()); err != nil {
 			panic(err)
 		}
 	}
@@ -524,7 +524,7 @@ func setupSchedulerStates(
 			devices := make([]device.Device, 0)
 			if mockTask.ContainerStarted {
 				if mockTask.SlotsNeeded == 0 {
-					_, err := agentState.allocateFreeDevices(0, containerID)
+					_, err := agentState.allocateFreeDevices(0, "", containerID)
 					assert.NilError(t, err)
 				} else {
 					i := 0
@@ -2,12 +2,14 @@ package kubernetesrm
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 
 	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/rm/rmerrors"
 	"github.com/determined-ai/determined/master/internal/rm/tasklist"
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -140,6 +142,7 @@ func (k *kubernetesResourcePool) Receive(ctx *actor.Context) error {
 	case SchedulerTick:
 		if k.reschedule {
 			k.schedulePendingTasks(ctx)
+			k.reportDemand(ctx)
 		}
 		k.reschedule = false
 		reschedule = false
@@ -238,6 +241,7 @@ func (k *kubernetesResourcePool) addTask(ctx *actor.Context, msg sproto.Allocate
 	if len(msg.Name) == 0 {
 		msg.Name = "Unnamed-k8-Task"
 	}
+	msg.RequestTime = time.Now()
 
 	ctx.Log().Infof(
 		"resources are requested by %s (Allocation ID: %s)",
@@ -517,6 +521,9 @@ func (k *kubernetesResourcePool) assignResources(
 	assigned := sproto.ResourcesAllocated{ID: req.AllocationID, Resources: allocations}
 	k.reqList.AddAllocationRaw(req.AllocationID, &assigned)
 	req.AllocationRef.System().Tell(req.AllocationRef, assigned.Clone())
+	if g, ok := k.groups[req.Group]; ok && !req.Restore {
+		prom.ObserveSchedulingLatency(k.poolConfig.PoolName, g.Priority, time.Since(req.RequestTime))
+	}
 
 	if req.Restore {
 		ctx.Log().
@@ -648,6 +655,24 @@ func (k *kubernetesResourcePool) schedulePendingTasks(ctx *actor.Context) {
 	}
 }
 
+// reportDemand updates the queue and slot metrics of the pool.
+func (k *kubernetesResourcePool) reportDemand(ctx *actor.Context) {
+	pods, err := k.summarizePods(ctx)
+	if err != nil {
+		ctx.Log().WithError(err).Debug("failed to summarize pods for metrics")
+		return
+	}
+	var queued, demanded int
+	for it := k.reqList.Iterator(); it.Next(); {
+		req := it.Value()
+		demanded += req.SlotsNeeded
+		if !k.reqList.IsScheduled(req.AllocationID) {
+			queued++
+		}
+	}
+	prom.SetResourcePoolDemand(k.poolConfig.PoolName, queued, demanded, pods.SlotsAvailable)
+}
+
 type k8sPodResources struct {
 	req             *sproto.AllocateRequest
 	podsActor       *actor.Ref
@@ -3,6 +3,7 @@ package sproto
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/aproto"
@@ -44,6 +45,23 @@ type (
 	}
 )
 
+// Message protocol from the API to an agent actor, or to the agents actor for all the agents in a
+// resource pool or with a label.
+type (
+	// ScheduleAgentMaintenance schedules a maintenance of the agent, replacing any scheduled one.
+	// Agent actors respond with the model.AgentSummary and the agents actor with the
+	// model.AgentsSummary of the agents in ResourcePool or with Label.
+	ScheduleAgentMaintenance struct {
+		ResourcePool string
+		Label        string
+		StartTime    time.Time
+		Timeout      time.Duration
+	}
+	// CancelAgentMaintenance cancels the maintenance of the agent and puts it back in service.
+	// Agent actors respond with the model.AgentSummary.
+	CancelAgentMaintenance struct{}
+)
+
 // AgentSummary contains information about an agent for external display.
 type AgentSummary struct {
 	Name   string
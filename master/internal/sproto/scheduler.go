@@ -4,4 +4,6 @@ package sproto
 type FittingRequirements struct {
 	// SingleAgent specifies that the task must be located within a single agent.
 	SingleAgent bool
+	// MIGProfile, if set, specifies that the task must run on MIG instances of the profile.
+	MIGProfile string
 }
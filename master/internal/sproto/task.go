@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -27,6 +28,9 @@ type (
 		TaskID            model.TaskID
 		JobID             model.JobID
 		JobSubmissionTime time.Time
+		// RequestTime is when the resource manager received the request. It is set by the
+		// resource manager and used to measure how long the request was queued.
+		RequestTime time.Time
 		// IsUserVisible determines whether the AllocateRequest should
 		// be considered in user-visible reports.
 		IsUserVisible bool
@@ -40,6 +44,9 @@ type (
 		SlotsNeeded         int
 		ResourcePool        string
 		FittingRequirements FittingRequirements
+		// Images are the images of the task by the type of device it runs on, used to prefer
+		// agents that already have the image and to pre-pull it while the task is queued.
+		Images map[device.Type]string
 
 		// Behavioral configuration.
 		Preemptible bool
@@ -49,6 +56,9 @@ type (
 
 		// Logging context of the allocation actor.
 		LogContext logger.Context
+		// TraceParent is the span of the task the allocation is for, if it is traced. The
+		// lifecycle of the allocation is traced as a child of it.
+		TraceParent trace.SpanContext
 	}
 
 	// IdleTimeoutConfig configures how idle timeouts should behave.
@@ -58,6 +68,18 @@ type (
 		UseRunnerState  bool
 		TimeoutDuration time.Duration
 		Debug           bool
+		// GPUIdle, if set, also counts the task as idle while all of its GPUs are.
+		GPUIdle *GPUIdleTimeoutConfig
+	}
+
+	// GPUIdleTimeoutConfig configures how GPU idle timeouts should behave.
+	GPUIdleTimeoutConfig struct {
+		// UtilizationThreshold is the GPU utilization, in percent, below which a GPU is idle.
+		UtilizationThreshold float64
+		// TimeoutDuration is how long the GPUs must be idle before the task is warned.
+		TimeoutDuration time.Duration
+		// GracePeriod is how long after the warning the task is considered idle.
+		GracePeriod time.Duration
 	}
 
 	// ProxyPortConfig configures a proxy the allocation should start.
@@ -180,6 +202,9 @@ const (
 	KillAllocation AllocationSignal = "kill"
 	// TerminateAllocation is the signal to kill an allocation; analogous to in SIGTERM.
 	TerminateAllocation AllocationSignal = "terminate"
+	// PreemptAllocation is the signal to preempt an allocation if it is preemptible, so that it
+	// checkpoints and is rescheduled; it is ignored by allocations that are not preemptible.
+	PreemptAllocation AllocationSignal = "preempt"
 )
 
 // Incoming task actor messages; task actors must accept these messages.
@@ -223,6 +248,8 @@ type (
 		Token        string
 		AgentRank    int
 		IsMultiAgent bool
+		// TraceContext propagates the trace of the allocation to where the resources start.
+		TraceContext map[string]string
 	}
 )
 
@@ -344,3 +371,14 @@ func NewProxyPortConfig(input expconf.ProxyPortsConfig, taskID model.TaskID) []*
 
 	return out
 }
+
+// NewImages returns the images of a task by device type, given the images of its environment.
+func NewImages(images interface{ For(device.Type) string }) map[device.Type]string {
+	out := map[device.Type]string{}
+	for _, t := range []device.Type{device.CPU, device.CUDA, device.ROCM} {
+		if image := images.For(t); image != "" {
+			out[t] = image
+		}
+	}
+	return out
+}
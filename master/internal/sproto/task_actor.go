@@ -32,6 +32,13 @@ type (
 		AgentID *string
 	}
 
+	// ContainerGPUTelemetry notifies the task actor of the usage of the devices of its container.
+	ContainerGPUTelemetry struct {
+		ContainerID cproto.ID
+		AgentID     string
+		Samples     []aproto.GPUSample
+	}
+
 	// GetResourcesContainerState requests cproto.Container state for a given clump of resources.
 	// If the resources aren't a container, this request returns a failure.
 	GetResourcesContainerState struct {
@@ -6,11 +6,14 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/internal/cluster"
+	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/portregistry"
 	"github.com/determined-ai/determined/master/internal/prom"
@@ -18,11 +21,13 @@ import (
 	"github.com/determined-ai/determined/master/internal/rm"
 	"github.com/determined-ai/determined/master/internal/rm/allocationmap"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/task/gputelemetry"
 	"github.com/determined-ai/determined/master/internal/task/idle"
 	"github.com/determined-ai/determined/master/internal/task/preemptible"
 	"github.com/determined-ai/determined/master/internal/task/tasklogger"
 	"github.com/determined-ai/determined/master/internal/task/taskmodel"
 	"github.com/determined-ai/determined/master/internal/telemetry"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/cproto"
@@ -65,6 +70,9 @@ type (
 		killCooldown *time.Time
 		// tracks if we have finished termination.
 		exited bool
+		// Marks that the scheduler began preempting the allocation, so resource managers that
+		// ask for the resources back repeatedly only count it as preempted once.
+		preemptedByScheduler bool
 
 		// State for specific sub-behaviors of an allocation.
 		// Encapsulates logic of rendezvousing containers of the currently
@@ -78,6 +86,13 @@ type (
 		logCtx          detLogger.Context
 		restored        bool
 		portsRegistered bool
+		// The trial whose profiler metrics GPU telemetry is stored with, 0 if the allocation is not
+		// for a trial, or nil until it is first looked up. GPU telemetry is stored off the actor,
+		// guarded by gpuTelemetryMu.
+		gpuTelemetryMu      sync.Mutex
+		gpuTelemetryTrialID *int
+
+		trace *allocationTrace
 	}
 
 	// MarkResourcesDaemon marks the given reservation as a daemon. In the event of a normal exit,
@@ -115,6 +130,11 @@ type (
 	}
 	// IsAllocationRestoring asks the allocation if it is in the middle of a restore.
 	IsAllocationRestoring struct{}
+
+	// idleWarning warns the allocation that it will soon be terminated due to inactivity.
+	idleWarning struct {
+		reason string
+	}
 )
 
 const (
@@ -148,6 +168,7 @@ func NewAllocation(
 		resources: resourcesList{},
 
 		logCtx: req.LogContext,
+		trace:  newAllocationTrace(req),
 	}
 }
 
@@ -205,10 +226,15 @@ func (a *Allocation) Receive(ctx *actor.Context) error {
 		}
 	case sproto.ReleaseResources:
 		a.Terminate(ctx, "allocation being preempted by the scheduler", msg.ForcePreemption)
+		if !a.exited && !a.preemptedByScheduler {
+			a.preemptedByScheduler = true
+			prom.IncPreemptions(a.req.ResourcePool)
+		}
 	case sproto.ChangeRP:
 		a.Terminate(ctx, "allocation resource pool changed", false)
 	case actor.PostStop:
 		a.Cleanup(ctx)
+		a.trace.end(a.exitErr)
 		// a.portsRegistered  is set to true right after ports are registered.
 		// This variable ensures to release ports even if there's a failure after restoring ports.
 		if a.portsRegistered {
@@ -222,9 +248,14 @@ func (a *Allocation) Receive(ctx *actor.Context) error {
 		if cfg := a.req.IdleTimeout; cfg != nil {
 			idle.Unregister(cfg.ServiceID)
 		}
+		gputelemetry.Unregister(a.model.AllocationID)
 		allocationmap.UnregisterAllocation(a.model.AllocationID)
 	case sproto.ContainerLog:
 		a.sendTaskLog(msg.ToTaskLog())
+	case sproto.ContainerGPUTelemetry:
+		a.recordGPUTelemetry(ctx, msg)
+	case idleWarning:
+		a.warnIdle(ctx, msg)
 
 	// These messages allow users (and sometimes an orchestrator, such as HP search)
 	// to interact with the allocation. The usually trace back to API calls.
@@ -284,6 +315,7 @@ func (a *Allocation) Receive(ctx *actor.Context) error {
 			switch msg.(type) {
 			case WatchRendezvousInfo:
 				a.rendezvous = newRendezvous(ctx, a.model.AllocationID, a.resources)
+				a.trace.startRendezvous()
 			case UnwatchRendezvousInfo, rendezvousTimeout:
 				// Ignore without active rendezvous.
 				return nil
@@ -297,6 +329,9 @@ func (a *Allocation) Receive(ctx *actor.Context) error {
 			} else {
 				ctx.Respond(w)
 			}
+			if a.rendezvous.ready() {
+				a.trace.rendezvoused()
+			}
 		case UnwatchRendezvousInfo:
 			a.rendezvous.unwatch(msg)
 		case rendezvousTimeout:
@@ -422,6 +457,8 @@ func (a *Allocation) ResourcesAllocated(ctx *actor.Context, msg sproto.Resources
 				AllocationSignal:    sproto.TerminateAllocation,
 				InformationalReason: err.Error(),
 			})
+		}, func(err error) {
+			ctx.Tell(ctx.Self(), idleWarning{reason: err.Error()})
 		})
 	}
 
@@ -471,6 +508,7 @@ func (a *Allocation) ResourcesAllocated(ctx *actor.Context, msg sproto.Resources
 				Token:        token,
 				AgentRank:    a.resources[cID].Rank,
 				IsMultiAgent: len(a.resources) > 1,
+				TraceContext: a.trace.carrier(),
 			}); err != nil {
 				return fmt.Errorf("starting resources (%v): %w", r, err)
 			}
@@ -516,13 +554,17 @@ func (a *Allocation) SetResourcesAsDaemon(
 	return nil
 }
 
-// HandleSignal handles an external signal to kill or terminate the allocation.
+// HandleSignal handles an external signal to kill, terminate or preempt the allocation.
 func (a *Allocation) HandleSignal(ctx *actor.Context, msg sproto.AllocationSignalWithReason) {
 	switch msg.AllocationSignal {
 	case sproto.KillAllocation:
 		a.Kill(ctx, msg.InformationalReason)
 	case sproto.TerminateAllocation:
 		a.Terminate(ctx, msg.InformationalReason, false)
+	case sproto.PreemptAllocation:
+		if a.req.Preemptible {
+			a.Terminate(ctx, msg.InformationalReason, false)
+		}
 	}
 }
 
@@ -570,6 +612,7 @@ func (a *Allocation) ResourcesStateChanged(
 		if a.rendezvous != nil && a.rendezvous.try() {
 			ctx.Log().
 				Info("all containers are connected successfully (task container state changed)")
+			a.trace.rendezvoused()
 		}
 		if len(a.req.ProxyPorts) > 0 && msg.ResourcesStarted.Addresses != nil &&
 			a.resources[msg.ResourcesID].Rank == 0 {
@@ -899,6 +942,9 @@ func (a *Allocation) terminated(ctx *actor.Context, reason string) {
 	}
 	a.exited = true
 	exitReason := fmt.Sprintf("allocation terminated after %s", reason)
+	// exitKind is the exit reason reported to Prometheus.
+	exitKind := "unallocated"
+	defer func() { prom.IncAllocationExits(a.req.ResourcePool, exitKind) }()
 	defer ctx.Tell(ctx.Self().Parent(), exit)
 	defer a.rm.Release(ctx, sproto.ResourcesReleased{AllocationID: a.req.AllocationID})
 	defer a.unregisterProxies(ctx)
@@ -932,16 +978,19 @@ func (a *Allocation) terminated(ctx *actor.Context, reason string) {
 	}
 	switch {
 	case a.killedWhileRunning:
+		exitKind = "killed"
 		exitReason = fmt.Sprintf("allocation stopped after %s", reason)
 		ctx.Log().Info(exitReason)
 		return
 	case a.req.Preemptible && preemptible.Acknowledged(a.req.AllocationID.String()):
+		exitKind = "preempted"
 		exitReason = fmt.Sprintf("allocation stopped after %s", reason)
 		ctx.Log().Info(exitReason)
 		return
 	case a.exitErr == nil && len(a.resources.exited()) > 0:
 		// This is true because searcher and preemption exits both ack preemption.
 		exit.UserRequestedStop = true
+		exitKind = "exited"
 		exitReason = fmt.Sprintf("allocation stopped early after %s", reason)
 		ctx.Log().Info(exitReason)
 		return
@@ -951,25 +1000,30 @@ func (a *Allocation) terminated(ctx *actor.Context, reason string) {
 			switch err.FailureType {
 			case sproto.ResourcesFailed, sproto.TaskError:
 				if a.killedDaemonsGracefully {
+					exitKind = "exited"
 					exitReason = fmt.Sprint("allocation terminated daemon processes as part of normal exit")
 					ctx.Log().Info(exitReason)
 					return
 				}
+				exitKind = "failed"
 				exitReason = fmt.Sprintf("allocation failed: %s", err)
 				ctx.Log().Info(exitReason)
 				exit.Err = err
 				return
 			case sproto.AgentError, sproto.AgentFailed:
+				exitKind = "agent_failed"
 				exitReason = fmt.Sprintf("allocation failed due to agent failure: %s", err)
 				ctx.Log().Warn(exitReason)
 				exit.Err = err
 				return
 			case sproto.TaskAborted, sproto.ResourcesAborted:
+				exitKind = "aborted"
 				exitReason = fmt.Sprintf("allocation aborted: %s", err.FailureType)
 				ctx.Log().Debug(exitReason)
 				exit.Err = err
 				return
 			case sproto.RestoreError:
+				exitKind = "restore_failed"
 				exitReason = fmt.Sprintf("allocation failed due to restore error: %s", err)
 				ctx.Log().Warn(exitReason)
 				exit.Err = err
@@ -979,6 +1033,7 @@ func (a *Allocation) terminated(ctx *actor.Context, reason string) {
 				panic(fmt.Errorf("unexpected allocation failure: %w", err))
 			}
 		default:
+			exitKind = "crashed"
 			exitReason = fmt.Sprintf("allocation handler crashed due to error: %s", err)
 			ctx.Log().Error(exitReason)
 			exit.Err = err
@@ -993,6 +1048,82 @@ func (a *Allocation) terminated(ctx *actor.Context, reason string) {
 	}
 }
 
+// recordGPUTelemetry exports the latest usage of the allocation's devices and, for trials, stores
+// the samples with the trial's profiler metrics in the background.
+func (a *Allocation) recordGPUTelemetry(ctx *actor.Context, msg sproto.ContainerGPUTelemetry) {
+	gputelemetry.Record(a.req.TaskID, a.model.AllocationID, msg.AgentID, msg.Samples)
+	if cfg := a.req.IdleTimeout; cfg != nil {
+		idle.RecordGPUUtilization(cfg.ServiceID, msg.Samples)
+	}
+
+	taskID, log := a.req.TaskID, ctx.Log()
+	go func() {
+		if err := a.storeGPUTelemetry(taskID, msg); err != nil {
+			log.WithError(err).Warn("failed to store GPU telemetry")
+		}
+	}()
+}
+
+// storeGPUTelemetry stores the samples with the profiler metrics of the task's trial, if any, in
+// one statement.
+func (a *Allocation) storeGPUTelemetry(
+	taskID model.TaskID, msg sproto.ContainerGPUTelemetry,
+) error {
+	a.gpuTelemetryMu.Lock()
+	defer a.gpuTelemetryMu.Unlock()
+
+	if a.gpuTelemetryTrialID == nil {
+		trialID, err := db.TrialIDByTaskID(context.TODO(), taskID)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			return errors.Wrap(err, "looking up trial")
+		}
+		a.gpuTelemetryTrialID = &trialID
+	}
+	if *a.gpuTelemetryTrialID == 0 {
+		return nil
+	}
+
+	var rows []db.TrialProfilerMetricsRow
+	for _, b := range gputelemetry.ProfilerMetricsBatches(
+		*a.gpuTelemetryTrialID, msg.AgentID, msg.Samples,
+	) {
+		labels, err := protojson.Marshal(b.Labels)
+		if err != nil {
+			return errors.Wrap(err, "marshaling labels")
+		}
+		rows = append(rows, db.TrialProfilerMetricsRow{
+			Values:     b.Values,
+			Batches:    b.Batches,
+			Timestamps: b.Timestamps,
+			Labels:     labels,
+		})
+	}
+	return db.InsertTrialProfilerMetricsBatches(context.TODO(), rows)
+}
+
+// warnIdle tells the user that the allocation will soon be terminated due to inactivity.
+func (a *Allocation) warnIdle(ctx *actor.Context, msg idleWarning) {
+	ctx.Log().Warnf("%s is idle: %s", a.req.Name, msg.reason)
+	a.sendTaskLog(&model.TaskLog{
+		Level: ptrs.Ptr(model.LogLevelWarning),
+		Log:   fmt.Sprintf("%s is idle: %s", a.req.Name, msg.reason),
+	})
+
+	url := config.GetMasterConfig().GPUIdle.WebhookURL
+	if url == "" {
+		return
+	}
+	if err := webhooks.ReportTaskGPUIdle(context.TODO(), url, webhooks.TaskPayload{
+		TaskID:       string(a.req.TaskID),
+		AllocationID: string(a.req.AllocationID),
+		Name:         a.req.Name,
+		ResourcePool: a.req.ResourcePool,
+		Reason:       msg.reason,
+	}); err != nil {
+		ctx.Log().WithError(err).Error("failed to report idle task")
+	}
+}
+
 // markResourcesStarted persists start information.
 func (a *Allocation) markResourcesStarted(ctx *actor.Context) {
 	a.model.StartTime = ptrs.Ptr(time.Now().UTC().Truncate(time.Millisecond))
@@ -1098,6 +1229,7 @@ func (a *Allocation) State() AllocationState {
 
 func (a *Allocation) setModelState(v model.AllocationState) {
 	a.model.State = &v
+	a.trace.transition(v)
 }
 
 func (a *Allocation) setMostProgressedModelState(v model.AllocationState) {
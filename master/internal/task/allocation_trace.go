@@ -0,0 +1,123 @@
+package task
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+var tracer = otel.Tracer("github.com/determined-ai/determined/master/internal/task")
+
+// allocationPhases names the span of the time an allocation spends in each state.
+var allocationPhases = map[model.AllocationState]string{
+	model.AllocationStatePending:     "queued",
+	model.AllocationStateWaiting:     "waiting",
+	model.AllocationStateAssigned:    "scheduled",
+	model.AllocationStatePulling:     "pulling",
+	model.AllocationStateStarting:    "starting",
+	model.AllocationStateRunning:     "running",
+	model.AllocationStateTerminating: "terminating",
+}
+
+// allocationTrace traces the lifecycle of an allocation as a span, with a child span for each
+// state the allocation spends time in and an event for each transition. If OpenTelemetry is not
+// configured, all of its spans are no-ops.
+type allocationTrace struct {
+	ctx  context.Context
+	span trace.Span
+
+	state      model.AllocationState
+	phase      trace.Span
+	rendezvous trace.Span
+}
+
+func newAllocationTrace(req sproto.AllocateRequest) *allocationTrace {
+	ctx := trace.ContextWithSpanContext(context.Background(), req.TraceParent)
+	ctx, span := tracer.Start(ctx, "allocation", trace.WithAttributes(
+		attribute.String("allocation.id", req.AllocationID.String()),
+		attribute.String("task.id", req.TaskID.String()),
+		attribute.String("job.id", req.JobID.String()),
+		attribute.String("allocation.name", req.Name),
+		attribute.Int("allocation.slots", req.SlotsNeeded),
+		attribute.String("allocation.resource_pool", req.ResourcePool),
+		attribute.Bool("allocation.restored", req.Restore),
+	))
+	span.AddEvent("request")
+	return &allocationTrace{ctx: ctx, span: span}
+}
+
+// transition ends the span of the previous state of the allocation and starts one for the new
+// state, if it changed.
+func (t *allocationTrace) transition(state model.AllocationState) {
+	if t == nil || state == t.state {
+		return
+	}
+	t.state = state
+	if t.phase != nil {
+		t.phase.End()
+		t.phase = nil
+	}
+
+	event := strings.ToLower(string(state))
+	if state == model.AllocationStateAssigned {
+		event = "scheduled"
+	}
+	t.span.AddEvent(event)
+	if name, ok := allocationPhases[state]; ok {
+		_, t.phase = tracer.Start(t.ctx, name)
+	}
+}
+
+// startRendezvous starts a span covering the wait for all containers to rendezvous.
+func (t *allocationTrace) startRendezvous() {
+	if t == nil {
+		return
+	}
+	_, t.rendezvous = tracer.Start(t.ctx, "rendezvous")
+}
+
+// rendezvoused ends the rendezvous span, if it is still open.
+func (t *allocationTrace) rendezvoused() {
+	if t == nil || t.rendezvous == nil {
+		return
+	}
+	t.rendezvous.End()
+	t.rendezvous = nil
+	t.span.AddEvent("rendezvous")
+}
+
+// carrier returns the trace context of the allocation in a form that can be sent to agents.
+func (t *allocationTrace) carrier() map[string]string {
+	if t == nil {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(t.ctx, carrier)
+	return carrier
+}
+
+// end ends every span of the allocation, recording err if it failed.
+func (t *allocationTrace) end(err error) {
+	if t == nil {
+		return
+	}
+	if t.phase != nil {
+		t.phase.End()
+	}
+	if t.rendezvous != nil {
+		t.rendezvous.End()
+	}
+	if err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	}
+	t.span.End()
+}
@@ -0,0 +1,76 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestAllocationTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	_, parent := tracer.Start(context.Background(), "trial")
+	tr := newAllocationTrace(sproto.AllocateRequest{
+		AllocationID: "task.1",
+		TaskID:       "task",
+		TraceParent:  parent.SpanContext(),
+	})
+	for _, s := range []model.AllocationState{
+		model.AllocationStatePending,
+		model.AllocationStateAssigned,
+		model.AllocationStatePulling,
+		model.AllocationStatePulling,
+		model.AllocationStateStarting,
+		model.AllocationStateRunning,
+	} {
+		tr.transition(s)
+	}
+	tr.startRendezvous()
+	tr.rendezvoused()
+	tr.rendezvoused()
+
+	carrier := tr.carrier()
+	remote := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(
+		context.Background(), propagation.MapCarrier(carrier)))
+	assert.Equal(t, remote.TraceID(), parent.SpanContext().TraceID())
+
+	tr.transition(model.AllocationStateTerminated)
+	tr.end(errors.New("exited"))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	var names []string
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+		names = append(names, s.Name())
+	}
+	assert.DeepEqual(t, names, []string{
+		"queued", "scheduled", "pulling", "starting", "rendezvous", "running", "allocation",
+	})
+
+	allocation := spans["allocation"]
+	assert.Equal(t, allocation.Parent().SpanID(), parent.SpanContext().SpanID())
+	assert.Equal(t, allocation.Status().Description, "exited")
+	var events []string
+	for _, e := range allocation.Events() {
+		events = append(events, e.Name)
+	}
+	assert.DeepEqual(t, events, []string{
+		"request", "pending", "scheduled", "pulling", "starting", "running", "rendezvous",
+		"terminated", "exception",
+	})
+	for _, name := range names[:len(names)-1] {
+		assert.Equal(t, spans[name].Parent().SpanID(), allocation.SpanContext().SpanID())
+	}
+}
@@ -0,0 +1,65 @@
+package gputelemetry
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+// The names of the system profiler metrics GPU telemetry is stored as. They differ from those the
+// harness profiler reports so that both can be collected for the same trial.
+const (
+	UtilizationMetric = "agent_gpu_util"
+	MemoryUsedMetric  = "agent_gpu_memory_used"
+	PowerMetric       = "agent_gpu_power"
+)
+
+// ProfilerMetricsBatch is a series of samples of one metric of one device, in the form trial
+// profiler metrics are stored.
+type ProfilerMetricsBatch struct {
+	Values     []float32
+	Batches    []int32
+	Timestamps []time.Time
+	Labels     *trialv1.TrialProfilerMetricLabels
+}
+
+// ProfilerMetricsBatches converts the samples of a trial's devices to system profiler metrics,
+// one batch per device and metric. Samples are not associated with a batch of training.
+func ProfilerMetricsBatches(
+	trialID int, agentID string, samples []aproto.GPUSample,
+) []ProfilerMetricsBatch {
+	var batches []ProfilerMetricsBatch
+	index := map[string]int{}
+	for _, s := range samples {
+		for _, m := range []struct {
+			name  string
+			value float64
+		}{
+			{UtilizationMetric, s.Utilization},
+			{MemoryUsedMetric, float64(s.MemoryUsed)},
+			{PowerMetric, s.Power},
+		} {
+			key := s.DeviceUUID + "/" + m.name
+			i, ok := index[key]
+			if !ok {
+				i = len(batches)
+				index[key] = i
+				batches = append(batches, ProfilerMetricsBatch{
+					Labels: &trialv1.TrialProfilerMetricLabels{
+						TrialId:    int32(trialID),
+						Name:       m.name,
+						AgentId:    agentID,
+						GpuUuid:    s.DeviceUUID,
+						MetricType: trialv1.TrialProfilerMetricLabels_PROFILER_METRIC_TYPE_SYSTEM,
+					},
+				})
+			}
+			b := &batches[i]
+			b.Values = append(b.Values, float32(m.value))
+			b.Batches = append(b.Batches, 0)
+			b.Timestamps = append(b.Timestamps, s.Time)
+		}
+	}
+	return batches
+}
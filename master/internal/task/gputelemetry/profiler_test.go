@@ -0,0 +1,30 @@
+package gputelemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+)
+
+func TestProfilerMetricsBatches(t *testing.T) {
+	now := time.Now()
+	batches := ProfilerMetricsBatches(7, "agent", []aproto.GPUSample{
+		{Time: now, DeviceUUID: "GPU-a", Utilization: 10, MemoryUsed: 1024, Power: 100},
+		{Time: now, DeviceUUID: "GPU-b", Utilization: 50},
+		{Time: now.Add(time.Second), DeviceUUID: "GPU-a", Utilization: 20, MemoryUsed: 2048},
+	})
+	require.Len(t, batches, 6)
+
+	util := batches[0]
+	require.Equal(t, UtilizationMetric, util.Labels.Name)
+	require.Equal(t, "GPU-a", util.Labels.GpuUuid)
+	require.Equal(t, int32(7), util.Labels.TrialId)
+	require.Equal(t, []float32{10, 20}, util.Values)
+	require.Equal(t, []int32{0, 0}, util.Batches)
+	require.Equal(t, []time.Time{now, now.Add(time.Second)}, util.Timestamps)
+	require.Equal(t, []float32{1024, 2048}, batches[1].Values)
+	require.Equal(t, "GPU-b", batches[3].Labels.GpuUuid)
+}
@@ -0,0 +1,131 @@
+package gputelemetry
+
+import (
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/syncx/mapx"
+	"github.com/determined-ai/determined/proto/pkg/taskv1"
+)
+
+// Sample is the latest usage of a device of an allocation.
+type Sample struct {
+	AgentID            string    `json:"agent_id"`
+	DeviceUUID         string    `json:"device_uuid"`
+	Time               time.Time `json:"time"`
+	UtilizationPercent float64   `json:"utilization_percent"`
+	MemoryUsedBytes    uint64    `json:"memory_used_bytes"`
+	PowerWatts         float64   `json:"power_watts"`
+}
+
+// Proto returns the sample as a protobuf.
+func (s Sample) Proto() *taskv1.GPUSample {
+	return &taskv1.GPUSample{
+		AgentId:            s.AgentID,
+		DeviceUuid:         s.DeviceUUID,
+		Time:               timestamppb.New(s.Time),
+		UtilizationPercent: s.UtilizationPercent,
+		MemoryUsedBytes:    s.MemoryUsedBytes,
+		PowerWatts:         s.PowerWatts,
+	}
+}
+
+// allocationSamples is the latest sample of each device of an allocation, by device UUID.
+type allocationSamples struct {
+	taskID  model.TaskID
+	devices map[string]Sample
+}
+
+// latest holds the latest samples of each allocation.
+var latest = mapx.New[model.AllocationID, allocationSamples]()
+
+// Record records the samples of a task's allocation's devices on an agent, keeping the latest
+// sample of each device and exporting it to Prometheus.
+func Record(
+	taskID model.TaskID, id model.AllocationID, agentID string, samples []aproto.GPUSample,
+) {
+	latest.WithLock(func(m map[model.AllocationID]allocationSamples) {
+		a, ok := m[id]
+		if !ok {
+			a = allocationSamples{taskID: taskID, devices: map[string]Sample{}}
+			m[id] = a
+		}
+		devices := a.devices
+		for _, s := range samples {
+			if prev, ok := devices[s.DeviceUUID]; ok && prev.Time.After(s.Time) {
+				continue
+			}
+			devices[s.DeviceUUID] = Sample{
+				AgentID:            agentID,
+				DeviceUUID:         s.DeviceUUID,
+				Time:               s.Time,
+				UtilizationPercent: s.Utilization,
+				MemoryUsedBytes:    s.MemoryUsed,
+				PowerWatts:         s.Power,
+			}
+			prom.SetGPUTelemetry(id, agentID, s.DeviceUUID,
+				s.Utilization, float64(s.MemoryUsed), s.Power)
+		}
+	})
+}
+
+// Latest returns the latest sample of each device of an allocation, ordered by device UUID.
+func Latest(id model.AllocationID) []Sample {
+	var samples []Sample
+	latest.WithLock(func(m map[model.AllocationID]allocationSamples) {
+		for _, s := range m[id].devices {
+			samples = append(samples, s)
+		}
+	})
+	sortByDevice(samples)
+	return samples
+}
+
+// LatestOfTask returns the latest sample of each device of a task's running allocations,
+// ordered by device UUID.
+func LatestOfTask(taskID model.TaskID) []Sample {
+	var samples []Sample
+	latest.WithLock(func(m map[model.AllocationID]allocationSamples) {
+		for _, a := range m {
+			if a.taskID != taskID {
+				continue
+			}
+			for _, s := range a.devices {
+				samples = append(samples, s)
+			}
+		}
+	})
+	sortByDevice(samples)
+	return samples
+}
+
+// Protos returns the samples as protobufs.
+func Protos(samples []Sample) []*taskv1.GPUSample {
+	var pbs []*taskv1.GPUSample
+	for _, s := range samples {
+		pbs = append(pbs, s.Proto())
+	}
+	return pbs
+}
+
+func sortByDevice(samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].DeviceUUID < samples[j].DeviceUUID
+	})
+}
+
+// Unregister forgets the samples of an allocation and removes them from Prometheus.
+func Unregister(id model.AllocationID) {
+	a, ok := latest.Delete(id)
+	if !ok {
+		return
+	}
+	for _, s := range a.devices {
+		prom.DeleteGPUTelemetry(id, s.AgentID, s.DeviceUUID)
+	}
+}
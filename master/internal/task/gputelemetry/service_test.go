@@ -0,0 +1,45 @@
+package gputelemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestRecord(t *testing.T) {
+	taskID := model.TaskID("test")
+	id := model.AllocationID("test.1.1")
+	defer Unregister(id)
+
+	now := time.Now()
+	Record(taskID, id, "agent", []aproto.GPUSample{
+		{Time: now, DeviceUUID: "GPU-b", Utilization: 50},
+		{Time: now, DeviceUUID: "GPU-a", Utilization: 10, MemoryUsed: 1024, Power: 100},
+		{Time: now.Add(-time.Second), DeviceUUID: "GPU-a", Utilization: 20},
+	})
+	require.Equal(t, []Sample{
+		{
+			AgentID: "agent", DeviceUUID: "GPU-a", Time: now,
+			UtilizationPercent: 10, MemoryUsedBytes: 1024, PowerWatts: 100,
+		},
+		{AgentID: "agent", DeviceUUID: "GPU-b", Time: now, UtilizationPercent: 50},
+	}, Latest(id))
+
+	other := model.AllocationID("test.1.2")
+	defer Unregister(other)
+	Record(taskID, other, "other", []aproto.GPUSample{{Time: now, DeviceUUID: "GPU-c"}})
+	Record("other", "other.1.1", "other", []aproto.GPUSample{{Time: now, DeviceUUID: "GPU-d"}})
+	defer Unregister("other.1.1")
+	var devices []string
+	for _, s := range LatestOfTask(taskID) {
+		devices = append(devices, s.DeviceUUID)
+	}
+	require.Equal(t, []string{"GPU-a", "GPU-b", "GPU-c"}, devices)
+
+	Unregister(id)
+	require.Empty(t, Latest(id))
+}
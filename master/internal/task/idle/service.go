@@ -4,16 +4,17 @@ import (
 	"time"
 
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/syncx/mapx"
 )
 
 var idlers = mapx.New[string, *Watcher]()
 
 // Register an idler to default service. The action is called at most once when the idle timeout is
-// exceeded. The action can trigger until Unregister is called.
+// exceeded. The action and warning can trigger until Unregister is called.
 // ID must be a globally unique identifier for the idler.
-func Register(cfg sproto.IdleTimeoutConfig, action func(error)) {
-	idlers.Store(cfg.ServiceID, New(cfg, action))
+func Register(cfg sproto.IdleTimeoutConfig, action func(error), warn func(error)) {
+	idlers.Store(cfg.ServiceID, New(cfg, action, warn))
 }
 
 // Unregister removes an idler from the service.
@@ -35,3 +36,13 @@ func RecordActivity(id string) {
 	}
 	iw.RecordActivity(time.Now())
 }
+
+// RecordGPUUtilization records the utilization of the GPUs of an idler.
+// ID must be a globally unique identifier for the idler.
+func RecordGPUUtilization(id string, samples []aproto.GPUSample) {
+	iw, ok := idlers.Load(id)
+	if !ok {
+		return
+	}
+	iw.RecordGPUUtilization(samples)
+}
@@ -3,9 +3,11 @@ package idle
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/pkg/syncx/waitgroupx"
 
@@ -24,6 +26,15 @@ var ErrIdle = fmt.Errorf("service is inactive")
 // TickInterval is the interval at which to check the proxy activity.
 var TickInterval = 5 * time.Second
 
+const (
+	// gpuStaleReports is how many intervals between GPU telemetry reports may pass without a report
+	// before the utilization of a task's GPUs is no longer known and they no longer count as idle.
+	gpuStaleReports = 3
+	// defaultGPUReportInterval is the interval between GPU telemetry reports assumed until a GPU
+	// has been reported twice; agents report a batch of samples every minute by default.
+	defaultGPUReportInterval = time.Minute
+)
+
 // Watcher watches the proxy activity to handle a task actor idle timeout.
 type Watcher struct {
 	// System dependencies.
@@ -32,19 +43,29 @@ type Watcher struct {
 	// Configuration.
 	cfg    sproto.IdleTimeoutConfig
 	action func(error)
+	warn   func(error)
 
 	// Mutable internal state.
 	mu                   sync.Mutex
 	wg                   waitgroupx.Group // TODO(mar): consistent pointer usage.
 	lastExplicitActivity *time.Time
+	gpuIdleSince         *time.Time
+	gpuIdleWarned        bool
+	// gpuLastSamples is the time of the latest sample of each GPU, by device UUID.
+	gpuLastSamples map[string]time.Time
+	// gpuReportInterval is the latest interval between reports of a GPU's samples.
+	gpuReportInterval time.Duration
 }
 
-// New creates a new idle timeout watcher. The action can be triggered until Close is called.
-func New(cfg sproto.IdleTimeoutConfig, action func(error)) *Watcher {
+// New creates a new idle timeout watcher. The action can be triggered until Close is called. The
+// warning, if set, is triggered once the GPUs have been idle for the GPU idle timeout, and again
+// each time they become idle after being used.
+func New(cfg sproto.IdleTimeoutConfig, action func(error), warn func(error)) *Watcher {
 	w := &Watcher{
 		syslog: syslog.WithField("id", cfg.ServiceID),
 		cfg:    cfg,
 		action: action,
+		warn:   warn,
 		wg:     waitgroupx.WithContext(context.Background()),
 	}
 
@@ -60,6 +81,67 @@ func (w *Watcher) RecordActivity(instant time.Time) {
 	w.mu.Unlock()
 }
 
+// RecordGPUUtilization notes the utilization of the GPUs of the task. The GPUs are idle from the
+// first sample after which no GPU reached the utilization threshold, as long as samples keep
+// arriving.
+func (w *Watcher) RecordGPUUtilization(samples []aproto.GPUSample) {
+	if w.cfg.GPUIdle == nil {
+		return
+	}
+	samples = append([]aproto.GPUSample(nil), samples...)
+	// Sorting busy samples last means a GPU in use marks the task busy at that instant.
+	sort.SliceStable(samples, func(i, j int) bool {
+		if !samples[i].Time.Equal(samples[j].Time) {
+			return samples[i].Time.Before(samples[j].Time)
+		}
+		return samples[i].Utilization < samples[j].Utilization
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	latest := map[string]time.Time{}
+	for _, s := range samples {
+		latest[s.DeviceUUID] = s.Time
+		switch {
+		case s.Utilization >= w.cfg.GPUIdle.UtilizationThreshold:
+			w.gpuIdleSince = nil
+			w.gpuIdleWarned = false
+		case w.gpuIdleSince == nil:
+			w.gpuIdleSince = ptrs.Ptr(s.Time)
+		}
+	}
+
+	if w.gpuLastSamples == nil {
+		w.gpuLastSamples = map[string]time.Time{}
+	}
+	for uuid, t := range latest {
+		prev, ok := w.gpuLastSamples[uuid]
+		if ok && !t.After(prev) {
+			continue
+		}
+		if ok {
+			w.gpuReportInterval = t.Sub(prev)
+		}
+		w.gpuLastSamples[uuid] = t
+	}
+}
+
+// gpuSamplesStale returns true if no GPU has been sampled for several report intervals, e.g.
+// because the agent stopped reporting telemetry. The caller must hold the lock.
+func (w *Watcher) gpuSamplesStale(now time.Time) bool {
+	var last time.Time
+	for _, t := range w.gpuLastSamples {
+		if t.After(last) {
+			last = t
+		}
+	}
+	interval := w.gpuReportInterval
+	if interval == 0 {
+		interval = defaultGPUReportInterval
+	}
+	return now.Sub(last) > gpuStaleReports*interval
+}
+
 // Close closes the idle timeout watcher.
 func (w *Watcher) Close() {
 	w.wg.Close()
@@ -81,6 +163,16 @@ func (w *Watcher) run(ctx context.Context) {
 }
 
 func (w *Watcher) tick() (done bool) {
+	if (w.cfg.UseProxyState || w.cfg.UseRunnerState) && w.tickActivity() {
+		return true
+	}
+	if w.cfg.GPUIdle != nil {
+		return w.tickGPU(time.Now())
+	}
+	return false
+}
+
+func (w *Watcher) tickActivity() (done bool) {
 	var lastActivity *time.Time
 	if w.cfg.UseProxyState {
 		service, ok := proxy.DefaultProxy.Summary(w.cfg.ServiceID)
@@ -119,3 +211,39 @@ func (w *Watcher) tick() (done bool) {
 	}
 	return false
 }
+
+func (w *Watcher) tickGPU(now time.Time) (done bool) {
+	cfg := w.cfg.GPUIdle
+	w.mu.Lock()
+	if w.gpuIdleSince != nil && w.gpuSamplesStale(now) {
+		// Without telemetry the GPUs may be in use, so they are idle again only from the next
+		// idle sample.
+		w.gpuIdleSince = nil
+		w.gpuIdleWarned = false
+	}
+	idleSince, warned := w.gpuIdleSince, w.gpuIdleWarned
+	w.mu.Unlock()
+	if idleSince == nil {
+		return false
+	}
+
+	idle := now.Sub(*idleSince)
+	switch {
+	case idle > cfg.TimeoutDuration+cfg.GracePeriod:
+		err := fmt.Errorf("GPUs idle for more than %s: %w",
+			(cfg.TimeoutDuration + cfg.GracePeriod).Round(time.Second), ErrIdle)
+		w.action(err)
+		return true
+	case idle > cfg.TimeoutDuration && !warned:
+		w.mu.Lock()
+		w.gpuIdleWarned = true
+		w.mu.Unlock()
+		if w.warn != nil {
+			w.warn(fmt.Errorf(
+				"GPUs idle for more than %s, the task will be terminated in %s unless they are used",
+				cfg.TimeoutDuration.Round(time.Second),
+				(cfg.TimeoutDuration + cfg.GracePeriod - idle).Round(time.Second)))
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package idle
 
 import (
+	"fmt"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/aproto"
 )
 
 func TestIdleTimeoutWatcherUseRunnerState(t *testing.T) {
@@ -22,7 +24,7 @@ func TestIdleTimeoutWatcherUseRunnerState(t *testing.T) {
 
 	Register(cfg, func(error) {
 		actionDone.Store(true)
-	})
+	}, nil)
 	defer Unregister(cfg.ServiceID)
 
 	RecordActivity(cfg.ServiceID)
@@ -30,6 +32,75 @@ func TestIdleTimeoutWatcherUseRunnerState(t *testing.T) {
 	require.True(t, waitForCondition(10*timeout, actionDone.Load))
 }
 
+func TestIdleTimeoutWatcherGPUIdle(t *testing.T) {
+	var actionErr, warnErr error
+	w := &Watcher{
+		syslog: syslog,
+		cfg: sproto.IdleTimeoutConfig{
+			ServiceID: "test",
+			GPUIdle: &sproto.GPUIdleTimeoutConfig{
+				UtilizationThreshold: 5,
+				TimeoutDuration:      time.Hour,
+				GracePeriod:          10 * time.Minute,
+			},
+		},
+		action: func(err error) { actionErr = err },
+		warn:   func(err error) { warnErr = err },
+	}
+
+	start := time.Now()
+	require.False(t, w.tickGPU(start.Add(2*time.Hour)), "no samples yet")
+
+	// report reports a sample of each GPU every minute, from one time to another.
+	report := func(from, to time.Time, utilization ...float64) {
+		for at := from; !at.After(to); at = at.Add(time.Minute) {
+			var samples []aproto.GPUSample
+			for i, u := range utilization {
+				samples = append(samples, aproto.GPUSample{
+					Time: at, DeviceUUID: fmt.Sprintf("GPU-%d", i), Utilization: u,
+				})
+			}
+			w.RecordGPUUtilization(samples)
+		}
+	}
+
+	// One busy GPU keeps the task busy.
+	report(start, start.Add(2*time.Hour), 50, 0)
+	require.False(t, w.tickGPU(start.Add(2*time.Hour)))
+	require.NoError(t, warnErr)
+
+	idleAt := start.Add(2*time.Hour + time.Minute)
+	report(idleAt, idleAt.Add(30*time.Minute), 1, 0)
+	require.False(t, w.tickGPU(idleAt.Add(30*time.Minute)))
+	require.NoError(t, warnErr)
+	report(idleAt.Add(31*time.Minute), idleAt.Add(65*time.Minute), 1, 0)
+	require.False(t, w.tickGPU(idleAt.Add(65*time.Minute)))
+	require.ErrorContains(t, warnErr, "terminated in 5m0s")
+
+	// Using the GPUs again resets the timeout and the warning.
+	busyAt := idleAt.Add(66 * time.Minute)
+	report(busyAt, busyAt, 80)
+	require.False(t, w.tickGPU(busyAt))
+	warnErr = nil
+	report(busyAt.Add(time.Minute), busyAt.Add(62*time.Minute), 0)
+	require.False(t, w.tickGPU(busyAt.Add(62*time.Minute)))
+	require.Error(t, warnErr)
+	require.NoError(t, actionErr)
+
+	// Once telemetry stops, the GPUs may be in use and no longer count as idle.
+	require.False(t, w.tickGPU(busyAt.Add(72*time.Minute)))
+	require.NoError(t, actionErr)
+
+	// Once it resumes, the GPUs are idle from the next idle sample.
+	resumeAt := busyAt.Add(73 * time.Minute)
+	report(resumeAt, resumeAt.Add(65*time.Minute), 0)
+	require.False(t, w.tickGPU(resumeAt.Add(65*time.Minute)))
+	require.NoError(t, actionErr)
+	report(resumeAt.Add(66*time.Minute), resumeAt.Add(71*time.Minute), 0)
+	require.True(t, w.tickGPU(resumeAt.Add(71*time.Minute)))
+	require.ErrorIs(t, actionErr, ErrIdle)
+}
+
 func waitForCondition(timeout time.Duration, condition func() bool) bool {
 	for i := 0; i < int(timeout/TickInterval); i++ {
 		if condition() {
@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/projectv1"
+	"github.com/determined-ai/determined/proto/pkg/rbacv1"
+)
+
+const (
+	defaultTaskLogSearchLimit   = 100
+	defaultTaskLogSearchContext = 2
+)
+
+// TaskLogSearcher is implemented by task log backends that can search logs across tasks.
+type TaskLogSearcher interface {
+	SearchTaskLogs(ctx context.Context, s model.TaskLogSearch) ([]*model.TaskLogSearchMatch, error)
+}
+
+type taskLogSearchLine struct {
+	ID        string     `json:"id"`
+	Timestamp *time.Time `json:"timestamp"`
+	RankID    *int       `json:"rank_id"`
+	Level     *string    `json:"level"`
+	StdType   *string    `json:"stdtype"`
+	Log       string     `json:"log"`
+}
+
+func newTaskLogSearchLine(l *model.TaskLog) taskLogSearchLine {
+	line := taskLogSearchLine{
+		Timestamp: l.Timestamp,
+		RankID:    l.RankID,
+		Level:     l.Level,
+		StdType:   l.StdType,
+		Log:       l.Log,
+	}
+	switch {
+	case l.ID != nil:
+		line.ID = strconv.Itoa(*l.ID)
+	case l.StringID != nil:
+		line.ID = *l.StringID
+	}
+	return line
+}
+
+func newTaskLogSearchLines(logs []*model.TaskLog) []taskLogSearchLine {
+	lines := make([]taskLogSearchLine, 0, len(logs))
+	for _, l := range logs {
+		lines = append(lines, newTaskLogSearchLine(l))
+	}
+	return lines
+}
+
+type taskLogSearchMatch struct {
+	TaskID       model.TaskID `json:"task_id"`
+	TrialID      int          `json:"trial_id"`
+	ExperimentID int          `json:"experiment_id"`
+	// Link is the path of the logs of the trial in the WebUI.
+	Link   string              `json:"link"`
+	Log    taskLogSearchLine   `json:"log"`
+	Before []taskLogSearchLine `json:"before"`
+	After  []taskLogSearchLine `json:"after"`
+}
+
+//	@Summary	Search the logs of trials.
+//	@Tags		Tasks
+//	@ID			get-task-log-search
+//	@Produce	json
+//	@Param		query			query	string	true	"Words to find, or a regular expression"
+//	@Param		mode			query	string	false	"One of text (default) or regex"
+//	@Param		experiment_id	query	int		false	"Only search the trials of this experiment"
+//	@Param		project_id		query	int		false	"Only search the trials of this project"
+//	@Param		user_id			query	int		false	"Only search the trials of this user"
+//	@Param		since			query	string	false	"RFC 3339 time after which logs match"
+//	@Param		until			query	string	false	"RFC 3339 time up to which logs match"
+//	@Param		context			query	int		false	"Lines around each match, default 2"
+//	@Param		limit			query	int		false	"Maximum matches, default 100"
+//	@Success	200				{}		string	""
+//	@Router		/tasks/logs/search [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getTaskLogSearch(c echo.Context) (interface{}, error) {
+	args := struct {
+		Query        string  `query:"query"`
+		Mode         string  `query:"mode"`
+		ExperimentID *int    `query:"experiment_id"`
+		ProjectID    *int    `query:"project_id"`
+		UserID       *int    `query:"user_id"`
+		Since        *string `query:"since"`
+		Until        *string `query:"until"`
+		Context      *int    `query:"context"`
+		Limit        *int    `query:"limit"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return nil, err
+	}
+	if args.ExperimentID == nil && args.ProjectID == nil && args.UserID == nil &&
+		args.Since == nil && args.Until == nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest,
+			"one of experiment_id, project_id, user_id, since or until is required")
+	}
+
+	search := model.TaskLogSearch{
+		Query:        args.Query,
+		ContextLines: defaultTaskLogSearchContext,
+		Limit:        defaultTaskLogSearchLimit,
+	}
+	var err error
+	if search.Mode, err = model.ParseTaskLogSearchMode(args.Mode); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	for _, t := range []struct {
+		name string
+		arg  *string
+		dst  **time.Time
+	}{
+		{"since", args.Since, &search.Since},
+		{"until", args.Until, &search.Until},
+	} {
+		if t.arg == nil {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, *t.arg)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("invalid %s time: %s", t.name, err))
+		}
+		*t.dst = &parsed
+	}
+	if args.Context != nil {
+		search.ContextLines = *args.Context
+	}
+	if args.Limit != nil {
+		search.Limit = *args.Limit
+	}
+	if err := search.Validate(); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	searcher, ok := m.taskLogBackend.(TaskLogSearcher)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusNotImplemented,
+			"the configured logging backend does not support searching logs")
+	}
+
+	ctx := c.Request().Context()
+	curUser := c.(*detContext.DetContext).MustGetUser()
+	if args.ExperimentID != nil {
+		if _, _, err := echoGetExperimentAndCheckCanDoActions(ctx, c, m, *args.ExperimentID,
+			expauth.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+			return nil, err
+		}
+	}
+	var proj *projectv1.Project
+	if args.ProjectID != nil {
+		if proj, err = (&apiServer{m: m}).GetProjectByID(
+			ctx, int32(*args.ProjectID), curUser); err != nil {
+			return nil, err
+		}
+	}
+
+	var trials []struct {
+		TaskID       model.TaskID
+		TrialID      int
+		ExperimentID int
+		WorkspaceID  int
+	}
+	query, err := expauth.AuthZProvider.Get().FilterExperimentsQuery(ctx, curUser, proj,
+		db.TrialLogSearchScopeQuery(
+			args.ExperimentID, args.ProjectID, args.UserID, search.Since, search.Until),
+		[]rbacv1.PermissionType{rbacv1.PermissionType_PERMISSION_TYPE_VIEW_EXPERIMENT_ARTIFACTS},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Scan(ctx, &trials); err != nil {
+		return nil, errors.Wrap(err, "error getting trials to search the logs of")
+	}
+
+	byTaskID := make(map[model.TaskID]int, len(trials))
+	for i, t := range trials {
+		search.TaskIDs = append(search.TaskIDs, t.TaskID)
+		byTaskID[t.TaskID] = i
+	}
+	matches, err := searcher.SearchTaskLogs(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]taskLogSearchMatch, 0, len(matches))
+	for _, match := range matches {
+		i, ok := byTaskID[model.TaskID(match.Log.TaskID)]
+		if !ok {
+			continue
+		}
+		t := trials[i]
+		resp = append(resp, taskLogSearchMatch{
+			TaskID:       t.TaskID,
+			TrialID:      t.TrialID,
+			ExperimentID: t.ExperimentID,
+			Link: fmt.Sprintf(
+				"/det/experiments/%d/trials/%d/logs", t.ExperimentID, t.TrialID),
+			Log:    newTaskLogSearchLine(match.Log),
+			Before: newTaskLogSearchLines(match.Before),
+			After:  newTaskLogSearchLines(match.After),
+		})
+	}
+	return resp, nil
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/determined-ai/determined/master/pkg/ptrs"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -85,6 +87,10 @@ type trial struct {
 	userInitiatedExit *model.ExitedReason
 
 	logCtx logger.Context
+
+	// traceParent is the span of the experiment, which the span of the trial is a child of.
+	traceParent trace.SpanContext
+	span        trace.Span
 }
 
 // newTrial creates a trial which will try to schedule itself after it receives its first workload.
@@ -157,8 +163,18 @@ func (t *trial) Receive(ctx *actor.Context) error {
 		})
 		ctx.AddLabels(t.logCtx)
 
+		_, t.span = tracer.Start(
+			trace.ContextWithSpanContext(context.Background(), t.traceParent), "trial",
+			trace.WithAttributes(
+				attribute.Int("trial.id", t.id),
+				attribute.Int("experiment.id", t.experimentID),
+				attribute.String("task.id", t.taskID.String()),
+			))
 		return t.maybeAllocateTask(ctx)
 	case actor.PostStop:
+		if t.span != nil {
+			defer t.span.End()
+		}
 		if !t.idSet {
 			return nil
 		}
@@ -314,10 +330,13 @@ func (t *trial) maybeAllocateTask(ctx *actor.Context) error {
 			ResourcePool:      t.config.Resources().ResourcePool(),
 			FittingRequirements: sproto.FittingRequirements{
 				SingleAgent: false,
+				MIGProfile:  t.migProfile(),
 			},
+			Images: sproto.NewImages(t.config.Environment().Image()),
 
 			Preemptible: true,
 			Restore:     true,
+			TraceParent: t.span.SpanContext(),
 			ProxyPorts: sproto.NewProxyPortConfig(
 				tasks.TrialSpecProxyPorts(t.taskSpec, t.config), t.taskID),
 		}
@@ -351,10 +370,13 @@ func (t *trial) maybeAllocateTask(ctx *actor.Context) error {
 		ResourcePool: t.config.Resources().ResourcePool(),
 		FittingRequirements: sproto.FittingRequirements{
 			SingleAgent: false,
+			MIGProfile:  t.migProfile(),
 		},
+		Images: sproto.NewImages(t.config.Environment().Image()),
 
 		Preemptible: true,
 		ProxyPorts:  sproto.NewProxyPortConfig(tasks.TrialSpecProxyPorts(t.taskSpec, t.config), t.taskID),
+		TraceParent: t.span.SpanContext(),
 	}
 
 	ctx.Log().
@@ -397,6 +419,14 @@ func (t *trial) addTask() error {
 	})
 }
 
+// migProfile returns the MIG profile the slots of the trial must have, if it asks for one.
+func (t *trial) migProfile() string {
+	if p := t.config.Resources().MIGProfile(); p != nil {
+		return *p
+	}
+	return ""
+}
+
 func (t *trial) buildTaskSpecifier(ctx *actor.Context) (*tasks.TrialSpec, error) {
 	if !t.trialCreationSent {
 		ctx.Tell(ctx.Self().Parent(), trialCreated{requestID: t.searcher.Create.RequestID})
@@ -496,6 +526,7 @@ func (t *trial) allocationExited(ctx *actor.Context, exit *task.AllocationExited
 			WithError(exit.Err).
 			Errorf("trial failed (restart %d/%d)", t.restarts, t.config.MaxRestarts())
 		t.restarts++
+		prom.IncTrialRestarts(t.config.Resources().ResourcePool())
 		if err := t.db.UpdateTrialRestarts(t.id, t.restarts); err != nil {
 			return err
 		}
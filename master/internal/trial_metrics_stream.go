@@ -0,0 +1,345 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/internal/metricstream"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ws"
+)
+
+const trialMetricsBackfillBatchSize = 1000
+
+// trialMetricsReport is a metrics report sent on the trial metrics stream.
+type trialMetricsReport struct {
+	Group        model.MetricType `json:"group"`
+	TrialID      int              `json:"trial_id"`
+	TrialRunID   int              `json:"trial_run_id"`
+	TotalBatches int              `json:"total_batches"`
+	EndTime      time.Time        `json:"end_time"`
+	Metrics      map[string]any   `json:"metrics"`
+}
+
+// trialMetricsMessage is a message sent on the trial metrics stream. A message with an error is
+// the last one sent.
+type trialMetricsMessage struct {
+	Report *trialMetricsReport `json:"report,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// trialMetricsPosition is the latest report of a trial and group sent on a stream.
+type trialMetricsPosition struct {
+	trialRunID   int
+	totalBatches int
+}
+
+type trialMetricsKey struct {
+	trialID int
+	group   model.MetricType
+}
+
+// trialMetricsStream is a subscription to the metrics of trials, along with where to resume each
+// trial and group from.
+type trialMetricsStream struct {
+	filter   metricstream.Filter
+	trialIDs []int
+	groups   []model.MetricType
+	sent     map[trialMetricsKey]trialMetricsPosition
+}
+
+// newTrialMetricsStream returns a stream of the metrics of trials, and of every trial of an
+// experiment if expID is set, in the given groups, by default training and validation. Each
+// trial and group resumes after the total batches in resume.
+func newTrialMetricsStream(
+	trialIDs []int, expID *int, groups []string, resume map[trialMetricsKey]int,
+) (*trialMetricsStream, error) {
+	s := &trialMetricsStream{
+		filter: metricstream.Filter{
+			TrialIDs:     map[int]bool{},
+			ExperimentID: expID,
+			Groups:       map[model.MetricType]bool{},
+		},
+		sent: map[trialMetricsKey]trialMetricsPosition{},
+	}
+	for _, trialID := range trialIDs {
+		if !s.filter.TrialIDs[trialID] {
+			s.filter.TrialIDs[trialID] = true
+			s.trialIDs = append(s.trialIDs, trialID)
+		}
+	}
+	if len(s.trialIDs) == 0 && expID == nil {
+		return nil, errors.New("must specify at least one trial_id or an experiment_id")
+	}
+
+	if len(groups) == 0 {
+		groups = []string{
+			model.TrainingMetricType.ToString(), model.ValidationMetricType.ToString(),
+		}
+	}
+	for _, g := range groups {
+		group := model.MetricType(g)
+		if err := group.Validate(); err != nil {
+			return nil, err
+		}
+		if !s.filter.Groups[group] {
+			s.filter.Groups[group] = true
+			s.groups = append(s.groups, group)
+		}
+	}
+
+	for key, totalBatches := range resume {
+		s.sent[key] = trialMetricsPosition{trialRunID: -1, totalBatches: totalBatches}
+	}
+	return s, nil
+}
+
+// parseTrialMetricsStream parses and authorizes the trial_id, experiment_id, group and resume
+// query parameters.
+func (m *Master) parseTrialMetricsStream(c echo.Context) (*trialMetricsStream, error) {
+	ctx := c.Request().Context()
+
+	var trialIDs []int
+	for _, v := range c.QueryParams()["trial_id"] {
+		trialID, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid trial_id "+v)
+		}
+		exp, err := db.ExperimentByTrialID(ctx, trialID)
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, api.NotFoundErrs("trial", v, false)
+		} else if err != nil {
+			return nil, err
+		}
+		if _, _, err := echoGetExperimentAndCheckCanDoActions(ctx, c, m, exp.ID,
+			expauth.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+			return nil, err
+		}
+		trialIDs = append(trialIDs, trialID)
+	}
+
+	var expID *int
+	if v := c.QueryParam("experiment_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid experiment_id "+v)
+		}
+		if _, _, err := echoGetExperimentAndCheckCanDoActions(ctx, c, m, id,
+			expauth.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+			return nil, err
+		}
+		expID = &id
+	}
+
+	resume := map[trialMetricsKey]int{}
+	if v := c.QueryParam("resume"); v != "" {
+		var positions map[int]map[model.MetricType]int
+		if err := json.Unmarshal([]byte(v), &positions); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid resume: "+err.Error())
+		}
+		for trialID, groups := range positions {
+			for group, totalBatches := range groups {
+				resume[trialMetricsKey{trialID, group}] = totalBatches
+			}
+		}
+	}
+
+	s, err := newTrialMetricsStream(trialIDs, expID, c.QueryParams()["group"], resume)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return s, nil
+}
+
+// next reports whether a report was not sent yet, and records it as sent if so.
+func (s *trialMetricsStream) next(r metricstream.Report) bool {
+	key := trialMetricsKey{int(r.TrialId), r.Group}
+	pos, ok := s.sent[key]
+	switch {
+	case !ok:
+	case int(r.TrialRunId) < pos.trialRunID:
+		return false
+	case int(r.TrialRunId) == pos.trialRunID || pos.trialRunID == -1:
+		if int(r.TotalBatches) <= pos.totalBatches {
+			return false
+		}
+	}
+	s.sent[key] = trialMetricsPosition{int(r.TrialRunId), int(r.TotalBatches)}
+	return true
+}
+
+// backfillTrialMetrics sends the persisted reports that were not sent yet.
+func (m *Master) backfillTrialMetrics(
+	ctx context.Context, s *trialMetricsStream, send func(metricstream.Report) error,
+) error {
+	trialIDs := s.trialIDs
+	if s.filter.ExperimentID != nil {
+		expTrialIDs, err := m.db.ExperimentTrialIDs(*s.filter.ExperimentID)
+		if err != nil {
+			return err
+		}
+		for _, trialID := range expTrialIDs {
+			if !s.filter.TrialIDs[trialID] {
+				trialIDs = append(trialIDs, trialID)
+			}
+		}
+	}
+
+	for _, trialID := range trialIDs {
+		for _, group := range s.groups {
+			after := -1
+			if pos, ok := s.sent[trialMetricsKey{trialID, group}]; ok {
+				after = pos.totalBatches
+			}
+			for {
+				reports, err := db.GetMetrics(
+					ctx, trialID, after, trialMetricsBackfillBatchSize, group)
+				if err != nil {
+					return err
+				}
+				for _, r := range reports {
+					report := metricstream.Report{Group: group, MetricsReport: r}
+					if !s.next(report) {
+						continue
+					}
+					if err := send(report); err != nil {
+						return err
+					}
+				}
+				if len(reports) < trialMetricsBackfillBatchSize {
+					break
+				}
+				after = int(reports[len(reports)-1].TotalBatches)
+			}
+		}
+	}
+	return nil
+}
+
+// runTrialMetricsStream sends the persisted reports of a stream, then each new report as it is
+// persisted, until ctx is done or the stream fails.
+func (m *Master) runTrialMetricsStream(
+	ctx context.Context, s *trialMetricsStream, send func(metricstream.Report) error,
+) error {
+	// Subscribe before backfilling, so that no report is missed in between.
+	sub, err := m.metricHub.Subscribe(s.filter)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	if err := m.backfillTrialMetrics(ctx, s, send); err != nil {
+		return fmt.Errorf("sending persisted metrics: %w", err)
+	}
+	for _, r := range sub.Follow() {
+		if s.next(r) {
+			if err := send(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case r, ok := <-sub.Reports():
+			if !ok {
+				return sub.Err()
+			}
+			if s.next(r) {
+				if err := send(r); err != nil {
+					return err
+				}
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+//	@Summary	Stream the metrics of trials over a WebSocket, first the persisted ones, then each new report as it is persisted.
+//	@Tags		Experiments
+//	@ID			stream-trial-metrics
+//	@Param		trial_id		query	[]integer	false	"Trials to stream the metrics of"
+//	@Param		experiment_id	query	integer		false	"Experiment to stream the metrics of every trial of"
+//	@Param		group			query	[]string	false	"Metric groups to stream (default training and validation)"
+//	@Param		resume			query	string		false	"JSON object of trial ID to group to the total_batches to resume after"
+//	@Success	101				{}		string	""
+//	@Router		/trials/metrics/stream [get]
+//
+// nolint:lll
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) getTrialMetricsStream(c echo.Context) error {
+	s, err := m.parseTrialMetricsStream(c)
+	if err != nil {
+		return err
+	}
+	return api.WebSocketRoute(func(socket *websocket.Conn, c echo.Context) error {
+		return m.streamTrialMetrics(c.Request().Context(), s, socket)
+	})(c)
+}
+
+func (m *Master) streamTrialMetrics(
+	ctx context.Context, s *trialMetricsStream, socket *websocket.Conn,
+) error {
+	conn, err := ws.Wrap[any, *trialMetricsMessage]("trial-metrics", socket)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.WithError(err).Debug("closing trial metrics stream")
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		defer cancel()
+		// Clients have nothing to send, so this only ends the stream once they close it.
+		for {
+			select {
+			case _, ok := <-conn.Inbox:
+				if !ok {
+					return
+				}
+			case <-conn.Done:
+				return
+			}
+		}
+	}()
+
+	send := func(msg *trialMetricsMessage) error {
+		select {
+		case conn.Outbox <- msg:
+			return nil
+		case <-conn.Done:
+			return conn.Error()
+		}
+	}
+	err = m.runTrialMetricsStream(ctx, s, func(r metricstream.Report) error {
+		return send(&trialMetricsMessage{Report: &trialMetricsReport{
+			Group:        r.Group,
+			TrialID:      int(r.TrialId),
+			TrialRunID:   int(r.TrialRunId),
+			TotalBatches: int(r.TotalBatches),
+			EndTime:      r.EndTime.AsTime(),
+			Metrics:      r.Metrics.AsMap(),
+		}})
+	})
+	if err != nil && ctx.Err() == nil {
+		return send(&trialMetricsMessage{Error: err.Error()})
+	}
+	return conn.Error()
+}
@@ -3,6 +3,7 @@ package trials
 import (
 	"context"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"time"
@@ -10,8 +11,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/downsample"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -325,9 +329,13 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 	metricNames []string,
 	startBatches int, endBatches int, xAxisMetricLabels []string,
 	maxDatapoints int, timeSeriesColumn string,
-	timeSeriesFilter *commonv1.PolymorphicFilter, metricType model.MetricType) (
+	timeSeriesFilter *commonv1.PolymorphicFilter, metricType model.MetricType,
+	algorithm downsample.Algorithm) (
 	metricMeasurements []db.MetricMeasurements, err error,
 ) {
+	if algorithm == "" {
+		algorithm = downsample.LTTB
+	}
 	var queryColumn, orderColumn string
 	metricsObjectName := model.TrialMetricsJSONPath(
 		metricType == model.ValidationMetricType)
@@ -341,7 +349,6 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 		queryColumn = timeSeriesColumn
 	}
 	subq := db.BunSelectMetricsQuery(metricType, false).Table("metrics").
-		ColumnExpr("(select setseed(1)) as _seed").
 		ColumnExpr("total_batches as batches").
 		ColumnExpr("trial_id").ColumnExpr("end_time as time")
 
@@ -376,8 +383,7 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 			metricsObjectName, metricName, bun.Safe(cast), bun.Ident(metricName))
 	}
 
-	subq = subq.Where("trial_id = ?", trialID).OrderExpr("random()").
-		Limit(maxDatapoints)
+	subq = subq.Where("trial_id = ?", trialID)
 	switch timeSeriesFilter {
 	case nil:
 		orderColumn = batches
@@ -438,5 +444,98 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 
 		metricMeasurements = append(metricMeasurements, metricM)
 	}
-	return metricMeasurements, nil
+
+	return downsampleMetrics(results, metricMeasurements, metricNames, orderColumn, algorithm,
+		maxDatapoints), nil
+}
+
+// downsampleMetrics reduces the metrics, ordered by orderColumn, to about maxDatapoints points.
+func downsampleMetrics(results []map[string]any, metricMeasurements []db.MetricMeasurements,
+	metricNames []string, orderColumn string, algorithm downsample.Algorithm, maxDatapoints int,
+) []db.MetricMeasurements {
+	xs := make([]float64, len(results))
+	ys := make([][]float64, len(metricNames))
+	for i := range ys {
+		ys[i] = make([]float64, len(results))
+	}
+	for i, result := range results {
+		if x, ok := downsampleValue(result[orderColumn]); ok {
+			xs[i] = x
+		} else {
+			xs[i] = float64(i)
+		}
+		for j, name := range metricNames {
+			if y, ok := downsampleValue(result[name]); ok {
+				ys[j][i] = y
+			} else {
+				ys[j][i] = math.NaN()
+			}
+		}
+	}
+
+	indices := downsample.Select(algorithm, xs, ys, maxDatapoints)
+	downsampled := make([]db.MetricMeasurements, len(indices))
+	for i, j := range indices {
+		downsampled[i] = metricMeasurements[j]
+	}
+	return downsampled
+}
+
+// DownsampleReports reduces the metrics reports of a trial, ordered by total batches, to about
+// maxDatapoints reports, keeping the reports that best represent each metric of metricType.
+func DownsampleReports(reports []*trialv1.MetricsReport, metricType model.MetricType,
+	algorithm downsample.Algorithm, maxDatapoints int,
+) []*trialv1.MetricsReport {
+	metricsObjectName := model.TrialMetricsJSONPath(metricType == model.ValidationMetricType)
+	xs := make([]float64, len(reports))
+	ys := map[string][]float64{}
+	for i, r := range reports {
+		xs[i] = float64(r.TotalBatches)
+		metrics := r.Metrics.GetFields()[metricsObjectName].GetStructValue().GetFields()
+		for name, v := range metrics {
+			y, ok := downsampleValue(v.AsInterface())
+			if !ok {
+				continue
+			}
+			if _, ok := ys[name]; !ok {
+				ys[name] = make([]float64, len(reports))
+				for j := range ys[name] {
+					ys[name][j] = math.NaN()
+				}
+			}
+			ys[name][i] = y
+		}
+	}
+
+	names := maps.Keys(ys)
+	slices.Sort(names)
+	series := make([][]float64, 0, len(names))
+	for _, name := range names {
+		series = append(series, ys[name])
+	}
+
+	indices := downsample.Select(algorithm, xs, series, maxDatapoints)
+	downsampled := make([]*trialv1.MetricsReport, len(indices))
+	for i, j := range indices {
+		downsampled[i] = reports[j]
+	}
+	return downsampled
+}
+
+func downsampleValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case time.Time:
+		return float64(v.UnixNano()) / float64(time.Second), true
+	default:
+		return 0, false
+	}
 }
@@ -116,6 +116,72 @@ func ReportExperimentStateChanged(
 	return nil
 }
 
+// ReportBudgetExceeded adds a webhook event for an exceeded chargeback budget to the queue if
+// record, which runs in the same transaction, returns true. The event is queued exactly when what
+// record stores is committed, so the alert is neither lost nor sent twice.
+func ReportBudgetExceeded(
+	ctx context.Context, url string, b BudgetPayload,
+	record func(context.Context, bun.IDB) (bool, error),
+) (bool, error) {
+	p, err := eventPayload(TriggerTypeBudgetExceeded, EventData{Budget: &b})
+	if err != nil {
+		return false, err
+	}
+	var recorded bool
+	err = db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		if recorded, err = record(ctx, tx); err != nil || !recorded {
+			return err
+		}
+		_, err = tx.NewInsert().Model(&Event{Payload: p, URL: url}).Exec(ctx)
+		return err
+	})
+	if err != nil || !recorded {
+		return false, err
+	}
+
+	singletonShipper.Wake()
+	return true, nil
+}
+
+// ReportSlotQuarantined adds a webhook event for a quarantined slot to the queue.
+func ReportSlotQuarantined(ctx context.Context, url string, s SlotPayload) error {
+	return reportToURL(ctx, url, TriggerTypeSlotQuarantined, EventData{Slot: &s})
+}
+
+// ReportTaskGPUIdle adds a webhook event for a task warned for idle GPUs to the queue.
+func ReportTaskGPUIdle(ctx context.Context, url string, t TaskPayload) error {
+	return reportToURL(ctx, url, TriggerTypeTaskGPUIdle, EventData{Task: &t})
+}
+
+// reportToURL adds a webhook event to the queue for a URL from the master config.
+func reportToURL(ctx context.Context, url string, tT TriggerType, data EventData) error {
+	p, err := eventPayload(tT, data)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Bun().NewInsert().Model(&Event{Payload: p, URL: url}).Exec(ctx); err != nil {
+		return err
+	}
+
+	singletonShipper.Wake()
+	return nil
+}
+
+// eventPayload returns the payload of a new event for a URL from the master config.
+func eventPayload(tT TriggerType, data EventData) ([]byte, error) {
+	p, err := json.Marshal(EventPayload{
+		ID:        uuid.New(),
+		Type:      tT,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error generating event payload: %w", err)
+	}
+	return p, nil
+}
+
 func generateEventPayload(
 	ctx context.Context,
 	wt WebhookType,
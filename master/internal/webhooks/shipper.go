@@ -17,6 +17,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	conf "github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/prom"
 )
 
 const (
@@ -154,10 +155,12 @@ func (w *worker) shipBatch(ctx context.Context) (int, error) {
 		wg.Add(1)
 		go func(e Event) {
 			defer wg.Done()
-			if err := back.Retry(
+			err := back.Retry(
 				func() error { return w.deliver(ctx, e) },
 				backoff(),
-			); err != nil {
+			)
+			prom.IncWebhookDeliveries(err)
+			if err != nil {
 				w.log.WithError(err).Error("failed to deliver webhook")
 			}
 		}(e)
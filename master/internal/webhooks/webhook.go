@@ -124,6 +124,18 @@ const (
 
 	// TriggerTypeMetricThresholdExceeded represents a threshold for a training metric value.
 	TriggerTypeMetricThresholdExceeded TriggerType = "METRIC_THRESHOLD_EXCEEDED"
+
+	// TriggerTypeBudgetExceeded represents the cost of a month exceeding the chargeback budget.
+	// It is sent to the URL in the chargeback config rather than to webhooks with triggers.
+	TriggerTypeBudgetExceeded TriggerType = "CHARGEBACK_BUDGET_EXCEEDED"
+
+	// TriggerTypeSlotQuarantined represents a slot disabled because its device is unhealthy.
+	// It is sent to the URL in the slot quarantine config rather than to webhooks with triggers.
+	TriggerTypeSlotQuarantined TriggerType = "SLOT_QUARANTINED"
+
+	// TriggerTypeTaskGPUIdle represents a task warned that it will be terminated for idle GPUs.
+	// It is sent to the URL in the GPU idle config rather than to webhooks with triggers.
+	TriggerTypeTaskGPUIdle TriggerType = "TASK_GPU_IDLE"
 )
 
 const (
@@ -260,6 +272,9 @@ type Condition struct {
 type EventData struct {
 	TestData   *string            `json:"data,omitempty"`
 	Experiment *ExperimentPayload `json:"experiment,omitempty"`
+	Budget     *BudgetPayload     `json:"budget,omitempty"`
+	Slot       *SlotPayload       `json:"slot,omitempty"`
+	Task       *TaskPayload       `json:"task,omitempty"`
 }
 
 // ExperimentPayload is the webhook request representation of an experiment.
@@ -273,3 +288,29 @@ type ExperimentPayload struct {
 	WorkspaceName string       `json:"workspace"`
 	ProjectName   string       `json:"project"`
 }
+
+// BudgetPayload is the webhook request representation of an exceeded chargeback budget.
+type BudgetPayload struct {
+	Month    string  `json:"month"`
+	Cost     float64 `json:"cost"`
+	Budget   float64 `json:"budget"`
+	Currency string  `json:"currency"`
+}
+
+// SlotPayload is the webhook request representation of a quarantined slot.
+type SlotPayload struct {
+	AgentID      string `json:"agent_id"`
+	ResourcePool string `json:"resource_pool"`
+	SlotID       int    `json:"slot_id"`
+	DeviceUUID   string `json:"device_uuid"`
+	Reason       string `json:"reason"`
+}
+
+// TaskPayload is the webhook request representation of a task warned for idle GPUs.
+type TaskPayload struct {
+	TaskID       string `json:"task_id"`
+	AllocationID string `json:"allocation_id"`
+	Name         string `json:"name"`
+	ResourcePool string `json:"resource_pool"`
+	Reason       string `json:"reason"`
+}
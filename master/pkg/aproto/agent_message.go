@@ -21,6 +21,8 @@ type AgentMessage struct {
 	StartContainer        *StartContainer
 	SignalContainer       *SignalContainer
 	AgentShutdown         *AgentShutdown
+	PrePullImages         *PrePullImages
+	MaintenanceChanged    *MaintenanceChanged
 }
 
 // MasterSetAgentOptions is the first message sent to an agent by the master. It lets
@@ -31,12 +33,33 @@ type MasterSetAgentOptions struct {
 	MasterInfo           MasterInfo
 	LoggingOptions       model.LoggingConfig
 	ContainersToReattach []ContainerReattach
+	// SlotResourceLimits, if set, overrides the agent's own slot resource limits for its
+	// resource pool.
+	SlotResourceLimits *model.SlotResourceLimitsConfig
+	// MIGProfiles are the MIG instances the agent partitions its MIG-enabled GPUs into while it
+	// is idle.
+	MIGProfiles []model.MIGProfileConfig
 }
 
 // StartContainer notifies the agent to start a container with the provided spec.
 type StartContainer struct {
 	Container cproto.Container
 	Spec      cproto.Spec
+	// TraceContext is the W3C trace context of the allocation the container is for, so that the
+	// agent's spans for the container join the allocation's trace.
+	TraceContext map[string]string
+}
+
+// PrePullImages tells the agent which images to pull ahead of the containers that need them and
+// to keep in its image cache. It replaces any previous list.
+type PrePullImages struct {
+	Images []string
+}
+
+// MaintenanceChanged tells the agent that its scheduled maintenance moved to a new phase, so that
+// it can run its maintenance hook.
+type MaintenanceChanged struct {
+	Maintenance model.AgentMaintenance
 }
 
 // SignalContainer notifies the agent to send the requested signal to the container.
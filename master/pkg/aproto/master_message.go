@@ -40,6 +40,9 @@ type MasterMessage struct {
 	ContainerStateChanged *ContainerStateChanged
 	ContainerLog          *ContainerLog
 	ContainerStatsRecord  *ContainerStatsRecord
+	DevicesHealthChanged  *DevicesHealthChanged
+	ContainerGPUTelemetry *ContainerGPUTelemetry
+	ImageCacheChanged     *ImageCacheChanged
 }
 
 // ContainerReattach is a struct describing containers that can be reattached.
@@ -72,6 +75,82 @@ type AgentStarted struct {
 	ContainersReattached []ContainerReattachAck
 }
 
+// DeviceProblemKind is a kind of device health problem.
+type DeviceProblemKind string
+
+const (
+	// XidError is an Xid error reported by the NVIDIA driver.
+	XidError DeviceProblemKind = "xid_error"
+	// RetiredPagesPending means memory pages were retired and the device needs a reset.
+	RetiredPagesPending DeviceProblemKind = "retired_pages_pending"
+	// UncorrectableECCErrors means the device memory had uncorrectable ECC errors.
+	UncorrectableECCErrors DeviceProblemKind = "uncorrectable_ecc_errors"
+	// ThermalThrottling means the device is slowed down because it is too hot.
+	ThermalThrottling DeviceProblemKind = "thermal_throttling"
+	// FallenOffBus means the device is no longer reachable by its driver.
+	FallenOffBus DeviceProblemKind = "fallen_off_bus"
+)
+
+// DeviceProblem is a health problem of a device.
+type DeviceProblem struct {
+	Kind   DeviceProblemKind
+	Detail string
+	// Fatal problems mean the device should not be scheduled on until an admin intervenes.
+	Fatal bool
+}
+
+func (p DeviceProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.Kind, p.Detail)
+}
+
+// DeviceHealth is the health of a device; a device without problems is healthy.
+type DeviceHealth struct {
+	Device   device.Device
+	Problems []DeviceProblem
+}
+
+// Fatal returns the fatal problems of the device.
+func (h DeviceHealth) Fatal() []DeviceProblem {
+	var fatal []DeviceProblem
+	for _, p := range h.Problems {
+		if p.Fatal {
+			fatal = append(fatal, p)
+		}
+	}
+	return fatal
+}
+
+// DevicesHealthChanged notifies the master of the health of every device of the agent, whenever
+// the health of any of them changes.
+type DevicesHealthChanged struct {
+	Devices []DeviceHealth
+}
+
+// GPUSample is the usage of a device at a point in time.
+type GPUSample struct {
+	Time       time.Time
+	DeviceUUID string
+	// Utilization is the percent of time the device was busy over the sample period.
+	Utilization float64
+	MemoryUsed  uint64
+	// Power is the power draw of the device in watts.
+	Power float64
+}
+
+// ContainerGPUTelemetry notifies the master of the usage of the devices assigned to a container
+// since the previous notification.
+type ContainerGPUTelemetry struct {
+	ContainerID cproto.ID
+	Samples     []GPUSample
+}
+
+// ImageCacheChanged notifies the master of the images present on the agent, whenever they change.
+// Each image is listed under the names a task may refer to it by, such as ubuntu:latest,
+// ubuntu and docker.io/library/ubuntu:latest.
+type ImageCacheChanged struct {
+	Images []string
+}
+
 // ContainerStateChanged notifies the master that the agent transitioned the container state.
 type ContainerStateChanged struct {
 	Container cproto.Container
@@ -26,4 +26,6 @@ type GetRPConfig struct{}
 type GetRPResponse struct {
 	AgentReconnectWait    model.Duration
 	MaxZeroSlotContainers int
+	SlotResourceLimits    *model.SlotResourceLimitsConfig
+	MIGProfiles           []model.MIGProfileConfig
 }
@@ -0,0 +1,36 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// WalkTgz reads a gzipped tar ball from r and calls fn with the path and contents of every
+// regular file in it, in archive order.
+func WalkTgz(r io.Reader, fn func(path string, size int64, content io.Reader) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("reading gzip stream: %w", err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return fmt.Errorf("reading tar stream: %w", err)
+		case hdr.Typeflag != tar.TypeReg:
+			continue
+		}
+		if err := fn(hdr.Name, hdr.Size, tr); err != nil {
+			return err
+		}
+	}
+}
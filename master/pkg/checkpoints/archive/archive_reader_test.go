@@ -0,0 +1,38 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkTgzRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	aw, err := NewArchiveWriter(&buf, ArchiveTgz)
+	require.NoError(t, err)
+
+	files := map[string]string{"a.json": "{}", "dir/b.txt": "hello"}
+	require.NoError(t, aw.WriteHeader("dir/", 0))
+	pw := NewPrefixWriter(aw, "dir/")
+	require.NoError(t, pw.WriteHeader("b.txt", 5))
+	_, err = pw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, pw.Close())
+	require.NoError(t, aw.WriteHeader("a.json", 2))
+	_, err = aw.Write([]byte("{}"))
+	require.NoError(t, err)
+	require.NoError(t, aw.Close())
+
+	read := map[string]string{}
+	err = WalkTgz(&buf, func(path string, size int64, content io.Reader) error {
+		b, err := io.ReadAll(content)
+		require.NoError(t, err)
+		require.Equal(t, size, int64(len(b)))
+		read[path] = string(b)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, files, read)
+}
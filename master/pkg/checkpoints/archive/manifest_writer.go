@@ -0,0 +1,74 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ManifestEntry describes a single file written to a ManifestWriter.
+type ManifestEntry struct {
+	Size     int64
+	Checksum string
+}
+
+// ManifestWriter is an ArchiveWriter that, instead of producing an archive, records the size and
+// SHA-256 checksum of every file written to it. Checksums take the form "sha256:<hex>".
+type ManifestWriter struct {
+	entries map[string]ManifestEntry
+	path    string
+	hash    hash.Hash
+	written int64
+}
+
+// NewManifestWriter returns a new, empty ManifestWriter.
+func NewManifestWriter() *ManifestWriter {
+	return &ManifestWriter{entries: map[string]ManifestEntry{}}
+}
+
+// WriteHeader finishes the current file and starts a new one.
+func (w *ManifestWriter) WriteHeader(path string, size int64) error {
+	w.finish()
+	if strings.HasSuffix(path, "/") {
+		// Directories have no content to check.
+		return nil
+	}
+	w.path = path
+	w.hash = sha256.New()
+	w.written = 0
+	return nil
+}
+
+// Write hashes the contents of the current file.
+func (w *ManifestWriter) Write(p []byte) (int, error) {
+	if w.hash == nil {
+		return 0, fmt.Errorf("write of %d bytes before any file header", len(p))
+	}
+	n, err := w.hash.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Close finishes the current file.
+func (w *ManifestWriter) Close() error {
+	w.finish()
+	return nil
+}
+
+// Manifest returns the files written so far, keyed by path.
+func (w *ManifestWriter) Manifest() map[string]ManifestEntry {
+	return w.entries
+}
+
+func (w *ManifestWriter) finish() {
+	if w.hash == nil {
+		return
+	}
+	w.entries[w.path] = ManifestEntry{
+		Size:     w.written,
+		Checksum: "sha256:" + hex.EncodeToString(w.hash.Sum(nil)),
+	}
+	w.hash = nil
+}
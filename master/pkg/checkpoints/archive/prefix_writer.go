@@ -0,0 +1,29 @@
+package archive
+
+// PrefixWriter is an ArchiveWriter that places every file it is given under a directory of
+// another ArchiveWriter. Closing it leaves the underlying ArchiveWriter open, so several
+// downloaders can write into the same archive.
+type PrefixWriter struct {
+	aw     ArchiveWriter
+	prefix string
+}
+
+// NewPrefixWriter returns a PrefixWriter that writes to aw with paths prefixed by prefix.
+func NewPrefixWriter(aw ArchiveWriter, prefix string) *PrefixWriter {
+	return &PrefixWriter{aw: aw, prefix: prefix}
+}
+
+// WriteHeader starts a new file at prefix + path.
+func (w *PrefixWriter) WriteHeader(path string, size int64) error {
+	return w.aw.WriteHeader(w.prefix+path, size)
+}
+
+// Write writes to the current file.
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	return w.aw.Write(p)
+}
+
+// Close does nothing; the underlying ArchiveWriter must be closed by its owner.
+func (w *PrefixWriter) Close() error {
+	return nil
+}
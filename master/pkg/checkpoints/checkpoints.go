@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
 	"github.com/determined-ai/determined/master/pkg/checkpoints/gcs"
 	"github.com/determined-ai/determined/master/pkg/checkpoints/s3"
+	"github.com/determined-ai/determined/master/pkg/checkpoints/sharedfs"
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
 )
 
@@ -35,7 +37,16 @@ func NewDownloader(
 	if err != nil {
 		return nil, err
 	}
+	return NewArchiveDownloader(aw, id, storageConfig)
+}
 
+// NewArchiveDownloader returns a new CheckpointDownloader that writes the files of the
+// checkpoint to aw.
+func NewArchiveDownloader(
+	aw archive.ArchiveWriter,
+	id string,
+	storageConfig *expconf.CheckpointStorageConfig,
+) (CheckpointDownloader, error) {
 	prefix := ""
 	switch storage := storageConfig.GetUnionMember().(type) {
 	case expconf.S3Config:
@@ -50,6 +61,9 @@ func NewDownloader(
 		}
 		return gcs.NewGCSDownloader(
 			aw, storage.Bucket(), strings.TrimLeft(prefix+"/"+id, "/")), nil
+	case expconf.SharedFSConfig:
+		return sharedfs.NewSharedFSDownloader(
+			aw, filepath.Join(storage.PathInHost(), id)), nil
 	default:
 		return nil,
 			fmt.Errorf("checkpoint download via master is not supported for %s",
@@ -57,6 +71,36 @@ func NewDownloader(
 	}
 }
 
+// NewUploader returns an ArchiveWriter that, instead of producing an archive, writes every file
+// given to it into the checkpoint with the given id in storage. Uploads stop when ctx is canceled.
+func NewUploader(
+	ctx context.Context,
+	id string,
+	storageConfig *expconf.CheckpointStorageConfig,
+) (archive.ArchiveWriter, error) {
+	prefix := ""
+	switch storage := storageConfig.GetUnionMember().(type) {
+	case expconf.S3Config:
+		if storage.Prefix() != nil {
+			prefix = *storage.Prefix()
+		}
+		return s3.NewS3Uploader(
+			ctx, storage.Bucket(), strings.TrimLeft(prefix+"/"+id, "/")), nil
+	case expconf.GCSConfig:
+		if storage.Prefix() != nil {
+			prefix = *storage.Prefix()
+		}
+		return gcs.NewGCSUploader(
+			ctx, storage.Bucket(), strings.TrimLeft(prefix+"/"+id, "/")), nil
+	case expconf.SharedFSConfig:
+		return sharedfs.NewSharedFSUploader(filepath.Join(storage.PathInHost(), id)), nil
+	default:
+		return nil,
+			fmt.Errorf("checkpoint upload via master is not supported for %s",
+				storageConfig2Str(storage))
+	}
+}
+
 func storageConfig2Str(config any) string {
 	switch config.(type) {
 	case expconf.AzureConfig:
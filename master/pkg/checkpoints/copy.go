@@ -0,0 +1,65 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// countingWriter is an ArchiveWriter that counts the bytes of file contents written through it.
+type countingWriter struct {
+	archive.ArchiveWriter
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ArchiveWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Copy copies every file of a checkpoint from one storage to another, then reads the copy back
+// to check that each file recorded in resources arrived with its recorded size. It returns the
+// number of bytes copied. Files already present in the destination are overwritten.
+func Copy(
+	ctx context.Context,
+	id string,
+	src *expconf.CheckpointStorageConfig,
+	dst *expconf.CheckpointStorageConfig,
+	resources map[string]int64,
+) (int64, error) {
+	if reflect.DeepEqual(src.GetUnionMember(), dst.GetUnionMember()) {
+		return 0, fmt.Errorf("checkpoint %s is already stored in the destination storage", id)
+	}
+
+	uploader, err := NewUploader(ctx, id, dst)
+	if err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{ArchiveWriter: uploader}
+	downloader, err := NewArchiveDownloader(cw, id, src)
+	if err != nil {
+		return 0, err
+	}
+	if err := downloader.Download(ctx); err != nil {
+		_ = downloader.Close()
+		return cw.written, err
+	}
+	if err := downloader.Close(); err != nil {
+		return cw.written, err
+	}
+
+	problems, err := Verify(ctx, id, dst, resources, nil)
+	if err != nil {
+		return cw.written, fmt.Errorf("unable to read back copy of checkpoint %s: %w", id, err)
+	}
+	if len(problems) > 0 {
+		return cw.written, fmt.Errorf("copy of checkpoint %s is incomplete: %s",
+			id, strings.Join(problems, "; "))
+	}
+	return cw.written, nil
+}
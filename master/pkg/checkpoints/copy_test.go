@@ -0,0 +1,57 @@
+package checkpoints
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func sharedFSStorage(hostPath string) *expconf.CheckpointStorageConfig {
+	return &expconf.CheckpointStorageConfig{
+		RawSharedFSConfig: &expconf.SharedFSConfig{RawHostPath: ptrs.Ptr(hostPath)},
+	}
+}
+
+func TestCopySharedFSToSharedFS(t *testing.T) {
+	srcPath, dstPath := t.TempDir(), t.TempDir()
+	src, dst := sharedFSStorage(srcPath), sharedFSStorage(dstPath)
+	id := "0c2d9a4e-8f1b-4e57-a0d3-6b7c8d9e0f1a"
+	files := map[string][]byte{
+		"metadata.json":            []byte(`{"steps_completed": 1}`),
+		"weights/model.ckpt":       []byte("some weights"),
+		"weights/optimizer/o.ckpt": []byte("some optimizer state"),
+	}
+	resources := map[string]int64{"weights/": 0, "weights/optimizer/": 0}
+	var total int64
+	for name, content := range files {
+		path := filepath.Join(srcPath, id, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+		resources[name] = int64(len(content))
+		total += int64(len(content))
+	}
+
+	copied, err := Copy(context.Background(), id, src, dst, resources)
+	require.NoError(t, err)
+	require.Equal(t, total, copied)
+	for name, content := range files {
+		actual, err := os.ReadFile(filepath.Join(dstPath, id, name)) //nolint:gosec
+		require.NoError(t, err)
+		require.Equal(t, content, actual)
+	}
+
+	// Copying onto the storage the checkpoint is read from is refused.
+	_, err = Copy(context.Background(), id, src, sharedFSStorage(srcPath), resources)
+	require.ErrorContains(t, err, "already stored")
+
+	// Files recorded but missing from the source make the copy fail verification.
+	resources["missing.bin"] = 4
+	_, err = Copy(context.Background(), id, src, sharedFSStorage(t.TempDir()), resources)
+	require.ErrorContains(t, err, "missing.bin: missing")
+}
@@ -107,3 +107,74 @@ func NewGCSDownloader(aw archive.ArchiveWriter, bucket string, prefix string) *G
 		buffer: make([]byte, DefaultDownloadPartSize),
 	}
 }
+
+// GCSUploader is an ArchiveWriter that, instead of producing an archive, uploads every file given
+// to it as an object under a prefix of a GCS bucket.
+type GCSUploader struct {
+	ctx    context.Context
+	bucket string
+	prefix string
+	client *storage.Client
+	w      *storage.Writer
+}
+
+// NewGCSUploader returns a new GCSUploader. Uploads stop when ctx is canceled.
+func NewGCSUploader(ctx context.Context, bucket string, prefix string) *GCSUploader {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &GCSUploader{
+		ctx:    ctx,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// WriteHeader finishes the current object and starts uploading a new one. Directories are
+// uploaded as empty objects, like the harness does.
+func (u *GCSUploader) WriteHeader(path string, size int64) error {
+	if err := u.finish(); err != nil {
+		return err
+	}
+	if u.client == nil {
+		client, err := storage.NewClient(u.ctx)
+		if err != nil {
+			return err
+		}
+		u.client = client
+	}
+	u.w = u.client.Bucket(u.bucket).Object(u.prefix + path).NewWriter(u.ctx)
+	return nil
+}
+
+// Write writes to the current object.
+func (u *GCSUploader) Write(p []byte) (int, error) {
+	if u.w == nil {
+		return 0, fmt.Errorf("write of %d bytes before any file header", len(p))
+	}
+	return u.w.Write(p)
+}
+
+// Close finishes the current object and releases the client.
+func (u *GCSUploader) Close() error {
+	err := u.finish()
+	if u.client != nil {
+		if cErr := u.client.Close(); err == nil {
+			err = cErr
+		}
+		u.client = nil
+	}
+	return err
+}
+
+func (u *GCSUploader) finish() error {
+	if u.w == nil {
+		return nil
+	}
+	err := u.w.Close()
+	u.w = nil
+	if err != nil {
+		return fmt.Errorf("checkpoint upload failed: %w", err)
+	}
+	return nil
+}
@@ -196,3 +196,88 @@ func newBatchDownloadIterator(aw archive.ArchiveWriter,
 		pos:     -1,
 	}
 }
+
+// S3Uploader is an ArchiveWriter that, instead of producing an archive, uploads every file given
+// to it as an object under a prefix of an S3 bucket.
+type S3Uploader struct {
+	ctx      context.Context
+	bucket   string
+	prefix   string
+	uploader *s3manager.Uploader
+	pw       *io.PipeWriter
+	done     chan error
+}
+
+// NewS3Uploader returns a new S3Uploader. Uploads stop when ctx is canceled.
+func NewS3Uploader(ctx context.Context, bucket string, prefix string) *S3Uploader {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &S3Uploader{
+		ctx:    ctx,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// WriteHeader finishes the current object and starts uploading a new one. Directories are
+// uploaded as empty objects, like the harness does.
+func (u *S3Uploader) WriteHeader(path string, size int64) error {
+	if err := u.finish(); err != nil {
+		return err
+	}
+	if u.uploader == nil {
+		region, err := GetS3BucketRegion(u.ctx, u.bucket)
+		if err != nil {
+			return err
+		}
+		sess, err := session.NewSession(&aws.Config{
+			Region: &region,
+		})
+		if err != nil {
+			return err
+		}
+		u.uploader = s3manager.NewUploader(sess)
+	}
+
+	key := u.prefix + path
+	pr, pw := io.Pipe()
+	u.pw, u.done = pw, make(chan error, 1)
+	go func() {
+		_, err := u.uploader.UploadWithContext(u.ctx, &s3manager.UploadInput{
+			Bucket: &u.bucket,
+			Key:    &key,
+			Body:   pr,
+		})
+		// Unblock the writer if the upload stopped early.
+		_ = pr.CloseWithError(err)
+		u.done <- err
+	}()
+	return nil
+}
+
+// Write writes to the current object.
+func (u *S3Uploader) Write(p []byte) (int, error) {
+	if u.pw == nil {
+		return 0, fmt.Errorf("write of %d bytes before any file header", len(p))
+	}
+	return u.pw.Write(p)
+}
+
+// Close finishes the current object.
+func (u *S3Uploader) Close() error {
+	return u.finish()
+}
+
+func (u *S3Uploader) finish() error {
+	if u.pw == nil {
+		return nil
+	}
+	_ = u.pw.Close()
+	err := <-u.done
+	u.pw, u.done = nil, nil
+	if err != nil {
+		return fmt.Errorf("checkpoint upload failed: %w", err)
+	}
+	return nil
+}
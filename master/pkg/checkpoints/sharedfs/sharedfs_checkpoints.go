@@ -0,0 +1,141 @@
+package sharedfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+)
+
+// SharedFSDownloader implements downloading a checkpoint from a shared filesystem mounted on the
+// master and sends it to the client in an archive file.
+type SharedFSDownloader struct {
+	aw   archive.ArchiveWriter
+	root string
+}
+
+// Download downloads the checkpoint.
+func (d *SharedFSDownloader) Download(ctx context.Context) error {
+	// Unlike an object store prefix, a missing directory is not read as an empty checkpoint: the
+	// shared filesystem may just not be mounted on the master.
+	if _, err := os.Stat(d.root); err != nil {
+		return fmt.Errorf("checkpoint download failed: %w", err)
+	}
+	return filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == d.root {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			return d.aw.WriteHeader(rel+"/", 0)
+		}
+		return d.fileDownload(path, rel)
+	})
+}
+
+func (d *SharedFSDownloader) fileDownload(path, rel string) error {
+	f, err := os.Open(path) //nolint:gosec // The path comes from walking the checkpoint directory.
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := d.aw.WriteHeader(rel, info.Size()); err != nil {
+		return err
+	}
+	_, err = io.Copy(d.aw, f)
+	return err
+}
+
+// Close closes the underlying ArchiveWriter.
+func (d *SharedFSDownloader) Close() error {
+	return d.aw.Close()
+}
+
+// NewSharedFSDownloader returns a new SharedFSDownloader that reads the checkpoint stored in the
+// directory root.
+func NewSharedFSDownloader(aw archive.ArchiveWriter, root string) *SharedFSDownloader {
+	return &SharedFSDownloader{
+		aw:   aw,
+		root: root,
+	}
+}
+
+// SharedFSUploader is an ArchiveWriter that, instead of producing an archive, writes every file
+// given to it into a checkpoint directory of a shared filesystem mounted on the master.
+type SharedFSUploader struct {
+	root string
+	f    *os.File
+}
+
+// NewSharedFSUploader returns a new SharedFSUploader that writes the checkpoint into the
+// directory root.
+func NewSharedFSUploader(root string) *SharedFSUploader {
+	return &SharedFSUploader{root: root}
+}
+
+// WriteHeader finishes the current file and starts a new one.
+func (u *SharedFSUploader) WriteHeader(path string, size int64) error {
+	if err := u.finish(); err != nil {
+		return err
+	}
+	rel := filepath.FromSlash(strings.TrimSuffix(path, "/"))
+	if !filepath.IsLocal(rel) {
+		return fmt.Errorf("checkpoint file path %q is outside of the checkpoint", path)
+	}
+	dst := filepath.Join(u.root, rel)
+	if strings.HasSuffix(path, "/") {
+		return os.MkdirAll(dst, 0o750)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	f, err := os.Create(dst) //nolint:gosec // The path is checked to be within the checkpoint.
+	if err != nil {
+		return err
+	}
+	u.f = f
+	return nil
+}
+
+// Write writes to the current file.
+func (u *SharedFSUploader) Write(p []byte) (int, error) {
+	if u.f == nil {
+		return 0, fmt.Errorf("write of %d bytes before any file header", len(p))
+	}
+	return u.f.Write(p)
+}
+
+// Close finishes the current file.
+func (u *SharedFSUploader) Close() error {
+	return u.finish()
+}
+
+func (u *SharedFSUploader) finish() error {
+	if u.f == nil {
+		return nil
+	}
+	err := u.f.Close()
+	u.f = nil
+	return err
+}
@@ -0,0 +1,76 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// ChecksumPrefix prefixes the hex encoded SHA-256 checksums of checkpoint files.
+const ChecksumPrefix = "sha256:"
+
+// Verify reads every file of the checkpoint back from storage and compares it against the
+// resources and checksums recorded when the checkpoint was reported. It returns the list of
+// problems found, which is empty if the checkpoint is intact, or an error if the checkpoint could
+// not be read at all.
+func Verify(
+	ctx context.Context,
+	id string,
+	storageConfig *expconf.CheckpointStorageConfig,
+	resources map[string]int64,
+	checksums map[string]string,
+) ([]string, error) {
+	mw := archive.NewManifestWriter()
+	downloader, err := NewArchiveDownloader(mw, id, storageConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := downloader.Download(ctx); err != nil {
+		return nil, err
+	}
+	if err := downloader.Close(); err != nil {
+		return nil, err
+	}
+	return CompareManifest(resources, checksums, mw.Manifest()), nil
+}
+
+// CompareManifest compares the files found in storage against the recorded resources and
+// checksums, returning a sorted description of every mismatch. Files in storage that were never
+// recorded are ignored, as are checksums that are not SHA-256.
+func CompareManifest(
+	resources map[string]int64,
+	checksums map[string]string,
+	manifest map[string]archive.ManifestEntry,
+) []string {
+	var problems []string
+	for path, size := range resources {
+		if strings.HasSuffix(path, "/") {
+			continue
+		}
+		entry, ok := manifest[path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: missing", path))
+			continue
+		case entry.Size != size:
+			problems = append(problems,
+				fmt.Sprintf("%s: size %d does not match recorded size %d", path, entry.Size, size))
+			continue
+		}
+
+		checksum, ok := checksums[path]
+		if !ok || !strings.HasPrefix(checksum, ChecksumPrefix) {
+			continue
+		}
+		if entry.Checksum != checksum {
+			problems = append(problems, fmt.Sprintf("%s: checksum %s does not match recorded %s",
+				path, entry.Checksum, checksum))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
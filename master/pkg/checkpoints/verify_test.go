@@ -0,0 +1,64 @@
+package checkpoints
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return ChecksumPrefix + hex.EncodeToString(sum[:])
+}
+
+func TestVerifySharedFS(t *testing.T) {
+	hostPath := t.TempDir()
+	id := "b3b1d2a3-5c8b-4c1f-9d7e-1f2a3b4c5d6e"
+	files := map[string][]byte{
+		"metadata.json":      []byte(`{"steps_completed": 1}`),
+		"weights/model.ckpt": []byte("some weights"),
+	}
+	resources := map[string]int64{"weights/": 0}
+	checksums := map[string]string{}
+	for name, content := range files {
+		path := filepath.Join(hostPath, id, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+		resources[name] = int64(len(content))
+		checksums[name] = checksum(content)
+	}
+	storageConfig := &expconf.CheckpointStorageConfig{
+		RawSharedFSConfig: &expconf.SharedFSConfig{RawHostPath: ptrs.Ptr(hostPath)},
+	}
+
+	problems, err := Verify(context.Background(), id, storageConfig, resources, checksums)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	// Same size, different contents.
+	require.NoError(t, os.WriteFile(
+		filepath.Join(hostPath, id, "weights/model.ckpt"), []byte("evil weights"), 0o600))
+	problems, err = Verify(context.Background(), id, storageConfig, resources, checksums)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0], "weights/model.ckpt: checksum")
+
+	// Without checksums, only sizes are checked.
+	require.NoError(t, os.Remove(filepath.Join(hostPath, id, "metadata.json")))
+	problems, err = Verify(context.Background(), id, storageConfig, resources, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"metadata.json: missing"}, problems)
+
+	// A checkpoint directory that is gone entirely cannot be told apart from an unmounted shared
+	// filesystem, so it is a read error rather than a corrupted checkpoint.
+	_, err = Verify(context.Background(), "missing", storageConfig, resources, checksums)
+	require.Error(t, err)
+}
@@ -35,6 +35,9 @@ type RunSpec struct {
 	UseFluentLogging bool
 	DeviceType       device.Type
 	Registry         *types.AuthConfig
+	// ShmSizeUnset is whether the task left its shared memory size unset, so that
+	// HostConfig.ShmSize is the default of the task container defaults.
+	ShmSizeUnset bool
 }
 
 // ChecksConfig describes the configuration for multiple readiness checks.
@@ -45,6 +45,8 @@ type Device struct {
 	Brand string `json:"brand"`
 	UUID  string `json:"uuid"`
 	Type  Type   `json:"type"`
+	// Profile is the MIG profile of the device, such as 1g.10gb, if it is a MIG instance.
+	Profile string `json:"profile,omitempty"`
 }
 
 func (d *Device) String() string {
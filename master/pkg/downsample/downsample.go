@@ -0,0 +1,209 @@
+// Package downsample reduces series of points to a target number of points. Every algorithm is
+// deterministic, so that the same series is always reduced to the same points.
+package downsample
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Algorithm is a downsampling algorithm.
+type Algorithm string
+
+const (
+	// Random keeps a pseudorandom sample of points, chosen with a fixed seed.
+	Random Algorithm = "random"
+	// LTTB keeps the points that best preserve the visual shape of a series, using the
+	// Largest-Triangle-Three-Buckets algorithm.
+	LTTB Algorithm = "lttb"
+	// MinMax keeps the smallest and largest value of each bucket, preserving spikes.
+	MinMax Algorithm = "min_max"
+	// Last keeps the last point of each bucket.
+	Last Algorithm = "last"
+)
+
+// Validate implements the check.Validatable interface.
+func (a Algorithm) Validate() []error {
+	switch a {
+	case Random, LTTB, MinMax, Last:
+		return nil
+	default:
+		return []error{fmt.Errorf(
+			"unknown downsampling algorithm %q, must be random, lttb, min_max or last", a)}
+	}
+}
+
+// Select returns the sorted indices of at most n points of a series to keep. xs must be increasing
+// and each of ys holds the values of one metric at xs, with NaN where the metric is missing. Each
+// metric is reduced to its share of n points and the union of their points is kept, so that a
+// point is kept with all of its metrics. Metrics with fewer points than their share leave the rest
+// to the others.
+func Select(algorithm Algorithm, xs []float64, ys [][]float64, n int) []int {
+	if len(xs) <= n {
+		return allIndices(len(xs))
+	}
+	if algorithm == Random {
+		//nolint:gosec // A fixed seed keeps the sample the same across calls.
+		indices := rand.New(rand.NewSource(1)).Perm(len(xs))[:n]
+		sort.Ints(indices)
+		return indices
+	}
+	if algorithm == Last || len(ys) == 0 || len(ys) > n {
+		return lastPerBucket(len(xs), n)
+	}
+
+	// Only downsample the points that have the metric.
+	present := make([][]int, len(ys))
+	for m, y := range ys {
+		for i, v := range y {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				present[m] = append(present[m], i)
+			}
+		}
+	}
+	// Sparse metrics go first, so that the points they leave over go to the dense ones.
+	order := allIndices(len(ys))
+	sort.SliceStable(order, func(a, b int) bool {
+		return len(present[order[a]]) < len(present[order[b]])
+	})
+
+	keep := map[int]bool{}
+	budget := n
+	for k, m := range order {
+		idx, y := present[m], ys[m]
+		share := budget / (len(order) - k)
+		var selected []int
+		switch {
+		case len(idx) <= share:
+			selected = allIndices(len(idx))
+		case algorithm == LTTB:
+			px, py := make([]float64, len(idx)), make([]float64, len(idx))
+			for j, i := range idx {
+				px[j], py[j] = xs[i], y[i]
+			}
+			selected = lttb(px, py, share)
+		default:
+			py := make([]float64, len(idx))
+			for j, i := range idx {
+				py[j] = y[i]
+			}
+			selected = minMaxPerBucket(py, share)
+		}
+		for _, j := range selected {
+			if !keep[idx[j]] {
+				keep[idx[j]] = true
+				budget--
+			}
+		}
+	}
+
+	indices := make([]int, 0, len(keep))
+	for i := range keep {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// bucket returns the bounds of the ith of k buckets that evenly split indices [lo, hi).
+func bucket(i, k, lo, hi int) (int, int) {
+	size := float64(hi-lo) / float64(k)
+	return lo + int(float64(i)*size), lo + int(float64(i+1)*size)
+}
+
+func lastPerBucket(length, n int) []int {
+	indices := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if _, end := bucket(i, n, 0, length); end > 0 &&
+			(len(indices) == 0 || indices[len(indices)-1] != end-1) {
+			indices = append(indices, end-1)
+		}
+	}
+	return indices
+}
+
+func minMaxPerBucket(ys []float64, n int) []int {
+	k := n / 2
+	if k == 0 {
+		return lastPerBucket(len(ys), n)
+	}
+	indices := make([]int, 0, 2*k)
+	for i := 0; i < k; i++ {
+		start, end := bucket(i, k, 0, len(ys))
+		if start == end {
+			continue
+		}
+		lo, hi := start, start
+		for j := start; j < end; j++ {
+			if ys[j] < ys[lo] {
+				lo = j
+			}
+			if ys[j] > ys[hi] {
+				hi = j
+			}
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		indices = append(indices, lo)
+		if hi != lo {
+			indices = append(indices, hi)
+		}
+	}
+	return indices
+}
+
+// lttb implements Largest-Triangle-Three-Buckets: it always keeps the first and last point and,
+// from each bucket in between, the point forming the largest triangle with the point kept from
+// the previous bucket and the average of the next bucket.
+func lttb(xs, ys []float64, n int) []int {
+	if n < 3 {
+		return lastPerBucket(len(xs), n)
+	}
+	indices := make([]int, 0, n)
+	indices = append(indices, 0)
+
+	k := n - 2
+	prev := 0
+	for i := 0; i < k; i++ {
+		start, end := bucket(i, k, 1, len(xs)-1)
+
+		// Average the next bucket, or use the last point after the last bucket.
+		nextStart, nextEnd := bucket(i+1, k, 1, len(xs)-1)
+		if i == k-1 {
+			nextStart, nextEnd = len(xs)-1, len(xs)
+		}
+		var avgX, avgY float64
+		for j := nextStart; j < nextEnd; j++ {
+			avgX += xs[j]
+			avgY += ys[j]
+		}
+		if count := float64(nextEnd - nextStart); count > 0 {
+			avgX, avgY = avgX/count, avgY/count
+		}
+
+		best, bestArea := -1, -1.0
+		for j := start; j < end; j++ {
+			area := math.Abs((xs[prev]-avgX)*(ys[j]-ys[prev]) - (xs[prev]-xs[j])*(avgY-ys[prev]))
+			if area > bestArea {
+				best, bestArea = j, area
+			}
+		}
+		if best >= 0 {
+			indices = append(indices, best)
+			prev = best
+		}
+	}
+
+	return append(indices, len(xs)-1)
+}
@@ -0,0 +1,102 @@
+package downsample
+
+import (
+	"math"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func series(n int) ([]float64, []float64) {
+	xs, ys := make([]float64, n), make([]float64, n)
+	for i := range xs {
+		xs[i] = float64(i * 10)
+		ys[i] = math.Sin(float64(i) / 10)
+	}
+	return xs, ys
+}
+
+func TestSelect(t *testing.T) {
+	xs, ys := series(1000)
+	// A spike that every algorithm but last should keep.
+	ys[555] = 100
+
+	for _, tc := range []struct {
+		algorithm Algorithm
+		keepSpike bool
+	}{
+		{LTTB, true},
+		{MinMax, true},
+		{Last, false},
+	} {
+		indices := Select(tc.algorithm, xs, [][]float64{ys}, 100)
+		assert.Assert(t, len(indices) <= 100, tc.algorithm)
+		assert.Assert(t, len(indices) >= 50, tc.algorithm)
+		for i := 1; i < len(indices); i++ {
+			assert.Assert(t, indices[i-1] < indices[i], tc.algorithm)
+		}
+		var spike bool
+		for _, i := range indices {
+			spike = spike || i == 555
+		}
+		assert.Equal(t, spike, tc.keepSpike, tc.algorithm)
+		// Downsampling is deterministic.
+		assert.DeepEqual(t, Select(tc.algorithm, xs, [][]float64{ys}, 100), indices)
+	}
+
+	lttbIndices := Select(LTTB, xs, [][]float64{ys}, 100)
+	assert.Equal(t, lttbIndices[0], 0)
+	assert.Equal(t, lttbIndices[len(lttbIndices)-1], 999)
+	assert.Equal(t, Select(Last, xs, nil, 100)[99], 999)
+
+	// Short series keep every point and random sampling keeps the same points every time.
+	assert.Equal(t, len(Select(LTTB, xs[:50], [][]float64{ys[:50]}, 100)), 50)
+	random := Select(Random, xs, [][]float64{ys}, 100)
+	assert.Equal(t, len(random), 100)
+	assert.DeepEqual(t, random, Select(Random, xs, [][]float64{ys}, 100))
+}
+
+func TestSelectMissingValues(t *testing.T) {
+	xs, ys := series(1000)
+	sparse := make([]float64, len(xs))
+	for i := range sparse {
+		sparse[i] = math.NaN()
+		if i%100 == 0 {
+			sparse[i] = float64(i)
+		}
+	}
+	indices := Select(LTTB, xs, [][]float64{ys, sparse}, 100)
+	// Every point of the sparse metric is kept, along with the points of the dense one.
+	kept := map[int]bool{}
+	for _, i := range indices {
+		kept[i] = true
+	}
+	for i := 0; i < 1000; i += 100 {
+		assert.Assert(t, kept[i], i)
+	}
+	assert.Assert(t, len(indices) <= 100)
+}
+
+func TestSelectManyMetrics(t *testing.T) {
+	xs, _ := series(1000)
+	// Metrics with spikes at different points would each keep their own points.
+	ys := make([][]float64, 10)
+	for m := range ys {
+		_, ys[m] = series(1000)
+		for i := range ys[m] {
+			ys[m][i] += float64(m * i % 7)
+		}
+	}
+	for _, algorithm := range []Algorithm{LTTB, MinMax} {
+		indices := Select(algorithm, xs, ys, 100)
+		assert.Assert(t, len(indices) <= 100, algorithm)
+		assert.Assert(t, len(indices) >= 50, algorithm)
+	}
+	// With more metrics than points, the last point of each bucket is kept.
+	assert.Equal(t, len(Select(LTTB, xs, ys, 5)), 5)
+}
+
+func TestAlgorithmValidate(t *testing.T) {
+	assert.Equal(t, len(LTTB.Validate()), 0)
+	assert.Equal(t, len(Algorithm("median").Validate()), 1)
+}
@@ -52,7 +52,7 @@ func makeOutputConfig(
 	tlsConfig model.TLSClientConfig,
 ) {
 	switch {
-	case loggingConfig.DefaultLoggingConfig != nil:
+	case loggingConfig.DefaultLoggingConfig != nil, loggingConfig.LokiLoggingConfig != nil:
 		// HACK: If a host resolves to both IPv4 and IPv6 addresses, Fluent Bit seems to only try IPv6 and
 		// fail if that connection doesn't work. IPv6 doesn't play well with Docker and many Linux
 		// distributions ship with an `/etc/hosts` that maps "localhost" to both 127.0.0.1 (IPv4) and
@@ -79,8 +79,15 @@ func makeOutputConfig(
   storage.total_limit_size 1G
 `, masterHost, masterPort)
 
-		if c := loggingConfig.DefaultLoggingConfig.AdditionalFluentOutputs; c != nil {
-			fmt.Fprint(config, *c)
+		var additionalOutputs *string
+		if c := loggingConfig.DefaultLoggingConfig; c != nil {
+			additionalOutputs = c.AdditionalFluentOutputs
+		} else {
+			// The master stores logs in Loki itself, so containers ship them as by default.
+			additionalOutputs = loggingConfig.LokiLoggingConfig.AdditionalFluentOutputs
+		}
+		if additionalOutputs != nil {
+			fmt.Fprint(config, *additionalOutputs)
 		}
 
 	case loggingConfig.ElasticLoggingConfig != nil:
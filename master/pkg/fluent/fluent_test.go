@@ -35,6 +35,13 @@ func TestAdditionalOutputConfig(t *testing.T) {
 				AdditionalFluentOutputs: ptrs.Ptr(additionalFluentOutputs),
 			},
 		},
+		{
+			LokiLoggingConfig: &model.LokiLoggingConfig{
+				Host:                    "test",
+				Port:                    3100,
+				AdditionalFluentOutputs: ptrs.Ptr(additionalFluentOutputs),
+			},
+		},
 	}
 
 	for _, loggingConfig := range loggingConfigs {
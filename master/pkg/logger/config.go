@@ -18,6 +18,60 @@ func DefaultConfig() *Config {
 type Config struct {
 	Level string `json:"level"`
 	Color bool   `json:"color"`
+	// Persistence keeps master logs beyond the in-memory buffer, which loses them on restart.
+	Persistence *PersistenceConfig `json:"persistence"`
+}
+
+const (
+	// PersistenceTypeFile persists master logs to a rotating file.
+	PersistenceTypeFile = "file"
+	// PersistenceTypePostgres persists master logs to the master_logs table.
+	PersistenceTypePostgres = "postgres"
+)
+
+// PersistenceConfig configures where master logs are persisted.
+type PersistenceConfig struct {
+	Type string `json:"type"`
+	// Path, MaxSizeMB and MaxBackups configure the file of the file type. The file is rotated
+	// once it exceeds MaxSizeMB, keeping MaxBackups old files.
+	Path       string `json:"path"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxBackups int    `json:"max_backups"`
+	// RetentionDays is how long the postgres type keeps logs.
+	RetentionDays int `json:"retention_days"`
+}
+
+// WithDefaults returns the config with unset limits defaulted.
+func (c PersistenceConfig) WithDefaults() PersistenceConfig {
+	if c.MaxSizeMB == 0 {
+		c.MaxSizeMB = 100
+	}
+	if c.MaxBackups == 0 {
+		c.MaxBackups = 5
+	}
+	if c.RetentionDays == 0 {
+		c.RetentionDays = 7
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c PersistenceConfig) Validate() []error {
+	var errs []error
+	switch c.Type {
+	case PersistenceTypeFile:
+		if c.Path == "" {
+			errs = append(errs, fmt.Errorf("log.persistence.path is required for the file type"))
+		}
+	case PersistenceTypePostgres:
+	default:
+		errs = append(errs, fmt.Errorf("log.persistence.type must be file or postgres"))
+	}
+	if c.MaxSizeMB < 0 || c.MaxBackups < 0 || c.RetentionDays < 0 {
+		errs = append(errs, fmt.Errorf(
+			"log.persistence max_size_mb, max_backups and retention_days must be non-negative"))
+	}
+	return errs
 }
 
 // Validate implements the check.Validatable interface.
@@ -73,6 +73,54 @@ type Entry struct {
 	Message string       `json:"message"`
 	Time    time.Time    `json:"time"`
 	Level   logrus.Level `json:"level"`
+	// Component is the component field of the entry, if it has one.
+	Component string `json:"component,omitempty"`
+}
+
+// NewEntry captures a logrus.Entry.
+func NewEntry(entry *logrus.Entry) *Entry {
+	e := &Entry{
+		Message: logrusMessageAndData(entry),
+		Time:    entry.Time,
+		Level:   entry.Level,
+	}
+	if component, ok := entry.Data["component"]; ok {
+		e.Component = fmt.Sprint(component)
+	}
+	return e
+}
+
+// EntryFilter selects log entries. Unset fields match every entry.
+type EntryFilter struct {
+	// Level matches entries at least as severe as it.
+	Level     *logrus.Level
+	Component string
+	Since     *time.Time
+	Until     *time.Time
+	// Text matches entries containing it, ignoring case.
+	Text string
+	// AfterID matches entries with a greater ID.
+	AfterID *int
+}
+
+// Matches reports whether the entry passes the filter.
+func (f EntryFilter) Matches(e *Entry) bool {
+	switch {
+	case f.Level != nil && e.Level > *f.Level:
+		return false
+	case f.Component != "" && e.Component != f.Component:
+		return false
+	case f.Since != nil && e.Time.Before(*f.Since):
+		return false
+	case f.Until != nil && !e.Time.Before(*f.Until):
+		return false
+	case f.Text != "" && !strings.Contains(strings.ToLower(e.Message), strings.ToLower(f.Text)):
+		return false
+	case f.AfterID != nil && e.ID <= *f.AfterID:
+		return false
+	default:
+		return true
+	}
 }
 
 // EntriesBatch is a batch of logger.Entry.
@@ -156,11 +204,7 @@ func (lb *LogBuffer) Len() int {
 
 // Fire implements the logrus.Hook interface.
 func (lb *LogBuffer) Fire(entry *logrus.Entry) error {
-	lb.write(&Entry{
-		Message: logrusMessageAndData(entry),
-		Time:    entry.Time,
-		Level:   entry.Level,
-	})
+	lb.write(NewEntry(entry))
 	return nil
 }
 
@@ -188,3 +232,23 @@ func LogrusLevelToProto(l logrus.Level) logv1.LogLevel {
 		panic("invalid logrus log level")
 	}
 }
+
+// ProtoLevelToLogrus translates one of our protobuf log levels to a logrus level.
+func ProtoLevelToLogrus(l logv1.LogLevel) (logrus.Level, error) {
+	switch l {
+	case logv1.LogLevel_LOG_LEVEL_TRACE:
+		return logrus.TraceLevel, nil
+	case logv1.LogLevel_LOG_LEVEL_DEBUG:
+		return logrus.DebugLevel, nil
+	case logv1.LogLevel_LOG_LEVEL_INFO:
+		return logrus.InfoLevel, nil
+	case logv1.LogLevel_LOG_LEVEL_WARNING:
+		return logrus.WarnLevel, nil
+	case logv1.LogLevel_LOG_LEVEL_ERROR:
+		return logrus.ErrorLevel, nil
+	case logv1.LogLevel_LOG_LEVEL_CRITICAL:
+		return logrus.FatalLevel, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %s", l)
+	}
+}
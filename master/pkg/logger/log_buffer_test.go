@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -267,3 +268,38 @@ func TestEntryFieldInclusion(t *testing.T) {
 	savedEntry = buffer.Entries(-1, -1, -1)[1]
 	assert.Equal(t, savedEntry.Message, originalEntry.Message+`  keyA="my great \"quote\""`)
 }
+
+func TestEntryFilter(t *testing.T) {
+	now := time.Now()
+	e := NewEntry(&logrus.Entry{
+		Message: "Scheduled Allocation",
+		Time:    now,
+		Level:   logrus.InfoLevel,
+		Data:    logrus.Fields{"component": "resource-pool"},
+	})
+	assert.Equal(t, e.Component, "resource-pool")
+
+	warn, debug := logrus.WarnLevel, logrus.DebugLevel
+	later := now.Add(time.Second)
+	e.ID = 5
+	before, at := 4, 5
+	for _, tc := range []struct {
+		filter  EntryFilter
+		matches bool
+	}{
+		{EntryFilter{}, true},
+		{EntryFilter{Level: &debug}, true},
+		{EntryFilter{Level: &warn}, false},
+		{EntryFilter{Component: "resource-pool"}, true},
+		{EntryFilter{Component: "agent"}, false},
+		{EntryFilter{Since: &now, Until: &later}, true},
+		{EntryFilter{Since: &later}, false},
+		{EntryFilter{Until: &now}, false},
+		{EntryFilter{Text: "scheduled allocation"}, true},
+		{EntryFilter{Text: "killed"}, false},
+		{EntryFilter{AfterID: &before}, true},
+		{EntryFilter{AfterID: &at}, false},
+	} {
+		assert.Equal(t, tc.filter.Matches(e), tc.matches, "%+v", tc.filter)
+	}
+}
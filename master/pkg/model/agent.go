@@ -21,6 +21,47 @@ type AgentSummary struct {
 	Enabled        bool         `json:"enabled"`
 	Draining       bool         `json:"draining"`
 	Version        string       `json:"version"`
+	Label          string       `json:"label"`
+	// Maintenance is the agent's scheduled maintenance, if any.
+	Maintenance *AgentMaintenance `json:"maintenance,omitempty"`
+}
+
+// AgentMaintenancePhase is how far along an agent's maintenance is.
+type AgentMaintenancePhase string
+
+const (
+	// MaintenanceScheduled means the maintenance has not started yet.
+	MaintenanceScheduled AgentMaintenancePhase = "SCHEDULED"
+	// MaintenanceDraining means the agent is drained, its preemptible tasks were asked to
+	// checkpoint and the master is waiting for the rest of its tasks to finish.
+	MaintenanceDraining AgentMaintenancePhase = "DRAINING"
+	// MaintenanceTerminating means the deadline passed and the remaining tasks are being killed.
+	MaintenanceTerminating AgentMaintenancePhase = "TERMINATING"
+	// MaintenanceSafeToReboot means no tasks are left on the agent.
+	MaintenanceSafeToReboot AgentMaintenancePhase = "SAFE_TO_REBOOT"
+	// MaintenanceCanceled means the maintenance was canceled and the agent is back in service.
+	MaintenanceCanceled AgentMaintenancePhase = "CANCELED"
+)
+
+// AgentMaintenance is a maintenance window of an agent: at StartTime, the agent is drained and
+// its tasks are moved off of it; tasks still running at Deadline are killed.
+type AgentMaintenance struct {
+	Phase          AgentMaintenancePhase `json:"phase"`
+	StartTime      time.Time             `json:"start_time"`
+	Deadline       time.Time             `json:"deadline"`
+	NumContainers  int                   `json:"num_containers"`
+	PhaseChangedAt time.Time             `json:"phase_changed_at"`
+}
+
+// ToProto converts an agent maintenance to a proto struct.
+func (m AgentMaintenance) ToProto() *agentv1.AgentMaintenance {
+	return &agentv1.AgentMaintenance{
+		Phase:          string(m.Phase),
+		StartTime:      protoutils.ToTimestamp(m.StartTime),
+		Deadline:       protoutils.ToTimestamp(m.Deadline),
+		NumContainers:  int32(m.NumContainers),
+		PhaseChangedAt: protoutils.ToTimestamp(m.PhaseChangedAt),
+	}
 }
 
 // ToProto converts an agent summary to a proto struct.
@@ -35,6 +76,11 @@ func (a AgentSummary) ToProto() *agentv1.Agent {
 		}
 	}
 
+	var maintenance *agentv1.AgentMaintenance
+	if a.Maintenance != nil {
+		maintenance = a.Maintenance.ToProto()
+	}
+
 	return &agentv1.Agent{
 		Id:             a.ID,
 		RegisteredTime: protoutils.ToTimestamp(a.RegisteredTime),
@@ -45,6 +91,8 @@ func (a AgentSummary) ToProto() *agentv1.Agent {
 		Enabled:        a.Enabled,
 		Draining:       a.Draining,
 		Version:        a.Version,
+		Label:          a.Label,
+		Maintenance:    maintenance,
 	}
 }
 
@@ -61,6 +109,8 @@ type SlotSummary struct {
 	Enabled   bool              `json:"enabled"`
 	Container *cproto.Container `json:"container"`
 	Draining  bool              `json:"draining"`
+	// DisabledReason is why the master disabled the slot on its own, if it did.
+	DisabledReason string `json:"disabled_reason,omitempty"`
 }
 
 // ToProto converts a SlotSummary to its protobuf representation.
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentSummaryToProtoMaintenance(t *testing.T) {
+	require.Nil(t, AgentSummary{ID: "agent"}.ToProto().Maintenance)
+
+	start := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	summary := AgentSummary{
+		ID:    "agent",
+		Label: "rack-1",
+		Maintenance: &AgentMaintenance{
+			Phase:          MaintenanceDraining,
+			StartTime:      start,
+			Deadline:       start.Add(time.Hour),
+			NumContainers:  2,
+			PhaseChangedAt: start,
+		},
+	}
+	agent := summary.ToProto()
+	require.Equal(t, "rack-1", agent.Label)
+	require.Equal(t, "DRAINING", agent.Maintenance.Phase)
+	require.Equal(t, start, agent.Maintenance.StartTime.AsTime())
+	require.Equal(t, start.Add(time.Hour), agent.Maintenance.Deadline.AsTime())
+	require.Equal(t, int32(2), agent.Maintenance.NumContainers)
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CheckpointCopyState is the state of a checkpoint copy job or of one of its checkpoints.
+type CheckpointCopyState string
+
+const (
+	// CheckpointCopyPending means the checkpoint has not been copied yet.
+	CheckpointCopyPending CheckpointCopyState = "PENDING"
+	// CheckpointCopyRunning means the job is still copying checkpoints.
+	CheckpointCopyRunning CheckpointCopyState = "RUNNING"
+	// CheckpointCopyCompleted means every checkpoint was copied, or this checkpoint was.
+	CheckpointCopyCompleted CheckpointCopyState = "COMPLETED"
+	// CheckpointCopyFailed means some checkpoints could not be copied, or this checkpoint could not.
+	CheckpointCopyFailed CheckpointCopyState = "FAILED"
+)
+
+// CheckpointCopyJob copies a set of checkpoints to another checkpoint storage. Once a checkpoint
+// is copied, the target storage becomes the one it is read from. Without a source, checkpoints
+// are read from the storage they currently reference.
+type CheckpointCopyJob struct {
+	bun.BaseModel `bun:"table:checkpoint_copy_jobs,alias:ccj"`
+
+	ID                int                 `bun:"id,pk,autoincrement" json:"id"`
+	State             CheckpointCopyState `bun:"state" json:"state"`
+	Source            json.RawMessage     `bun:"source,nullzero" json:"source"`
+	Target            json.RawMessage     `bun:"target" json:"target"`
+	RequestedBy       UserID              `bun:"requested_by" json:"requested_by"`
+	StartTime         time.Time           `bun:"start_time" json:"start_time"`
+	EndTime           *time.Time          `bun:"end_time" json:"end_time"`
+	TotalCheckpoints  int                 `bun:"total_checkpoints" json:"total_checkpoints"`
+	CopiedCheckpoints int                 `bun:"copied_checkpoints" json:"copied_checkpoints"`
+	FailedCheckpoints int                 `bun:"failed_checkpoints" json:"failed_checkpoints"`
+	TotalBytes        int64               `bun:"total_bytes" json:"total_bytes"`
+	CopiedBytes       int64               `bun:"copied_bytes" json:"copied_bytes"`
+	Error             *string             `bun:"error" json:"error"`
+}
+
+// CheckpointCopy is the progress of copying a single checkpoint as part of a CheckpointCopyJob.
+type CheckpointCopy struct {
+	bun.BaseModel `bun:"table:checkpoint_copies,alias:cc"`
+
+	JobID          int                 `bun:"job_id,pk" json:"job_id"`
+	CheckpointUUID uuid.UUID           `bun:"checkpoint_uuid,pk" json:"checkpoint_uuid"`
+	State          CheckpointCopyState `bun:"state" json:"state"`
+	Size           int64               `bun:"size" json:"size"`
+	EndTime        *time.Time          `bun:"end_time" json:"end_time"`
+	Error          *string             `bun:"error" json:"error"`
+}
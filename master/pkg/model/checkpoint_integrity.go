@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckpointIntegrity is the outcome of reading a checkpoint back from storage and comparing it
+// against what was reported.
+type CheckpointIntegrity string
+
+const (
+	// CheckpointUnverified means the checkpoint has not been read back yet.
+	CheckpointUnverified CheckpointIntegrity = "UNVERIFIED"
+	// CheckpointVerified means every reported file was found intact.
+	CheckpointVerified CheckpointIntegrity = "VERIFIED"
+	// CheckpointCorrupted means reported files were missing or did not match.
+	CheckpointCorrupted CheckpointIntegrity = "CORRUPTED"
+)
+
+// CheckpointIntegrityStatus is the integrity of a checkpoint as recorded in `checkpoints_v2`.
+type CheckpointIntegrityStatus struct {
+	UUID      uuid.UUID           `bun:"uuid" json:"uuid"`
+	Integrity CheckpointIntegrity `bun:"integrity" json:"integrity"`
+	CheckedAt *time.Time          `bun:"integrity_checked_at" json:"checked_at"`
+	Error     *string             `bun:"integrity_error" json:"error"`
+}
+
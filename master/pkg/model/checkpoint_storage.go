@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// CheckpointStorageScope is the level at which checkpoint storage usage is aggregated.
+type CheckpointStorageScope string
+
+const (
+	// CheckpointStorageScopeExperiment aggregates checkpoint storage usage per experiment.
+	CheckpointStorageScopeExperiment CheckpointStorageScope = "EXPERIMENT"
+	// CheckpointStorageScopeProject aggregates checkpoint storage usage per project.
+	CheckpointStorageScopeProject CheckpointStorageScope = "PROJECT"
+	// CheckpointStorageScopeWorkspace aggregates checkpoint storage usage per workspace.
+	CheckpointStorageScopeWorkspace CheckpointStorageScope = "WORKSPACE"
+	// CheckpointStorageScopeUser aggregates checkpoint storage usage per experiment owner.
+	CheckpointStorageScopeUser CheckpointStorageScope = "USER"
+)
+
+// ParseCheckpointStorageScope converts a case-insensitive string into a CheckpointStorageScope.
+func ParseCheckpointStorageScope(s string) (CheckpointStorageScope, error) {
+	switch scope := CheckpointStorageScope(strings.ToUpper(s)); scope {
+	case CheckpointStorageScopeExperiment, CheckpointStorageScopeProject,
+		CheckpointStorageScopeWorkspace, CheckpointStorageScopeUser:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("invalid checkpoint storage scope: %q", s)
+	}
+}
+
+// CheckpointStorageUsage represents a row from the `checkpoint_storage_usage` table.
+type CheckpointStorageUsage struct {
+	bun.BaseModel `bun:"table:checkpoint_storage_usage"`
+
+	Scope     CheckpointStorageScope `bun:"scope,pk" json:"scope"`
+	ScopeID   int                    `bun:"scope_id,pk" json:"scope_id"`
+	Size      int64                  `bun:"size" json:"size"`
+	Count     int64                  `bun:"count" json:"count"`
+	UpdatedAt time.Time              `bun:"updated_at" json:"updated_at"`
+}
+
+// CheckpointStorageUsageSample is the checkpoint storage usage of a scope at the end of a period.
+type CheckpointStorageUsageSample struct {
+	PeriodStart time.Time `bun:"period_start" json:"period_start"`
+	Size        int64     `bun:"size" json:"size"`
+	Count       int64     `bun:"count" json:"count"`
+}
@@ -620,6 +620,7 @@ type CheckpointV2 struct {
 	Resources     map[string]int64       `db:"resources"`
 	Metadata      map[string]interface{} `db:"metadata"`
 	Size          int64                  `db:"size"`
+	Checksums     map[string]string      `db:"checksums" bun:",nullzero"`
 }
 
 // CheckpointTrainingMetadata is a substruct of checkpoints encapsulating training specific
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ExperimentExportFormatVersion is the version of the experiment export format. Imports of other
+// versions are refused.
+const ExperimentExportFormatVersion = 1
+
+// ExperimentExport is everything needed to recreate an experiment's history on another cluster.
+// IDs in the export are those of the exporting cluster; they are remapped on import.
+type ExperimentExport struct {
+	FormatVersion     int       `json:"format_version"`
+	DeterminedVersion string    `json:"determined_version"`
+	ExportedAt        time.Time `json:"exported_at"`
+
+	Experiment  ExperimentExportRecord   `json:"experiment"`
+	Trials      []TrialExportRecord      `json:"trials"`
+	Metrics     []MetricsExportRecord    `json:"metrics"`
+	Checkpoints []CheckpointExportRecord `json:"checkpoints"`
+}
+
+// ExperimentExportRecord is the exported row of the `experiments` table. The model definition is
+// carried separately from the JSON document.
+type ExperimentExportRecord struct {
+	bun.BaseModel `bun:"table:experiments,alias:e"`
+
+	ID              int             `bun:"id,pk,autoincrement" json:"id"`
+	JobID           JobID           `bun:"job_id" json:"-"`
+	ProjectID       int             `bun:"project_id" json:"-"`
+	OwnerID         *UserID         `bun:"owner_id" json:"-"`
+	State           State           `bun:"state" json:"state"`
+	Config          json.RawMessage `bun:"config" json:"config"`
+	OriginalConfig  string          `bun:"original_config" json:"original_config"`
+	ModelDefinition []byte          `bun:"model_definition" json:"-"`
+	Notes           string          `bun:"notes" json:"notes"`
+	StartTime       time.Time       `bun:"start_time" json:"start_time"`
+	EndTime         *time.Time      `bun:"end_time" json:"end_time"`
+	Archived        bool            `bun:"archived" json:"archived"`
+	Progress        *float64        `bun:"progress" json:"progress"`
+	GitRemote       *string         `bun:"git_remote" json:"git_remote"`
+	GitCommit       *string         `bun:"git_commit" json:"git_commit"`
+	GitCommitter    *string         `bun:"git_committer" json:"git_committer"`
+	GitCommitDate   *time.Time      `bun:"git_commit_date" json:"git_commit_date"`
+	Unmanaged       bool            `bun:"unmanaged" json:"unmanaged"`
+}
+
+// TrialExportRecord is an exported row of the `trials` table.
+type TrialExportRecord struct {
+	bun.BaseModel `bun:"table:trials,alias:t"`
+
+	ID                   int             `bun:"id,pk,autoincrement" json:"id"`
+	TaskID               TaskID          `bun:"task_id" json:"-"`
+	ExperimentID         int             `bun:"experiment_id" json:"-"`
+	RequestID            *string         `bun:"request_id" json:"request_id"`
+	State                State           `bun:"state" json:"state"`
+	StartTime            time.Time       `bun:"start_time" json:"start_time"`
+	EndTime              *time.Time      `bun:"end_time" json:"end_time"`
+	HParams              json.RawMessage `bun:"hparams" json:"hparams"`
+	Seed                 int64           `bun:"seed" json:"seed"`
+	TotalBatches         int             `bun:"total_batches" json:"total_batches"`
+	Restarts             int             `bun:"restarts" json:"restarts"`
+	RunID                int             `bun:"run_id" json:"run_id"`
+	SummaryMetrics       json.RawMessage `bun:"summary_metrics" json:"summary_metrics"`
+	SummaryMetricsTS     *time.Time      `bun:"summary_metrics_timestamp" json:"summary_metrics_time"`
+	LatestValidationID   *int            `bun:"latest_validation_id" json:"latest_validation_id"`
+	BestValidationID     *int            `bun:"best_validation_id" json:"best_validation_id"`
+	SearcherMetric       *float64        `bun:"searcher_metric_value" json:"searcher_metric"`
+	SignedSearcherMetric *float64        `bun:"searcher_metric_value_signed" json:"signed_metric"`
+}
+
+// MetricsExportRecord is an exported row of the `metrics` table.
+type MetricsExportRecord struct {
+	bun.BaseModel `bun:"table:metrics,alias:m"`
+
+	ID            int             `bun:"id,pk,autoincrement" json:"id"`
+	TrialID       int             `bun:"trial_id" json:"trial_id"`
+	TrialRunID    int             `bun:"trial_run_id" json:"trial_run_id"`
+	EndTime       *time.Time      `bun:"end_time" json:"end_time"`
+	Metrics       json.RawMessage `bun:"metrics" json:"metrics"`
+	TotalBatches  int             `bun:"total_batches" json:"total_batches"`
+	PartitionType string          `bun:"partition_type" json:"partition_type"`
+	CustomType    *string         `bun:"custom_type" json:"custom_type"`
+}
+
+// CheckpointExportRecord is an exported row of the `checkpoints_v2` table.
+type CheckpointExportRecord struct {
+	bun.BaseModel `bun:"table:checkpoints_v2,alias:c"`
+
+	ID         int             `bun:"id,pk,autoincrement" json:"-"`
+	UUID       uuid.UUID       `bun:"uuid" json:"uuid"`
+	TaskID     TaskID          `bun:"task_id" json:"-"`
+	ReportTime time.Time       `bun:"report_time" json:"report_time"`
+	State      State           `bun:"state" json:"state"`
+	Resources  json.RawMessage `bun:"resources" json:"resources"`
+	Metadata   json.RawMessage `bun:"metadata" json:"metadata"`
+	Size       int64           `bun:"size" json:"size"`
+	Checksums  json.RawMessage `bun:"checksums,nullzero" json:"checksums"`
+	// Storage is the storage the checkpoint was copied to, if it is not in its experiment's.
+	Storage json.RawMessage `bun:"storage,nullzero" json:"storage,omitempty"`
+
+	TrialID int `bun:"trial_id,scanonly" json:"trial_id"`
+}
+
+// ExperimentImportResult maps the IDs in an ExperimentExport to those of the imported records.
+type ExperimentImportResult struct {
+	ExperimentID int                     `json:"experiment_id"`
+	TrialIDs     map[int]int             `json:"trial_ids"`
+	Checkpoints  map[uuid.UUID]uuid.UUID `json:"checkpoints"`
+}
@@ -7,9 +7,11 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/pkg/union"
 )
 
@@ -17,6 +19,7 @@ import (
 type LoggingConfig struct {
 	DefaultLoggingConfig *DefaultLoggingConfig `union:"type,default" json:"-"`
 	ElasticLoggingConfig *ElasticLoggingConfig `union:"type,elastic" json:"-"`
+	LokiLoggingConfig    *LokiLoggingConfig    `union:"type,loki" json:"-"`
 }
 
 // Resolve resolves the parts of the TaskContainerDefaultsConfig that must be evaluated on
@@ -28,6 +31,12 @@ func (c LoggingConfig) Resolve() error {
 			return err
 		}
 	}
+	if c.LokiLoggingConfig != nil {
+		err := c.LokiLoggingConfig.Resolve()
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -46,9 +55,131 @@ func (c *LoggingConfig) UnmarshalJSON(data []byte) error {
 	return errors.Wrap(json.Unmarshal(data, DefaultParser(c)), "failed to parse logging options")
 }
 
+// Printable returns a copy of the config with secrets hidden.
+func (c LoggingConfig) Printable() LoggingConfig {
+	if c.DefaultLoggingConfig != nil && c.DefaultLoggingConfig.Archive != nil {
+		printable := *c.DefaultLoggingConfig
+		archive := printable.Archive.Printable()
+		printable.Archive = &archive
+		c.DefaultLoggingConfig = &printable
+	}
+	if c.LokiLoggingConfig != nil && c.LokiLoggingConfig.Security.Password != nil {
+		printable := *c.LokiLoggingConfig
+		printable.Security.Password = ptrs.Ptr("********")
+		c.LokiLoggingConfig = &printable
+	}
+	return c
+}
+
 // DefaultLoggingConfig configures logging for tasks using Fluent+HTTP to the master.
 type DefaultLoggingConfig struct {
 	AdditionalFluentOutputs *string `json:"additional_fluent_outputs,omitempty"`
+	// Archive, if set, moves the logs of long finished tasks out of Postgres.
+	Archive *TaskLogArchiveConfig `json:"archive,omitempty"`
+}
+
+// TaskLogArchiveConfig configures compacting the logs of tasks that finished a while ago into
+// compressed objects in storage outside of Postgres.
+type TaskLogArchiveConfig struct {
+	// ArchiveAfter is how long after a task ends its logs are archived.
+	ArchiveAfter Duration `json:"archive_after"`
+	// Interval is how often to look for tasks whose logs should be archived.
+	Interval Duration `json:"interval"`
+	// BatchSize is the most tasks archived per interval.
+	BatchSize int                         `json:"batch_size"`
+	Storage   TaskLogArchiveStorageConfig `json:"storage"`
+}
+
+// UnmarshalJSON fills in the defaults of a TaskLogArchiveConfig.
+func (c *TaskLogArchiveConfig) UnmarshalJSON(data []byte) error {
+	*c = TaskLogArchiveConfig{
+		ArchiveAfter: Duration(30 * 24 * time.Hour),
+		Interval:     Duration(time.Hour),
+		BatchSize:    100,
+	}
+	type DefaultParser *TaskLogArchiveConfig
+	return errors.Wrap(json.Unmarshal(data, DefaultParser(c)), "failed to parse log archive options")
+}
+
+// Validate implements the check.Validatable interface.
+func (c TaskLogArchiveConfig) Validate() []error {
+	var errs []error
+	if c.ArchiveAfter <= 0 {
+		errs = append(errs, errors.New("archive_after must be positive"))
+	}
+	if c.Interval <= 0 {
+		errs = append(errs, errors.New("interval must be positive"))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, errors.New("batch_size must be positive"))
+	}
+	return errs
+}
+
+// Printable returns a copy of the config with secrets hidden.
+func (c TaskLogArchiveConfig) Printable() TaskLogArchiveConfig {
+	if c.Storage.S3 != nil && c.Storage.S3.SecretKey != nil {
+		printable := *c.Storage.S3
+		printable.SecretKey = ptrs.Ptr("********")
+		c.Storage.S3 = &printable
+	}
+	return c
+}
+
+// TaskLogArchiveStorageConfig configures where archived task logs are stored.
+type TaskLogArchiveStorageConfig struct {
+	SharedFS *SharedFSLogArchiveConfig `union:"type,shared_fs" json:"-"`
+	S3       *S3LogArchiveConfig       `union:"type,s3" json:"-"`
+}
+
+// MarshalJSON serializes TaskLogArchiveStorageConfig.
+func (c TaskLogArchiveStorageConfig) MarshalJSON() ([]byte, error) {
+	return union.Marshal(c)
+}
+
+// UnmarshalJSON deserializes TaskLogArchiveStorageConfig.
+func (c *TaskLogArchiveStorageConfig) UnmarshalJSON(data []byte) error {
+	if err := union.Unmarshal(data, c); err != nil {
+		return err
+	}
+
+	type DefaultParser *TaskLogArchiveStorageConfig
+	return errors.Wrap(json.Unmarshal(data, DefaultParser(c)), "failed to parse archive storage")
+}
+
+// SharedFSLogArchiveConfig stores archived task logs in a directory of the master's filesystem.
+type SharedFSLogArchiveConfig struct {
+	HostPath string `json:"host_path"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c SharedFSLogArchiveConfig) Validate() []error {
+	if c.HostPath == "" {
+		return []error{errors.New("host_path must be set")}
+	}
+	return nil
+}
+
+// S3LogArchiveConfig stores archived task logs in an S3-compatible bucket.
+type S3LogArchiveConfig struct {
+	Bucket      string  `json:"bucket"`
+	Prefix      string  `json:"prefix"`
+	Region      *string `json:"region,omitempty"`
+	EndpointURL *string `json:"endpoint_url,omitempty"`
+	AccessKey   *string `json:"access_key,omitempty"`
+	SecretKey   *string `json:"secret_key,omitempty"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c S3LogArchiveConfig) Validate() []error {
+	var errs []error
+	if c.Bucket == "" {
+		errs = append(errs, errors.New("bucket must be set"))
+	}
+	if (c.AccessKey != nil) != (c.SecretKey != nil) {
+		errs = append(errs, errors.New("access_key and secret_key must be specified together"))
+	}
+	return errs
 }
 
 // ElasticLoggingConfig configures logging for tasks using Fluent+Elastic.
@@ -85,6 +216,63 @@ func (o *ElasticSecurityConfig) Resolve() error {
 	return o.TLS.Resolve()
 }
 
+// LokiLoggingConfig configures logging for tasks using Fluent+HTTP to the master, which stores
+// them in Grafana Loki.
+type LokiLoggingConfig struct {
+	Host     string             `json:"host"`
+	Port     int                `json:"port"`
+	Security LokiSecurityConfig `json:"security"`
+	// TenantID is sent as the X-Scope-OrgID header to multi-tenant Loki clusters.
+	TenantID *string `json:"tenant_id,omitempty"`
+	// QueryLookback is how far back log queries look; it should not exceed the cluster's
+	// max_query_length.
+	QueryLookback           Duration `json:"query_lookback"`
+	AdditionalFluentOutputs *string  `json:"additional_fluent_outputs,omitempty"`
+}
+
+// UnmarshalJSON fills in the defaults of a LokiLoggingConfig.
+func (o *LokiLoggingConfig) UnmarshalJSON(data []byte) error {
+	*o = LokiLoggingConfig{
+		Port:          3100,
+		QueryLookback: Duration(30 * 24 * time.Hour),
+	}
+	type DefaultParser *LokiLoggingConfig
+	return errors.Wrap(json.Unmarshal(data, DefaultParser(o)), "failed to parse loki options")
+}
+
+// Validate implements the check.Validatable interface.
+func (o LokiLoggingConfig) Validate() []error {
+	var errs []error
+	if o.Host == "" {
+		errs = append(errs, errors.New("host must be set"))
+	}
+	if o.QueryLookback <= 0 {
+		errs = append(errs, errors.New("query_lookback must be positive"))
+	}
+	return errs
+}
+
+// Resolve resolves the configuration.
+func (o *LokiLoggingConfig) Resolve() error {
+	return o.Security.TLS.Resolve()
+}
+
+// LokiSecurityConfig configures security-related options for the loki logging backend.
+type LokiSecurityConfig struct {
+	Username *string         `json:"username"`
+	Password *string         `json:"password"`
+	TLS      TLSClientConfig `json:"tls"`
+}
+
+// Validate implements the check.Validatable interface.
+func (o LokiSecurityConfig) Validate() []error {
+	var errs []error
+	if (o.Username != nil) != (o.Password != nil) {
+		errs = append(errs, errors.New("username and password must be specified together"))
+	}
+	return errs
+}
+
 // TLSClientConfig configures how to make a TLS connection.
 type TLSClientConfig struct {
 	Enabled         bool   `json:"enabled"`
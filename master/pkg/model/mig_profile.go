@@ -0,0 +1,25 @@
+package model
+
+import (
+	"regexp"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// migProfileRegexp matches the names of MIG profiles, such as 1g.10gb or 1g.10gb+me.
+var migProfileRegexp = regexp.MustCompile(`^\d+g\.\d+gb(\+[a-z0-9]+)?$`)
+
+// MIGProfileConfig is a MIG profile that agents partition each of their MIG-enabled GPUs into.
+type MIGProfileConfig struct {
+	Profile string `json:"profile"`
+	Count   int    `json:"count"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c MIGProfileConfig) Validate() []error {
+	return []error{
+		check.True(migProfileRegexp.MatchString(c.Profile),
+			"mig profile must look like 1g.10gb, got %q", c.Profile),
+		check.GreaterThan(c.Count, 0, "mig profile count must be > 0"),
+	}
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ModelVersionStage is the lifecycle stage of a model version.
+type ModelVersionStage string
+
+const (
+	// ModelVersionStageNone is the stage of newly registered model versions.
+	ModelVersionStageNone ModelVersionStage = "NONE"
+	// ModelVersionStageStaging marks a model version as a candidate for production.
+	ModelVersionStageStaging ModelVersionStage = "STAGING"
+	// ModelVersionStageProduction marks a model version as serving production traffic.
+	ModelVersionStageProduction ModelVersionStage = "PRODUCTION"
+	// ModelVersionStageArchived marks a model version as retired.
+	ModelVersionStageArchived ModelVersionStage = "ARCHIVED"
+)
+
+// modelVersionStageTransitions lists the stages each stage may move to.
+var modelVersionStageTransitions = map[ModelVersionStage][]ModelVersionStage{
+	ModelVersionStageNone: {ModelVersionStageStaging, ModelVersionStageArchived},
+	ModelVersionStageStaging: {
+		ModelVersionStageNone, ModelVersionStageProduction, ModelVersionStageArchived,
+	},
+	ModelVersionStageProduction: {ModelVersionStageStaging, ModelVersionStageArchived},
+	ModelVersionStageArchived:   {ModelVersionStageNone, ModelVersionStageStaging},
+}
+
+// ParseModelVersionStage converts a case-insensitive string into a ModelVersionStage.
+func ParseModelVersionStage(s string) (ModelVersionStage, error) {
+	stage := ModelVersionStage(strings.ToUpper(s))
+	if _, ok := modelVersionStageTransitions[stage]; !ok {
+		return "", fmt.Errorf("invalid model version stage: %q", s)
+	}
+	return stage, nil
+}
+
+// CanTransitionTo returns whether a model version may move from stage s to stage to.
+func (s ModelVersionStage) CanTransitionTo(to ModelVersionStage) bool {
+	for _, allowed := range modelVersionStageTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelVersionTransitionStatus is the state of a request to move a model version between stages.
+type ModelVersionTransitionStatus string
+
+const (
+	// ModelVersionTransitionPending means the transition is waiting on approval.
+	ModelVersionTransitionPending ModelVersionTransitionStatus = "PENDING"
+	// ModelVersionTransitionApplied means the model version moved to the requested stage.
+	ModelVersionTransitionApplied ModelVersionTransitionStatus = "APPLIED"
+	// ModelVersionTransitionRejected means a reviewer turned the transition down.
+	ModelVersionTransitionRejected ModelVersionTransitionStatus = "REJECTED"
+)
+
+// ModelVersionTransition represents a row from the `model_version_transitions` table.
+type ModelVersionTransition struct {
+	bun.BaseModel `bun:"table:model_version_transitions,alias:mvt"`
+
+	ID             int                          `bun:"id,pk,autoincrement" json:"id"`
+	ModelVersionID int                          `bun:"model_version_id" json:"model_version_id"`
+	FromStage      ModelVersionStage            `bun:"from_stage" json:"from_stage"`
+	ToStage        ModelVersionStage            `bun:"to_stage" json:"to_stage"`
+	Status         ModelVersionTransitionStatus `bun:"status" json:"status"`
+	RequestedBy    UserID                       `bun:"requested_by" json:"requested_by"`
+	RequestedAt    time.Time                    `bun:"requested_at" json:"requested_at"`
+	Comment        string                       `bun:"comment" json:"comment"`
+	ReviewedBy     *UserID                      `bun:"reviewed_by" json:"reviewed_by"`
+	ReviewedAt     *time.Time                   `bun:"reviewed_at" json:"reviewed_at"`
+	ReviewComment  string                       `bun:"review_comment" json:"review_comment"`
+
+	ModelID             int     `bun:"model_id,scanonly" json:"model_id"`
+	ModelVersionNum     int     `bun:"model_version_num,scanonly" json:"model_version_num"`
+	RequestedByUsername string  `bun:"requested_by_username,scanonly" json:"requested_by_username"`
+	ReviewedByUsername  *string `bun:"reviewed_by_username,scanonly" json:"reviewed_by_username"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// SlotResourceLimitsConfig configures the CPU, memory and shared memory limits that agents give
+// each container in proportion to the fraction of the agent's slots it holds.
+type SlotResourceLimitsConfig struct {
+	Enabled bool `json:"enabled"`
+	// ReservedCPUs and ReservedMemoryMB are kept for the system and not given to containers.
+	ReservedCPUs     int `json:"reserved_cpus"`
+	ReservedMemoryMB int `json:"reserved_memory_mb"`
+	// ShmFraction is the fraction of its memory limit a container gets as shared memory, unless
+	// the task sets its own shared memory size. Zero leaves the shared memory size alone.
+	ShmFraction float64 `json:"shm_fraction"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c SlotResourceLimitsConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.ReservedCPUs, 0, "reserved_cpus must be >= 0"),
+		check.GreaterThanOrEqualTo(c.ReservedMemoryMB, 0, "reserved_memory_mb must be >= 0"),
+		check.GreaterThanOrEqualTo(c.ShmFraction, 0.0, "shm_fraction must be >= 0"),
+		check.LessThanOrEqualTo(c.ShmFraction, 1.0, "shm_fraction must be <= 1"),
+	}
+}
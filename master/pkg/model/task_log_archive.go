@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// TaskLogArchive records that the logs of a task were moved out of Postgres into an object in
+// archive storage. Logs with IDs up to MaxLogID are in the archive; any later logs stay in
+// Postgres.
+type TaskLogArchive struct {
+	bun.BaseModel `bun:"table:task_log_archives,alias:tla"`
+
+	TaskID     TaskID    `bun:"task_id,pk" json:"task_id"`
+	Key        string    `bun:"key" json:"key"`
+	LogCount   int       `bun:"log_count" json:"log_count"`
+	MaxLogID   int64     `bun:"max_log_id" json:"max_log_id"`
+	Size       int64     `bun:"size" json:"size"`
+	ArchivedAt time.Time `bun:"archived_at" json:"archived_at"`
+}
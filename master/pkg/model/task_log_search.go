@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	// MaxTaskLogSearchLimit is the most matches a task log search returns.
+	MaxTaskLogSearchLimit = 1000
+	// MaxTaskLogSearchContext is the most context lines returned on each side of a match.
+	MaxTaskLogSearchContext = 10
+)
+
+// TaskLogSearchMode is how the query of a task log search is interpreted.
+type TaskLogSearchMode string
+
+const (
+	// TaskLogSearchText matches logs containing every word of the query.
+	TaskLogSearchText TaskLogSearchMode = "text"
+	// TaskLogSearchRegex matches logs containing a match of the query as a regular expression.
+	TaskLogSearchRegex TaskLogSearchMode = "regex"
+)
+
+// ParseTaskLogSearchMode parses a task log search mode, defaulting to text.
+func ParseTaskLogSearchMode(s string) (TaskLogSearchMode, error) {
+	switch m := TaskLogSearchMode(s); m {
+	case "":
+		return TaskLogSearchText, nil
+	case TaskLogSearchText, TaskLogSearchRegex:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid search mode %q, must be text or regex", s)
+	}
+}
+
+// TaskLogSearch is a search for logs matching a query across a set of tasks.
+type TaskLogSearch struct {
+	Query   string
+	Mode    TaskLogSearchMode
+	TaskIDs []TaskID
+	// Since and Until, if set, bound the timestamps of matching logs, exclusive and inclusive
+	// respectively.
+	Since *time.Time
+	Until *time.Time
+	// ContextLines is the number of logs from the same task and rank returned before and after
+	// each match.
+	ContextLines int
+	Limit        int
+}
+
+// Validate checks a task log search.
+func (s TaskLogSearch) Validate() error {
+	if s.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	if s.Mode == TaskLogSearchRegex {
+		// Backends use their own regular expression engines, but rejecting what Go can't parse
+		// catches most mistakes with a better error.
+		if _, err := regexp.Compile(s.Query); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+	}
+	if s.Since != nil && s.Until != nil && !s.Since.Before(*s.Until) {
+		return fmt.Errorf("since must be before until")
+	}
+	if s.ContextLines < 0 || s.ContextLines > MaxTaskLogSearchContext {
+		return fmt.Errorf("context must be between 0 and %d", MaxTaskLogSearchContext)
+	}
+	if s.Limit <= 0 || s.Limit > MaxTaskLogSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTaskLogSearchLimit)
+	}
+	return nil
+}
+
+// TaskLogSearchMatch is a log that matched a task log search, along with its context.
+type TaskLogSearchMatch struct {
+	Log *TaskLog
+	// Before and After are logs of the same task and rank around the match, oldest first.
+	Before []*TaskLog
+	After  []*TaskLog
+}
@@ -216,6 +216,8 @@ type ResourcesConfigV0 struct {
 	RawShmSize        *int     `json:"shm_size"`
 	RawResourcePool   *string  `json:"resource_pool"`
 	RawPriority       *int     `json:"priority"`
+	// MIGProfile is the MIG profile of the slots the task runs on, such as 1g.10gb.
+	RawMIGProfile *string `json:"mig_profile"`
 
 	RawDevices DevicesConfigV0 `json:"devices"`
 }
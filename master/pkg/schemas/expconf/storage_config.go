@@ -14,6 +14,9 @@ import (
 const (
 	// DefaultSharedFSContainerPath is the base storage path inside containers for SharedFS storage.
 	DefaultSharedFSContainerPath = "/determined_shared_fs"
+	// LatestCheckpointSharedFSContainerPath is where a trial finds the SharedFS storage that its
+	// latest checkpoint was copied to, when that is not its own checkpoint storage.
+	LatestCheckpointSharedFSContainerPath = "/determined_latest_checkpoint_shared_fs"
 	// DefaultSharedFSPropagation is the propagation setting for SharedFS storage.
 	DefaultSharedFSPropagation = "rprivate"
 )
@@ -120,6 +123,17 @@ func (s SharedFSConfigV0) PathInContainer() string {
 	return filepath.Join(DefaultSharedFSContainerPath, *s.RawStoragePath)
 }
 
+// PathInHost calculates where the full StoragePath will be on the host.
+func (s SharedFSConfigV0) PathInHost() string {
+	if s.RawStoragePath == nil {
+		return *s.RawHostPath
+	}
+	if filepath.IsAbs(*s.RawStoragePath) {
+		return *s.RawStoragePath
+	}
+	return filepath.Join(*s.RawHostPath, *s.RawStoragePath)
+}
+
 // S3ConfigV0 configures storing checkpoints on S3.
 //
 //go:generate ../gen.sh
@@ -1663,6 +1663,18 @@ var (
             ],
             "default": null
         },
+        "mig_profile": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "checks": {
+                "must be a MIG profile such as 1g.10gb": {
+                    "pattern": "^[0-9]+g\\.[0-9]+gb(\\+[a-z0-9]+)?$"
+                }
+            },
+            "default": null
+        },
         "native_parallel": {
             "type": [
                 "boolean",
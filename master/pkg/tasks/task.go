@@ -289,6 +289,7 @@ func (t *TaskSpec) ToDockerSpec() cproto.Spec {
 			UseFluentLogging: true,
 			DeviceType:       deviceType,
 			Registry:         env.RegistryAuth(),
+			ShmSizeUnset:     t.ShmSize == 0,
 		},
 	}
 
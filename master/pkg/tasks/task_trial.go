@@ -2,7 +2,9 @@ package tasks
 
 import (
 	"archive/tar"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
 
 	"github.com/docker/docker/api/types/mount"
@@ -120,8 +122,12 @@ func (s TrialSpec) ToTaskSpec() TaskSpec {
 		"DET_STEPS_COMPLETED":   strconv.Itoa(s.StepsCompleted),
 		"DET_TASK_TYPE":         string(model.TaskTypeTrial),
 	}
+	latestStorage := s.latestCheckpointStorage()
 	if s.LatestCheckpoint != nil && s.LatestCheckpoint.UUID != nil {
 		envVars["DET_LATEST_CHECKPOINT"] = s.LatestCheckpoint.UUID.String()
+		if latestStorage != nil {
+			envVars["DET_LATEST_CHECKPOINT_STORAGE"] = jsonify(*latestStorage)
+		}
 	}
 
 	res.ExtraEnvVars = envVars
@@ -147,12 +153,42 @@ func (s TrialSpec) ToTaskSpec() TaskSpec {
 			&mount.BindOptions{Propagation: expconf.DefaultSharedFSPropagation},
 		)
 	}
+	if latestStorage != nil && latestStorage.RawSharedFSConfig != nil {
+		addMount(
+			latestStorage.RawSharedFSConfig.HostPath(),
+			expconf.LatestCheckpointSharedFSContainerPath,
+			&mount.BindOptions{Propagation: expconf.DefaultSharedFSPropagation},
+		)
+	}
 	res.Mounts = mounts
 	res.TaskType = model.TaskTypeTrial
 
 	return res
 }
 
+// latestCheckpointStorage returns the storage of the latest checkpoint if it is not the trial's
+// own checkpoint storage, as is the case for checkpoints copied to other storage.
+func (s TrialSpec) latestCheckpointStorage() *expconf.CheckpointStorageConfig {
+	if s.LatestCheckpoint == nil || s.LatestCheckpoint.ExperimentConfig == nil {
+		return nil
+	}
+	raw, err := json.Marshal(s.LatestCheckpoint.ExperimentConfig)
+	if err != nil {
+		return nil
+	}
+	legacy, err := expconf.ParseLegacyConfigJSON(raw)
+	if err != nil {
+		return nil
+	}
+	storage := legacy.CheckpointStorage
+	if reflect.DeepEqual(
+		storage.GetUnionMember(), s.ExperimentConfig.CheckpointStorage().GetUnionMember(),
+	) {
+		return nil
+	}
+	return &storage
+}
+
 // MakeEnvPorts fills in `Environment.Ports` i.e. exposed ports for container config.
 func (s *TrialSpec) MakeEnvPorts() expconf.EnvironmentConfigV0 {
 	ppc := s.ProxyPorts()
@@ -0,0 +1,3 @@
+DROP TABLE public.checkpoint_storage_usage_history;
+DROP TABLE public.checkpoint_storage_usage;
+DROP TYPE public.checkpoint_storage_scope;
@@ -0,0 +1,46 @@
+CREATE TYPE public.checkpoint_storage_scope AS ENUM (
+    'EXPERIMENT',
+    'PROJECT',
+    'WORKSPACE',
+    'USER'
+);
+
+CREATE TABLE public.checkpoint_storage_usage (
+    scope public.checkpoint_storage_scope NOT NULL,
+    scope_id integer NOT NULL,
+    size bigint NOT NULL DEFAULT 0,
+    count bigint NOT NULL DEFAULT 0,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (scope, scope_id)
+);
+
+CREATE TABLE public.checkpoint_storage_usage_history (
+    id bigserial PRIMARY KEY,
+    scope public.checkpoint_storage_scope NOT NULL,
+    scope_id integer NOT NULL,
+    size bigint NOT NULL,
+    count bigint NOT NULL,
+    recorded_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ix_checkpoint_storage_usage_history_scope_recorded_at
+    ON public.checkpoint_storage_usage_history USING btree (scope, scope_id, recorded_at);
+
+INSERT INTO public.checkpoint_storage_usage (scope, scope_id, size, count)
+SELECT 'EXPERIMENT', e.id, e.checkpoint_size, e.checkpoint_count
+FROM public.experiments e
+UNION ALL
+SELECT 'PROJECT', e.project_id, SUM(e.checkpoint_size), SUM(e.checkpoint_count)
+FROM public.experiments e
+GROUP BY e.project_id
+UNION ALL
+SELECT 'WORKSPACE', p.workspace_id, SUM(e.checkpoint_size), SUM(e.checkpoint_count)
+FROM public.experiments e JOIN public.projects p ON e.project_id = p.id
+GROUP BY p.workspace_id
+UNION ALL
+SELECT 'USER', e.owner_id, SUM(e.checkpoint_size), SUM(e.checkpoint_count)
+FROM public.experiments e
+GROUP BY e.owner_id;
+
+INSERT INTO public.checkpoint_storage_usage_history (scope, scope_id, size, count, recorded_at)
+SELECT scope, scope_id, size, count, updated_at FROM public.checkpoint_storage_usage;
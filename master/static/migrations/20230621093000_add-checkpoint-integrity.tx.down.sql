@@ -0,0 +1,7 @@
+ALTER TABLE public.checkpoints_v2
+    DROP COLUMN checksums,
+    DROP COLUMN integrity,
+    DROP COLUMN integrity_checked_at,
+    DROP COLUMN integrity_error;
+
+DROP TYPE public.checkpoint_integrity;
@@ -0,0 +1,14 @@
+CREATE TYPE public.checkpoint_integrity AS ENUM (
+    'UNVERIFIED',
+    'VERIFIED',
+    'CORRUPTED'
+);
+
+ALTER TABLE public.checkpoints_v2
+    ADD COLUMN checksums jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN integrity public.checkpoint_integrity NOT NULL DEFAULT 'UNVERIFIED',
+    ADD COLUMN integrity_checked_at timestamptz NULL,
+    ADD COLUMN integrity_error text NULL;
+
+CREATE INDEX ix_checkpoints_v2_integrity ON public.checkpoints_v2 USING btree (integrity)
+    WHERE integrity != 'VERIFIED';
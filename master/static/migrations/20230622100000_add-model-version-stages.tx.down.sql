@@ -0,0 +1,8 @@
+DROP TABLE public.model_version_transitions;
+
+ALTER TABLE public.model_versions
+    DROP COLUMN stage,
+    DROP COLUMN stage_changed_at;
+
+DROP TYPE public.model_version_transition_status;
+DROP TYPE public.model_version_stage;
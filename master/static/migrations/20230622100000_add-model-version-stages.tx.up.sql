@@ -0,0 +1,36 @@
+CREATE TYPE public.model_version_stage AS ENUM (
+    'NONE',
+    'STAGING',
+    'PRODUCTION',
+    'ARCHIVED'
+);
+
+CREATE TYPE public.model_version_transition_status AS ENUM (
+    'PENDING',
+    'APPLIED',
+    'REJECTED'
+);
+
+ALTER TABLE public.model_versions
+    ADD COLUMN stage public.model_version_stage NOT NULL DEFAULT 'NONE',
+    ADD COLUMN stage_changed_at timestamptz NULL;
+
+CREATE TABLE public.model_version_transitions (
+    id serial PRIMARY KEY,
+    model_version_id integer NOT NULL REFERENCES public.model_versions(id) ON DELETE CASCADE,
+    from_stage public.model_version_stage NOT NULL,
+    to_stage public.model_version_stage NOT NULL,
+    status public.model_version_transition_status NOT NULL,
+    requested_by integer NOT NULL REFERENCES public.users(id),
+    requested_at timestamptz NOT NULL DEFAULT now(),
+    comment text NOT NULL DEFAULT '',
+    reviewed_by integer NULL REFERENCES public.users(id),
+    reviewed_at timestamptz NULL,
+    review_comment text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX ix_model_version_transitions_model_version_id
+    ON public.model_version_transitions USING btree (model_version_id);
+CREATE UNIQUE INDEX ix_model_version_transitions_one_pending
+    ON public.model_version_transitions USING btree (model_version_id)
+    WHERE status = 'PENDING';
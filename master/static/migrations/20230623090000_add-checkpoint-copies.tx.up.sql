@@ -0,0 +1,75 @@
+-- A checkpoint copied to other storage records that storage, which then takes the place of the
+-- experiment's checkpoint storage wherever the checkpoint is read from.
+ALTER TABLE public.checkpoints_v2
+    ADD COLUMN storage jsonb NULL;
+
+-- Mostly copied from /migrations/20230306115327_add-checkpoint-size.tx.up.sql
+CREATE OR REPLACE VIEW public.checkpoints_new_view AS
+    SELECT
+        c.id AS id,
+        c.uuid AS uuid,
+        c.task_id,
+        c.allocation_id,
+        c.report_time,
+        c.state,
+        c.resources,
+        c.metadata,
+        t.id AS trial_id,
+        e.id AS experiment_id,
+        CASE
+        WHEN c.storage IS NULL THEN
+            e.config
+        ELSE
+            jsonb_set(e.config, '{checkpoint_storage}', c.storage)
+        END AS experiment_config,
+        t.hparams AS hparams,
+        s.metrics AS training_metrics,
+        v.metrics->'validation_metrics' AS validation_metrics,
+        (v.metrics->'validation_metrics'->>(e.config->'searcher'->>'metric'))::float8 AS searcher_metric,
+        CAST(c.metadata->>'steps_completed' AS int) as steps_completed,
+        2 AS checkpoint_version,
+        c.size
+    FROM checkpoints_v2 AS c
+    LEFT JOIN trials AS t on c.task_id = t.task_id
+    LEFT JOIN experiments AS e on t.experiment_id = e.id
+    LEFT JOIN raw_validations AS v on CAST(c.metadata->>'steps_completed' AS int) = v.total_batches and t.id = v.trial_id
+    LEFT JOIN raw_steps AS s on CAST(c.metadata->>'steps_completed' AS int) = s.total_batches and t.id = s.trial_id
+    -- avoiding the steps view causes Postgres to not "Materialize" in this join.
+    WHERE s.archived IS NULL OR s.archived = false
+      AND v.archived IS NULL OR v.archived = false;
+
+CREATE TYPE public.checkpoint_copy_state AS ENUM (
+    'PENDING',
+    'RUNNING',
+    'COMPLETED',
+    'FAILED'
+);
+
+CREATE TABLE public.checkpoint_copy_jobs (
+    id serial PRIMARY KEY,
+    state public.checkpoint_copy_state NOT NULL DEFAULT 'RUNNING',
+    source jsonb NULL,
+    target jsonb NOT NULL,
+    requested_by integer NOT NULL REFERENCES public.users(id),
+    start_time timestamptz NOT NULL DEFAULT now(),
+    end_time timestamptz NULL,
+    total_checkpoints integer NOT NULL DEFAULT 0,
+    copied_checkpoints integer NOT NULL DEFAULT 0,
+    failed_checkpoints integer NOT NULL DEFAULT 0,
+    total_bytes bigint NOT NULL DEFAULT 0,
+    copied_bytes bigint NOT NULL DEFAULT 0,
+    error text NULL
+);
+
+CREATE TABLE public.checkpoint_copies (
+    job_id integer NOT NULL REFERENCES public.checkpoint_copy_jobs(id) ON DELETE CASCADE,
+    checkpoint_uuid uuid NOT NULL,
+    state public.checkpoint_copy_state NOT NULL DEFAULT 'PENDING',
+    size bigint NOT NULL DEFAULT 0,
+    end_time timestamptz NULL,
+    error text NULL,
+    PRIMARY KEY (job_id, checkpoint_uuid)
+);
+
+CREATE INDEX ix_checkpoint_copies_checkpoint_uuid ON public.checkpoint_copies
+    USING btree (checkpoint_uuid);
@@ -0,0 +1,8 @@
+CREATE TABLE public.task_log_archives (
+    task_id text PRIMARY KEY REFERENCES public.tasks(task_id) ON DELETE CASCADE,
+    key text NOT NULL,
+    log_count integer NOT NULL,
+    max_log_id bigint NOT NULL,
+    size bigint NOT NULL,
+    archived_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+DROP INDEX CONCURRENTLY IF EXISTS public.ix_task_logs_log_tsv;
+--gopg:split
+DROP INDEX CONCURRENTLY IF EXISTS public.ix_task_logs_log_trgm;
@@ -0,0 +1,19 @@
+-- Not transactional, so that the indexes are built concurrently without blocking writes to
+-- task_logs. A build that failed leaves an invalid index behind, so drop it before retrying.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+--gopg:split
+
+-- encode(log, 'escape') is how task log filters already read log text; indexing the same
+-- expression lets both substring filters and regular expression searches use the trigram index.
+DROP INDEX CONCURRENTLY IF EXISTS public.ix_task_logs_log_trgm;
+--gopg:split
+CREATE INDEX CONCURRENTLY ix_task_logs_log_trgm ON public.task_logs
+    USING gin (encode(log, 'escape') gin_trgm_ops);
+--gopg:split
+
+-- Only the start of each line is indexed for text search, since a tsvector is limited to 1MB and
+-- longer lines would fail to insert.
+DROP INDEX CONCURRENTLY IF EXISTS public.ix_task_logs_log_tsv;
+--gopg:split
+CREATE INDEX CONCURRENTLY ix_task_logs_log_tsv ON public.task_logs
+    USING gin (to_tsvector('simple', left(encode(log, 'escape'), 65536)));
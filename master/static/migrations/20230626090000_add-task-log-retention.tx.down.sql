@@ -0,0 +1 @@
+ALTER TABLE public.tasks DROP COLUMN log_retention_max_lines;
@@ -0,0 +1,3 @@
+-- The line limit that log retention last trimmed the logs of an ended task to, so that tasks are
+-- only trimmed again if the limit is lowered.
+ALTER TABLE public.tasks ADD COLUMN log_retention_max_lines integer;
@@ -0,0 +1 @@
+DROP TABLE public.chargeback_budget_alerts;
@@ -0,0 +1,7 @@
+-- One row for each month whose chargeback budget was exceeded, so the alert is sent only once.
+CREATE TABLE public.chargeback_budget_alerts (
+    month date PRIMARY KEY,
+    cost double precision NOT NULL,
+    budget double precision NOT NULL,
+    alerted_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,11 @@
+-- Master logs, when they are persisted to Postgres. level is the logrus level, where lower values
+-- are more severe.
+CREATE TABLE public.master_logs (
+    id bigserial PRIMARY KEY,
+    time timestamptz NOT NULL,
+    level smallint NOT NULL,
+    component text,
+    message text NOT NULL
+);
+
+CREATE INDEX ix_master_logs_time ON public.master_logs USING btree (time);
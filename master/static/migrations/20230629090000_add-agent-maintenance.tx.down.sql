@@ -0,0 +1 @@
+DROP TABLE resourcemanagers_agent_maintenance;
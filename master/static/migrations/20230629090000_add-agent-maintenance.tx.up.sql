@@ -0,0 +1,9 @@
+-- The scheduled maintenance of agents, so that it survives a restart of the master.
+CREATE TABLE resourcemanagers_agent_maintenance (
+    agent_id text PRIMARY KEY,
+    phase text NOT NULL,
+    start_time timestamptz NOT NULL,
+    deadline timestamptz NOT NULL,
+    num_containers integer NOT NULL,
+    phase_changed_at timestamptz NOT NULL
+);
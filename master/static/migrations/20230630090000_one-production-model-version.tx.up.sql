@@ -0,0 +1,13 @@
+-- Keep only the version that most recently entered production in it.
+UPDATE public.model_versions mv
+SET stage = 'ARCHIVED', stage_changed_at = now()
+WHERE stage = 'PRODUCTION' AND id != (
+    SELECT id FROM public.model_versions p
+    WHERE p.model_id = mv.model_id AND p.stage = 'PRODUCTION'
+    ORDER BY p.stage_changed_at DESC NULLS LAST, p.version DESC
+    LIMIT 1
+);
+
+CREATE UNIQUE INDEX ix_model_versions_one_production
+    ON public.model_versions USING btree (model_id)
+    WHERE stage = 'PRODUCTION';
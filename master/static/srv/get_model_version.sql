@@ -1,5 +1,5 @@
 WITH mv AS (
-  SELECT version, checkpoint_uuid, model_versions.id, creation_time, name, comment, metadata, labels, notes, username, user_id, last_updated_time
+  SELECT version, checkpoint_uuid, model_versions.id, creation_time, name, comment, metadata, labels, notes, username, user_id, last_updated_time, stage, stage_changed_at
     FROM model_versions
     LEFT JOIN users ON users.id = model_versions.user_id
     WHERE model_id = $1 AND model_versions.version = $2
@@ -25,5 +25,6 @@ SELECT
     mv.version, mv.id,
     mv.creation_time, mv.notes,
     mv.name, mv.comment, mv.metadata,
-    mv.username, mv.user_id, mv.last_updated_time
+    mv.username, mv.user_id, mv.last_updated_time,
+    'MODEL_VERSION_STAGE_' || mv.stage AS stage, mv.stage_changed_at
     FROM c, m, mv;
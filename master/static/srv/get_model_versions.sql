@@ -11,7 +11,9 @@ WITH mv AS (
     notes,
     username,
     user_id,
-    last_updated_time
+    last_updated_time,
+    stage,
+    stage_changed_at
   FROM model_versions
   LEFT JOIN users ON users.id = model_versions.user_id
   WHERE model_id = $1
@@ -36,7 +38,10 @@ cnv AS (
     c.metadata,
     t.id AS trial_id,
     e.id AS experiment_id,
-    e.config AS experiment_config,
+    CASE
+        WHEN c.storage IS NULL THEN e.config
+        ELSE jsonb_set(e.config, '{checkpoint_storage}', c.storage)
+    END AS experiment_config,
     t.hparams,
     s.metrics AS training_metrics,
     v.metrics -> 'validation_metrics'::text AS validation_metrics,
@@ -141,6 +146,7 @@ SELECT
     mv.version, mv.id,
     mv.creation_time, mv.notes,
     mv.username, mv.user_id,
-    mv.name, mv.comment, mv.metadata, mv.last_updated_time
+    mv.name, mv.comment, mv.metadata, mv.last_updated_time,
+    'MODEL_VERSION_STAGE_' || mv.stage AS stage, mv.stage_changed_at
     FROM pcv c, mv, m
     WHERE c.uuid = mv.checkpoint_uuid::text;
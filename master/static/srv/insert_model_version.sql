@@ -26,7 +26,7 @@ WITH mv AS (
 			current_timestamp,
 			current_timestamp
 		)
-	RETURNING id, checkpoint_uuid, version, creation_time, name, comment, model_id, metadata, labels, user_id
+	RETURNING id, checkpoint_uuid, version, creation_time, name, comment, model_id, metadata, labels, user_id, stage
 ),
 u AS (
 	SELECT username FROM users WHERE id = $8
@@ -52,6 +52,7 @@ SELECT
     mv.version, mv.id,
     mv.creation_time,
     mv.name, mv.comment, mv.metadata,
-		u.username
+		u.username,
+    'MODEL_VERSION_STAGE_' || mv.stage AS stage
     FROM c, mv, m, u
     WHERE c.uuid = mv.checkpoint_uuid::text;
@@ -30,9 +30,17 @@ const ClusterLogs: React.FC = () => {
       options.limit = 0;
     }
 
-    return detApi.StreamingCluster.masterLogs(options.offset, options.limit, options.follow, {
-      signal: config.canceler.signal,
-    });
+    return detApi.StreamingCluster.masterLogs(
+      options.offset,
+      options.limit,
+      options.follow,
+      undefined,
+      undefined,
+      undefined,
+      undefined,
+      undefined,
+      { signal: config.canceler.signal },
+    );
   }, []);
 
   return (
@@ -195,6 +195,7 @@ const LearningCurve: React.FC<Props> = ({
         undefined,
         undefined,
         undefined,
+        undefined,
         { signal: canceler.signal },
       ),
       (event) => {